@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/generator"
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/schemas"
+)
+
+// The exit codes of failures, by their kind. Failures of other kinds, such
+// as bad flags or output that cannot be written, exit with exitFailure.
+const (
+	exitFailure    = 1
+	exitParse      = 2
+	exitGeneration = 3
+	exitConflict   = 4
+	exitWarnings   = 5
+)
+
+// generationError is an error in generating code, rather than in writing
+// it, so that it is reported as one.
+type generationError struct {
+	err error
+}
+
+func (e generationError) Error() string {
+	return e.err.Error()
+}
+
+func (e generationError) Unwrap() error {
+	return e.err
+}
+
+// diagnostic is a warning or an error as --output-format json writes it, as
+// a line of its own on standard error.
+type diagnostic struct {
+	// Kind is "warning" or "error".
+	Kind string `json:"kind"`
+	// Code is the generator.WarningCode of a warning, or the kind of an
+	// error: "parse", "generation", "conflict", "warnings" or "error".
+	Code     string `json:"code"`
+	Severity string `json:"severity,omitempty"`
+	File     string `json:"file,omitempty"`
+	Pointer  string `json:"pointer,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	Message  string `json:"message"`
+}
+
+// reportWarning writes a warning from the generator to standard error.
+func reportWarning(w generator.Warning) {
+	if outputFormat != "json" {
+		log("Warning: %s", w)
+		return
+	}
+	writeDiagnostic(diagnostic{
+		Kind:     "warning",
+		Code:     string(w.Code),
+		Severity: string(w.Severity),
+		File:     w.SchemaFile,
+		Pointer:  w.Pointer,
+		Message:  w.Message,
+	})
+}
+
+// reportError writes an error to standard error.
+func reportError(err error) {
+	if outputFormat != "json" {
+		log("Failed: %s", err)
+		return
+	}
+	d := diagnostic{Kind: "error", Message: err.Error()}
+	d.Code, _ = classifyError(err)
+	var invalid *generator.InvalidSchemaError
+	var pathErr *fs.PathError
+	if errors.As(err, &invalid) {
+		d.File = invalid.Name
+	} else if errors.As(err, &pathErr) {
+		d.File = pathErr.Path
+	}
+	var parseErr *schemas.ParseError
+	var schemaErr *generator.SchemaError
+	switch {
+	case errors.As(err, &parseErr):
+		d.Line, d.Column, d.Pointer = parseErr.Line, parseErr.Column, parseErr.Pointer
+	case errors.As(err, &schemaErr) && len(schemaErr.Errors) > 0:
+		first := schemaErr.Errors[0]
+		d.Line, d.Column, d.Pointer = first.Line, first.Column, first.Path
+	}
+	writeDiagnostic(d)
+}
+
+// classifyError returns the kind of an error, as the code of its
+// diagnostic, and the exit code for it. Schema files that cannot be read
+// are parse errors too.
+func classifyError(err error) (string, int) {
+	var invalid *generator.InvalidSchemaError
+	var generation generationError
+	var pathErr *fs.PathError
+	var warnings *generator.WarningsError
+	switch {
+	case errors.As(err, &invalid), errors.As(err, &generation) && errors.As(err, &pathErr):
+		return "parse", exitParse
+	case errors.Is(err, generator.ErrConflictingOutput):
+		return "conflict", exitConflict
+	case errors.As(err, &warnings):
+		return "warnings", exitWarnings
+	case errors.As(err, &generation):
+		return "generation", exitGeneration
+	default:
+		return "error", exitFailure
+	}
+}
+
+func writeDiagnostic(d diagnostic) {
+	b, err := json.Marshal(d)
+	if err != nil {
+		// Only strings and numbers are marshaled, so this cannot happen.
+		panic(err)
+	}
+	fmt.Fprintf(os.Stderr, "%s\n", b)
+}
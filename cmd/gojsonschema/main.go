@@ -1,14 +1,28 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
 
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/diff"
 	"github.com/lets-dev-it-out/go-jsonschema/pkg/generator"
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/infer"
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/lint"
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/schemas"
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/yamlutils"
 )
 
 var (
@@ -18,122 +32,482 @@ var (
 	schemaPackages    []string
 	schemaOutputs     []string
 	schemaRootTypes   []string
+	filePackages      []string
+	fileOutputs       []string
+	fileRootTypes     []string
 	capitalizations   []string
 	resolveExtensions []string
 	yamlExtensions    = []string{".yml", ".yaml"}
+	validateFormats   bool
+	formatMappings    []string
+	extraTags         []string
+	omitEmpty         string
+	optionalValues    bool
+	nullablePointers  bool
+	integerType       string
+	integerFromBounds bool
+	jsonNumber        bool
+	onlyModels        bool
+	fullValidation    bool
+	aggregateErrors   bool
+	nestedDefaults    bool
+	builders          bool
+	constructors      bool
+	getters           bool
+	deepCopy          bool
+	equal             bool
+	sql               bool
+	validateOnMarshal bool
+	preserveOrder     bool
+	captureExtras     bool
+	strict            bool
+	easyJSON          bool
+	roundTripTests    bool
+	fuzzTests         bool
+	fieldConstants    bool
+	openAPI           bool
+	bundle            bool
+	catalogSchemas    []string
+	inferSchema       bool
+	lintJSON          bool
+	docs              bool
+	randomValues      bool
+	parallelism       int
+	deleteStale       bool
+	inferPackage      bool
+	splitFiles        bool
+	typesPerFile      int
+	fileHeader        string
+	lineWidth         uint
+	indentSpaces      uint
+	unwrappedComments bool
+	packageDocs       bool
+	sourceComments    bool
+	embedSchema       bool
+	watch             bool
+	watchInterval     time.Duration
+	dryRun            bool
+	outputFormat      string
+	failOnWarning     bool
+	lenient           bool
+	onlyReferenced    bool
+	sharedInline      bool
 )
 
 var rootCmd = &cobra.Command{
-	Use:   "gojsonschema FILE ...",
+	Use:   "gojsonschema FILE|DIR|GLOB ...",
 	Short: "Generates Go code from JSON Schema files.",
+	// The schema files are arguments of the root command itself, which cobra
+	// would otherwise take for unknown subcommands.
+	Args: cobra.ArbitraryArgs,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		if outputFormat != "text" && outputFormat != "json" {
+			abort(fmt.Sprintf("Unknown output format %q; must be text or json.", outputFormat))
+		}
+	},
 	Run: func(cmd *cobra.Command, args []string) {
-		if len(args) == 0 {
-			abort("No arguments specified. Run with --help for usage.")
+		if len(args) == 0 && len(catalogSchemas) == 0 {
+			if !stdinIsPiped() {
+				abort("No arguments specified. Run with --help for usage.")
+			}
+			// As in a pipeline, e.g. "curl ... | gojsonschema -p foo > foo.go".
+			args = []string{"-"}
+		}
+
+		if bundle && (len(args) != 1 || len(catalogSchemas) > 0) {
+			abort("--bundle takes exactly one schema.")
+		}
+
+		if inferSchema && (bundle || len(catalogSchemas) > 0) {
+			abort("--infer cannot be combined with --bundle or --schema.")
+		}
+
+		if dryRun && (bundle || inferSchema) {
+			abort("--dry-run cannot be combined with --bundle or --infer.")
+		}
+
+		if watch && (bundle || inferSchema) {
+			abort("--watch cannot be combined with --bundle or --infer.")
+		}
+		for _, arg := range args {
+			if watch && arg == "-" {
+				abort("--watch cannot read schemas from standard input.")
+			}
 		}
 
-		if defaultPackage == "" && len(schemaPackages) == 0 {
+		if !bundle && !inferSchema && !inferPackage && defaultPackage == "" &&
+			len(schemaPackages) == 0 && len(filePackages) == 0 {
 			abort("Package name not specified.")
 		}
 
-		schemaPackageMap, err := stringSliceToStringMap(schemaPackages)
+		generator, err := generator.New(newConfig())
 		if err != nil {
 			abortWithErr(err)
 		}
 
-		schemaOutputMap, err := stringSliceToStringMap(schemaOutputs)
+		// An interrupt cancels fetching and generating, which then fail
+		// instead of writing partial output.
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		if inferSchema {
+			readers := make([]io.Reader, 0, len(args))
+			for _, fileName := range args {
+				verboseLog("Reading %s", fileName)
+				if fileName == "-" {
+					readers = append(readers, os.Stdin)
+					continue
+				}
+				f, err := os.Open(fileName)
+				if err != nil {
+					abortWithErr(err)
+				}
+				defer func() {
+					_ = f.Close()
+				}()
+				readers = append(readers, f)
+			}
+			schema, err := infer.FromReader(io.MultiReader(readers...))
+			if err != nil {
+				abortWithErr(err)
+			}
+			source, err := json.MarshalIndent(schema, "", "  ")
+			if err != nil {
+				abortWithErr(err)
+			}
+			writeOutput(defaultOutput, append(source, '\n'))
+			os.Exit(0)
+		}
+
+		if bundle {
+			verboseLog("Bundling %s", args[0])
+			source, err := generator.BundleContext(ctx, args[0])
+			if err != nil {
+				abortWithErr(err)
+			}
+			writeOutput(defaultOutput, source)
+			os.Exit(0)
+		}
+
+		if watch {
+			watchAndGenerate(ctx, generator, args)
+			os.Exit(0)
+		}
+		abortWithErr(generate(ctx, generator, args))
+		os.Exit(0)
+	},
+}
+
+// generate generates code for the schemas in the catalog and the files that
+// arguments name, and writes it, or with --dry-run, writes the diff to the
+// output files to standard output instead.
+func generate(ctx context.Context, g *generator.Generator, args []string) error {
+	for _, name := range catalogSchemas {
+		verboseLog("Loading %s from the schema catalog", name)
+		if err := g.DoCatalogSchema(ctx, name); err != nil {
+			return generationError{err}
+		}
+	}
+
+	fileNames, err := expandArgs(args)
+	if err != nil {
+		return err
+	}
+	for _, fileName := range fileNames {
+		verboseLog("Loading %s", fileName)
+	}
+	if err := g.DoFiles(ctx, fileNames...); err != nil {
+		return generationError{err}
+	}
+
+	if dryRun {
+		verboseLog("Comparing output files")
+		d, err := g.Diff("")
 		if err != nil {
-			abortWithErr(err)
+			return err
+		}
+		if _, err := os.Stdout.Write(d); err != nil {
+			return err
+		}
+		if len(d) > 0 {
+			return errOutOfDate
 		}
+		return nil
+	}
+
+	verboseLog("Writing output files")
+	return g.Write("")
+}
 
-		schemaRootTypeMap, err := stringSliceToStringMap(schemaRootTypes)
+// errOutOfDate is returned by generate with --dry-run when the output files
+// differ from the code generated.
+var errOutOfDate = errors.New("generated code is out of date")
+
+var validateCmd = &cobra.Command{
+	Use:   "validate SCHEMA FILE ...",
+	Short: "Validates JSON or YAML files against a JSON Schema file.",
+	Long: `Validates JSON or YAML files against a JSON Schema file, or an HTTP or HTTPS URL.
+The schema is read as it is when generating code, with the same flags, so the
+warnings about it are the same.`,
+	Args: cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg := newConfig()
+		if cfg.DefaultPackageName == "" {
+			// Code is generated for the warnings, but isn't written.
+			cfg.DefaultPackageName = "main"
+		}
+		generator, err := generator.New(cfg)
 		if err != nil {
 			abortWithErr(err)
 		}
 
-		cfg := generator.Config{
-			Warner: func(message string) {
-				log("Warning: %s", message)
-			},
-			Capitalizations:    capitalizations,
-			DefaultOutputName:  defaultOutput,
-			DefaultPackageName: defaultPackage,
-			SchemaMappings:     []generator.SchemaMapping{},
-			ResolveExtensions:  resolveExtensions,
-			YAMLExtensions:     yamlExtensions,
+		stdinArgs := 0
+		for _, arg := range args {
+			if arg == "-" {
+				stdinArgs++
+			}
 		}
-		for _, id := range allKeys(schemaPackageMap, schemaOutputMap, schemaRootTypeMap) {
-			mapping := generator.SchemaMapping{SchemaID: id}
-			if s, ok := schemaPackageMap[id]; ok {
-				mapping.PackageName = s
-			} else {
-				mapping.PackageName = defaultPackage
+		if stdinArgs > 1 {
+			abort("Only one of the schema and the files can be read from standard input.")
+		}
+
+		valid := true
+		for _, fileName := range args[1:] {
+			verboseLog("Validating %s", fileName)
+			document, err := readDocument(fileName)
+			if err != nil {
+				abortWithErr(err)
 			}
-			if s, ok := schemaOutputMap[id]; ok {
-				mapping.OutputName = s
+			errs, err := generator.Validate(args[0], document)
+			if err != nil {
+				abortWithErr(err)
 			}
-			if s, ok := schemaRootTypeMap[id]; ok {
-				mapping.RootType = s
+			for _, e := range errs {
+				fmt.Printf("%s: %s\n", fileName, e)
 			}
-			cfg.SchemaMappings = append(cfg.SchemaMappings, mapping)
+			valid = valid && len(errs) == 0
+		}
+		if !valid {
+			os.Exit(1)
 		}
+	},
+}
 
-		generator, err := generator.New(cfg)
+var diffCmd = &cobra.Command{
+	Use:   "diff OLD NEW",
+	Short: "Reports the changes between two versions of a JSON Schema file.",
+	Long: `Reports the changes between two versions of a JSON Schema file, and exits with
+status 1 if any of them break the Go types generated from it.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		changes := diff.Compare(readSchema(args[0]), readSchema(args[1]))
+		for _, c := range changes {
+			fmt.Println(c)
+		}
+		if diff.HasBreaking(changes) {
+			os.Exit(1)
+		}
+	},
+}
+
+var lintCmd = &cobra.Command{
+	Use:   "lint SCHEMA ...",
+	Short: "Reports the constructs in JSON Schema files that the generator handles poorly.",
+	Long: `Reports the constructs in JSON Schema files that the generator handles poorly,
+such as untitled anonymous unions, enums of mixed types and overlapping
+patternProperties, and exits with status 1 if there are any.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		type fileIssue struct {
+			File string `json:"file"`
+			lint.Issue
+		}
+		issues := []fileIssue{}
+		fileNames, err := expandArgs(args)
 		if err != nil {
 			abortWithErr(err)
 		}
+		for _, fileName := range fileNames {
+			for _, issue := range lint.Lint(readSchema(fileName)) {
+				issues = append(issues, fileIssue{File: fileName, Issue: issue})
+			}
+		}
 
-		for _, fileName := range args {
-			verboseLog("Loading %s", fileName)
-			if err = generator.DoFile(fileName); err != nil {
+		if lintJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(issues); err != nil {
 				abortWithErr(err)
 			}
+		} else {
+			for _, issue := range issues {
+				fmt.Printf("%s: %s\n", issue.File, issue.Issue)
+			}
+		}
+		if len(issues) > 0 {
+			os.Exit(1)
 		}
+	},
+}
 
-		for fileName, source := range generator.Sources() {
-			if fileName != "-" {
-				verboseLog("Writing %s", fileName)
-			}
+// readSchema reads a schema file, or an OpenAPI document with --openapi,
+// without generating code from it. The name "-" stands for standard input.
+func readSchema(fileName string) *schemas.Schema {
+	var schema *schemas.Schema
+	var err error
+	switch {
+	case fileName == "-" && openAPI:
+		schema, err = schemas.FromOpenAPIReader(os.Stdin)
+	case fileName == "-":
+		schema, err = schemas.FromReader(os.Stdin)
+	case openAPI:
+		schema, err = schemas.FromOpenAPIFile(fileName)
+	default:
+		schema, err = schemas.FromFile(fileName)
+	}
+	if err != nil {
+		abortWithErr(fmt.Errorf("error parsing %s: %w", fileName, err))
+	}
+	return schema
+}
 
-			if fileName == "-" {
-				if _, err = os.Stdout.Write(source); err != nil {
-					abortWithErr(err)
-				}
-			} else {
-				if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
-					abortWithErr(err)
-				}
-				w, err := os.OpenFile(fileName, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
-				if err != nil {
-					abortWithErr(err)
-				}
-				if _, err = w.Write(source); err != nil {
-					abortWithErr(err)
+// stdinIsPiped reports whether standard input is a pipe or a file, rather
+// than a terminal.
+func stdinIsPiped() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice == 0
+}
+
+// expandArgs returns the schema files that arguments name: the JSON and YAML
+// files in directories, recursively, and the files that glob patterns, as
+// generator.Glob takes, match, each in lexical order. Other arguments, such
+// as files, URLs and "-", are kept as they are, and so are the files that
+// more than one argument names, but only where they are first named.
+func expandArgs(args []string) ([]string, error) {
+	var fileNames []string
+	seen := map[string]bool{}
+	add := func(fileName string) {
+		if !seen[fileName] {
+			seen[fileName] = true
+			fileNames = append(fileNames, fileName)
+		}
+	}
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		switch {
+		case err == nil && info.IsDir():
+			err := filepath.WalkDir(arg, func(fileName string, d fs.DirEntry, err error) error {
+				if err == nil && !d.IsDir() && isSchemaFileName(fileName) {
+					add(fileName)
 				}
-				_ = w.Close()
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+		case err != nil && strings.ContainsAny(arg, "*?["):
+			matches, err := generator.Glob(arg)
+			if err != nil {
+				return nil, err
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no files match %s", arg)
 			}
+			for _, fileName := range matches {
+				add(fileName)
+			}
+		default:
+			add(arg)
 		}
+	}
+	return fileNames, nil
+}
 
-		os.Exit(0)
-	},
+// isSchemaFileName reports whether a file in a directory given as an
+// argument is a schema file, by its extension: .json, .yml, .yaml or one
+// given with --yaml-extension.
+func isSchemaFileName(fileName string) bool {
+	switch ext := filepath.Ext(fileName); ext {
+	case ".json", ".yml", ".yaml":
+		return true
+	}
+	for _, yamlExt := range yamlExtensions {
+		if strings.HasSuffix(fileName, yamlExt) {
+			return true
+		}
+	}
+	return false
+}
+
+// readDocument reads a JSON or YAML document, as encoding/json would decode
+// it. As with schemas, documents that start with "{" or "[" are read as JSON,
+// and all others as YAML. The name "-" stands for standard input.
+func readDocument(fileName string) (interface{}, error) {
+	var b []byte
+	var err error
+	if fileName == "-" {
+		b, err = io.ReadAll(os.Stdin)
+	} else {
+		b, err = os.ReadFile(fileName)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if trimmed := bytes.TrimLeft(b, " \t\r\n"); len(trimmed) == 0 || (trimmed[0] != '{' && trimmed[0] != '[') {
+		var doc interface{}
+		if err := yaml.Unmarshal(b, &doc); err != nil {
+			return nil, fmt.Errorf("error parsing %s: %w", fileName, err)
+		}
+		if b, err = yamlutils.MarshalJSON(doc); err != nil {
+			return nil, err
+		}
+	}
+	var doc interface{}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", fileName, err)
+	}
+	return doc, nil
 }
 
 func main() {
+	rootCmd.AddCommand(validateCmd)
+	rootCmd.AddCommand(diffCmd)
+	lintCmd.Flags().BoolVar(&lintJSON, "json", false,
+		"Write the issues as a JSON array, for CI.")
+	rootCmd.AddCommand(lintCmd)
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false,
 		"Verbose output")
 	rootCmd.PersistentFlags().StringVarP(&defaultPackage, "package", "p", "",
 		`Default name of package to declare Go files under, unless overridden with
 --schema-package`)
+	rootCmd.PersistentFlags().BoolVar(&inferPackage, "infer-package", false,
+		`Derive the package of Go files that no other flag gives one from the nearest
+go.mod file and the directory that they are written to.`)
 	rootCmd.PersistentFlags().StringVarP(&defaultOutput, "output", "o", "-",
 		"File to write (- for standard output)")
 	rootCmd.PersistentFlags().StringSliceVar(&schemaPackages, "schema-package", nil,
 		`Name of package to declare Go files for a specific schema ID under;
-must be in the format URI=PACKAGE.`)
+must be in the format URI=PACKAGE. A * in URI matches any text, and {name}
+in PACKAGE then stands for the last element of each schema ID, without its
+extension, e.g. https://example.com/schemas/*=github.com/example/gen/{name}.`)
 	rootCmd.PersistentFlags().StringSliceVar(&schemaOutputs, "schema-output", nil,
 		`File to write (- for standard output) a specific schema ID to;
-must be in the format URI=FILENAME.`)
+must be in the format URI=FILENAME. URI and FILENAME may use * and {name}
+as with --schema-package.`)
 	rootCmd.PersistentFlags().StringSliceVar(&schemaRootTypes, "schema-root-type", nil,
 		`Override name to use for the root type of a specific schema ID;
 must be in the format URI=TYPE. By default, it is derived from the file name.`)
+	rootCmd.PersistentFlags().StringSliceVar(&filePackages, "file-package", nil,
+		`Name of package to declare Go files for the schema in a specific file under,
+for schemas without IDs; must be in the format PATH=PACKAGE, where PATH may be
+a glob pattern such as schemas/**/*.json. Takes precedence over --schema-package.`)
+	rootCmd.PersistentFlags().StringSliceVar(&fileOutputs, "file-output", nil,
+		`File to write (- for standard output) the schema in a specific file to;
+must be in the format PATH=FILENAME.`)
+	rootCmd.PersistentFlags().StringSliceVar(&fileRootTypes, "file-root-type", nil,
+		`Override name to use for the root type of the schema in a specific file,
+for schemas without IDs; must be in the format PATH=TYPE, where PATH may be a
+glob pattern. Takes precedence over --schema-root-type.`)
 	rootCmd.PersistentFlags().StringSliceVar(&capitalizations, "capitalization", nil,
 		`Specify a preferred Go capitalization for a string. For example, by default a field
 named 'id' becomes 'Id'. With --capitalization ID, it will be generated as 'ID'.`)
@@ -142,19 +516,302 @@ named 'id' becomes 'Id'. With --capitalization ID, it will be generated as 'ID'.
 also look for foo.json if --resolve-extension json is provided.`)
 	rootCmd.PersistentFlags().StringSliceVar(&yamlExtensions, "yaml-extension", nil,
 		`Add a file extension that should be recognized as YAML. Default are .yml, .yaml.`)
+	rootCmd.PersistentFlags().BoolVar(&validateFormats, "validate-formats", false,
+		`Generate validation code for string fields with the "email", "idn-email",
+"hostname" or "regex" format.`)
+	rootCmd.PersistentFlags().StringSliceVar(&extraTags, "extra-tag", nil,
+		`Add a struct tag key (e.g. mapstructure or bson) to every generated field, in addition
+to json and yaml.`)
+	rootCmd.PersistentFlags().StringVar(&omitEmpty, "omitempty", string(generator.OmitEmptyOptional),
+		`Which fields to tag with omitempty: optional, always or never. Can be overridden
+per property with the x-omitempty extension.`)
+	rootCmd.PersistentFlags().BoolVar(&optionalValues, "optional-value-types", false,
+		`Declare optional fields with value types instead of pointers.`)
+	rootCmd.PersistentFlags().BoolVar(&nullablePointers, "nullable-required-pointers", false,
+		`Declare required fields that may be null as pointers, accepting an explicit null.`)
+	rootCmd.PersistentFlags().StringVar(&integerType, "integer-type", "int",
+		`Go type to use for integers.`)
+	rootCmd.PersistentFlags().BoolVar(&integerFromBounds, "integer-type-from-bounds", false,
+		`Use int32, int64, uint32 or uint64 for integers with a minimum or maximum,
+depending on their bounds; bounds that none of them can hold get the default type,
+with a warning.`)
+	rootCmd.PersistentFlags().BoolVar(&jsonNumber, "json-number", false,
+		`Declare numbers and integers as json.Number to preserve their exact values.`)
+	rootCmd.PersistentFlags().BoolVar(&onlyModels, "only-models", false,
+		`Generate only types, without methods that validate values when unmarshaling.`)
+	rootCmd.PersistentFlags().BoolVar(&fullValidation, "full-validation", false,
+		`Enable all optional validation, such as of string formats.`)
+	rootCmd.PersistentFlags().BoolVar(&aggregateErrors, "aggregate-errors", false,
+		`Report every validation error when unmarshaling, rather than only the first.`)
+	rootCmd.PersistentFlags().BoolVar(&nestedDefaults, "nested-defaults", false,
+		`Fill in the defaults of absent nested objects when unmarshaling, if they have
+no required properties.`)
+	rootCmd.PersistentFlags().BoolVar(&builders, "builders", false,
+		`Generate a builder for each struct, which checks required fields when building.`)
+	rootCmd.PersistentFlags().BoolVar(&constructors, "constructors", false,
+		`Generate a NewX function for each struct with defaults, which fills them in.`)
+	rootCmd.PersistentFlags().BoolVar(&getters, "getters", false,
+		`Generate nil-safe getters for struct fields, which dereference optional values.`)
+	rootCmd.PersistentFlags().BoolVar(&deepCopy, "deep-copy", false,
+		`Generate DeepCopy and DeepCopyInto methods for structs.`)
+	rootCmd.PersistentFlags().BoolVar(&equal, "equal", false,
+		`Generate Equal methods for structs, which compare values without reflection.`)
+	rootCmd.PersistentFlags().BoolVar(&sql, "sql", false,
+		`Generate Scan and Value methods for enums, for use with database/sql. Fields with a format get none; map them to types that implement the interfaces with --format-mapping.`)
+	rootCmd.PersistentFlags().BoolVar(&validateOnMarshal, "validate-on-marshal", false,
+		`Validate values when marshaling them, as well as when unmarshaling them.`)
+	rootCmd.PersistentFlags().BoolVar(&preserveOrder, "preserve-order", false,
+		`Marshal the properties of structs in the order that the schema declares them.`)
+	rootCmd.PersistentFlags().BoolVar(&captureExtras, "capture-extras", false,
+		`Keep properties that the schema does not declare in an AdditionalProperties field.`)
+	rootCmd.PersistentFlags().BoolVar(&strict, "disallow-unknown-fields", false,
+		`Fail to unmarshal objects with properties that the schema does not declare.`)
+	rootCmd.PersistentFlags().BoolVar(&easyJSON, "easyjson", false,
+		`Generate easyjson marshalers for structs, which avoid reflection.`)
+	rootCmd.PersistentFlags().BoolVar(&roundTripTests, "round-trip-tests", false,
+		`Generate a _test.go file for each output file, which round-trips the schema's examples and defaults.`)
+	rootCmd.PersistentFlags().BoolVar(&fuzzTests, "fuzz-tests", false,
+		`Generate a fuzz target for each struct and enum in the _test.go file for each output file.`)
+	rootCmd.PersistentFlags().BoolVar(&fieldConstants, "field-name-constants", false,
+		`Declare a constant with the JSON name of each struct field, e.g. FooNameJSON = "name".`)
+	rootCmd.PersistentFlags().BoolVar(&openAPI, "openapi", false,
+		`Read OpenAPI 3.0 or 3.1 documents, and generate types from their components.schemas.`)
+	rootCmd.PersistentFlags().BoolVar(&docs, "docs", false,
+		`Generate a Markdown reference of the types in each output file next to it, e.g. foo.md next to foo.go.`)
+	rootCmd.PersistentFlags().BoolVar(&randomValues, "random-values", false,
+		`Generate a GenerateFoo(r *rand.Rand) Foo function for each type, which returns a random valid value.`)
+	rootCmd.PersistentFlags().IntVar(&parallelism, "parallelism", 0,
+		`Number of schema files to read and parse at a time (default the number of CPUs); code is still generated from them one at a time`)
+	rootCmd.PersistentFlags().BoolVar(&deleteStale, "delete-stale", false,
+		`Delete the Go files generated by an earlier run in the output directories that this run doesn't write.`)
+	rootCmd.PersistentFlags().BoolVar(&packageDocs, "package-doc", false,
+		`Generate a doc.go next to the Go files, with a package comment made of the titles,
+descriptions and $ids of the schemas.`)
+	rootCmd.PersistentFlags().BoolVar(&sourceComments, "source-comments", false,
+		`End the comment of each type with the file and JSON Pointer of its schema, e.g.
+Source: schema.json#/definitions/job.`)
+	rootCmd.PersistentFlags().BoolVar(&splitFiles, "split-files", false,
+		`Split each output file into a file per type declared for a definition, with the types
+nested in it, e.g. schema_address.go next to schema.go.`)
+	rootCmd.PersistentFlags().IntVar(&typesPerFile, "types-per-file", 0,
+		`With --split-files, put this many definitions' types in each file, e.g. schema_1.go.`)
+	rootCmd.PersistentFlags().StringVar(&fileHeader, "file-header", "",
+		`File with a Go text/template for a comment, such as a license, to put at the top of
+every generated Go file; see generator.FileHeaderData for the fields it can use.`)
+	rootCmd.PersistentFlags().UintVar(&lineWidth, "line-width", 80,
+		`Length to wrap comments in generated code to, counting their indentation.`)
+	rootCmd.PersistentFlags().UintVar(&indentSpaces, "indent-spaces", 0,
+		`Indent generated code with this many spaces per level instead of tabs.`)
+	rootCmd.PersistentFlags().BoolVar(&unwrappedComments, "no-wrap-comments", false,
+		`Keep the lines of descriptions in comments as they are instead of wrapping them.`)
+	rootCmd.PersistentFlags().BoolVar(&watch, "watch", false,
+		`Keep running, and generate the code again whenever the schema files, those they
+refer to, or the files in the directories and glob patterns given change.`)
+	rootCmd.PersistentFlags().DurationVar(&watchInterval, "watch-interval", time.Second/2,
+		`How often --watch polls the files for changes.`)
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "output-format", "text",
+		`Format of warnings and errors: text, or json for a JSON object per line, with
+the code, severity, file and JSON Pointer of each.`)
+	rootCmd.PersistentFlags().BoolVar(&failOnWarning, "fail-on-warning", false,
+		`Fail, without writing anything, if there are any warnings, such as renamed types
+or constraints that are not validated.`)
+	rootCmd.PersistentFlags().BoolVar(&onlyReferenced, "only-referenced-definitions", false,
+		`Generate types only for the definitions that the root of each schema refers to,
+rather than for all of them, unless the root declares no type.`)
+	rootCmd.PersistentFlags().BoolVar(&sharedInline, "shared-inline-types", false,
+		`Declare one type for all of the object schemas declared inline in a schema file
+that are identical, rather than a type for each.`)
+	rootCmd.PersistentFlags().BoolVar(&lenient, "lenient", false,
+		`Generate interface{}, with a warning, for subschemas whose types cannot be generated,
+such as ones with $refs that cannot be resolved, instead of failing.`)
+	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false,
+		`Write a unified diff from the output files to the code generated to standard
+output, instead of writing the code, and fail if they differ.`)
+	rootCmd.PersistentFlags().BoolVar(&embedSchema, "embed-schema", false,
+		`Declare a JSONSchema() []byte method for the root type of each schema, which returns
+the schema bundled as by --bundle.`)
+	rootCmd.PersistentFlags().BoolVar(&bundle, "bundle", false,
+		`Instead of generating code, write the schema as a single JSON document with the
+schemas it refers to in other files inlined as definitions.`)
+	rootCmd.PersistentFlags().BoolVar(&inferSchema, "infer", false,
+		`Instead of generating code, write a schema inferred from the input files, which are
+sample JSON documents.`)
+	rootCmd.PersistentFlags().StringSliceVar(&catalogSchemas, "schema", nil,
+		`Generate code for a schema from the schemastore.org catalog, given by name, e.g.
+github-workflow; may be repeated.`)
+	rootCmd.PersistentFlags().StringSliceVar(&formatMappings, "format-mapping", nil,
+		`Map a JSON Schema format to a Go type; must be in the format FORMAT=TYPE, where
+TYPE is qualified with its import path, e.g. decimal=github.com/shopspring/decimal.Decimal.`)
 
 	abortWithErr(rootCmd.Execute())
 }
 
+// newConfig returns the generator configuration given by the flags.
+func newConfig() generator.Config {
+	schemaPackageMap, err := stringSliceToStringMap(schemaPackages)
+	if err != nil {
+		abortWithErr(err)
+	}
+
+	schemaOutputMap, err := stringSliceToStringMap(schemaOutputs)
+	if err != nil {
+		abortWithErr(err)
+	}
+
+	schemaRootTypeMap, err := stringSliceToStringMap(schemaRootTypes)
+	if err != nil {
+		abortWithErr(err)
+	}
+
+	filePackageMap, err := stringSliceToStringMap(filePackages)
+	if err != nil {
+		abortWithErr(err)
+	}
+
+	fileOutputMap, err := stringSliceToStringMap(fileOutputs)
+	if err != nil {
+		abortWithErr(err)
+	}
+
+	fileRootTypeMap, err := stringSliceToStringMap(fileRootTypes)
+	if err != nil {
+		abortWithErr(err)
+	}
+
+	formatMappingMap, err := stringSliceToStringMap(formatMappings)
+	if err != nil {
+		abortWithErr(err)
+	}
+
+	var header []byte
+	if fileHeader != "" {
+		if header, err = os.ReadFile(fileHeader); err != nil {
+			abortWithErr(err)
+		}
+	}
+
+	cfg := generator.Config{
+		Warner:                   reportWarning,
+		Capitalizations:          capitalizations,
+		DefaultOutputName:        defaultOutput,
+		DefaultPackageName:       defaultPackage,
+		SchemaMappings:           []generator.SchemaMapping{},
+		ResolveExtensions:        resolveExtensions,
+		YAMLExtensions:           yamlExtensions,
+		ValidateFormats:          validateFormats,
+		ExtraTags:                extraTags,
+		OmitEmpty:                generator.OmitEmptyMode(omitEmpty),
+		OptionalValueTypes:       optionalValues,
+		NullableRequiredPointers: nullablePointers,
+		DefaultIntegerType:       integerType,
+		IntegerTypeFromBounds:    integerFromBounds,
+		JSONNumber:               jsonNumber,
+		OnlyModels:               onlyModels,
+		FullValidation:           fullValidation,
+		AggregateErrors:          aggregateErrors,
+		NestedDefaults:           nestedDefaults,
+		Builders:                 builders,
+		Constructors:             constructors,
+		Getters:                  getters,
+		DeepCopy:                 deepCopy,
+		Equal:                    equal,
+		SQL:                      sql,
+		ValidateOnMarshal:        validateOnMarshal,
+		PreserveOrder:            preserveOrder,
+		CaptureExtras:            captureExtras,
+		DisallowUnknownFields:    strict,
+		EasyJSON:                 easyJSON,
+		RoundTripTests:           roundTripTests,
+		FuzzTests:                fuzzTests,
+		FieldNameConstants:       fieldConstants,
+		OpenAPI:                  openAPI,
+		Docs:                     docs,
+		RandomValues:             randomValues,
+		Parallelism:              parallelism,
+		DeleteStaleFiles:         deleteStale,
+		InferPackageNames:        inferPackage,
+		SplitFiles:               splitFiles,
+		TypesPerFile:             typesPerFile,
+		FileHeader:               string(header),
+		LineWidth:                lineWidth,
+		IndentSpaces:             indentSpaces,
+		UnwrappedComments:        unwrappedComments,
+		PackageDocs:              packageDocs,
+		SourceComments:           sourceComments,
+		EmbedSchema:              embedSchema,
+		FailOnWarning:            failOnWarning,
+		Lenient:                  lenient,
+		SharedInlineTypes:        sharedInline,
+		RootTypeOverrides:        fileRootTypeMap,
+
+		OnlyReferencedDefinitions: onlyReferenced,
+	}
+	for _, id := range allKeys(schemaPackageMap, schemaOutputMap, schemaRootTypeMap) {
+		mapping := generator.SchemaMapping{SchemaID: id}
+		if s, ok := schemaPackageMap[id]; ok {
+			mapping.PackageName = s
+		} else {
+			mapping.PackageName = defaultPackage
+		}
+		if s, ok := schemaOutputMap[id]; ok {
+			mapping.OutputName = s
+		}
+		if s, ok := schemaRootTypeMap[id]; ok {
+			mapping.RootType = s
+		}
+		cfg.SchemaMappings = append(cfg.SchemaMappings, mapping)
+	}
+	for _, p := range allKeys(filePackageMap, fileOutputMap) {
+		mapping := generator.SchemaMapping{FilePath: p}
+		if s, ok := filePackageMap[p]; ok {
+			mapping.PackageName = s
+		} else {
+			mapping.PackageName = defaultPackage
+		}
+		if s, ok := fileOutputMap[p]; ok {
+			mapping.OutputName = s
+		}
+		cfg.SchemaMappings = append(cfg.SchemaMappings, mapping)
+	}
+	for _, format := range allKeys(formatMappingMap) {
+		cfg.FormatMappings = append(cfg.FormatMappings, generator.FormatMapping{
+			Format: format,
+			GoType: formatMappingMap[format],
+		})
+	}
+	return cfg
+}
+
+func writeOutput(fileName string, source []byte) {
+	if fileName == "-" {
+		if _, err := os.Stdout.Write(source); err != nil {
+			abortWithErr(err)
+		}
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+		abortWithErr(err)
+	}
+	w, err := os.OpenFile(fileName, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		abortWithErr(err)
+	}
+	if _, err = w.Write(source); err != nil {
+		abortWithErr(err)
+	}
+	_ = w.Close()
+}
+
 func abortWithErr(err error) {
 	if err != nil {
-		abort(err.Error())
+		reportError(err)
+		_, code := classifyError(err)
+		os.Exit(code)
 	}
 }
 
 func abort(message string) {
-	log("Failed: %s", message)
-	os.Exit(1)
+	abortWithErr(errors.New(message))
 }
 
 func stringSliceToStringMap(s []string) (map[string]string, error) {
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"os"
+	"time"
+
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/generator"
+)
+
+// fileState is what --watch compares to tell that a file has changed. The
+// hash of its contents catches edits that keep its size and modification
+// time, which file systems with coarse timestamps make likely.
+type fileState struct {
+	exists  bool
+	modTime time.Time
+	size    int64
+	sum     [sha256.Size]byte
+}
+
+// watchAndGenerate generates code as generate does, and then again whenever
+// one of the schema files that it has read, or that the arguments name,
+// changes, until ctx is done. The files are polled every --watch-interval,
+// rather than watched with notifications, which network file systems and
+// editors that replace files instead of writing them make unreliable; schema
+// files are small enough to read that often. Errors are reported, and the
+// files are watched on, so that they can be fixed.
+func watchAndGenerate(ctx context.Context, g *generator.Generator, args []string) {
+	// Files stay watched once read, so that those that a schema no longer
+	// refers to, or that failed to parse, are noticed if they change again.
+	watched := map[string]fileState{}
+	argFiles := func() []string {
+		fileNames, err := expandArgs(args)
+		if err != nil {
+			return nil
+		}
+		return fileNames
+	}
+
+	for {
+		// The files are recorded before generating, so that changes made
+		// while the generator reads them are noticed afterwards.
+		started := time.Now()
+		for _, fileName := range argFiles() {
+			watched[fileName] = fileState{}
+		}
+		for fileName := range watched {
+			watched[fileName] = statFile(fileName)
+		}
+
+		if err := generate(ctx, g, args); ctx.Err() != nil {
+			return
+		} else if err != nil {
+			reportError(err)
+		} else {
+			log("Generated code; watching for changes")
+		}
+
+		// Files that were first read by this run, such as those that the
+		// schemas refer to, can only be told to have changed while it read
+		// them by when they were modified.
+		changed := false
+		for _, fileName := range g.InputFiles() {
+			if _, ok := watched[fileName]; !ok {
+				state := statFile(fileName)
+				watched[fileName] = state
+				changed = changed || state.modTime.After(started)
+			}
+		}
+
+		ticker := time.NewTicker(watchInterval)
+		for !changed {
+			select {
+			case <-ctx.Done():
+				ticker.Stop()
+				return
+			case <-ticker.C:
+			}
+			changed = filesChanged(watched, argFiles())
+		}
+		ticker.Stop()
+
+		log("Files changed; generating code again")
+		var err error
+		if g, err = generator.New(newConfig()); err != nil {
+			abortWithErr(err)
+		}
+	}
+}
+
+// filesChanged reports whether any of the watched files has changed since
+// its state was recorded, or whether the arguments name files now that they
+// didn't before, such as ones added to a directory.
+func filesChanged(watched map[string]fileState, argFiles []string) bool {
+	for _, fileName := range argFiles {
+		if _, ok := watched[fileName]; !ok {
+			return true
+		}
+	}
+	for fileName, state := range watched {
+		if statFile(fileName) != state {
+			return true
+		}
+	}
+	return false
+}
+
+// statFile returns the state of a file, which is the zero state if it
+// doesn't exist or can't be read.
+func statFile(fileName string) fileState {
+	info, err := os.Stat(fileName)
+	if err != nil {
+		return fileState{}
+	}
+	b, err := os.ReadFile(fileName)
+	if err != nil {
+		return fileState{}
+	}
+	return fileState{exists: true, modTime: info.ModTime(), size: info.Size(), sum: sha256.Sum256(b)}
+}
@@ -0,0 +1,19 @@
+package codegen
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+)
+
+// GenerateAST generates the file in a style and parses it into a syntax tree,
+// with its comments, adding its positions to fset. The declarations are still
+// emitted as text, so the tree is for post-processing: it can be changed and
+// then printed with go/printer or go/format, which is how the generator turns
+// files into source. Code that does not parse is reported as an error rather
+// than printed.
+func (p *File) GenerateAST(fset *token.FileSet, style Style) (*ast.File, error) {
+	out := NewStyledEmitter(style)
+	p.Generate(out)
+	return parser.ParseFile(fset, p.FileName, out.Bytes(), parser.ParseComments)
+}
@@ -0,0 +1,89 @@
+package codegen
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/mitchellh/go-wordwrap"
+)
+
+// commentLines returns the lines of a line comment with the text s, such as
+// the description of a schema, without their "//" prefixes. Since line
+// comments only end at line breaks, s cannot end them early, and needs no
+// escaping other than by sanitizeComment.
+//
+// If width is not zero, the paragraphs of s, which are separated by blank
+// lines, are reflowed and wrapped to width, so that text that was wrapped to
+// another width in the schema reads as one paragraph. List items start new
+// lines, and indented lines, tables and headings are kept as they are.
+// Leading, trailing and repeated blank lines are dropped.
+func commentLines(s string, width uint) []string {
+	var lines, paragraph []string
+	flush := func() {
+		if len(paragraph) > 0 {
+			wrapped := wordwrap.WrapString(strings.Join(paragraph, " "), width)
+			lines = append(lines, strings.Split(wrapped, "\n")...)
+			paragraph = nil
+		}
+	}
+	blank := false
+	for _, line := range strings.Split(sanitizeComment(s), "\n") {
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			flush()
+			blank = len(lines) > 0
+			continue
+		}
+		if blank {
+			lines = append(lines, "")
+			blank = false
+		}
+		switch {
+		case width == 0 || isVerbatimCommentLine(line):
+			flush()
+			lines = append(lines, line)
+		case isListItem(line):
+			flush()
+			paragraph = []string{line}
+		default:
+			paragraph = append(paragraph, line)
+		}
+	}
+	flush()
+	return lines
+}
+
+// isVerbatimCommentLine reports whether a line of comment text is laid out
+// by hand, as Markdown code blocks, tables and headings are, so that it must
+// not be reflowed.
+func isVerbatimCommentLine(line string) bool {
+	switch line[0] {
+	case ' ', '\t', '|', '#':
+		return true
+	}
+	return false
+}
+
+// isListItem reports whether a line of comment text starts a Markdown list
+// item, such as "- foo" or "1. foo".
+func isListItem(line string) bool {
+	marker := strings.TrimLeftFunc(line, unicode.IsDigit)
+	if len(marker) == len(line) {
+		return strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* ") || strings.HasPrefix(line, "+ ")
+	}
+	return strings.HasPrefix(marker, ". ") || strings.HasPrefix(marker, ") ")
+}
+
+// sanitizeComment makes text safe to emit as line comments: line breaks
+// become newlines, invalid UTF-8 becomes U+FFFD, and other control
+// characters and byte order marks, which Go source cannot contain, are
+// dropped.
+func sanitizeComment(s string) string {
+	s = strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(strings.ToValidUTF8(s, "\uFFFD"))
+	return strings.Map(func(r rune) rune {
+		if (unicode.IsControl(r) && r != '\n' && r != '\t') || r == '\uFEFF' {
+			return -1
+		}
+		return r
+	}, s)
+}
@@ -3,21 +3,37 @@ package codegen
 import (
 	"fmt"
 	"strings"
-
-	"github.com/mitchellh/go-wordwrap"
 )
 
 type Emitter struct {
-	sb            strings.Builder
-	maxLineLength uint
-	start         bool
-	indent        uint
+	sb     strings.Builder
+	style  Style
+	start  bool
+	indent uint
+}
+
+// Style is how an Emitter lays out the code that it emits.
+type Style struct {
+	// LineWidth is the length that comments are wrapped to, counting their
+	// indentation.
+	LineWidth uint
+	// IndentSpaces is the number of spaces that each level is indented with,
+	// or zero to indent with tabs, which count as one character each.
+	IndentSpaces uint
+	// UnwrappedComments keeps the lines of comments as they are, however
+	// long they are.
+	UnwrappedComments bool
 }
 
 func NewEmitter(maxLineLength uint) *Emitter {
+	return NewStyledEmitter(Style{LineWidth: maxLineLength})
+}
+
+// NewStyledEmitter returns an emitter that lays out code in a style.
+func NewStyledEmitter(style Style) *Emitter {
 	return &Emitter{
-		maxLineLength: maxLineLength,
-		start:         true,
+		style: style,
+		start: true,
 	}
 }
 
@@ -36,16 +52,35 @@ func (e *Emitter) Indent(n int) {
 	e.indent += uint(n)
 }
 
+// Comment emits s as line comments, wrapped to the line width of the
+// emitter's style unless it keeps comments unwrapped. See commentLines.
 func (e *Emitter) Comment(s string) {
-	if s != "" {
-		limit := e.maxLineLength - uint(e.indent)
-		lines := strings.Split(wordwrap.WrapString(s, limit), "\n")
-		for _, line := range lines {
+	var width uint
+	if !e.style.UnwrappedComments {
+		width = e.commentWidth()
+	}
+	for _, line := range commentLines(s, width) {
+		if line == "" {
+			e.Println("//")
+		} else {
 			e.Println("// %s", line)
 		}
 	}
 }
 
+// commentWidth returns the length that comments are wrapped to at the
+// current indentation, which is at least one.
+func (e *Emitter) commentWidth() uint {
+	indent := e.indent
+	if e.style.IndentSpaces > 0 {
+		indent *= e.style.IndentSpaces
+	}
+	if indent >= e.style.LineWidth {
+		return 1
+	}
+	return e.style.LineWidth - indent
+}
+
 func (e *Emitter) Print(format string, args ...interface{}) {
 	e.checkIndent()
 	fmt.Fprintf(&e.sb, format, args...)
@@ -64,13 +99,15 @@ func (e *Emitter) Newline() {
 
 func (e *Emitter) checkIndent() {
 	if e.start {
-		for i := uint(0); i < e.indent; i++ {
-			e.sb.WriteRune('\t')
+		if e.style.IndentSpaces > 0 {
+			e.sb.WriteString(strings.Repeat(" ", int(e.indent*e.style.IndentSpaces)))
+		} else {
+			e.sb.WriteString(strings.Repeat("\t", int(e.indent)))
 		}
 		e.start = false
 	}
 }
 
 func (e *Emitter) MaxLineLength() uint {
-	return e.maxLineLength
+	return e.style.LineWidth
 }
@@ -1,10 +1,12 @@
 package codegen
 
 import (
-	"github.com/lets-dev-it-out/go-jsonschema/pkg/schemas"
 	"sort"
+	"strconv"
 	"strings"
+	"unicode"
 
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/schemas"
 	"github.com/sanity-io/litter"
 )
 
@@ -17,13 +19,38 @@ type Named interface {
 	GetName() string
 }
 
+// Owned is a declaration that belongs with a type declared in the same
+// package, such as one of its methods, and is generated right after it.
+type Owned interface {
+	Decl
+	OwnerName() string
+}
+
+// GeneratedComment is the comment at the top of every generated file, which
+// marks it as generated for tools and readers.
+const GeneratedComment = "Code generated by github.com/lets-dev-it-out/go-jsonschema, DO NOT EDIT."
+
 type File struct {
 	FileName string
-	Package  Package
+	// Header is text, such as a license, that is emitted as line comments
+	// above GeneratedComment, as it is.
+	Header  string
+	Package Package
 }
 
 func (p *File) Generate(out *Emitter) {
-	out.Comment("Code generated by github.com/lets-dev-it-out/go-jsonschema, DO NOT EDIT.")
+	if p.Header != "" {
+		for _, line := range strings.Split(strings.TrimRight(sanitizeComment(p.Header), "\n"), "\n") {
+			if line = strings.TrimRight(line, " \t"); line == "" {
+				out.Println("//")
+			} else {
+				out.Println("// %s", line)
+			}
+		}
+		out.Newline()
+	}
+	// Never wrapped, since tools only recognize it on one line.
+	out.Println("// %s", GeneratedComment)
 	out.Newline()
 	p.Package.Generate(out)
 }
@@ -58,35 +85,48 @@ func (p *Package) hasImport(q string) bool {
 	return false
 }
 
+// Name returns the name of the package, which is the last element of its
+// import path other than a major version such as v2, as in example.com/lib/v2,
+// without the characters that identifiers cannot have, so that a package in
+// the directory my-types is named mytypes.
 func (p *Package) Name() string {
-	s := p.QualifiedName
-	if i := strings.LastIndex(s, "/"); i != -1 && i < len(s)-1 {
-		return s[i+1:]
+	elems := strings.Split(strings.TrimSuffix(p.QualifiedName, "/"), "/")
+	name := elems[len(elems)-1]
+	if len(elems) > 1 && isMajorVersion(name) {
+		name = elems[len(elems)-2]
+	}
+	sanitized := strings.Map(func(r rune) rune {
+		if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, name)
+	if sanitized == "" {
+		return name
+	}
+	if unicode.IsDigit([]rune(sanitized)[0]) {
+		return "_" + sanitized
+	}
+	return sanitized
+}
+
+// isMajorVersion reports whether an element of an import path is a major
+// version suffix, such as v2.
+func isMajorVersion(elem string) bool {
+	if len(elem) < 2 || elem[0] != 'v' {
+		return false
 	}
-	return s
+	_, err := strconv.Atoi(elem[1:])
+	return err == nil
 }
 
 func (p *Package) Generate(out *Emitter) {
 	out.Comment(p.Comment)
 	out.Println("package %s", p.Name())
-	if len(p.Imports) > 0 {
-		for _, i := range p.Imports {
-			i.Generate(out)
-		}
-	}
+	p.generateImports(out)
 	out.Newline()
 
-	sorted := make([]Decl, len(p.Decls))
-	copy(sorted, p.Decls)
-	sort.Slice(sorted, func(i, j int) bool {
-		if a, ok := sorted[i].(Named); ok {
-			if b, ok := sorted[j].(Named); ok {
-				return a.GetName() < b.GetName()
-			}
-		}
-		return false
-	})
-	for i, t := range sorted {
+	for i, t := range p.sortedDecls() {
 		if i > 0 {
 			out.Newline()
 		}
@@ -94,42 +134,116 @@ func (p *Package) Generate(out *Emitter) {
 	}
 }
 
+// generateImports emits the imports of the package as one declaration,
+// grouped as goimports groups them: the standard library first, then the
+// rest, each sorted by path.
+func (p *Package) generateImports(out *Emitter) {
+	if len(p.Imports) == 0 {
+		return
+	}
+	out.Newline()
+	if len(p.Imports) == 1 {
+		p.Imports[0].Generate(out)
+		return
+	}
+
+	var std, other []Import
+	for _, i := range p.Imports {
+		if isStandardImport(i.QualifiedName) {
+			std = append(std, i)
+		} else {
+			other = append(other, i)
+		}
+	}
+	for _, group := range [][]Import{std, other} {
+		sort.Slice(group, func(i, j int) bool {
+			return group[i].QualifiedName < group[j].QualifiedName
+		})
+	}
+	out.Println("import (")
+	out.Indent(1)
+	for _, i := range std {
+		i.generateSpec(out)
+	}
+	if len(std) > 0 && len(other) > 0 {
+		out.Newline()
+	}
+	for _, i := range other {
+		i.generateSpec(out)
+	}
+	out.Indent(-1)
+	out.Println(")")
+}
+
+// isStandardImport reports whether an import path is of a package in the
+// standard library, whose first element, unlike a domain, has no dot.
+func isStandardImport(path string) bool {
+	first := path
+	if i := strings.Index(path, "/"); i != -1 {
+		first = path[:i]
+	}
+	return !strings.Contains(first, ".")
+}
+
 // Var is a "var <name> = <value>".
 type Var struct {
 	Type  Type
 	Name  string
 	Value interface{}
+	// Owner is the name of the type that the variable belongs with, if any.
+	Owner string
 }
 
 func (v *Var) GetName() string {
 	return v.Name
 }
 
+func (v *Var) OwnerName() string {
+	return v.Owner
+}
+
 func (v *Var) Generate(out *Emitter) {
 	out.Print("var %s ", v.Name)
 	if v.Type != nil {
 		v.Type.Generate(out)
 	}
-	out.Print(" = %s", litter.Sdump(v.Value))
+	if expr, ok := v.Value.(Expr); ok {
+		out.Print(" = %s", string(expr))
+	} else {
+		out.Print(" = %s", litter.Sdump(v.Value))
+	}
 }
 
+// Expr is a raw Go expression, emitted verbatim when used as a value.
+type Expr string
+
 // Constant is a "const <name> = <value>".
 type Constant struct {
 	Type  Type
 	Name  string
 	Value interface{}
+	// Owner is the name of the type that the constant belongs with, if any.
+	Owner string
 }
 
 func (c *Constant) GetName() string {
 	return c.Name
 }
 
+func (c *Constant) OwnerName() string {
+	return c.Owner
+}
+
 func (c *Constant) Generate(out *Emitter) {
 	out.Print("const %s ", c.Name)
 	if c.Type != nil {
 		c.Type.Generate(out)
 	}
-	out.Print(" = %s", litter.Sdump(c.Value))
+	if expr, ok := c.Value.(Expr); ok {
+		out.Print(" = %s", string(expr))
+	} else {
+		out.Print(" = %s", litter.Sdump(c.Value))
+	}
 }
 
 // Fragment is an arbitary piece of code.
@@ -142,6 +256,13 @@ func (f Fragment) Generate(out *Emitter) {
 // Method defines a method and how to generate it.
 type Method struct {
 	Impl func(*Emitter)
+	// Owner is the name of the type that the method, or function, belongs
+	// with, if any. Functions shared by many types belong with none.
+	Owner string
+}
+
+func (m *Method) OwnerName() string {
+	return m.Owner
 }
 
 func (m *Method) Generate(out *Emitter) {
@@ -157,10 +278,15 @@ type Import struct {
 }
 
 func (i *Import) Generate(out *Emitter) {
+	out.Print("import ")
+	i.generateSpec(out)
+}
+
+func (i *Import) generateSpec(out *Emitter) {
 	if i.Name != "" {
-		out.Println("import %s %q", i.Name, i.QualifiedName)
+		out.Println("%s %q", i.Name, i.QualifiedName)
 	} else {
-		out.Println("import %q", i.QualifiedName)
+		out.Println("%q", i.QualifiedName)
 	}
 }
 
@@ -324,7 +450,9 @@ func (f *StructField) Generate(out *Emitter) {
 	out.Comment(f.Comment)
 	out.Print("%s ", f.Name)
 	f.Type.Generate(out)
-	if f.Tags != "" {
+	if strings.Contains(f.Tags, "`") {
+		out.Print(" %s", strconv.Quote(f.Tags))
+	} else if f.Tags != "" {
 		out.Print(" `%s`", f.Tags)
 	}
 }
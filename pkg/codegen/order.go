@@ -0,0 +1,134 @@
+package codegen
+
+import "sort"
+
+// sortedDecls returns the declarations of the package in the order they are
+// generated in, so that regenerating the same schemas gives the same code
+// however their types were reached:
+//
+//   - type declarations, each after the types that it refers to, unless they
+//     refer back to it, and otherwise by name;
+//   - right after each type, the declarations that it owns: its constants,
+//     then its variables, then its methods and functions, each in the order
+//     they were added;
+//   - the variables and constants that belong to no type, by name;
+//   - the other declarations, such as functions shared by many types, in the
+//     order they were added.
+func (p *Package) sortedDecls() []Decl {
+	types := map[string]*TypeDecl{}
+	var names []string
+	for _, d := range p.Decls {
+		if td, ok := d.(*TypeDecl); ok {
+			if _, ok := types[td.Name]; !ok {
+				names = append(names, td.Name)
+			}
+			types[td.Name] = td
+		}
+	}
+	sort.Strings(names)
+
+	owned := map[string][]Decl{}
+	var named []Named
+	var rest []Decl
+	for _, d := range p.Decls {
+		if _, ok := d.(*TypeDecl); ok {
+			continue
+		}
+		if o, ok := d.(Owned); ok && types[o.OwnerName()] != nil {
+			owned[o.OwnerName()] = append(owned[o.OwnerName()], d)
+		} else if n, ok := d.(Named); ok {
+			named = append(named, n)
+		} else {
+			rest = append(rest, d)
+		}
+	}
+	for _, decls := range owned {
+		sort.SliceStable(decls, func(i, j int) bool {
+			return ownedRank(decls[i]) < ownedRank(decls[j])
+		})
+	}
+	sort.SliceStable(named, func(i, j int) bool {
+		return named[i].GetName() < named[j].GetName()
+	})
+
+	sorted := make([]Decl, 0, len(p.Decls))
+	visited := map[string]bool{}
+	var visit func(name string)
+	visit = func(name string) {
+		if visited[name] {
+			return
+		}
+		visited[name] = true
+		for _, dep := range ReferencedTypeDecls(types[name].Type) {
+			if types[dep.Name] != nil {
+				visit(dep.Name)
+			}
+		}
+		sorted = append(sorted, types[name])
+		sorted = append(sorted, owned[name]...)
+	}
+	for _, name := range names {
+		visit(name)
+	}
+	for _, n := range named {
+		sorted = append(sorted, n)
+	}
+	return append(sorted, rest...)
+}
+
+// ReferencedTypeDecls returns the declarations of the types in the same
+// package that a type refers to, directly or through its elements and
+// fields, sorted by name.
+func ReferencedTypeDecls(t Type) []*TypeDecl {
+	decls := map[*TypeDecl]bool{}
+	collectTypeDecls(t, decls)
+	sorted := make([]*TypeDecl, 0, len(decls))
+	for d := range decls {
+		sorted = append(sorted, d)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+func collectTypeDecls(t Type, decls map[*TypeDecl]bool) {
+	switch t := t.(type) {
+	case NamedType:
+		collectTypeDecls(&t, decls)
+	case *NamedType:
+		if t.Package == nil && t.Decl != nil {
+			decls[t.Decl] = true
+		}
+	case PointerType:
+		collectTypeDecls(t.Type, decls)
+	case *PointerType:
+		collectTypeDecls(t.Type, decls)
+	case ArrayType:
+		collectTypeDecls(t.Type, decls)
+	case *ArrayType:
+		collectTypeDecls(t.Type, decls)
+	case MapType:
+		collectTypeDecls(t.KeyType, decls)
+		collectTypeDecls(t.ValueType, decls)
+	case *MapType:
+		collectTypeDecls(t.KeyType, decls)
+		collectTypeDecls(t.ValueType, decls)
+	case *StructType:
+		for _, f := range t.Fields {
+			collectTypeDecls(f.Type, decls)
+		}
+	}
+}
+
+// ownedRank orders the kinds of declarations that a type owns.
+func ownedRank(d Decl) int {
+	switch d.(type) {
+	case *Constant:
+		return 0
+	case *Var:
+		return 1
+	default:
+		return 2
+	}
+}
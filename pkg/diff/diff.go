@@ -0,0 +1,265 @@
+// Package diff compares two versions of a schema, and reports the changes
+// that break the Go types generated from it, so that schema repositories can
+// catch them in CI.
+package diff
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/schemas"
+)
+
+// Change is a difference between two versions of a schema.
+type Change struct {
+	// Path is the JSON Pointer, within the schema, of what changed.
+	Path string
+	// Message describes the change.
+	Message string
+	// Breaking is whether code that uses the types generated from the old
+	// version may fail to compile against those generated from the new one,
+	// or documents that were valid may be rejected.
+	Breaking bool
+}
+
+func (c Change) String() string {
+	kind := "non-breaking"
+	if c.Breaking {
+		kind = "BREAKING"
+	}
+	return fmt.Sprintf("%s: %s: %s", kind, c.Path, c.Message)
+}
+
+// HasBreaking reports whether any of the changes is breaking.
+func HasBreaking(changes []Change) bool {
+	for _, c := range changes {
+		if c.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// Compare returns the changes from one version of a schema to another:
+//
+//   - Definitions and properties that are removed are breaking, since their
+//     types and fields are removed.
+//   - Changes to the type of a value, including whether it is nullable, or to
+//     the definition it refers to, are breaking, since its Go type changes.
+//   - Properties that become required or optional are breaking, since their
+//     fields become values or pointers.
+//   - Properties that are added are breaking if they are required.
+//   - Enums that are added or removed are breaking, since their Go types
+//     change, and so are enum values that are removed, since their constants
+//     are removed and documents that use them are rejected.
+//
+// Other additions, such as definitions and enum values, are reported as
+// non-breaking. References are compared by name, without following them.
+func Compare(old, new *schemas.Schema) []Change {
+	c := &comparer{}
+	oldDefs, newDefs := old.AllDefinitions(), new.AllDefinitions()
+	for _, name := range unionKeys(oldDefs, newDefs) {
+		path := "/" + definitionsKey(name, new, old) + "/" + escape(name)
+		oldDef, inOld := oldDefs[name]
+		newDef, inNew := newDefs[name]
+		switch {
+		case !inNew:
+			c.add(path, true, "definition removed")
+		case !inOld:
+			c.add(path, false, "definition added")
+		default:
+			c.compare(path, oldDef, newDef)
+		}
+	}
+	if old.ObjectAsType != nil && new.ObjectAsType != nil {
+		c.compare("", (*schemas.Type)(old.ObjectAsType), (*schemas.Type)(new.ObjectAsType))
+	}
+	return c.changes
+}
+
+type comparer struct {
+	changes []Change
+}
+
+func (c *comparer) add(path string, breaking bool, format string, args ...interface{}) {
+	c.changes = append(c.changes, Change{Path: path, Message: fmt.Sprintf(format, args...), Breaking: breaking})
+}
+
+func (c *comparer) compare(path string, old, new *schemas.Type) {
+	if old.Ref != new.Ref {
+		c.add(path+"/$ref", true, "reference changed from %s to %s", describeRef(old.Ref), describeRef(new.Ref))
+		return
+	}
+	if oldTypes, newTypes := typeNames(old), typeNames(new); oldTypes != newTypes {
+		c.add(path+"/type", true, "type changed from %s to %s", oldTypes, newTypes)
+		return
+	}
+
+	c.compareEnum(path, old.Enum, new.Enum)
+
+	oldRequired, newRequired := stringSet(old.Required), stringSet(new.Required)
+	for _, name := range unionKeys(old.Properties, new.Properties) {
+		propPath := path + "/properties/" + escape(name)
+		oldProp, inOld := old.Properties[name]
+		newProp, inNew := new.Properties[name]
+		switch {
+		case !inNew:
+			c.add(propPath, true, "property removed")
+		case !inOld:
+			if newRequired[name] {
+				c.add(propPath, true, "required property added")
+			} else {
+				c.add(propPath, false, "optional property added")
+			}
+		default:
+			if !oldRequired[name] && newRequired[name] {
+				c.add(propPath, true, "property became required")
+			} else if oldRequired[name] && !newRequired[name] {
+				c.add(propPath, true, "property became optional")
+			}
+			c.compare(propPath, oldProp, newProp)
+		}
+	}
+
+	switch {
+	case old.Items.IsTuple() && new.Items.IsTuple():
+		c.compareTuple(path+"/items", old.Items.Tuple, new.Items.Tuple)
+	case old.Items.IsTuple() != new.Items.IsTuple():
+		c.add(path+"/items", true, "items changed from %s to %s", describeItems(old.Items), describeItems(new.Items))
+	case old.Items != nil && new.Items != nil:
+		c.compare(path+"/items", old.Items.All(), new.Items.All())
+	case old.Items != nil:
+		c.add(path+"/items", true, "items schema removed")
+	case new.Items != nil:
+		c.add(path+"/items", true, "items schema added")
+	}
+}
+
+// compareTuple compares the item schemas of arrays of schemas by position.
+func (c *comparer) compareTuple(path string, old, new []*schemas.Type) {
+	for i := 0; i < len(old) || i < len(new); i++ {
+		itemPath := fmt.Sprintf("%s/%d", path, i)
+		switch {
+		case i >= len(new):
+			c.add(itemPath, true, "item schema removed")
+		case i >= len(old):
+			c.add(itemPath, true, "item schema added")
+		default:
+			c.compare(itemPath, old[i], new[i])
+		}
+	}
+}
+
+func describeItems(items *schemas.Items) string {
+	if items.IsTuple() {
+		return "an array of schemas"
+	}
+	if items == nil {
+		return "none"
+	}
+	return "a schema"
+}
+
+func (c *comparer) compareEnum(path string, old, new []interface{}) {
+	if len(old) == 0 && len(new) == 0 {
+		return
+	}
+	if len(old) == 0 {
+		c.add(path+"/enum", true, "enum added")
+		return
+	}
+	if len(new) == 0 {
+		c.add(path+"/enum", true, "enum removed")
+		return
+	}
+
+	contains := func(values []interface{}, v interface{}) bool {
+		for _, value := range values {
+			if reflect.DeepEqual(value, v) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, v := range old {
+		if !contains(new, v) {
+			c.add(path+"/enum", true, "enum value %s removed", formatValue(v))
+		}
+	}
+	for _, v := range new {
+		if !contains(old, v) {
+			c.add(path+"/enum", false, "enum value %s added", formatValue(v))
+		}
+	}
+}
+
+// typeNames returns the sorted type names of a schema, which defaults to an
+// object if it has properties, as the generator does.
+func typeNames(t *schemas.Type) string {
+	names := append([]string(nil), t.Type...)
+	if len(names) == 0 && len(t.Properties) > 0 {
+		names = []string{schemas.TypeNameObject}
+	}
+	if len(names) == 0 {
+		return "any"
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func describeRef(ref string) string {
+	if ref == "" {
+		return "none"
+	}
+	return fmt.Sprintf("%q", ref)
+}
+
+// definitionsKey returns the keyword that the first of the schemas to declare
+// a definition declares it with.
+func definitionsKey(name string, schemas ...*schemas.Schema) string {
+	for _, s := range schemas {
+		if _, ok := s.Definitions[name]; ok {
+			return "definitions"
+		}
+		if _, ok := s.Defs[name]; ok {
+			return "$defs"
+		}
+	}
+	return "definitions"
+}
+
+func formatValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return fmt.Sprint(v)
+}
+
+func escape(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
+
+func stringSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
+
+func unionKeys(a, b map[string]*schemas.Type) []string {
+	seen := map[string]bool{}
+	var keys []string
+	for _, m := range []map[string]*schemas.Type{a, b} {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
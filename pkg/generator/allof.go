@@ -0,0 +1,55 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/schemas"
+)
+
+// flattenAllOf returns the type that a type with allOf is generated as: the
+// type merged with each of its subschemas, following their $refs. The
+// result is the same for every call with the same type, so that it is only
+// declared once.
+//
+// Subschemas that refer to other files are not merged, since the $refs
+// within them would be resolved against the wrong file; the type is then
+// generated without them, as before allOf was supported.
+func (g *schemaGenerator) flattenAllOf(t *schemas.Type) (*schemas.Type, error) {
+	if len(t.AllOf) == 0 || t.Ref != "" {
+		return t, nil
+	}
+	if flat, ok := g.flattened[t]; ok {
+		return flat, nil
+	}
+
+	flat := *t
+	flat.AllOf = nil
+	merged := &flat
+	for i, sub := range t.AllOf {
+		for sub.Ref != "" {
+			var fileName string
+			var err error
+			sub, _, fileName, err = g.resolveRef(sub.Ref, g.schema, g.schemaFileName)
+			if err != nil {
+				return nil, err
+			}
+			if fileName != g.schemaFileName {
+				g.warnf(t, WarningUnsupported, "allOf subschemas in other files are not merged; ignoring allOf/%d", i)
+				g.flattened[t] = t
+				return t, nil
+			}
+		}
+		sub, err := g.flattenAllOf(sub)
+		if err != nil {
+			return nil, err
+		}
+		if merged, err = schemas.Merge(merged, sub); err != nil {
+			return nil, fmt.Errorf("cannot merge allOf/%d: %w", i, err)
+		}
+	}
+	if len(merged.AllOf) > 0 {
+		g.warnf(t, WarningNotValidated, "Some allOf constraints cannot be merged into one type; they are not validated")
+	}
+	g.flattened[t] = merged
+	return merged, nil
+}
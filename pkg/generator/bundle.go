@@ -0,0 +1,289 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/yamlutils"
+)
+
+// Bundle returns the schema in a file, at an HTTP or HTTPS URL, or in
+// standard input if the name is "-", as a single JSON document that doesn't refer to any other. The definitions in
+// other files that it refers to, directly or through other files, are copied
+// into its own definitions, and so are the files that it refers to as a
+// whole. References are rewritten to point to the copies.
+func (g *Generator) Bundle(fileName string) ([]byte, error) {
+	return g.BundleContext(context.Background(), fileName)
+}
+
+// BundleContext is Bundle with a context, which the files that the schema
+// refers to by URL are fetched with.
+func (g *Generator) BundleContext(ctx context.Context, fileName string) ([]byte, error) {
+	defer g.withContext(ctx)()
+
+	return g.bundle(fileName)
+}
+
+// bundle is Bundle, with the context of the call in progress.
+func (g *Generator) bundle(fileName string) ([]byte, error) {
+	rootFileName := fileName
+	if !isHTTPURL(fileName) && fileName != "-" {
+		var err error
+		if rootFileName, err = g.canonicalFileName(fileName); err != nil {
+			return nil, err
+		}
+	}
+
+	b := &bundler{
+		Generator:    g,
+		rootFileName: rootFileName,
+		defsKey:      "definitions",
+		documents:    map[string]yaml.MapSlice{},
+		names:        map[string]string{},
+		taken:        map[string]bool{},
+	}
+	root, err := b.document(rootFileName)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := yamlutils.Value(root, "definitions"); !ok {
+		if _, ok := yamlutils.Value(root, "$defs"); ok {
+			b.defsKey = "$defs"
+		}
+	}
+	defs, _ := yamlutils.Value(root, b.defsKey)
+	rootDefs, _ := defs.(yaml.MapSlice)
+	for _, item := range rootDefs {
+		b.taken[fmt.Sprint(item.Key)] = true
+	}
+
+	rewritten, err := b.rewrite(root, rootFileName)
+	if err != nil {
+		return nil, err
+	}
+	result := rewritten.(yaml.MapSlice)
+	if len(b.defs) > 0 {
+		defs, _ := yamlutils.Value(result, b.defsKey)
+		rootDefs, _ := defs.(yaml.MapSlice)
+		result = yamlutils.SetValue(result, b.defsKey, append(rootDefs, b.defs...))
+	}
+
+	data, err := yamlutils.MarshalJSON(result)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+type bundler struct {
+	*Generator
+	rootFileName string
+	// defsKey is the keyword that the root schema declares its definitions
+	// with, "definitions" or "$defs".
+	defsKey   string
+	documents map[string]yaml.MapSlice
+	// names maps the file name and definition name of each copied schema,
+	// joined with "#", to its name among the root's definitions.
+	names map[string]string
+	taken map[string]bool
+	defs  yaml.MapSlice
+}
+
+// document reads and caches a schema document, in JSON or YAML.
+func (b *bundler) document(fileName string) (yaml.MapSlice, error) {
+	if doc, ok := b.documents[fileName]; ok {
+		return doc, nil
+	}
+
+	var r io.ReadCloser
+	var err error
+	switch {
+	case fileName == "-":
+		r = io.NopCloser(os.Stdin)
+	case isHTTPURL(fileName):
+		r, err = b.fetch(fileName)
+	default:
+		r, err = b.openFile(fileName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", fileName, err)
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+
+	var doc yaml.MapSlice
+	if err := yaml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", fileName, err)
+	}
+	b.documents[fileName] = doc
+	return doc, nil
+}
+
+// rewrite returns a copy of a value from the document in a file, with the
+// references in it rewritten to point into the bundle.
+func (b *bundler) rewrite(value interface{}, fileName string) (interface{}, error) {
+	switch v := value.(type) {
+	case yaml.MapSlice:
+		result := make(yaml.MapSlice, 0, len(v))
+		for _, item := range v {
+			switch item.Key {
+			case "enum", "const", "default", "examples":
+				// Values, which may have a "$ref" key of their own
+			case "$ref":
+				if ref, ok := item.Value.(string); ok {
+					rewritten, err := b.ref(ref, fileName)
+					if err != nil {
+						return nil, err
+					}
+					item.Value = rewritten
+				}
+			default:
+				rewritten, err := b.rewrite(item.Value, fileName)
+				if err != nil {
+					return nil, err
+				}
+				item.Value = rewritten
+			}
+			result = append(result, item)
+		}
+		return result, nil
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, elem := range v {
+			rewritten, err := b.rewrite(elem, fileName)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = rewritten
+		}
+		return result, nil
+	default:
+		return value, nil
+	}
+}
+
+// ref rewrites a reference from the document in a file to point into the
+// bundle, copying the schema it points to if it is in another document.
+func (b *bundler) ref(ref, fileName string) (string, error) {
+	refFileName, pointer := ref, ""
+	if i := strings.IndexRune(ref, '#'); i != -1 {
+		refFileName, pointer = ref[:i], ref[i+1:]
+	}
+
+	target := fileName
+	if refFileName != "" {
+		var err error
+		if u, ok := schemaURL(refFileName, fileName); ok {
+			target = u
+		} else if target, err = b.resolveFileName(refFileName, fileName); err != nil {
+			return "", fmt.Errorf("could not follow $ref %q: %w", ref, err)
+		}
+	}
+	if target == b.rootFileName {
+		return "#" + pointer, nil
+	}
+
+	defName, rest := "", pointer
+	for _, prefix := range []string{"/definitions/", "/$defs/"} {
+		if strings.HasPrefix(pointer, prefix) {
+			defName = pointer[len(prefix):]
+			if i := strings.IndexRune(defName, '/'); i != -1 {
+				defName, rest = defName[:i], defName[i:]
+			} else {
+				rest = ""
+			}
+			defName = strings.NewReplacer("~1", "/", "~0", "~").Replace(defName)
+			break
+		}
+	}
+
+	name, err := b.copy(target, defName)
+	if err != nil {
+		return "", fmt.Errorf("could not follow $ref %q: %w", ref, err)
+	}
+	return "#/" + b.defsKey + "/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(name) + rest, nil
+}
+
+// copy copies a definition in the document in a file, or the whole document
+// if defName is empty, into the root's definitions, and returns its name
+// there.
+func (b *bundler) copy(fileName, defName string) (string, error) {
+	key := fileName + "#" + defName
+	if name, ok := b.names[key]; ok {
+		return name, nil
+	}
+
+	doc, err := b.document(fileName)
+	if err != nil {
+		return "", err
+	}
+
+	base := path.Base(strings.SplitN(fileName, "?", 2)[0])
+	base = strings.TrimSuffix(base, path.Ext(base))
+	var schema interface{}
+	var name string
+	if defName == "" {
+		// Drop what only makes sense at the root of a document; any of its
+		// definitions that are referred to are copied on their own.
+		whole := make(yaml.MapSlice, 0, len(doc))
+		for _, item := range doc {
+			switch item.Key {
+			case "$schema", "$id", "id", "definitions", "$defs":
+			default:
+				whole = append(whole, item)
+			}
+		}
+		schema, name = whole, b.uniqueName(base)
+	} else {
+		var ok bool
+		for _, key := range []string{"definitions", "$defs"} {
+			defs, _ := yamlutils.Value(doc, key)
+			m, _ := defs.(yaml.MapSlice)
+			if schema, ok = yamlutils.Value(m, defName); ok {
+				break
+			}
+		}
+		if !ok {
+			return "", fmt.Errorf("definition %q does not exist in %s", defName, fileName)
+		}
+		name = defName
+		if b.taken[name] {
+			name = b.uniqueName(base + "_" + defName)
+		}
+		b.taken[name] = true
+	}
+
+	b.names[key] = name
+	i := len(b.defs)
+	b.defs = append(b.defs, yaml.MapItem{Key: name})
+	rewritten, err := b.rewrite(schema, fileName)
+	if err != nil {
+		return "", err
+	}
+	b.defs[i].Value = rewritten
+	return name, nil
+}
+
+// uniqueName returns a name for a copied schema that isn't already taken.
+func (b *bundler) uniqueName(name string) string {
+	unique := name
+	for i := 2; b.taken[unique]; i++ {
+		unique = fmt.Sprintf("%s_%d", name, i)
+	}
+	b.taken[unique] = true
+	return unique
+}
@@ -0,0 +1,71 @@
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// DefaultCatalogURL is the URL of the schemastore.org catalog, which lists
+// the schemas of many configuration files and tools.
+const DefaultCatalogURL = "https://www.schemastore.org/api/json/catalog.json"
+
+type catalogEntry struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// DoCatalogSchema generates types for a schema listed in the schema catalog,
+// which is schemastore.org's unless Config.CatalogURL says otherwise. The
+// schema may be given by the name of its file on the catalog's server, such
+// as "github-workflow" for https://json.schemastore.org/github-workflow.json,
+// or by its name in the catalog, such as "GitHub Workflow", ignoring case.
+func (g *Generator) DoCatalogSchema(ctx context.Context, name string) error {
+	if g.catalog == nil {
+		if err := g.fetchCatalog(ctx); err != nil {
+			return err
+		}
+	}
+
+	var schemaURL string
+	for _, entry := range g.catalog {
+		if strings.TrimSuffix(path.Base(entry.URL), ".json") == name {
+			schemaURL = entry.URL
+			break
+		}
+		if schemaURL == "" && strings.EqualFold(entry.Name, name) {
+			schemaURL = entry.URL
+		}
+	}
+	if schemaURL == "" {
+		return fmt.Errorf("schema %q not found in catalog", name)
+	}
+	return g.DoURL(ctx, schemaURL)
+}
+
+func (g *Generator) fetchCatalog(ctx context.Context) error {
+	catalogURL := g.config.CatalogURL
+	if catalogURL == "" {
+		catalogURL = DefaultCatalogURL
+	}
+
+	defer g.withContext(ctx)()
+	body, err := g.fetch(catalogURL)
+	if err != nil {
+		return fmt.Errorf("error fetching catalog %s: %w", catalogURL, err)
+	}
+	defer func() {
+		_ = body.Close()
+	}()
+
+	var catalog struct {
+		Schemas []catalogEntry `json:"schemas"`
+	}
+	if err := json.NewDecoder(body).Decode(&catalog); err != nil {
+		return fmt.Errorf("error parsing catalog %s: %w", catalogURL, err)
+	}
+	g.catalog = catalog.Schemas
+	return nil
+}
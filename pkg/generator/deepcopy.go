@@ -0,0 +1,233 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/codegen"
+)
+
+const funcNameDeepCopyJSONValue = "deepCopyJSONValue"
+
+// generateDeepCopy declares DeepCopyInto and DeepCopy methods for a struct,
+// which copy the maps, slices and pointers it holds, recursively.
+func (g *schemaGenerator) generateDeepCopy(decl *codegen.TypeDecl, structType *codegen.StructType) {
+	g.output.deepCopyDecls[decl] = true
+	for _, f := range structType.Fields {
+		if containsInterface(f.Type) {
+			g.declareDeepCopyJSONValue()
+			break
+		}
+	}
+
+	g.output.file.Package.AddDecl(&codegen.Method{
+		Owner: decl.Name,
+		Impl: func(out *codegen.Emitter) {
+			out.Comment("DeepCopyInto copies the receiver into out, which must be non-nil.")
+			out.Println("func (in *%s) DeepCopyInto(out *%s) {", decl.Name, decl.Name)
+			out.Indent(1)
+			out.Println("*out = *in")
+			for _, f := range structType.Fields {
+				if g.needsDeepCopy(f.Type) {
+					g.emitDeepCopy(out, "out."+f.Name, "in."+f.Name, f.Type, 0)
+				}
+			}
+			out.Indent(-1)
+			out.Println("}")
+			out.Newline()
+
+			out.Comment("DeepCopy returns a deep copy of the receiver.")
+			out.Println("func (in *%s) DeepCopy() *%s {", decl.Name, decl.Name)
+			out.Indent(1)
+			out.Println("if in == nil {")
+			out.Indent(1)
+			out.Println("return nil")
+			out.Indent(-1)
+			out.Println("}")
+			out.Println("out := new(%s)", decl.Name)
+			out.Println("in.DeepCopyInto(out)")
+			out.Println("return out")
+			out.Indent(-1)
+			out.Println("}")
+		},
+	})
+}
+
+// emitDeepCopy emits code that assigns a deep copy of the value src to dst,
+// which must be addressable. depth numbers the variables of nested loops.
+func (g *schemaGenerator) emitDeepCopy(out *codegen.Emitter, dst, src string, t codegen.Type, depth int) {
+	if !g.needsDeepCopy(t) {
+		out.Println("%s = %s", dst, src)
+		return
+	}
+
+	if nt, ok := t.(*codegen.NamedType); ok && g.hasDeepCopy(nt) {
+		out.Println("%s.DeepCopyInto(&%s)", src, dst)
+		return
+	}
+
+	u := underlyingType(t)
+	if at, ok := asArrayType(u); ok {
+		u = at
+	}
+	switch x := u.(type) {
+	case *codegen.PointerType:
+		out.Println("if %s != nil {", src)
+		out.Indent(1)
+		out.Println("%s = new(%s)", dst, typeString(x.Type))
+		if nt, ok := x.Type.(*codegen.NamedType); ok && g.hasDeepCopy(nt) {
+			out.Println("%s.DeepCopyInto(%s)", src, dst)
+		} else {
+			g.emitDeepCopy(out, "*"+dst, "*"+src, x.Type, depth)
+		}
+		out.Indent(-1)
+		out.Println("}")
+
+	case *codegen.ArrayType:
+		out.Println("if %s != nil {", src)
+		out.Indent(1)
+		out.Println("%s = make(%s, len(%s))", dst, typeString(t), src)
+		if g.needsDeepCopy(x.Type) {
+			i := fmt.Sprintf("i%d", depth)
+			out.Println("for %s := range %s {", i, src)
+			out.Indent(1)
+			g.emitDeepCopy(out, fmt.Sprintf("%s[%s]", dst, i), fmt.Sprintf("%s[%s]", src, i), x.Type, depth+1)
+			out.Indent(-1)
+			out.Println("}")
+		} else {
+			out.Println("copy(%s, %s)", dst, src)
+		}
+		out.Indent(-1)
+		out.Println("}")
+
+	case *codegen.MapType:
+		k, v, c := fmt.Sprintf("k%d", depth), fmt.Sprintf("v%d", depth), fmt.Sprintf("c%d", depth)
+		out.Println("if %s != nil {", src)
+		out.Indent(1)
+		out.Println("%s = make(%s, len(%s))", dst, typeString(t), src)
+		out.Println("for %s, %s := range %s {", k, v, src)
+		out.Indent(1)
+		if g.needsDeepCopy(x.ValueType) {
+			out.Println("var %s %s", c, typeString(x.ValueType))
+			g.emitDeepCopy(out, c, v, x.ValueType, depth+1)
+			out.Println("%s[%s] = %s", dst, k, c)
+		} else {
+			out.Println("%s[%s] = %s", dst, k, v)
+		}
+		out.Indent(-1)
+		out.Println("}")
+		out.Indent(-1)
+		out.Println("}")
+
+	default:
+		out.Println("%s = %s(%s)", dst, funcNameDeepCopyJSONValue, src)
+	}
+}
+
+// needsDeepCopy reports whether copying a value of type t by assignment
+// would share memory with the original.
+func (g *schemaGenerator) needsDeepCopy(t codegen.Type) bool {
+	if nt, ok := t.(*codegen.NamedType); ok {
+		if g.hasDeepCopy(nt) {
+			return true
+		}
+		if _, ok := nt.Decl.Type.(*codegen.StructType); ok {
+			return false
+		}
+	}
+	switch underlyingType(t).(type) {
+	case *codegen.PointerType, *codegen.ArrayType, codegen.ArrayType, *codegen.MapType,
+		codegen.EmptyInterfaceType, *codegen.EmptyInterfaceType:
+		return true
+	default:
+		return false
+	}
+}
+
+// hasDeepCopy reports whether t is a struct with generated deep copy methods.
+// Structs declared in other packages are assumed to have them.
+func (g *schemaGenerator) hasDeepCopy(t *codegen.NamedType) bool {
+	if t.Package != nil {
+		_, ok := t.Decl.Type.(*codegen.StructType)
+		return ok
+	}
+	return g.output.deepCopyDecls[t.Decl]
+}
+
+// declareDeepCopyJSONValue declares the function that deep copies values of
+// empty interface types, which hold values decoded from JSON.
+func (g *schemaGenerator) declareDeepCopyJSONValue() {
+	if g.output.funcsByName[funcNameDeepCopyJSONValue] {
+		return
+	}
+	g.output.funcsByName[funcNameDeepCopyJSONValue] = true
+	g.output.addHelper(funcNameDeepCopyJSONValue, &codegen.Method{
+		Impl: func(out *codegen.Emitter) {
+			out.Comment(fmt.Sprintf("%s returns a deep copy of a value decoded from JSON.",
+				funcNameDeepCopyJSONValue))
+			out.Println("func %s(v interface{}) interface{} {", funcNameDeepCopyJSONValue)
+			out.Indent(1)
+			out.Println("switch v := v.(type) {")
+			out.Println("case map[string]interface{}:")
+			out.Indent(1)
+			out.Println("c := make(map[string]interface{}, len(v))")
+			out.Println("for k, e := range v {")
+			out.Indent(1)
+			out.Println("c[k] = %s(e)", funcNameDeepCopyJSONValue)
+			out.Indent(-1)
+			out.Println("}")
+			out.Println("return c")
+			out.Indent(-1)
+			out.Println("case []interface{}:")
+			out.Indent(1)
+			out.Println("c := make([]interface{}, len(v))")
+			out.Println("for i, e := range v {")
+			out.Indent(1)
+			out.Println("c[i] = %s(e)", funcNameDeepCopyJSONValue)
+			out.Indent(-1)
+			out.Println("}")
+			out.Println("return c")
+			out.Indent(-1)
+			out.Println("default:")
+			out.Indent(1)
+			out.Println("return v")
+			out.Indent(-1)
+			out.Println("}")
+			out.Indent(-1)
+			out.Println("}")
+		},
+	})
+}
+
+// underlyingType returns the type that a named type other than a struct is
+// declared as, or else t itself.
+func underlyingType(t codegen.Type) codegen.Type {
+	for {
+		nt, ok := t.(*codegen.NamedType)
+		if !ok || nt.Decl.Type == nil {
+			return t
+		}
+		if _, ok := nt.Decl.Type.(*codegen.StructType); ok {
+			return t
+		}
+		t = nt.Decl.Type
+	}
+}
+
+// containsInterface reports whether values of t may hold empty interface
+// values, other than within structs.
+func containsInterface(t codegen.Type) bool {
+	switch x := underlyingType(t).(type) {
+	case codegen.EmptyInterfaceType, *codegen.EmptyInterfaceType:
+		return true
+	case *codegen.PointerType:
+		return containsInterface(x.Type)
+	case *codegen.ArrayType:
+		return containsInterface(x.Type)
+	case codegen.ArrayType:
+		return containsInterface(x.Type)
+	case *codegen.MapType:
+		return containsInterface(x.ValueType)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,102 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/codegen"
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/schemas"
+)
+
+// docEntry is a declared type that is described in the Markdown reference
+// written next to the file of an output.
+type docEntry struct {
+	typeName string
+	schema   *schemas.Type
+	theType  codegen.Type
+}
+
+// addDocEntry records a declared type for the Markdown reference.
+func (g *schemaGenerator) addDocEntry(declName string, t *schemas.Type, theType codegen.Type) {
+	g.output.docs = append(g.output.docs, docEntry{
+		typeName: declName,
+		schema:   t,
+		theType:  theType,
+	})
+}
+
+// docsFile returns the Markdown reference of the types of the outputs that
+// share a file, with a section for each type in the order that they were
+// declared. Structs get a
+// table of their properties, with their Go types, defaults, descriptions and
+// constraints, and other types get their Go type and constraints.
+func docsFile(outputs []*output) []byte {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Package %s\n", outputs[0].file.Package.Name())
+	var entries []docEntry
+	for _, o := range outputs {
+		entries = append(entries, o.docs...)
+	}
+	for _, entry := range entries {
+		fmt.Fprintf(&sb, "\n## %s\n\n", entry.typeName)
+		if description := strings.TrimSpace(entry.schema.Description); description != "" {
+			fmt.Fprintf(&sb, "%s\n\n", description)
+		}
+
+		st, ok := entry.theType.(*codegen.StructType)
+		if !ok {
+			fmt.Fprintf(&sb, "Type: `%s`\n", typeString(entry.theType))
+			if summary := constraintSummary(entry.schema); summary != "" {
+				fmt.Fprintf(&sb, "\n%s\n", markdownText(summary))
+			}
+			continue
+		}
+
+		required := map[string]bool{}
+		for _, name := range st.RequiredJSONFields {
+			required[name] = true
+		}
+		sb.WriteString("| Property | Type | Required | Default | Description |\n")
+		sb.WriteString("| --- | --- | --- | --- | --- |\n")
+		for _, f := range st.Fields {
+			if f.JSONName == "" || f.SchemaType == nil {
+				continue
+			}
+			requiredCell := "no"
+			if required[f.JSONName] {
+				requiredCell = "yes"
+			}
+			var defaultCell string
+			if f.SchemaType.Default != nil {
+				if b, err := json.Marshal(f.SchemaType.Default); err == nil {
+					defaultCell = "`" + markdownCell(string(b)) + "`"
+				}
+			}
+			description := strings.TrimSpace(f.SchemaType.Description)
+			if summary := constraintSummary(f.SchemaType); summary != "" {
+				if description != "" {
+					description += "\n\n"
+				}
+				description += markdownText(summary)
+			}
+			fmt.Fprintf(&sb, "| `%s` | `%s` | %s | %s | %s |\n",
+				markdownCell(f.JSONName), markdownCell(typeString(f.Type)), requiredCell, defaultCell,
+				markdownCell(description))
+		}
+	}
+	return []byte(sb.String())
+}
+
+// markdownCell escapes text for a table cell, in which pipes end the cell and
+// line breaks end the row.
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>").Replace(s)
+}
+
+// markdownText escapes the characters that constraints such as patterns may
+// contain, but that Markdown would otherwise interpret. Descriptions are left
+// alone, since they are often written in Markdown.
+func markdownText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "<", "&lt;").Replace(s)
+}
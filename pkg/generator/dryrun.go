@@ -0,0 +1,262 @@
+package generator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines around each hunk of a diff.
+const diffContext = 3
+
+// maxDiffEdits bounds the work done to find the shortest diff between two
+// files. Files that differ by more lines are diffed as if all of the lines
+// between their common beginning and end had been replaced.
+const maxDiffEdits = 2000
+
+// Diff returns what Write would change in a directory, as a unified diff
+// from the files in it to those that Files returns, without changing
+// anything. Files that don't exist yet are diffed from /dev/null, and with
+// Config.DeleteStaleFiles, the files that Write would delete are diffed to
+// it. The diff is empty if the directory is up to date. Files named "-",
+// for standard output, cannot be diffed, and are an error.
+func (g *Generator) Diff(outputDir string) ([]byte, error) {
+	files := g.Files()
+	if err := g.warningsError(); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	written := map[string]bool{}
+	var dirs []string
+	for _, file := range files {
+		if file.Name == "-" {
+			return nil, errors.New("cannot diff code written to standard output; give an output file")
+		}
+		path := outputPath(outputDir, file.Name)
+		old, err := os.ReadFile(path)
+		oldName := path
+		if errors.Is(err, fs.ErrNotExist) {
+			oldName = os.DevNull
+		} else if err != nil {
+			return nil, err
+		}
+		writeUnifiedDiff(&buf, oldName, path, string(old), string(file.Content))
+		written[filepath.Clean(path)] = true
+		if dir := filepath.Dir(path); !contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	if !g.config.DeleteStaleFiles {
+		return buf.Bytes(), nil
+	}
+	for _, dir := range dirs {
+		stale, err := staleFiles(dir, written)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		for _, path := range stale {
+			old, err := os.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			writeUnifiedDiff(&buf, path, os.DevNull, string(old), "")
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// diffLine is a line of a diff: one that both files have (' '), or that
+// only the old ('-') or the new ('+') file has.
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// writeUnifiedDiff writes the diff between two versions of a file in the
+// unified format, with diffContext lines of context. Nothing is written if
+// they are the same.
+func writeUnifiedDiff(buf *bytes.Buffer, oldName, newName, oldText, newText string) {
+	if oldText == newText {
+		return
+	}
+	lines := diffLines(splitLines(oldText), splitLines(newText))
+	fmt.Fprintf(buf, "--- %s\n+++ %s\n", oldName, newName)
+
+	// The lines of each file before each line of the diff, for the hunk
+	// headers.
+	oldAt := make([]int, len(lines)+1)
+	newAt := make([]int, len(lines)+1)
+	for i, line := range lines {
+		oldAt[i+1], newAt[i+1] = oldAt[i], newAt[i]
+		if line.op != '+' {
+			oldAt[i+1]++
+		}
+		if line.op != '-' {
+			newAt[i+1]++
+		}
+	}
+
+	for i := 0; i < len(lines); {
+		if lines[i].op == ' ' {
+			i++
+			continue
+		}
+		// Changes separated by no more than twice the context share a hunk.
+		last := i
+		for j := i + 1; j < len(lines) && j-last <= 2*diffContext; j++ {
+			if lines[j].op != ' ' {
+				last = j
+			}
+		}
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := last + diffContext + 1
+		if end > len(lines) {
+			end = len(lines)
+		}
+		fmt.Fprintf(buf, "@@ -%s +%s @@\n",
+			hunkRange(oldAt[start], oldAt[end]-oldAt[start]),
+			hunkRange(newAt[start], newAt[end]-newAt[start]))
+		for _, line := range lines[start:end] {
+			buf.WriteByte(line.op)
+			buf.WriteString(line.text)
+			if !strings.HasSuffix(line.text, "\n") {
+				buf.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = end
+	}
+}
+
+// hunkRange formats the lines of a file that a hunk covers, after the
+// lines before them, as GNU diff does.
+func hunkRange(before, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", before)
+	case 1:
+		return fmt.Sprintf("%d", before+1)
+	}
+	return fmt.Sprintf("%d,%d", before+1, count)
+}
+
+// splitLines splits text into lines, each with its line feed, except for a
+// last line without one.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns the shortest diff between two lists of lines, found
+// with Myers' algorithm, unless they differ by more than maxDiffEdits lines.
+func diffLines(a, b []string) []diffLine {
+	var prefix, suffix []diffLine
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		prefix = append(prefix, diffLine{' ', a[0]})
+		a, b = a[1:], b[1:]
+	}
+	for len(a) > 0 && len(b) > 0 && a[len(a)-1] == b[len(b)-1] {
+		suffix = append([]diffLine{{' ', a[len(a)-1]}}, suffix...)
+		a, b = a[:len(a)-1], b[:len(b)-1]
+	}
+
+	lines := prefix
+	if edits := shortestEdits(a, b); edits != nil {
+		lines = append(lines, edits...)
+	} else {
+		for _, text := range a {
+			lines = append(lines, diffLine{'-', text})
+		}
+		for _, text := range b {
+			lines = append(lines, diffLine{'+', text})
+		}
+	}
+	return append(lines, suffix...)
+}
+
+// shortestEdits returns the shortest diff between two lists of lines, or
+// nil if it is longer than maxDiffEdits lines. For each number of edits d,
+// v holds the furthest line of a reached on each diagonal k = x - y; the
+// diff is then traced back through them.
+func shortestEdits(a, b []string) []diffLine {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+	var trace [][]int
+	for d := 0; d <= n+m && d <= maxDiffEdits; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return traceEdits(a, b, trace)
+			}
+		}
+	}
+	return nil
+}
+
+// traceEdits traces the diff back from the end of both lists of lines, for
+// shortestEdits. trace[d] holds v before d edits were tried, for diagonals
+// -d to d.
+func traceEdits(a, b []string, trace [][]int) []diffLine {
+	x, y := len(a), len(b)
+	var reversed []diffLine
+	for d := len(trace) - 1; d > 0; d-- {
+		// v at d-1 edits, which is trace[d] since it was saved before the
+		// edits of round d.
+		v := trace[d]
+		at := func(k int) int { return v[k+d] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x, y = x-1, y-1
+			reversed = append(reversed, diffLine{' ', a[x]})
+		}
+		if x == prevX {
+			y--
+			reversed = append(reversed, diffLine{'+', b[y]})
+		} else {
+			x--
+			reversed = append(reversed, diffLine{'-', a[x]})
+		}
+	}
+	for x > 0 {
+		x, y = x-1, y-1
+		reversed = append(reversed, diffLine{' ', a[x]})
+	}
+
+	lines := make([]diffLine, len(reversed))
+	for i, line := range reversed {
+		lines[len(lines)-1-i] = line
+	}
+	return lines
+}
@@ -0,0 +1,648 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/codegen"
+)
+
+const (
+	packageJLexer  = "github.com/mailru/easyjson/jlexer"
+	packageJWriter = "github.com/mailru/easyjson/jwriter"
+)
+
+const funcNameDecodeRawObject = "decodeRawObject"
+
+// generateEasyJSON declares MarshalEasyJSON and UnmarshalEasyJSON methods for
+// a struct, so that it implements easyjson.Marshaler and
+// easyjson.Unmarshaler, along with the methods that they use to write and read
+// its fields without reflection. If the struct has no MarshalJSON or
+// UnmarshalJSON method of its own, these are declared too; otherwise the
+// easyjson methods call them, so that values are still validated. Fields are
+// written in the given order of JSON names, or else in the order of the
+// struct's fields. If strict is true, unknown properties are an error. The
+// nested fields are skipped when reading, since UnmarshalJSON reads them.
+func (g *schemaGenerator) generateEasyJSON(decl *codegen.TypeDecl, structType *codegen.StructType,
+	hasUnmarshal, hasMarshal, strict bool, order []string, nested []nestedField) {
+	g.output.easyJSONDecls[decl] = true
+	declName := decl.Name
+	fields := make([]codegen.StructField, 0, len(structType.Fields))
+	if order != nil {
+		for _, name := range order {
+			for _, f := range structType.Fields {
+				if f.JSONName == name {
+					fields = append(fields, f)
+				}
+			}
+		}
+	} else {
+		for _, f := range structType.Fields {
+			if f.JSONName != "" {
+				fields = append(fields, f)
+			}
+		}
+	}
+
+	g.output.file.Package.AddImport(packageJLexer, "")
+	g.output.file.Package.AddImport(packageJWriter, "")
+	if strict {
+		g.output.file.Package.AddImport("fmt", "")
+	}
+	for _, f := range fields {
+		g.addEasyJSONImports(f.Type)
+	}
+
+	g.output.file.Package.AddDecl(&codegen.Method{
+		Owner: declName,
+		Impl: func(out *codegen.Emitter) {
+			out.Comment("decodeEasyJSON reads the fields of the value from in, without validating them.")
+			out.Println("func (%s *%s) decodeEasyJSON(in *jlexer.Lexer) {", varNameReceiver, declName)
+			out.Indent(1)
+			out.Println("isTopLevel := in.IsStart()")
+			out.Println("if in.IsNull() {")
+			out.Indent(1)
+			out.Println("if isTopLevel {")
+			out.Indent(1)
+			out.Println("in.Consumed()")
+			out.Indent(-1)
+			out.Println("}")
+			out.Println("in.Skip()")
+			out.Println("return")
+			out.Indent(-1)
+			out.Println("}")
+			out.Println("in.Delim('{')")
+			out.Println("for !in.IsDelim('}') {")
+			out.Indent(1)
+			out.Println("key := in.UnsafeString()")
+			out.Println("in.WantColon()")
+			out.Println("switch key {")
+			for _, f := range fields {
+				out.Println("case %q:", f.JSONName)
+				out.Indent(1)
+				if isNestedField(nested, f) {
+					out.Println("in.SkipRecursive()")
+				} else {
+					g.emitEasyJSONDecode(out, varNameReceiver+"."+f.Name, f.Type, 0)
+				}
+				out.Indent(-1)
+			}
+			out.Println("default:")
+			out.Indent(1)
+			if strict {
+				out.Println(`in.AddError(fmt.Errorf("json: unknown field %%q", key))`)
+			}
+			out.Println("in.SkipRecursive()")
+			out.Indent(-1)
+			out.Println("}")
+			out.Println("in.WantComma()")
+			out.Indent(-1)
+			out.Println("}")
+			out.Println("in.Delim('}')")
+			out.Println("if isTopLevel {")
+			out.Indent(1)
+			out.Println("in.Consumed()")
+			out.Indent(-1)
+			out.Println("}")
+			out.Indent(-1)
+			out.Println("}")
+			out.Newline()
+
+			out.Comment("encodeEasyJSON writes the fields of the value to w, without validating them.")
+			out.Println("func (%s %s) encodeEasyJSON(w *jwriter.Writer) {", varNameReceiver, declName)
+			out.Indent(1)
+			g.emitEasyJSONFields(out, fields)
+			out.Indent(-1)
+			out.Println("}")
+			out.Newline()
+
+			out.Comment("UnmarshalEasyJSON implements easyjson.Unmarshaler.")
+			out.Println("func (%s *%s) UnmarshalEasyJSON(in *jlexer.Lexer) {", varNameReceiver, declName)
+			out.Indent(1)
+			if hasUnmarshal {
+				out.Println("if data := in.Raw(); in.Ok() {")
+				out.Indent(1)
+				out.Println("in.AddError(%s.UnmarshalJSON(data))", varNameReceiver)
+				out.Indent(-1)
+				out.Println("}")
+			} else {
+				out.Println("%s.decodeEasyJSON(in)", varNameReceiver)
+			}
+			out.Indent(-1)
+			out.Println("}")
+			out.Newline()
+
+			out.Comment("MarshalEasyJSON implements easyjson.Marshaler.")
+			out.Println("func (%s %s) MarshalEasyJSON(w *jwriter.Writer) {", varNameReceiver, declName)
+			out.Indent(1)
+			if hasMarshal {
+				out.Println("w.Raw(%s.MarshalJSON())", varNameReceiver)
+			} else {
+				out.Println("%s.encodeEasyJSON(w)", varNameReceiver)
+			}
+			out.Indent(-1)
+			out.Println("}")
+
+			if !hasUnmarshal {
+				out.Newline()
+				out.Comment("UnmarshalJSON implements json.Unmarshaler.")
+				out.Println("func (%s *%s) UnmarshalJSON(b []byte) error {", varNameReceiver, declName)
+				out.Indent(1)
+				out.Println("in := jlexer.Lexer{Data: b}")
+				out.Println("%s.decodeEasyJSON(&in)", varNameReceiver)
+				out.Println("return in.Error()")
+				out.Indent(-1)
+				out.Println("}")
+			}
+			if !hasMarshal {
+				out.Newline()
+				out.Comment("MarshalJSON implements json.Marshaler.")
+				out.Println("func (%s %s) MarshalJSON() ([]byte, error) {", varNameReceiver, declName)
+				out.Indent(1)
+				out.Println("w := jwriter.Writer{}")
+				out.Println("%s.encodeEasyJSON(&w)", varNameReceiver)
+				out.Println("return w.BuildBytes()")
+				out.Indent(-1)
+				out.Println("}")
+			}
+		},
+	})
+}
+
+// emitEasyJSONFields emits code that writes the given fields to w as a JSON
+// object, leaving out empty fields that are tagged with "omitempty". Whether a
+// comma is needed before a field is tracked at run time only while no field
+// has been written for certain.
+func (g *schemaGenerator) emitEasyJSONFields(out *codegen.Emitter, fields []codegen.StructField) {
+	needsFirst := false
+	for i, f := range fields {
+		if easyJSONEmptyCheck(varNameReceiver+"."+f.Name, f.Type, hasOmitEmpty(f)) == "" {
+			break
+		}
+		if i < len(fields)-1 {
+			needsFirst = true
+		}
+	}
+
+	out.Println("w.RawByte('{')")
+	if needsFirst {
+		out.Println("first := true")
+	}
+	written, known := false, true
+	for _, f := range fields {
+		src := varNameReceiver + "." + f.Name
+		check := easyJSONEmptyCheck(src, f.Type, hasOmitEmpty(f))
+		if check != "" {
+			out.Println("if %s {", check)
+			out.Indent(1)
+		}
+		name, _ := json.Marshal(f.JSONName)
+		key := string(name) + ":"
+		switch {
+		case written:
+			out.Println("w.RawString(%s)", goStringLiteral(","+key))
+		case known:
+			out.Println("w.RawString(%s)", goStringLiteral(key))
+		default:
+			out.Println("if !first {")
+			out.Indent(1)
+			out.Println("w.RawByte(',')")
+			out.Indent(-1)
+			out.Println("}")
+			out.Println("w.RawString(%s)", goStringLiteral(key))
+		}
+		if !written && needsFirst {
+			out.Println("first = false")
+		}
+		g.emitEasyJSONEncode(out, src, f.Type, check != "", 0)
+		if check != "" {
+			out.Indent(-1)
+			out.Println("}")
+			if !written {
+				known = false
+			}
+		} else {
+			written, known = true, true
+		}
+	}
+	out.Println("w.RawByte('}')")
+}
+
+// emitEasyJSONDecode emits code that reads a value of type t from in into
+// dst, which must be addressable. Types that have no easyjson methods, other
+// than primitives, slices, maps and empty interfaces, are decoded with
+// encoding/json. depth numbers the variables of nested loops.
+func (g *schemaGenerator) emitEasyJSONDecode(out *codegen.Emitter, dst string, t codegen.Type, depth int) {
+	if nt, ok := t.(*codegen.NamedType); ok && g.hasEasyJSON(nt) {
+		out.Println("%s.UnmarshalEasyJSON(in)", dst)
+		return
+	}
+
+	t = easyJSONType(t)
+	switch x := t.(type) {
+	case codegen.PrimitiveType:
+		out.Println("if in.IsNull() {")
+		out.Indent(1)
+		out.Println("in.Skip()")
+		out.Indent(-1)
+		out.Println("} else {")
+		out.Indent(1)
+		out.Println("%s = in.%s()", dst, easyJSONMethodName(x.Type))
+		out.Indent(-1)
+		out.Println("}")
+
+	case *codegen.PointerType:
+		out.Println("if in.IsNull() {")
+		out.Indent(1)
+		out.Println("in.Skip()")
+		out.Println("%s = nil", dst)
+		out.Indent(-1)
+		out.Println("} else {")
+		out.Indent(1)
+		out.Println("if %s == nil {", dst)
+		out.Indent(1)
+		out.Println("%s = new(%s)", dst, typeString(x.Type))
+		out.Indent(-1)
+		out.Println("}")
+		if nt, ok := x.Type.(*codegen.NamedType); ok && g.hasEasyJSON(nt) {
+			out.Println("%s.UnmarshalEasyJSON(in)", dst)
+		} else if p, ok := easyJSONType(x.Type).(codegen.PrimitiveType); ok {
+			// Null has already been checked for
+			out.Println("*%s = in.%s()", dst, easyJSONMethodName(p.Type))
+		} else {
+			g.emitEasyJSONDecode(out, "*"+dst, x.Type, depth)
+		}
+		out.Indent(-1)
+		out.Println("}")
+
+	case *codegen.ArrayType:
+		v := fmt.Sprintf("v%d", depth)
+		out.Println("if in.IsNull() {")
+		out.Indent(1)
+		out.Println("in.Skip()")
+		out.Println("%s = nil", dst)
+		out.Indent(-1)
+		out.Println("} else {")
+		out.Indent(1)
+		out.Println("in.Delim('[')")
+		out.Println("if %s == nil {", dst)
+		out.Indent(1)
+		out.Println("%s = %s{}", dst, typeString(x))
+		out.Indent(-1)
+		out.Println("} else {")
+		out.Indent(1)
+		out.Println("%s = %s[:0]", dst, parenthesize(dst))
+		out.Indent(-1)
+		out.Println("}")
+		out.Println("for !in.IsDelim(']') {")
+		out.Indent(1)
+		out.Println("var %s %s", v, typeString(x.Type))
+		g.emitEasyJSONDecode(out, v, x.Type, depth+1)
+		out.Println("%s = append(%s, %s)", dst, dst, v)
+		out.Println("in.WantComma()")
+		out.Indent(-1)
+		out.Println("}")
+		out.Println("in.Delim(']')")
+		out.Indent(-1)
+		out.Println("}")
+
+	case *codegen.MapType:
+		if !isStringType(x.KeyType) {
+			emitEasyJSONDecodeFallback(out, dst)
+			return
+		}
+		k, v := fmt.Sprintf("k%d", depth), fmt.Sprintf("v%d", depth)
+		out.Println("if in.IsNull() {")
+		out.Indent(1)
+		out.Println("in.Skip()")
+		out.Println("%s = nil", dst)
+		out.Indent(-1)
+		out.Println("} else {")
+		out.Indent(1)
+		out.Println("in.Delim('{')")
+		out.Println("if %s == nil {", dst)
+		out.Indent(1)
+		out.Println("%s = make(%s)", dst, typeString(x))
+		out.Indent(-1)
+		out.Println("}")
+		out.Println("for !in.IsDelim('}') {")
+		out.Indent(1)
+		out.Println("%s := in.String()", k)
+		out.Println("in.WantColon()")
+		out.Println("var %s %s", v, typeString(x.ValueType))
+		g.emitEasyJSONDecode(out, v, x.ValueType, depth+1)
+		out.Println("%s[%s] = %s", parenthesize(dst), k, v)
+		out.Println("in.WantComma()")
+		out.Indent(-1)
+		out.Println("}")
+		out.Println("in.Delim('}')")
+		out.Indent(-1)
+		out.Println("}")
+
+	case codegen.EmptyInterfaceType, *codegen.EmptyInterfaceType, codegen.NullType:
+		out.Println("%s = in.Interface()", dst)
+
+	default:
+		emitEasyJSONDecodeFallback(out, dst)
+	}
+}
+
+func emitEasyJSONDecodeFallback(out *codegen.Emitter, dst string) {
+	out.Println("if data := in.Raw(); in.Ok() {")
+	out.Indent(1)
+	out.Println("in.AddError(json.Unmarshal(data, &%s))", dst)
+	out.Indent(-1)
+	out.Println("}")
+}
+
+// emitEasyJSONEncode emits code that writes the value src of type t to w.
+// Map keys are sorted, as they are by encoding/json. If nonNil is true, src is
+// known not to be nil. depth numbers the variables of nested loops.
+func (g *schemaGenerator) emitEasyJSONEncode(
+	out *codegen.Emitter, src string, t codegen.Type, nonNil bool, depth int) {
+	if nt, ok := t.(*codegen.NamedType); ok && g.hasEasyJSON(nt) {
+		out.Println("%s.MarshalEasyJSON(w)", src)
+		return
+	}
+	if nt, ok := t.(*codegen.NamedType); ok && g.isPlainNamedType(nt) {
+		if p, ok := easyJSONType(nt.Decl.Type).(codegen.PrimitiveType); ok && p.Type != typeJSONNumber {
+			out.Println("w.%s(%s(%s))", easyJSONMethodName(p.Type), p.Type, src)
+			return
+		}
+		t = nt.Decl.Type
+	}
+
+	t = easyJSONType(t)
+	switch x := t.(type) {
+	case codegen.PrimitiveType:
+		if x.Type == typeJSONNumber {
+			emitEasyJSONEncodeFallback(out, src)
+			return
+		}
+		out.Println("w.%s(%s)", easyJSONMethodName(x.Type), src)
+
+	case *codegen.PointerType:
+		emitEasyJSONNilCheck(out, src, nonNil)
+		if nt, ok := x.Type.(*codegen.NamedType); ok && g.hasEasyJSON(nt) {
+			out.Println("%s.MarshalEasyJSON(w)", src)
+		} else {
+			g.emitEasyJSONEncode(out, "*"+src, x.Type, false, depth)
+		}
+		emitEasyJSONNilCheckEnd(out, nonNil)
+
+	case *codegen.ArrayType:
+		i, v := fmt.Sprintf("i%d", depth), fmt.Sprintf("v%d", depth)
+		emitEasyJSONNilCheck(out, src, nonNil)
+		out.Println("w.RawByte('[')")
+		out.Println("for %s, %s := range %s {", i, v, src)
+		out.Indent(1)
+		out.Println("if %s > 0 {", i)
+		out.Indent(1)
+		out.Println("w.RawByte(',')")
+		out.Indent(-1)
+		out.Println("}")
+		g.emitEasyJSONEncode(out, v, x.Type, false, depth+1)
+		out.Indent(-1)
+		out.Println("}")
+		out.Println("w.RawByte(']')")
+		emitEasyJSONNilCheckEnd(out, nonNil)
+
+	case *codegen.MapType:
+		if !isStringType(x.KeyType) {
+			emitEasyJSONEncodeFallback(out, src)
+			return
+		}
+		i, k, keys := fmt.Sprintf("i%d", depth), fmt.Sprintf("k%d", depth), fmt.Sprintf("keys%d", depth)
+		emitEasyJSONNilCheck(out, src, nonNil)
+		out.Println("%s := make([]string, 0, len(%s))", keys, src)
+		out.Println("for %s := range %s {", k, src)
+		out.Indent(1)
+		out.Println("%s = append(%s, %s)", keys, keys, k)
+		out.Indent(-1)
+		out.Println("}")
+		out.Println("sort.Strings(%s)", keys)
+		out.Println("w.RawByte('{')")
+		out.Println("for %s, %s := range %s {", i, k, keys)
+		out.Indent(1)
+		out.Println("if %s > 0 {", i)
+		out.Indent(1)
+		out.Println("w.RawByte(',')")
+		out.Indent(-1)
+		out.Println("}")
+		out.Println("w.String(%s)", k)
+		out.Println("w.RawByte(':')")
+		g.emitEasyJSONEncode(out, fmt.Sprintf("%s[%s]", parenthesize(src), k), x.ValueType, false, depth+1)
+		out.Indent(-1)
+		out.Println("}")
+		out.Println("w.RawByte('}')")
+		emitEasyJSONNilCheckEnd(out, nonNil)
+
+	default:
+		emitEasyJSONEncodeFallback(out, src)
+	}
+}
+
+// emitEasyJSONNilCheck emits the start of a block that writes src unless it is
+// nil, in which case null is written, unless src is known not to be nil.
+func emitEasyJSONNilCheck(out *codegen.Emitter, src string, nonNil bool) {
+	if nonNil {
+		return
+	}
+	out.Println("if %s == nil {", src)
+	out.Indent(1)
+	out.Println(`w.RawString("null")`)
+	out.Indent(-1)
+	out.Println("} else {")
+	out.Indent(1)
+}
+
+func emitEasyJSONNilCheckEnd(out *codegen.Emitter, nonNil bool) {
+	if nonNil {
+		return
+	}
+	out.Indent(-1)
+	out.Println("}")
+}
+
+func emitEasyJSONEncodeFallback(out *codegen.Emitter, src string) {
+	out.Println("w.Raw(json.Marshal(%s))", src)
+}
+
+// addEasyJSONImports imports the packages that the code emitted for reading
+// and writing values of type t uses.
+func (g *schemaGenerator) addEasyJSONImports(t codegen.Type) {
+	if nt, ok := t.(*codegen.NamedType); ok && (nt.Decl.Type == nil || g.hasEasyJSON(nt)) {
+		// Types still being declared can only be structs, which refer back to
+		// themselves
+		return
+	}
+	if nt, ok := t.(*codegen.NamedType); ok {
+		// Named types are decoded with encoding/json
+		g.output.file.Package.AddImport("encoding/json", "")
+		if !g.isPlainNamedType(nt) {
+			return
+		}
+		t = nt.Decl.Type
+	}
+	t = easyJSONType(t)
+	switch x := t.(type) {
+	case codegen.PrimitiveType:
+		if x.Type == typeJSONNumber {
+			g.output.file.Package.AddImport("encoding/json", "")
+		}
+	case *codegen.PointerType:
+		g.addEasyJSONImports(x.Type)
+	case *codegen.ArrayType:
+		g.addEasyJSONImports(x.Type)
+	case *codegen.MapType:
+		if isStringType(x.KeyType) {
+			g.output.file.Package.AddImport("sort", "")
+			g.addEasyJSONImports(x.ValueType)
+		} else {
+			g.output.file.Package.AddImport("encoding/json", "")
+		}
+	default:
+		g.output.file.Package.AddImport("encoding/json", "")
+	}
+}
+
+// easyJSONType normalizes arrays that are declared as values, and the
+// primitive empty interfaces of enums with values of mixed types.
+func easyJSONType(t codegen.Type) codegen.Type {
+	if at, ok := asArrayType(t); ok {
+		return at
+	}
+	if p, ok := t.(codegen.PrimitiveType); ok && p.Type == "interface{}" {
+		return codegen.EmptyInterfaceType{}
+	}
+	return t
+}
+
+// isPlainNamedType reports whether values of t, which is not a struct, are
+// marshaled like values of its underlying type. This is the case unless
+// enums and maps are given MarshalJSON methods, with ValidateOnMarshal.
+func (g *schemaGenerator) isPlainNamedType(t *codegen.NamedType) bool {
+	if t.Package != nil || t.Decl.Type == nil || g.config.ValidateOnMarshal {
+		return false
+	}
+	_, ok := t.Decl.Type.(*codegen.StructType)
+	return !ok
+}
+
+// hasEasyJSON reports whether t is a struct with generated easyjson methods.
+// Structs declared in other packages are assumed to have them.
+func (g *schemaGenerator) hasEasyJSON(t *codegen.NamedType) bool {
+	if t.Package != nil {
+		_, ok := t.Decl.Type.(*codegen.StructType)
+		return ok
+	}
+	return g.output.easyJSONDecls[t.Decl]
+}
+
+// easyJSONEmptyCheck returns an expression that is true unless the value src
+// of type t is empty, and so left out by "omitempty", or "" if it is never
+// left out.
+func easyJSONEmptyCheck(src string, t codegen.Type, omitEmpty bool) string {
+	if !omitEmpty {
+		return ""
+	}
+	switch x := easyJSONType(underlyingType(t)).(type) {
+	case *codegen.PointerType, codegen.EmptyInterfaceType, *codegen.EmptyInterfaceType, codegen.NullType:
+		return src + " != nil"
+	case *codegen.ArrayType, *codegen.MapType:
+		return fmt.Sprintf("len(%s) != 0", src)
+	case codegen.PrimitiveType:
+		switch {
+		case x.Type == "string" || x.Type == typeJSONNumber:
+			return src + ` != ""`
+		case x.Type == "bool":
+			return src
+		default:
+			return src + " != 0"
+		}
+	default:
+		return ""
+	}
+}
+
+// easyJSONMethodName returns the name of the jlexer.Lexer and
+// jwriter.Writer methods that read and write a primitive type.
+func easyJSONMethodName(typeName string) string {
+	if typeName == typeJSONNumber {
+		return "JsonNumber"
+	}
+	return strings.ToUpper(typeName[:1]) + typeName[1:]
+}
+
+func hasOmitEmpty(f codegen.StructField) bool {
+	return strings.HasSuffix(reflect.StructTag(f.Tags).Get("json"), ",omitempty")
+}
+
+func isStringType(t codegen.Type) bool {
+	p, ok := t.(codegen.PrimitiveType)
+	return ok && p.Type == "string"
+}
+
+// parenthesize wraps a dereferenced expression in parentheses, so that it
+// can be indexed.
+func parenthesize(expr string) string {
+	if strings.HasPrefix(expr, "*") {
+		return "(" + expr + ")"
+	}
+	return expr
+}
+
+// goStringLiteral returns a Go literal of s, which is a raw string literal
+// if possible, for readability.
+func goStringLiteral(s string) string {
+	if strings.Contains(s, "`") {
+		return strconv.Quote(s)
+	}
+	return "`" + s + "`"
+}
+
+// declareDecodeRawObject declares the function that splits a JSON object
+// into its properties with jlexer, for the checks that are made before a
+// struct is unmarshaled.
+func (g *schemaGenerator) declareDecodeRawObject() {
+	if g.output.funcsByName[funcNameDecodeRawObject] {
+		return
+	}
+	g.output.funcsByName[funcNameDecodeRawObject] = true
+	g.output.file.Package.AddImport("encoding/json", "")
+	g.output.file.Package.AddImport(packageJLexer, "")
+	g.output.addHelper(funcNameDecodeRawObject, &codegen.Method{
+		Impl: func(out *codegen.Emitter) {
+			out.Comment(fmt.Sprintf("%s splits b, a JSON object, into its properties, without decoding "+
+				"their values. Null is split into a nil map.", funcNameDecodeRawObject))
+			out.Println("func %s(b []byte) (map[string]json.RawMessage, error) {", funcNameDecodeRawObject)
+			out.Indent(1)
+			out.Println("in := jlexer.Lexer{Data: b}")
+			out.Println("if in.IsNull() {")
+			out.Indent(1)
+			out.Println("in.Skip()")
+			out.Println("in.Consumed()")
+			out.Println("return nil, in.Error()")
+			out.Indent(-1)
+			out.Println("}")
+			out.Println("raw := map[string]json.RawMessage{}")
+			out.Println("in.Delim('{')")
+			out.Println("for !in.IsDelim('}') {")
+			out.Indent(1)
+			out.Println("key := in.String()")
+			out.Println("in.WantColon()")
+			out.Println("raw[key] = in.Raw()")
+			out.Println("in.WantComma()")
+			out.Indent(-1)
+			out.Println("}")
+			out.Println("in.Delim('}')")
+			out.Println("in.Consumed()")
+			out.Println("return raw, in.Error()")
+			out.Indent(-1)
+			out.Println("}")
+		},
+	})
+}
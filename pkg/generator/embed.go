@@ -0,0 +1,61 @@
+package generator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/codegen"
+)
+
+// generateEmbeddedSchema declares a JSONSchema method for the root type of a
+// schema, which returns the schema as Bundle returns it, so that services can
+// serve it, or validate against it, at runtime. Types with a field named
+// JSONSchema are skipped, since the method would collide with it.
+func (g *schemaGenerator) generateEmbeddedSchema(decl *codegen.TypeDecl) {
+	if structType, ok := decl.Type.(*codegen.StructType); ok {
+		for _, f := range structType.Fields {
+			if f.Name == "JSONSchema" {
+				g.warnf(nil, WarningSkipped, "Not embedding the schema in %s, which has a field named JSONSchema",
+					decl.Name)
+				return
+			}
+		}
+	}
+	if g.schemaFileName == "-" {
+		g.warnf(nil, WarningSkipped, "Not embedding the schema in %s, since it was read from standard input",
+			decl.Name)
+		return
+	}
+	doc, err := g.bundle(g.schemaFileName)
+	if err != nil {
+		g.warnf(nil, WarningSkipped, "Not embedding the schema in %s: %s", decl.Name, err)
+		return
+	}
+
+	// Raw string literals cannot hold backticks, which are concatenated
+	// with them instead, nor byte order marks, which Go source cannot
+	// contain but JSON strings can.
+	literal := "`" + strings.ReplaceAll(string(doc), "`", "` + \"`\" + `") + "`"
+	if strings.ContainsRune(string(doc), '\uFEFF') {
+		literal = strconv.Quote(string(doc))
+	}
+	constName := "jsonSchema" + decl.Name
+	g.output.file.Package.AddDecl(&codegen.Constant{
+		Name:  constName,
+		Value: codegen.Expr(literal),
+		Owner: decl.Name,
+	})
+	g.output.file.Package.AddDecl(&codegen.Method{
+		Owner: decl.Name,
+		Impl: func(out *codegen.Emitter) {
+			out.Comment(fmt.Sprintf("JSONSchema returns the JSON Schema that %s was generated from, with the "+
+				"schemas that it refers to in other files bundled into its definitions.", decl.Name))
+			out.Println("func (%s) JSONSchema() []byte {", decl.Name)
+			out.Indent(1)
+			out.Println("return []byte(%s)", constName)
+			out.Indent(-1)
+			out.Println("}")
+		},
+	})
+}
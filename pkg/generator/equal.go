@@ -0,0 +1,210 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/codegen"
+)
+
+const funcNameEqualJSONValue = "equalJSONValue"
+
+// generateEqual declares an Equal method for a struct, which compares its
+// fields structurally. Structs with a field named Equal are skipped, since the
+// method would collide with it.
+func (g *schemaGenerator) generateEqual(decl *codegen.TypeDecl, structType *codegen.StructType) {
+	for _, f := range structType.Fields {
+		if f.Name == "Equal" {
+			g.warnf(nil, WarningSkipped, "Not generating an Equal method for %s, which has a field named Equal",
+				decl.Name)
+			return
+		}
+	}
+
+	g.output.equalDecls[decl] = true
+	for _, f := range structType.Fields {
+		if containsInterface(f.Type) {
+			g.declareEqualJSONValue()
+			break
+		}
+	}
+
+	g.output.file.Package.AddDecl(&codegen.Method{
+		Owner: decl.Name,
+		Impl: func(out *codegen.Emitter) {
+			out.Comment("Equal reports whether the value is equal to other. Optional fields are " +
+				"only equal if both are set or both are unset, while nil and empty arrays and " +
+				"maps are equal.")
+			out.Println("func (%s %s) Equal(other %s) bool {", varNameReceiver, decl.Name, decl.Name)
+			out.Indent(1)
+			for _, f := range structType.Fields {
+				g.emitEqual(out, varNameReceiver+"."+f.Name, "other."+f.Name, f.Type, 0)
+			}
+			out.Println("return true")
+			out.Indent(-1)
+			out.Println("}")
+		},
+	})
+}
+
+// emitEqual emits code that returns false unless the values a and b are
+// equal. depth numbers the variables of nested loops.
+func (g *schemaGenerator) emitEqual(out *codegen.Emitter, a, b string, t codegen.Type, depth int) {
+	if nt, ok := t.(*codegen.NamedType); ok && g.hasEqual(nt) {
+		out.Println("if !%s.Equal(%s) {", a, b)
+		out.Indent(1)
+		out.Println("return false")
+		out.Indent(-1)
+		out.Println("}")
+		return
+	}
+
+	u := underlyingType(t)
+	if at, ok := asArrayType(u); ok {
+		u = at
+	}
+	switch x := u.(type) {
+	case *codegen.PointerType:
+		out.Println("if (%s == nil) != (%s == nil) {", a, b)
+		out.Indent(1)
+		out.Println("return false")
+		out.Indent(-1)
+		out.Println("}")
+		out.Println("if %s != nil {", a)
+		out.Indent(1)
+		if nt, ok := x.Type.(*codegen.NamedType); ok && g.hasEqual(nt) {
+			out.Println("if !%s.Equal(*%s) {", a, b)
+			out.Indent(1)
+			out.Println("return false")
+			out.Indent(-1)
+			out.Println("}")
+		} else {
+			g.emitEqual(out, "*"+a, "*"+b, x.Type, depth)
+		}
+		out.Indent(-1)
+		out.Println("}")
+
+	case *codegen.ArrayType:
+		i := fmt.Sprintf("i%d", depth)
+		out.Println("if len(%s) != len(%s) {", a, b)
+		out.Indent(1)
+		out.Println("return false")
+		out.Indent(-1)
+		out.Println("}")
+		out.Println("for %s := range %s {", i, a)
+		out.Indent(1)
+		g.emitEqual(out, fmt.Sprintf("%s[%s]", a, i), fmt.Sprintf("%s[%s]", b, i), x.Type, depth+1)
+		out.Indent(-1)
+		out.Println("}")
+
+	case *codegen.MapType:
+		k, va, vb := fmt.Sprintf("k%d", depth), fmt.Sprintf("va%d", depth), fmt.Sprintf("vb%d", depth)
+		out.Println("if len(%s) != len(%s) {", a, b)
+		out.Indent(1)
+		out.Println("return false")
+		out.Indent(-1)
+		out.Println("}")
+		out.Println("for %s, %s := range %s {", k, va, a)
+		out.Indent(1)
+		out.Println("%s, ok := %s[%s]", vb, b, k)
+		out.Println("if !ok {")
+		out.Indent(1)
+		out.Println("return false")
+		out.Indent(-1)
+		out.Println("}")
+		g.emitEqual(out, va, vb, x.ValueType, depth+1)
+		out.Indent(-1)
+		out.Println("}")
+
+	case codegen.EmptyInterfaceType, *codegen.EmptyInterfaceType, codegen.NullType:
+		out.Println("if !%s(%s, %s) {", funcNameEqualJSONValue, a, b)
+		out.Indent(1)
+		out.Println("return false")
+		out.Indent(-1)
+		out.Println("}")
+
+	default:
+		if isPrimitiveType(t) {
+			out.Println("if %s != %s {", a, b)
+		} else {
+			g.output.file.Package.AddImport("reflect", "")
+			out.Println("if !reflect.DeepEqual(%s, %s) {", a, b)
+		}
+		out.Indent(1)
+		out.Println("return false")
+		out.Indent(-1)
+		out.Println("}")
+	}
+}
+
+// hasEqual reports whether t is a struct with a generated Equal method.
+// Structs declared in other packages are assumed to have one.
+func (g *schemaGenerator) hasEqual(t *codegen.NamedType) bool {
+	if t.Package != nil {
+		_, ok := t.Decl.Type.(*codegen.StructType)
+		return ok
+	}
+	return g.output.equalDecls[t.Decl]
+}
+
+// declareEqualJSONValue declares the function that compares values of empty
+// interface types, which hold values decoded from JSON.
+func (g *schemaGenerator) declareEqualJSONValue() {
+	if g.output.funcsByName[funcNameEqualJSONValue] {
+		return
+	}
+	g.output.funcsByName[funcNameEqualJSONValue] = true
+	g.output.addHelper(funcNameEqualJSONValue, &codegen.Method{
+		Impl: func(out *codegen.Emitter) {
+			out.Comment(fmt.Sprintf("%s reports whether two values decoded from JSON are equal.",
+				funcNameEqualJSONValue))
+			out.Println("func %s(a, b interface{}) bool {", funcNameEqualJSONValue)
+			out.Indent(1)
+			out.Println("switch a := a.(type) {")
+			out.Println("case map[string]interface{}:")
+			out.Indent(1)
+			out.Println("b, ok := b.(map[string]interface{})")
+			out.Println("if !ok || len(a) != len(b) {")
+			out.Indent(1)
+			out.Println("return false")
+			out.Indent(-1)
+			out.Println("}")
+			out.Println("for k, va := range a {")
+			out.Indent(1)
+			out.Println("if vb, ok := b[k]; !ok || !%s(va, vb) {", funcNameEqualJSONValue)
+			out.Indent(1)
+			out.Println("return false")
+			out.Indent(-1)
+			out.Println("}")
+			out.Indent(-1)
+			out.Println("}")
+			out.Println("return true")
+			out.Indent(-1)
+			out.Println("case []interface{}:")
+			out.Indent(1)
+			out.Println("b, ok := b.([]interface{})")
+			out.Println("if !ok || len(a) != len(b) {")
+			out.Indent(1)
+			out.Println("return false")
+			out.Indent(-1)
+			out.Println("}")
+			out.Println("for i := range a {")
+			out.Indent(1)
+			out.Println("if !%s(a[i], b[i]) {", funcNameEqualJSONValue)
+			out.Indent(1)
+			out.Println("return false")
+			out.Indent(-1)
+			out.Println("}")
+			out.Indent(-1)
+			out.Println("}")
+			out.Println("return true")
+			out.Indent(-1)
+			out.Println("default:")
+			out.Indent(1)
+			out.Println("return a == b")
+			out.Indent(-1)
+			out.Println("}")
+			out.Indent(-1)
+			out.Println("}")
+		},
+	})
+}
@@ -0,0 +1,80 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/schemas"
+)
+
+// ErrUnsupportedRef is wrapped by the errors for $refs that the generator
+// cannot follow, such as ones whose fragments are not JSON Pointers.
+var ErrUnsupportedRef = errors.New("unsupported $ref format")
+
+// ErrConflictingOutput is wrapped by the errors for schemas that are mapped
+// to the same output file as other schemas, but to a different package.
+var ErrConflictingOutput = errors.New("conflict")
+
+// InvalidSchemaError is returned for a schema that cannot be parsed, since
+// it is not valid JSON or YAML, or not valid against the meta-schema. Err
+// is the error from parsing, such as a *schemas.ParseError or a
+// *SchemaError, whose message is the message of the InvalidSchemaError.
+type InvalidSchemaError struct {
+	// Name is the name of the file or URL that the schema was read from.
+	Name string
+	Err  error
+}
+
+func (e *InvalidSchemaError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *InvalidSchemaError) Unwrap() error {
+	return e.Err
+}
+
+// WarningsError is returned, with Config.FailOnWarning, for the warnings
+// that generating code reported.
+type WarningsError struct {
+	Warnings []Warning
+}
+
+func (e *WarningsError) Error() string {
+	if len(e.Warnings) == 1 {
+		return fmt.Sprintf("warning treated as error: %s", e.Warnings[0])
+	}
+	messages := make([]string, 0, len(e.Warnings))
+	for _, w := range e.Warnings {
+		messages = append(messages, w.String())
+	}
+	return fmt.Sprintf("%d warnings treated as errors: %s", len(e.Warnings), strings.Join(messages, "; "))
+}
+
+// MissingDefinitionError is returned for a $ref to a definition, or another
+// subschema, that does not exist.
+type MissingDefinitionError struct {
+	// Name is the name of the definition: the last token of the pointer in
+	// Ref.
+	Name string
+	Ref  string
+	Err  error
+}
+
+func (e *MissingDefinitionError) Error() string {
+	return fmt.Sprintf("%s (from ref %q)", e.Err, e.Ref)
+}
+
+func (e *MissingDefinitionError) Unwrap() error {
+	return e.Err
+}
+
+// resolveError returns the error for a $ref whose pointer could not be
+// resolved by schemas.Resolve.
+func resolveError(ref, pointer string, err error) error {
+	if errors.Is(err, schemas.ErrNotExist) {
+		name := strings.NewReplacer("~1", "/", "~0", "~").Replace(pointer[strings.LastIndex(pointer, "/")+1:])
+		return &MissingDefinitionError{Name: name, Ref: ref, Err: err}
+	}
+	return fmt.Errorf("%w (from ref %q)", err, ref)
+}
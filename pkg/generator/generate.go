@@ -1,17 +1,32 @@
 package generator
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"go/ast"
 	"go/format"
+	"go/printer"
+	"go/token"
+	"io"
+	"io/fs"
+	"math"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"text/template"
 	"unicode"
 
 	"github.com/lets-dev-it-out/go-jsonschema/pkg/codegen"
 	"github.com/lets-dev-it-out/go-jsonschema/pkg/schemas"
-	"github.com/pkg/errors"
+	"github.com/sanity-io/litter"
 )
 
 type Config struct {
@@ -21,114 +36,773 @@ type Config struct {
 	YAMLExtensions     []string
 	DefaultPackageName string
 	DefaultOutputName  string
-	Warner             func(string)
+	// Warner is called with each warning about a schema; MessageWarner
+	// adapts a function that takes only their messages.
+	Warner func(Warning)
+	// ValidateFormats enables generated checks for the "email", "idn-email",
+	// "hostname" and "regex" formats on string fields.
+	ValidateFormats bool
+	FormatMappings  []FormatMapping
+	// ExtraTags are struct tag keys, such as "mapstructure" or "bson", that are
+	// added to every field in addition to "json" and "yaml".
+	ExtraTags []string
+	OmitEmpty OmitEmptyMode
+	// OptionalValueTypes declares optional fields with value types instead of
+	// pointers, so absent and zero values cannot be told apart.
+	OptionalValueTypes bool
+	// NullableRequiredPointers declares required fields whose type is either
+	// null or one other type as pointers, so that an explicit null is
+	// accepted and can be represented.
+	NullableRequiredPointers bool
+	// DefaultIntegerType is the Go type used for integers; defaults to "int".
+	DefaultIntegerType string
+	// IntegerTypeFromBounds picks int32, int64, uint32 or uint64 for integers
+	// that have a minimum or maximum, based on those bounds. Integers whose
+	// bounds none of them can hold get DefaultIntegerType, with a warning.
+	IntegerTypeFromBounds bool
+	// JSONNumber declares numbers and integers as json.Number, so that their
+	// values are preserved exactly.
+	JSONNumber bool
+	// OnlyModels generates plain types, without any methods for validating
+	// values when unmarshaling them. String enums still get their String and
+	// IsValid methods and Parse functions.
+	OnlyModels bool
+	// FullValidation enables every optional kind of validation, such as
+	// ValidateFormats and ValidateOnMarshal.
+	FullValidation bool
+	// AggregateErrors makes the generated validation code report every
+	// violation as ValidationErrors, rather than stopping at the first one.
+	AggregateErrors bool
+	// NestedDefaults fills in the defaults of nested objects that are absent
+	// when unmarshaling, provided that they have no required properties.
+	NestedDefaults bool
+	// Builders generates a builder for each struct, for constructing values
+	// in code with their required fields checked. Structs with defaults get
+	// the constructors of Constructors too, which their builders start from.
+	Builders bool
+	// Constructors generates a NewX function for each struct with defaults,
+	// which returns a value with them filled in. Defaults that can only be
+	// decoded from JSON at run time make it return an error too.
+	Constructors bool
+	// Getters generates a nil-safe getter for each field of each struct.
+	Getters bool
+	// DeepCopy generates DeepCopy and DeepCopyInto methods for each struct.
+	DeepCopy bool
+	// Equal generates an Equal method for each struct, which compares values
+	// structurally without reflection.
+	Equal bool
+	// SQL generates Scan and Value methods for enums, so that they implement
+	// sql.Scanner and driver.Valuer. Fields with a format get none; see
+	// FormatMappings for types that support database/sql themselves.
+	SQL bool
+	// ValidateOnMarshal generates MarshalJSON methods that check required
+	// fields, enum values and constraints, so that invalid values are not
+	// marshaled.
+	ValidateOnMarshal bool
+	// PreserveOrder generates MarshalJSON methods for structs that write their
+	// properties in the order the schema declares them, rather than in the
+	// alphabetical order of their fields.
+	PreserveOrder bool
+	// CaptureExtras adds an AdditionalProperties field to each struct, which
+	// holds the properties that the schema does not declare when unmarshaling
+	// and is written back when marshaling.
+	CaptureExtras bool
+	// DisallowUnknownFields makes unmarshaling fail on properties that the
+	// schema of a struct does not declare, unless it allows additional
+	// properties explicitly.
+	DisallowUnknownFields bool
+	// EasyJSON generates MarshalEasyJSON and UnmarshalEasyJSON methods for
+	// each struct, for github.com/mailru/easyjson, which read and write its
+	// fields without reflection. MarshalJSON and UnmarshalJSON use them too.
+	EasyJSON bool
+	// RoundTripTests generates a _test.go file next to each output file, with
+	// tests that unmarshal and marshal the examples and defaults declared in
+	// the schema.
+	RoundTripTests bool
+	// FuzzTests adds a fuzz target for each struct and enum to the _test.go
+	// file next to each output file, which checks that unmarshaling arbitrary
+	// input does not panic. The examples and defaults in the schema seed it.
+	FuzzTests bool
+	// FieldNameConstants declares a constant with the JSON name of each field
+	// of each struct, e.g. FooNameJSON = "name".
+	FieldNameConstants bool
+	// OpenAPI parses input files as OpenAPI 3.0 or 3.1 documents, generating
+	// types from their components.schemas. See schemas.FromOpenAPIReader.
+	OpenAPI bool
+	// FileSystem, if set, is what DoFile and references to other files read
+	// schemas from, such as an embed.FS, instead of the operating system. As
+	// with fs.FS, file names are slash-separated and relative to its root.
+	FileSystem fs.FS
+	// CatalogURL is the URL of the schema catalog that DoCatalogSchema looks
+	// schemas up in. It defaults to DefaultCatalogURL.
+	CatalogURL string
+	// Docs writes a Markdown reference of the types of each output file next
+	// to it, e.g. foo.md next to foo.go, with a table of the properties of
+	// each struct.
+	Docs bool
+	// RandomValues generates a GenerateFoo(r *rand.Rand) Foo function for
+	// each type, which returns a random value that is valid against the
+	// schema, for property-based tests of code that consumes the types.
+	RandomValues bool
+	// TypeHooks can override the Go types that schemas are generated as, such
+	// as to use an existing Money type for objects with an amount and a
+	// currency. They are called in order for each schema, before the
+	// "x-go-type" extension is looked at.
+	TypeHooks []TypeHook
+	// FileHooks are called in order with each output file once its types
+	// have been generated, before it is written, to add declarations to it,
+	// such as interfaces or init functions, or to change it, such as the tags
+	// of struct fields. The _test.go files of RoundTripTests and FuzzTests
+	// are passed to them too.
+	FileHooks []FileHook
+	// Parallelism is the number of files that DoFiles reads and parses at a
+	// time. It defaults to GOMAXPROCS. Code is still generated from them one
+	// file at a time.
+	Parallelism int
+	// DeleteStaleFiles makes Write delete the Go files that an earlier run
+	// generated in the directories that it writes to, but that this one
+	// didn't, such as those of schemas that have been removed.
+	DeleteStaleFiles bool
+	// RootTypeOverrides names the root types of the schemas in files, by
+	// file path or glob pattern, matched as SchemaMapping.FilePath is. They
+	// take precedence over the root types of SchemaMappings, and are the way
+	// to name the root types of schemas without IDs without mapping them to
+	// other packages or files.
+	RootTypeOverrides map[string]string
+	// InferPackageNames derives the package of each output file whose
+	// package is not given, by DefaultPackageName or a mapping, from the
+	// module path in the nearest go.mod file and the file's directory within
+	// the module.
+	InferPackageNames bool
+	// SplitFiles splits each output file, other than standard output, into
+	// a file for each type declared for a definition or the root of a
+	// schema, along with the types nested in it and its methods, named after
+	// the output file and the type, such as "schema_address.go" for type
+	// Address in "schema.go". Declarations that belong to no such type, such
+	// as ValidationError, stay in the output file itself.
+	SplitFiles bool
+	// TypesPerFile, with SplitFiles, puts up to this many of those types in
+	// each file, named "schema_1.go", "schema_2.go" and so on, instead of
+	// one.
+	TypesPerFile int
+	// FileHeader is a text/template for comment text, such as a license,
+	// that is added to the top of every generated Go file. It is executed
+	// with a FileHeaderData, and each line of its output becomes a line
+	// comment. The standard "Code generated ... DO NOT EDIT." comment always
+	// follows it, so that tools, and DeleteStaleFiles, still recognize the
+	// file as generated.
+	FileHeader string
+	// ASTHooks are called with the syntax tree of each generated Go file,
+	// after the FileHooks, to change it before it is printed. The tree is
+	// parsed from the emitted code, so they post-process it.
+	ASTHooks []ASTHook
+	// LineWidth is the length that the comments in generated code, such as
+	// the descriptions of schemas, are wrapped to, counting their
+	// indentation. It defaults to 80.
+	LineWidth uint
+	// IndentSpaces indents generated code with this many spaces per level
+	// instead of tabs, as gofmt does.
+	IndentSpaces uint
+	// UnwrappedComments keeps the lines of descriptions in comments as they
+	// are, instead of wrapping them to LineWidth.
+	UnwrappedComments bool
+	// PackageDocs adds a doc.go file to each directory that Go files are
+	// generated in, other than standard output, with a package comment made
+	// of the titles, descriptions and $ids of the schemas generated into it.
+	PackageDocs bool
+	// SourceComments ends the comment of each type declared for a subschema
+	// with where the subschema is, such as
+	// "Source: schema.json#/definitions/job", to trace generated code back
+	// to its schema.
+	SourceComments bool
+	// EmbedSchema declares a JSONSchema() []byte method for the root type of
+	// each schema file, which returns the schema, with the schemas that it
+	// refers to in other files bundled into it as Bundle does, so that
+	// services can serve it, or validate against it, at runtime.
+	EmbedSchema bool
+	// FailOnWarning makes warnings errors, for generated code that must
+	// represent its schemas exactly: the call that generates code for a
+	// schema, or Write or Diff for the warnings about the output files,
+	// returns a *WarningsError with the warnings reported since the last
+	// such call. The warnings are still passed to Warner.
+	FailOnWarning bool
+	// Lenient generates interface{}, with a warning, for subschemas whose
+	// types cannot be generated, such as ones with $refs that cannot be
+	// resolved, instead of failing, so that large schemas that use
+	// unsupported constructs in a few places still generate usable code.
+	Lenient bool
+	// OnlyReferencedDefinitions generates types only for the definitions
+	// that the root of each schema file refers to, directly or through other
+	// definitions, rather than for all of them. Schema files that are only
+	// loaded because another refers to them, such as shared definitions
+	// files, get types only for what is referred to. The definitions of
+	// schema files given to the generator whose roots declare no type,
+	// which only hold definitions, are all generated still.
+	OnlyReferencedDefinitions bool
+	// SharedInlineTypes declares one type for all of the object schemas
+	// declared inline, such as the schemas of properties, in a schema file
+	// that are identical, rather than a type for each.
+	SharedInlineTypes bool
 }
 
+// ASTHook changes the syntax tree of a generated Go file, such as to rename
+// declarations or rewrite struct tags, before it is printed. The positions of
+// the tree are in fset.
+type ASTHook func(fset *token.FileSet, file *ast.File)
+
+// TypeHook returns the Go type to use for a schema instead of generating one,
+// along with the packages to import for it, or a nil type to leave the schema
+// to the generator or the next hook. Returning an error stops generation.
+type TypeHook func(t *schemas.Type) (codegen.Type, []codegen.Import, error)
+
+// FileHook inspects or changes a file before it is written.
+type FileHook func(file *codegen.File)
+
+// OmitEmptyMode controls which fields are tagged with "omitempty". It can be
+// overridden per property with the "x-omitempty" extension.
+type OmitEmptyMode string
+
+const (
+	// OmitEmptyOptional tags only optional fields. This is the default.
+	OmitEmptyOptional OmitEmptyMode = "optional"
+	// OmitEmptyAlways tags every field, including required ones.
+	OmitEmptyAlways OmitEmptyMode = "always"
+	// OmitEmptyNever tags no fields.
+	OmitEmptyNever OmitEmptyMode = "never"
+)
+
+// FormatMapping maps values of a JSON Schema "format" to a Go type. GoType is
+// either a predeclared type (e.g. "string") or a qualified type name, such as
+// "github.com/shopspring/decimal.Decimal", whose package will be imported.
+type FormatMapping struct {
+	Format string
+	GoType string
+}
+
+// SchemaMapping maps the schemas with an ID, or in a file, to a Go package,
+// output file and root type name. SchemaID may be a pattern in which "*" matches any text,
+// such as "https://example.com/schemas/*", to map a whole family of schemas
+// at once; "{name}" in PackageName and OutputName then stands for the last
+// element of each schema's ID, without extensions, as in
+// "github.com/example/gen/{name}". A mapping for the ID itself takes
+// precedence over patterns, and of the patterns that match, the one with the
+// most text besides wildcards is used.
 type SchemaMapping struct {
-	SchemaID    string
+	SchemaID string
+	// FilePath maps the schema in a file instead of the schema with an ID,
+	// for schemas without IDs. It may be a pattern as Glob takes, such as
+	// "schemas/*.json" or "schemas/**/*.json", and is matched against the
+	// names of schema files as given and as absolute paths. Mappings by file path take
+	// precedence over those by ID.
+	FilePath    string
 	PackageName string
 	RootType    string
 	OutputName  string
 }
 
 type Generator struct {
-	config                Config
+	config Config
+	// outputs are the output files by name, and outputsBySchemaID the
+	// outputs that the schemas with each ID have been mapped to by ID.
 	outputs               map[string]*output
+	outputsBySchemaID     map[string]*output
 	schemaCacheByFileName map[string]*schemas.Schema
 	inScope               map[qualifiedDefinition]struct{}
-	warner                func(string)
+	// sharedDefinitions are the definitions in other files that have been
+	// referred to, by the $id of their schema and their pointer.
+	sharedDefinitions map[string]sharedDefinition
+	// flattened holds the types with allOf that have been merged into one.
+	flattened map[*schemas.Type]*schemas.Type
+	// checkedDecls are the types whose UnmarshalJSON methods return
+	// validation errors, and validatedDecls those with Validate methods.
+	checkedDecls   map[*codegen.TypeDecl]bool
+	validatedDecls map[*codegen.TypeDecl]bool
+	// fallibleConstructors are the structs with constructors, which return
+	// errors if they are set.
+	fallibleConstructors map[*codegen.TypeDecl]bool
+	// hookedFiles are the files that the FileHooks have been called with,
+	// since Sources may be called more than once.
+	hookedFiles map[*codegen.File]bool
+	warner      func(Warning)
+	// failedWarnings are the warnings that have been reported, with
+	// Config.FailOnWarning, but not yet returned as a WarningsError.
+	failedWarnings []Warning
+	// ctx is the context of the call in progress, if it was given one, which
+	// schemas referred to by URL are fetched with, and which stops generation
+	// when it is done.
+	ctx context.Context
+	// catalog is the schema catalog, once DoCatalogSchema has fetched it.
+	catalog []catalogEntry
+	// header is the parsed Config.FileHeader, if any.
+	header *template.Template
+	// sourceHashes are the SHA-256 hashes of the documents that schemas were
+	// parsed from, which DoFiles records from many goroutines. The lock is
+	// shared by the copies that withoutWarnings makes.
+	sourceHashesMu *sync.Mutex
+	sourceHashes   map[*schemas.Schema]string
 }
 
 func New(config Config) (*Generator, error) {
-	return &Generator{
+	switch config.OmitEmpty {
+	case "", OmitEmptyOptional, OmitEmptyAlways, OmitEmptyNever:
+	default:
+		return nil, fmt.Errorf("invalid omitempty mode %q", config.OmitEmpty)
+	}
+	if config.Lenient && config.FailOnWarning {
+		return nil, errors.New("lenient mode and failing on warnings cannot both be enabled")
+	}
+	if config.OnlyModels && config.FullValidation {
+		return nil, errors.New("only models and full validation cannot both be enabled")
+	}
+	if config.OnlyModels && config.Builders {
+		return nil, errors.New("only models and builders cannot both be enabled")
+	}
+	if config.OnlyModels && config.SQL {
+		return nil, errors.New("only models and SQL methods cannot both be enabled")
+	}
+	if config.OnlyModels && config.ValidateOnMarshal {
+		return nil, errors.New("only models and validation on marshal cannot both be enabled")
+	}
+	if config.OnlyModels && config.CaptureExtras {
+		return nil, errors.New("only models and capturing extra properties cannot both be enabled")
+	}
+	if config.OnlyModels && config.DisallowUnknownFields {
+		return nil, errors.New("only models and disallowing unknown fields cannot both be enabled")
+	}
+	if config.CaptureExtras && config.DisallowUnknownFields {
+		return nil, errors.New("capturing extra properties and disallowing unknown fields cannot both be enabled")
+	}
+	if config.FullValidation {
+		config.ValidateFormats = true
+		config.ValidateOnMarshal = true
+	}
+	var header *template.Template
+	if config.FileHeader != "" {
+		var err error
+		if header, err = template.New("header").Parse(config.FileHeader); err != nil {
+			return nil, fmt.Errorf("invalid file header template: %w", err)
+		}
+	}
+
+	g := &Generator{
 		config:                config,
 		outputs:               map[string]*output{},
+		outputsBySchemaID:     map[string]*output{},
 		schemaCacheByFileName: map[string]*schemas.Schema{},
 		inScope:               map[qualifiedDefinition]struct{}{},
+		sharedDefinitions:     map[string]sharedDefinition{},
+		flattened:             map[*schemas.Type]*schemas.Type{},
+		checkedDecls:          map[*codegen.TypeDecl]bool{},
+		validatedDecls:        map[*codegen.TypeDecl]bool{},
+		fallibleConstructors:  map[*codegen.TypeDecl]bool{},
+		hookedFiles:           map[*codegen.File]bool{},
 		warner:                config.Warner,
-	}, nil
+		header:                header,
+		sourceHashesMu:        &sync.Mutex{},
+		sourceHashes:          map[*schemas.Schema]string{},
+	}
+	if config.FailOnWarning {
+		// Copies made by withoutWarnings, which clear the warner, record
+		// nothing either.
+		g.warner = func(w Warning) {
+			if config.Warner != nil {
+				config.Warner(w)
+			}
+			g.failedWarnings = append(g.failedWarnings, w)
+		}
+	}
+	return g, nil
 }
 
-func (g *Generator) Sources() map[string][]byte {
-	sources := make(map[string]*strings.Builder, len(g.outputs))
-	for _, output := range g.outputs {
-		if output.file.FileName == "" {
-			continue
+// GeneratedFile is a file of generated code, or of the docs or tests that go
+// with it.
+type GeneratedFile struct {
+	// Name is the name of the file, or "-" for standard output.
+	Name string
+	// Package is the qualified name of the Go package of the file.
+	Package string
+	// SchemaIDs are the IDs of the schemas that the file was generated from,
+	// sorted. Schemas without IDs are not listed.
+	SchemaIDs []string
+	Content   []byte
+}
+
+// Files returns the generated files, sorted by name.
+func (g *Generator) Files() []GeneratedFile {
+	outputs := make([]*output, 0, len(g.outputs))
+	for _, o := range g.outputs {
+		if o.file.FileName != "" {
+			outputs = append(outputs, o)
 		}
-		emitter := codegen.NewEmitter(80)
-		output.file.Generate(emitter)
+	}
+	sort.Slice(outputs, func(i, j int) bool {
+		return outputs[i].file.FileName < outputs[j].file.FileName
+	})
 
-		sb, ok := sources[output.file.FileName]
+	var files []GeneratedFile
+	runHooks := func(file *codegen.File) {
+		if !g.hookedFiles[file] {
+			g.hookedFiles[file] = true
+			for _, hook := range g.config.FileHooks {
+				hook(file)
+			}
+		}
+	}
+	add := func(file *codegen.File, o *output, split bool) {
+		file.Header = g.fileHeader(file, o)
+		src, err := g.formatFile(file, split)
+		if err != nil {
+			g.warn(Warning{
+				Code: WarningUnformatted,
+				Message: fmt.Sprintf("The generated code of %s could not be formatted automatically; "+
+					"falling back to unformatted: %s", file.FileName, err),
+			})
+			emitter := codegen.NewStyledEmitter(g.style())
+			file.Generate(emitter)
+			src = emitter.Bytes()
+		}
+		files = append(files, GeneratedFile{
+			Name:      file.FileName,
+			Package:   file.Package.QualifiedName,
+			SchemaIDs: o.sortedSchemaIDs(),
+			Content:   src,
+		})
+	}
+	for _, o := range outputs {
+		runHooks(o.file)
+	}
+	helpers, stripped := g.sharedHelpers(outputs)
+	for _, o := range outputs {
+		file, ok := stripped[o]
 		if !ok {
-			sb = &strings.Builder{}
-			sources[output.file.FileName] = sb
+			file = o.file
+		}
+		if g.config.SplitFiles && o.file.FileName != "-" {
+			for _, file := range g.splitFile(o, file) {
+				add(file, o, true)
+			}
+		} else {
+			add(file, o, ok)
+		}
+
+		if g.config.Docs && len(o.docs) > 0 {
+			if o.file.FileName == "-" {
+				g.warn(Warning{Code: WarningSkipped, Message: "Not generating docs for standard output"})
+			} else {
+				files = append(files, GeneratedFile{
+					Name:      strings.TrimSuffix(o.file.FileName, ".go") + ".md",
+					Package:   o.file.Package.QualifiedName,
+					SchemaIDs: o.sortedSchemaIDs(),
+					Content:   docsFile([]*output{o}),
+				})
+			}
+		}
+
+		if g.hasTests(o) {
+			if o.file.FileName == "-" {
+				g.warn(Warning{Code: WarningSkipped, Message: "Not generating tests for standard output"})
+			} else {
+				file := g.testFile(o)
+				runHooks(file)
+				add(file, o, false)
+			}
 		}
-		_, _ = sb.WriteString(emitter.String())
 	}
+	for _, h := range helpers {
+		add(h.file, h.output, true)
+	}
+	if g.config.PackageDocs {
+		for _, doc := range g.packageDocs(outputs) {
+			add(doc.file, doc.output, false)
+		}
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].Name < files[j].Name
+	})
+	return files
+}
 
-	result := make(map[string][]byte, len(sources))
-	for f, sb := range sources {
-		source := []byte(sb.String())
-		src, err := format.Source(source)
-		if err != nil {
-			g.config.Warner(fmt.Sprintf("The generated code could not be formatted automatically; "+
-				"falling back to unformatted: %s", err))
-			src = source
+// formatFile returns the formatted source of a file, which is parsed, changed
+// by the ASTHooks and printed. Split files, and the files that the shared
+// helpers of a package are moved into or out of, only import the packages
+// that they use.
+func (g *Generator) formatFile(file *codegen.File, split bool) ([]byte, error) {
+	fset := token.NewFileSet()
+	syntax, err := file.GenerateAST(fset, g.style())
+	if err != nil {
+		return nil, err
+	}
+	if split {
+		file.Package.Imports = usedImports(syntax, file.Package.Imports)
+		fset = token.NewFileSet()
+		if syntax, err = file.GenerateAST(fset, g.style()); err != nil {
+			return nil, err
 		}
-		result[f] = src
 	}
-	return result
+	for _, hook := range g.config.ASTHooks {
+		hook(fset, syntax)
+	}
+	var buf bytes.Buffer
+	if g.config.IndentSpaces > 0 {
+		// As format.Node prints, other than indenting with spaces.
+		printConfig := printer.Config{Mode: printer.UseSpaces, Tabwidth: int(g.config.IndentSpaces)}
+		if err := printConfig.Fprint(&buf, fset, syntax); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	if err := format.Node(&buf, fset, syntax); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// style returns the style that generated code is laid out in.
+func (g *Generator) style() codegen.Style {
+	style := codegen.Style{
+		LineWidth:         g.config.LineWidth,
+		IndentSpaces:      g.config.IndentSpaces,
+		UnwrappedComments: g.config.UnwrappedComments,
+	}
+	if style.LineWidth == 0 {
+		style.LineWidth = 80
+	}
+	return style
+}
+
+// Sources returns the contents of the generated files by their names. See
+// Files.
+func (g *Generator) Sources() map[string][]byte {
+	files := g.Files()
+	sources := make(map[string][]byte, len(files))
+	for _, file := range files {
+		sources[file.Name] = file.Content
+	}
+	return sources
+}
+
+// InputFiles returns the names of the schema files that types have been
+// generated from, including those that other schemas refer to, cleaned and
+// sorted, so that tools can tell when the code needs to be generated again.
+// Standard input and URLs are not included.
+func (g *Generator) InputFiles() []string {
+	seen := map[string]bool{}
+	var fileNames []string
+	for _, o := range g.outputs {
+		for fileName := range o.sources {
+			if fileName == "-" || isHTTPURL(fileName) {
+				continue
+			}
+			if g.config.FileSystem != nil {
+				fileName = path.Clean(fileName)
+			} else {
+				fileName = filepath.Clean(fileName)
+			}
+			if !seen[fileName] {
+				seen[fileName] = true
+				fileNames = append(fileNames, fileName)
+			}
+		}
+	}
+	sort.Strings(fileNames)
+	return fileNames
 }
 
+// DoFile generates types for the schema in a file, or in standard input if
+// the name is "-".
 func (g *Generator) DoFile(fileName string) error {
+	return g.DoFileContext(context.Background(), fileName)
+}
+
+// DoFileContext is DoFile with a context, which stops generation, and the
+// fetching of the schemas that the file refers to by URL, when it is done.
+func (g *Generator) DoFileContext(ctx context.Context, fileName string) error {
+	defer g.withContext(ctx)()
+
 	var err error
 	var schema *schemas.Schema
 	if fileName == "-" {
-		schema, err = schemas.FromJSONReader(os.Stdin)
+		schema, err = g.parse(fileName, os.Stdin)
 		if err != nil {
-			return errors.Wrap(err, "error parsing from standard input")
+			return fmt.Errorf("error parsing from standard input: %w", err)
 		}
 	} else {
 		schema, err = g.parseFile(fileName)
 		if err != nil {
-			return errors.Wrapf(err, "error parsing from file %s", fileName)
+			return fmt.Errorf("error parsing from file %s: %w", fileName, err)
 		}
 	}
 	return g.addFile(fileName, schema)
 }
 
+// DoReader generates types for a schema read from r, in JSON or YAML. The
+// name is treated as the schema's file name: it determines the name of the
+// root type, and relative references are resolved against it.
+func (g *Generator) DoReader(name string, r io.Reader) error {
+	return g.DoReaderContext(context.Background(), name, r)
+}
+
+// DoReaderContext is DoReader with a context, as for DoFileContext.
+func (g *Generator) DoReaderContext(ctx context.Context, name string, r io.Reader) error {
+	defer g.withContext(ctx)()
+
+	schema, err := g.parse(name, r)
+	if err != nil {
+		return fmt.Errorf("error parsing %s: %w", name, err)
+	}
+	return g.addFile(name, schema)
+}
+
+// DoSchema generates types for a schema that has been parsed or built in
+// memory. The name is treated as by DoReader.
+func (g *Generator) DoSchema(name string, schema *schemas.Schema) error {
+	return g.DoSchemaContext(context.Background(), name, schema)
+}
+
+// DoSchemaContext is DoSchema with a context, as for DoFileContext.
+func (g *Generator) DoSchemaContext(ctx context.Context, name string, schema *schemas.Schema) error {
+	defer g.withContext(ctx)()
+
+	return g.addFile(name, schema)
+}
+
+// withContext makes ctx the context of the call in progress, until the
+// function that it returns is called. Calls made within calls, such as by
+// DoCatalogSchema, restore the context of the outer call.
+func (g *Generator) withContext(ctx context.Context) func() {
+	outer := g.ctx
+	g.ctx = ctx
+	return func() {
+		g.ctx = outer
+	}
+}
+
+// context returns the context of the call in progress.
+func (g *Generator) context() context.Context {
+	if g.ctx == nil {
+		return context.Background()
+	}
+	return g.ctx
+}
+
 func (g *Generator) parseFile(fileName string) (*schemas.Schema, error) {
+	f, err := g.openFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	return g.parse(fileName, f)
+}
+
+// openFile opens a schema file, from the FileSystem if one is configured.
+func (g *Generator) openFile(fileName string) (io.ReadCloser, error) {
+	if g.config.FileSystem != nil {
+		return g.config.FileSystem.Open(fileName)
+	}
+	return os.Open(fileName)
+}
+
+// parse parses a schema read from a file or URL, whose name tells whether it
+// is written in YAML.
+func (g *Generator) parse(name string, r io.Reader) (*schemas.Schema, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := g.parseBytes(name, b)
+	if err != nil {
+		return nil, &InvalidSchemaError{Name: name, Err: err}
+	}
+	g.recordSourceHash(schema, b)
+	return schema, nil
+}
+
+func (g *Generator) parseBytes(name string, b []byte) (*schemas.Schema, error) {
 	// TODO: Refactor into some kind of loader
-	isYAML := false
+	if g.config.OpenAPI {
+		return schemas.FromOpenAPIReader(bytes.NewReader(b))
+	}
+	if err := g.checkMetaSchema(b); err != nil {
+		return nil, err
+	}
 	for _, yamlExt := range g.config.YAMLExtensions {
-		if strings.HasSuffix(fileName, yamlExt) {
-			isYAML = true
-			break
+		if strings.HasSuffix(name, yamlExt) {
+			return schemas.FromYAMLReader(bytes.NewReader(b))
 		}
 	}
-	if isYAML {
-		return schemas.FromYAMLFile(fileName)
-	} else {
-		return schemas.FromJSONFile(fileName)
-	}
+	return schemas.FromReader(bytes.NewReader(b))
 }
 
 func (g *Generator) addFile(fileName string, schema *schemas.Schema) error {
-	o, err := g.findOutputFileForSchemaID(schema.ID)
+	return g.addSchemaFile(fileName, schema, false)
+}
+
+// addReferencedFile generates types for a schema file that is loaded because
+// another refers to it, rather than because it was given to the generator.
+func (g *Generator) addReferencedFile(fileName string, schema *schemas.Schema) error {
+	return g.addSchemaFile(fileName, schema, true)
+}
+
+func (g *Generator) addSchemaFile(fileName string, schema *schemas.Schema, referenced bool) error {
+	if err := g.context().Err(); err != nil {
+		return err
+	}
+	for _, w := range schema.Warnings() {
+		g.warn(Warning{Code: WarningDraft, SchemaFile: fileName, Message: w})
+	}
+
+	o, err := g.findOutputFile(schema, fileName)
 	if err != nil {
 		return err
 	}
 
-	return (&schemaGenerator{
+	err = (&schemaGenerator{
 		Generator:      g,
 		schema:         schema,
 		schemaFileName: fileName,
 		output:         o,
+		referenced:     referenced,
 	}).generateRootType()
+	if err != nil {
+		return err
+	}
+	return g.warningsError()
 }
 
 func (g *Generator) loadSchemaFromFile(fileName, parentFileName string) (*schemas.Schema, error) {
-	if !filepath.IsAbs(fileName) {
+	if u, ok := schemaURL(fileName, parentFileName); ok {
+		return g.loadSchemaFromURL(u)
+	}
+
+	qualified, err := g.resolveFileName(fileName, parentFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	if schema, ok := g.schemaCacheByFileName[qualified]; ok {
+		return schema, nil
+	}
+
+	schema, err := g.parseFile(qualified)
+	if err != nil {
+		return nil, err
+	}
+	g.schemaCacheByFileName[qualified] = schema
+
+	if err = g.addReferencedFile(qualified, schema); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// resolveFileName returns the canonical name of a schema file that another
+// one refers to, trying each of the ResolveExtensions in turn.
+func (g *Generator) resolveFileName(fileName, parentFileName string) (string, error) {
+	if g.config.FileSystem != nil {
+		fileName = path.Join(path.Dir(parentFileName), fileName)
+	} else if !filepath.IsAbs(fileName) {
 		fileName = filepath.Join(filepath.Dir(parentFileName), fileName)
 	}
 
@@ -137,72 +811,100 @@ func (g *Generator) loadSchemaFromFile(fileName, parentFileName string) (*schema
 		qualified := fileName + ext
 
 		// Poor man's resolving loop
-		if i < len(exts)-1 && !fileExists(qualified) {
+		if i < len(exts)-1 && !g.fileExists(qualified) {
 			continue
 		}
+		return g.canonicalFileName(qualified)
+	}
+	return "", fmt.Errorf("could not resolve schema %q", fileName)
+}
 
-		var err error
-		qualified, err = filepath.EvalSymlinks(qualified)
-		if err != nil {
-			return nil, err
-		}
-
-		if schema, ok := g.schemaCacheByFileName[qualified]; ok {
-			return schema, nil
-		}
-
-		schema, err := g.parseFile(qualified)
-		if err != nil {
-			return nil, err
+func (g *Generator) getRootTypeName(schema *schemas.Schema, fileName string) (string, error) {
+	if name, ok := g.rootTypeOverride(fileName); ok {
+		return name, nil
+	}
+	if m, ok := g.fileMapping(fileName); ok && m.RootType != "" {
+		return m.RootType, nil
+	}
+	if m, ok := g.schemaMapping(schema.ID); ok && m.RootType != "" {
+		return m.RootType, nil
+	}
+	if schema.ObjectAsType != nil {
+		if name, err := goName((*schemas.Type)(schema.ObjectAsType)); name != "" || err != nil {
+			return name, err
 		}
-		g.schemaCacheByFileName[qualified] = schema
+	}
+	return g.identifierFromFileName(fileName), nil
+}
 
-		if err = g.addFile(qualified, schema); err != nil {
-			return nil, err
-		}
-		return schema, nil
+// definitionName returns the Go identifier for a definition, honoring the
+// "x-go-name" extension.
+func (g *Generator) definitionName(name string, def *schemas.Type) (string, error) {
+	if name, err := goName(def); name != "" || err != nil {
+		return name, err
 	}
-	return nil, fmt.Errorf("could not resolve schema %q", fileName)
+	return g.identifierize(name), nil
 }
 
-func (g *Generator) getRootTypeName(schema *schemas.Schema, fileName string) string {
-	for _, m := range g.config.SchemaMappings {
-		if m.SchemaID == schema.ID && m.RootType != "" {
-			return m.RootType
-		}
+// goName returns the identifier that the "x-go-name" extension gives a
+// type, if any. It fails if that is not a valid Go identifier.
+func goName(t *schemas.Type) (string, error) {
+	if t.GoName != "" && !token.IsIdentifier(t.GoName) {
+		return "", fmt.Errorf("x-go-name %q is not a valid Go identifier", t.GoName)
 	}
-	return g.identifierFromFileName(fileName)
+	return t.GoName, nil
 }
 
-func (g *Generator) findOutputFileForSchemaID(id string) (*output, error) {
-	if o, ok := g.outputs[id]; ok {
+// findOutputFile returns the output that the types of a schema, read from a
+// file, are generated in, as mapped by its file name, or else by its ID.
+func (g *Generator) findOutputFile(schema *schemas.Schema, fileName string) (*output, error) {
+	if m, ok := g.fileMapping(fileName); ok {
+		o, err := g.beginOutput(schema.ID, m.OutputName, m.PackageName)
+		if err != nil {
+			return nil, err
+		}
+		o.sources[fileName] = schema
+		return o, nil
+	}
+	if o, ok := g.outputsBySchemaID[schema.ID]; ok {
+		o.sources[fileName] = schema
 		return o, nil
 	}
 
-	for _, m := range g.config.SchemaMappings {
-		if m.SchemaID == id {
-			return g.beginOutput(id, m.OutputName, m.PackageName)
-		}
+	outputName, packageName := g.config.DefaultOutputName, g.config.DefaultPackageName
+	if m, ok := g.schemaMapping(schema.ID); ok {
+		outputName, packageName = m.OutputName, m.PackageName
+	}
+	o, err := g.beginOutput(schema.ID, outputName, packageName)
+	if err != nil {
+		return nil, err
 	}
-	return g.beginOutput(id, g.config.DefaultOutputName, g.config.DefaultPackageName)
+	g.outputsBySchemaID[schema.ID] = o
+	o.sources[fileName] = schema
+	return o, nil
 }
 
 func (g *Generator) beginOutput(
 	id string,
 	outputName, packageName string) (*output, error) {
+	if packageName == "" && g.config.InferPackageNames {
+		var err error
+		if packageName, err = inferPackageName(outputName); err != nil {
+			return nil, fmt.Errorf("unable to infer the Go package name for schema URI %q: %w", id, err)
+		}
+	}
 	if packageName == "" {
 		return nil, fmt.Errorf("unable to map schema URI %q to a Go package name", id)
 	}
 
-	for _, o := range g.outputs {
-		if o.file.FileName == outputName && o.file.Package.QualifiedName != packageName {
+	if o, ok := g.outputs[outputName]; ok {
+		if o.file.Package.QualifiedName != packageName {
 			return nil, fmt.Errorf(
-				"conflict: same file (%s) mapped to two different Go packages (%q and %q) for schema %q",
-				o.file.FileName, o.file.Package.QualifiedName, packageName, id)
-		}
-		if o.file.FileName == outputName && o.file.Package.QualifiedName == packageName {
-			return o, nil
+				"%w: same file (%s) mapped to two different Go packages (%q and %q) for schema %q",
+				ErrConflictingOutput, o.file.FileName, o.file.Package.QualifiedName, packageName, id)
 		}
+		o.schemaIDs[id] = true
+		return o, nil
 	}
 
 	pkg := codegen.Package{
@@ -210,15 +912,25 @@ func (g *Generator) beginOutput(
 	}
 
 	output := &output{
-		warner: g.warner,
 		file: &codegen.File{
 			FileName: outputName,
 			Package:  pkg,
 		},
+		schemaIDs:     map[string]bool{id: true},
 		declsBySchema: map[*schemas.Type]*codegen.TypeDecl{},
+		declsByShape:  map[string]*codegen.TypeDecl{},
 		declsByName:   map[string]*codegen.TypeDecl{},
+		varsByName:    map[string]*codegen.Var{},
+		funcsByName:   map[string]bool{},
+		helpers:       map[codegen.Decl]string{},
+		deepCopyDecls: map[*codegen.TypeDecl]bool{},
+		randomDecls:   map[*codegen.TypeDecl]bool{},
+		equalDecls:    map[*codegen.TypeDecl]bool{},
+		easyJSONDecls: map[*codegen.TypeDecl]bool{},
+		topLevel:      map[*codegen.TypeDecl]bool{},
+		sources:       map[string]*schemas.Schema{},
 	}
-	g.outputs[id] = output
+	g.outputs[outputName] = output
 	return output, nil
 }
 
@@ -231,12 +943,11 @@ func (g *Generator) makeEnumConstantName(typeName, value string) string {
 
 func (g *Generator) identifierFromFileName(fileName string) string {
 	s := filepath.Base(fileName)
-	for _, ext := range g.config.ResolveExtensions {
-		trimmed := strings.TrimSuffix(s, ext)
-		if trimmed == s {
+	for _, ext := range append(g.config.ResolveExtensions, g.config.YAMLExtensions...) {
+		if trimmed := strings.TrimSuffix(s, ext); trimmed != s {
+			s = trimmed
 			break
 		}
-		s = trimmed
 	}
 	return g.identifierize(s)
 }
@@ -252,6 +963,9 @@ func (g *Generator) identifierize(s string) string {
 		_, _ = sb.WriteString(g.capitalize(part))
 	}
 	ident := sb.String()
+	if ident == "" {
+		return "Blank"
+	}
 	if !unicode.IsLetter(rune(ident[0])) {
 		ident = "A" + ident
 	}
@@ -275,31 +989,67 @@ type schemaGenerator struct {
 	output         *output
 	schema         *schemas.Schema
 	schemaFileName string
+	// referenced is set for schema files that are only loaded because
+	// another refers to them.
+	referenced bool
 }
 
 func (g *schemaGenerator) generateRootType() error {
 	if g.schema.ObjectAsType == nil {
 		return errors.New("schema has no root")
 	}
+	if g.config.OnlyReferencedDefinitions && g.referenced {
+		// The types that are referred to are generated as they are.
+		return nil
+	}
 
-	for _, name := range sortDefinitionsByName(g.schema.Definitions) {
-		def := g.schema.Definitions[name]
-		_, err := g.generateDeclaredType(def, newNameScope(g.identifierize(name)))
+	root := (*schemas.Type)(g.schema.ObjectAsType)
+	hasRoot := len(root.Type) > 0 || impliedTypeName(root) != ""
+	defs := g.schema.AllDefinitions()
+	if g.config.OnlyReferencedDefinitions && hasRoot {
+		// Those the root refers to are generated along with it.
+		defs = nil
+	}
+	for _, name := range sortDefinitionsByName(defs) {
+		if err := g.context().Err(); err != nil {
+			return err
+		}
+		def := defs[name]
+		if shared, _, _ := g.shareDefinition(g.schema, g.schemaFileName, definitionPointer(g.schema, name), def); shared != g.schema {
+			// Generated from another copy of the schema.
+			continue
+		}
+		defName, err := g.definitionName(name, def)
+		if err != nil {
+			return fmt.Errorf("could not generate type for definition %q: %w", name, err)
+		}
+		t, err := g.generateDeclaredType(def, newNameScope(defName))
 		if err != nil {
 			return err
 		}
+		g.output.markTopLevel(t)
 	}
-	if len(g.schema.ObjectAsType.Type) == 0 {
+	if !hasRoot {
 		return nil
 	}
 
-	rootTypeName := g.getRootTypeName(g.schema, g.schemaFileName)
+	rootTypeName, err := g.getRootTypeName(g.schema, g.schemaFileName)
+	if err != nil {
+		return fmt.Errorf("could not generate root type: %w", err)
+	}
 	if _, ok := g.output.declsByName[rootTypeName]; ok {
 		return nil
 	}
 
-	_, err := g.generateDeclaredType((*schemas.Type)(g.schema.ObjectAsType), newNameScope(rootTypeName))
-	return err
+	t, err := g.generateDeclaredType(root, newNameScope(rootTypeName))
+	if err != nil {
+		return err
+	}
+	g.output.markTopLevel(t)
+	if named, ok := t.(*codegen.NamedType); ok && g.config.EmbedSchema && g.output.declsByName[named.Decl.Name] == named.Decl {
+		g.generateEmbeddedSchema(named.Decl)
+	}
+	return nil
 }
 
 func (g *schemaGenerator) generateReferencedType(ref string) (codegen.Type, error) {
@@ -308,10 +1058,15 @@ func (g *schemaGenerator) generateReferencedType(ref string) (codegen.Type, erro
 		fileName = ref
 	} else {
 		fileName, scope = ref[0:i], ref[i+1:]
-		if !strings.HasPrefix(strings.ToLower(scope), "/definitions/") {
-			return nil, fmt.Errorf("unsupported $ref format; must point to definition within file: %q", ref)
+		if scope == "/" {
+			scope = ""
+		}
+		if scope != "" && !strings.HasPrefix(scope, "/") {
+			return nil, fmt.Errorf("%w; must be a JSON Pointer within file: %q", ErrUnsupportedRef, ref)
 		}
-		defName = scope[13:]
+		// Subschemas are named after the last token of their pointer, e.g.
+		// the definition for "#/definitions/Address".
+		defName = strings.NewReplacer("~1", "/", "~0", "~").Replace(scope[strings.LastIndex(scope, "/")+1:])
 	}
 
 	var schema *schemas.Schema
@@ -319,7 +1074,15 @@ func (g *schemaGenerator) generateReferencedType(ref string) (codegen.Type, erro
 		var err error
 		schema, err = g.loadSchemaFromFile(fileName, g.schemaFileName)
 		if err != nil {
-			return nil, fmt.Errorf("could not follow $ref %q to file %q: %s", ref, fileName, err)
+			return nil, fmt.Errorf("could not follow $ref %q to file %q: %w", ref, fileName, err)
+		}
+		if u, ok := schemaURL(fileName, g.schemaFileName); ok {
+			// Resolve references within the schema against its URL
+			fileName = u
+		} else if qualified, err := g.resolveFileName(fileName, g.schemaFileName); err == nil {
+			// Resolve references within the file against its directory,
+			// and match mappings by file path against its path.
+			fileName = qualified
 		}
 	} else {
 		schema = g.schema
@@ -327,29 +1090,49 @@ func (g *schemaGenerator) generateReferencedType(ref string) (codegen.Type, erro
 
 	qual := qualifiedDefinition{
 		schema: schema,
-		name:   defName,
+		name:   scope,
 	}
 
 	var def *schemas.Type
-	if defName != "" {
-		// TODO: Support nested definitions
-		var ok bool
-		def, ok = schema.Definitions[defName]
-		if !ok {
-			return nil, fmt.Errorf("definition %q (from ref %q) does not exist in schema", defName, ref)
+	if scope != "" {
+		var err error
+		def, err = schemas.Resolve(schema, scope)
+		if err != nil {
+			return nil, resolveError(ref, scope, err)
+		}
+		if fileName == "" {
+			if def, err = g.flattenAllOf(def); err != nil {
+				return nil, err
+			}
 		}
-		if len(def.Type) == 0 && len(def.Properties) == 0 {
+		goType, err := g.customType(def)
+		if err != nil {
+			return nil, err
+		}
+		if goType != nil {
+			return goType, nil
+		}
+		if len(def.Type) == 0 && impliedTypeName(def) == "" {
 			return &codegen.EmptyInterfaceType{}, nil
 		}
-		defName = g.identifierize(defName)
+		if defName, err = g.definitionName(defName, def); err != nil {
+			return nil, resolveError(ref, scope, err)
+		}
 	} else {
 		def = (*schemas.Type)(schema.ObjectAsType)
-		defName = g.getRootTypeName(schema, fileName)
+		var err error
+		if defName, err = g.getRootTypeName(schema, fileName); err != nil {
+			return nil, fmt.Errorf("could not follow $ref %q: %w", ref, err)
+		}
 		if len(def.Type) == 0 {
 			// Minor hack to make definitions default to being objects
 			def.Type = schemas.TypeList{schemas.TypeNameObject}
 		}
 	}
+	if fileName != "" {
+		schema, fileName, def = g.shareDefinition(schema, fileName, scope, def)
+		qual.schema = schema
+	}
 
 	_, isCycle := g.inScope[qual]
 	if !isCycle {
@@ -361,7 +1144,7 @@ func (g *schemaGenerator) generateReferencedType(ref string) (codegen.Type, erro
 
 	var sg *schemaGenerator
 	if fileName != "" {
-		output, err := g.findOutputFileForSchemaID(schema.ID)
+		output, err := g.findOutputFile(schema, fileName)
 		if err != nil {
 			return nil, err
 		}
@@ -382,9 +1165,10 @@ func (g *schemaGenerator) generateReferencedType(ref string) (codegen.Type, erro
 	}
 
 	nt := t.(*codegen.NamedType)
+	sg.output.markTopLevel(nt)
 
 	if isCycle {
-		g.warner(fmt.Sprintf("Cycle detected; must wrap type %s in pointer", nt.Decl.Name))
+		g.warnf(def, WarningCycle, "Cycle detected; must wrap type %s in pointer", nt.Decl.Name)
 		t = codegen.WrapTypeInPointer(t)
 	}
 
@@ -409,19 +1193,57 @@ func (g *schemaGenerator) generateReferencedType(ref string) (codegen.Type, erro
 	}, nil
 }
 
+// withSourceComment returns the comment of a type declared for a subschema,
+// followed, with Config.SourceComments, by the file and JSON Pointer of the
+// subschema. Local files are named by their paths relative to the working
+// directory.
+func (g *schemaGenerator) withSourceComment(comment string, t *schemas.Type) string {
+	if !g.config.SourceComments {
+		return comment
+	}
+	source := g.schemaFileName
+	if g.config.FileSystem == nil && source != "-" && !isHTTPURL(source) {
+		if wd, err := os.Getwd(); err == nil && filepath.IsAbs(source) {
+			if rel, err := filepath.Rel(wd, source); err == nil {
+				source = rel
+			}
+		}
+		source = filepath.ToSlash(filepath.Clean(source))
+	}
+	if pointer, ok := g.pointerTo(t); ok && pointer != "" {
+		source += "#" + pointer
+	}
+	if comment = strings.TrimRight(comment, "\n"); comment != "" {
+		comment += "\n\n"
+	}
+	return comment + "Source: " + source
+}
+
 func (g *schemaGenerator) generateDeclaredType(
 	t *schemas.Type, scope nameScope) (codegen.Type, error) {
+	t, err := g.flattenAllOf(t)
+	if err != nil {
+		return nil, err
+	}
 	if decl, ok := g.output.declsBySchema[t]; ok {
 		return &codegen.NamedType{Decl: decl}, nil
 	}
 
+	goType, err := g.customType(t)
+	if err != nil {
+		return nil, err
+	}
+	if goType != nil {
+		return goType, nil
+	}
+
 	if t.Enum != nil {
 		return g.generateEnumType(t, scope)
 	}
 
 	decl := codegen.TypeDecl{
-		Name:    g.output.uniqueTypeName(scope.string()),
-		Comment: t.Description,
+		Name:    g.uniqueTypeName(t, scope.string()),
+		Comment: g.withSourceComment(t.Description, t),
 	}
 	g.output.declsBySchema[t] = &decl
 	g.output.declsByName[decl.Name] = &decl
@@ -430,7 +1252,7 @@ func (g *schemaGenerator) generateDeclaredType(
 	if err != nil {
 		return nil, err
 	}
-	if isNamedType(theType) {
+	if _, ok := theType.(*codegen.CustomNameType); (ok && t.Ref != "") || isNamedType(theType) {
 		// Don't declare named types under a new name
 		delete(g.output.declsBySchema, t)
 		delete(g.output.declsByName, decl.Name)
@@ -439,28 +1261,135 @@ func (g *schemaGenerator) generateDeclaredType(
 	decl.Type = theType
 
 	g.output.file.Package.AddDecl(&decl)
+	if g.config.RoundTripTests || g.config.FuzzTests {
+		g.addTestCases(decl.Name, t, theType)
+	}
+	if g.config.Docs {
+		g.addDocEntry(decl.Name, t, theType)
+	}
+	if g.config.RandomValues {
+		g.generateRandom(&decl, t)
+	}
 
-	if structType, ok := theType.(*codegen.StructType); ok {
-		var validators []validator
-		for _, f := range structType.RequiredJSONFields {
-			validators = append(validators, &requiredValidator{f, decl.Name})
+	if structType, ok := theType.(*codegen.StructType); ok && (g.config.Constructors || g.config.Builders) &&
+		hasDefaults(structType, nil) {
+		if err := g.generateConstructor(&decl, structType); err != nil {
+			return nil, err
 		}
-		for _, f := range structType.Fields {
-			if f.DefaultValue != nil {
-				validators = append(validators, &defaultValidator{
-					jsonName:         f.JSONName,
-					fieldName:        f.Name,
-					defaultValueType: f.Type,
-					defaultValue:     f.DefaultValue,
-				})
-			}
-			if _, ok := f.Type.(codegen.NullType); ok {
-				validators = append(validators, &nullTypeValidator{
-					fieldName: f.Name,
-					jsonName:  f.JSONName,
+	}
+	if structType, ok := theType.(*codegen.StructType); ok && g.config.Getters {
+		g.generateGetters(decl.Name, structType)
+	}
+	if structType, ok := theType.(*codegen.StructType); ok && g.config.FieldNameConstants {
+		g.generateFieldNameConstants(decl.Name, structType)
+	}
+	if structType, ok := theType.(*codegen.StructType); ok && g.config.DeepCopy {
+		g.generateDeepCopy(&decl, structType)
+	}
+	if structType, ok := theType.(*codegen.StructType); ok && g.config.Equal {
+		g.generateEqual(&decl, structType)
+	}
+
+	if g.config.OnlyModels {
+		if structType, ok := theType.(*codegen.StructType); ok {
+			var order []string
+			if g.config.PreserveOrder {
+				order = propertyOrder(t, structType)
+			}
+			if g.config.EasyJSON {
+				g.generateEasyJSON(&decl, structType, false, false, false, order, nil)
+			} else if order != nil {
+				g.generateMarshal(&decl, nil, false, order, false)
+			}
+		}
+		return &codegen.NamedType{Decl: &decl}, nil
+	}
+
+	if _, ok := theType.(*codegen.MapType); ok {
+		var constraints []validator
+		if t.MinProperties != 0 || t.MaxProperties != 0 {
+			constraints = append(constraints, &propertiesValidator{
+				value:         "*" + varNameReceiver,
+				minProperties: t.MinProperties,
+				maxProperties: t.MaxProperties,
+			})
+		}
+		if v := g.newPropertyNamesValidator(decl.Name, "*"+varNameReceiver, t); v != nil {
+			constraints = append(constraints, v)
+		}
+		if len(constraints) > 0 {
+			g.generateMapValidation(decl.Name, constraints...)
+			if g.config.ValidateOnMarshal {
+				g.generateMarshal(&decl, nil, true, nil, false)
+			}
+		}
+	}
+
+	if constraints := g.valueConstraints(decl.Name, t, theType); len(constraints) > 0 {
+		g.generateValueValidation(decl.Name, theType, constraints...)
+		if g.config.ValidateOnMarshal {
+			g.generateMarshal(&decl, nil, true, nil, false)
+		}
+	}
+
+	if structType, ok := theType.(*codegen.StructType); ok {
+		var validators, constraints []validator
+		props := &objectProperties{}
+		if t.MinProperties != 0 || t.MaxProperties != 0 {
+			validators = append(validators, &propertiesValidator{
+				value:               varNameRawMap,
+				minProperties:       t.MinProperties,
+				maxProperties:       t.MaxProperties,
+				beforeJSONUnmarshal: true,
+			})
+		}
+		if v := g.newPropertyNamesValidator(decl.Name, varNameRawMap, t); v != nil {
+			v.beforeJSONUnmarshal = true
+			validators = append(validators, v)
+		}
+		for _, f := range structType.RequiredJSONFields {
+			validators = append(validators, &requiredValidator{
+				jsonName: f,
+				nullable: g.isNullableRequiredField(structType, f),
+				props:    props,
+			})
+		}
+		if t.Not != nil {
+			if v := g.newNotValidator(t.Not); v != nil {
+				validators = append(validators, v)
+			}
+		}
+		for _, f := range structType.Fields {
+			if f.SchemaType != nil && f.SchemaType.Not != nil {
+				if v := g.newPropertyNotValidator(decl.Name, f); v != nil {
+					validators = append(validators, v)
+				}
+			}
+		}
+		for _, f := range structType.Fields {
+			if f.DefaultValue != nil {
+				v, err := g.newDefaultValidator(f)
+				if err != nil {
+					return nil, err
+				}
+				v.props = props
+				validators = append(validators, v)
+			} else if g.config.NestedDefaults && hasNestedDefaults(f.Type, nil) {
+				// Unmarshaling an empty object applies the nested defaults
+				validators = append(validators, &defaultValidator{
+					jsonName:  f.JSONName,
+					fieldName: f.Name,
+					json:      "{}",
+					props:     props,
+				})
+			}
+			if _, ok := f.Type.(codegen.NullType); ok {
+				validators = append(validators, &nullTypeValidator{
+					fieldName: f.Name,
+					jsonName:  f.JSONName,
 				})
 			} else {
-				t, arrayDepth := f.Type, 0
+				t, st, arrayDepth := f.Type, f.SchemaType, 0
 				for v, ok := t.(*codegen.ArrayType); ok; v, ok = t.(*codegen.ArrayType) {
 					arrayDepth++
 					if _, ok := v.Type.(codegen.NullType); ok {
@@ -470,74 +1399,1335 @@ func (g *schemaGenerator) generateDeclaredType(
 							arrayDepth: arrayDepth,
 						})
 						break
+					} else if st != nil {
+						if st.MinItems != 0 || st.MaxItems != 0 || st.UniqueItems {
+							constraints = append(constraints, g.newArrayValidator(f, st, v, arrayDepth))
+						}
+					}
+
+					t = v.Type
+					if st != nil {
+						st = st.Items.All()
+					}
+				}
+			}
+			if g.config.ValidateFormats {
+				if v := g.newFormatValidator(f); v != nil {
+					validators = append(validators, v)
+				}
+			}
+			if v := g.newNumericValidator(f); v != nil {
+				constraints = append(constraints, v)
+			}
+			if v := g.newStringValidator(decl.Name, f); v != nil {
+				constraints = append(constraints, v)
+			}
+		}
+
+		// The checks that UnmarshalJSON makes on the raw object, of the number
+		// of properties, and in the UnmarshalJSON methods of the nested
+		// values, are made on the struct by its Validate method only
+		var checks []validator
+		if t.MinProperties != 0 || t.MaxProperties != 0 {
+			checks = append(checks, &propertiesValidator{
+				fields:        structType.Fields,
+				minProperties: t.MinProperties,
+				maxProperties: t.MaxProperties,
+			})
+		}
+		// The values of fields of types with Validate methods are validated by
+		// the struct's Validate method too, and so are those of the struct's
+		// own type if it has one.
+		isValidated := func(d *codegen.TypeDecl) bool { return g.validatedDecls[d] }
+		validated := validatedFields(structType, isValidated)
+		if len(constraints) > 0 || len(checks) > 0 || len(validated) > 0 {
+			isValidated = func(d *codegen.TypeDecl) bool { return d == &decl || g.validatedDecls[d] }
+			validated = validatedFields(structType, isValidated)
+		}
+		if len(validated) > 0 {
+			g.declarePrefixValidationError()
+			if validatesInMaps(validated, isValidated) {
+				g.output.file.Package.AddImport("strings", "")
+			}
+			nestedValidator := &nestedValidateValidator{
+				fields:    validated,
+				validated: isValidated,
+				fail:      returnNestedError,
+			}
+			if g.config.AggregateErrors {
+				nestedValidator.fail = appendNestedError
+			}
+			checks = append(checks, nestedValidator)
+		}
+		if len(checks) > 0 && len(constraints) > 0 {
+			g.generateValidateMethod(decl.Name, methodNameValidateFields, nil, constraints)
+			validators = append(validators, &validateMethodValidator{
+				declName:  decl.Name,
+				method:    methodNameValidateFields,
+				aggregate: g.config.AggregateErrors,
+			})
+			g.generateValidateMethod(decl.Name, methodNameValidate, nil, append([]validator{
+				&validateMethodValidator{
+					declName:  decl.Name,
+					method:    methodNameValidateFields,
+					value:     varNameReceiver,
+					aggregate: g.config.AggregateErrors,
+				},
+			}, checks...))
+		} else if len(checks) > 0 {
+			g.generateValidateMethod(decl.Name, methodNameValidate, nil, checks)
+		} else if len(constraints) > 0 {
+			g.generateValidateMethod(decl.Name, methodNameValidate, nil, constraints)
+			validators = append(validators, &validateMethodValidator{
+				declName:  decl.Name,
+				aggregate: g.config.AggregateErrors,
+			})
+		}
+		hasValidate := len(constraints) > 0 || len(checks) > 0
+
+		if g.config.Builders {
+			g.generateBuilder(&decl, structType, hasValidate)
+		}
+		extras := hasExtrasField(structType)
+		var order []string
+		if g.config.PreserveOrder {
+			order = propertyOrder(t, structType)
+		}
+		hasMarshal := false
+		if g.config.ValidateOnMarshal || g.config.PreserveOrder || extras {
+			var required []codegen.StructField
+			validates := false
+			if g.config.ValidateOnMarshal {
+				required, validates = g.requiredNillableFields(structType), hasValidate
+			}
+			hasMarshal = g.generateMarshal(&decl, required, validates, order, extras)
+		}
+
+		hasError := len(constraints) > 0
+		for _, v := range validators {
+			hasError = hasError || v.desc().hasError
+		}
+		// The errors of the values of nested fields whose types are checked
+		// when they are unmarshaled are located in the struct. Fields of the
+		// struct's own type are nested fields too if it is checked.
+		nested := nestedFields(structType, func(d *codegen.TypeDecl) bool { return g.checkedDecls[d] })
+		if hasError || len(nested) > 0 {
+			nested = nestedFields(structType, func(d *codegen.TypeDecl) bool { return d == &decl || g.checkedDecls[d] })
+			hasError = true
+		}
+
+		// Structs that allow additional properties still need a method when
+		// unknown fields are disallowed, to decode them leniently when nested
+		// in a strict decoder
+		strict := g.config.DisallowUnknownFields && !allowsAdditionalProperties(t)
+		hasUnmarshal := len(validators) > 0 || len(nested) > 0 || extras || g.config.DisallowUnknownFields
+		if g.config.EasyJSON {
+			g.generateEasyJSON(&decl, structType, hasUnmarshal, hasMarshal, strict, order, nested)
+		}
+		if hasUnmarshal {
+			captures := g.captureProperties(props, structType, validators, nested, extras)
+			// With easyjson, the nested values are unmarshaled from the raw map.
+			needsRaw := extras || (g.config.EasyJSON && len(nested) > 0)
+			for _, v := range validators {
+				needsRaw = needsRaw || v.desc().usesRawMap
+			}
+			if hasError {
+				g.declareValidationError()
+				g.checkedDecls[&decl] = true
+			}
+			if len(nested) > 0 {
+				g.output.file.Package.AddImport("encoding/json", "")
+				g.declarePrefixValidationError()
+				if nestedInMaps(nested) {
+					g.output.file.Package.AddImport("sort", "")
+					g.output.file.Package.AddImport("strings", "")
+				}
+			}
+			fail := returnError
+			if g.config.AggregateErrors && hasError {
+				fail = appendError
+			}
+
+			if needsRaw || !g.config.EasyJSON {
+				g.output.file.Package.AddImport("encoding/json", "")
+			}
+			if needsRaw && g.config.EasyJSON {
+				g.declareDecodeRawObject()
+			}
+			if strict && !g.config.EasyJSON {
+				g.output.file.Package.AddImport("bytes", "")
+			}
+			g.output.file.Package.AddDecl(&codegen.Method{
+				Owner: decl.Name,
+				Impl: func(out *codegen.Emitter) {
+					out.Comment("UnmarshalJSON implements json.Unmarshaler.")
+					out.Println("func (j *%s) UnmarshalJSON(b []byte) error {", decl.Name)
+					out.Indent(1)
+					if needsRaw && g.config.EasyJSON {
+						out.Println("%s, err := %s(b)", varNameRawMap, funcNameDecodeRawObject)
+						out.Println("if err != nil { return err }")
+					} else if needsRaw {
+						out.Println("var %s map[string]json.RawMessage", varNameRawMap)
+						out.Println("if err := json.Unmarshal(b, &%s); err != nil { return err }",
+							varNameRawMap)
+					}
+					if g.config.AggregateErrors && hasError {
+						out.Println("var %s %s", varNameErrors, typeNameValidationErrors)
+					}
+					if !props.decoded {
+						for _, v := range validators {
+							if v.desc().beforeJSONUnmarshal {
+								v.generate(out, fail)
+							}
+						}
+					}
+
+					out.Println("type Plain %s", decl.Name)
+					out.Println("var %s Plain", varNamePlainStruct)
+					// The struct is decoded along with the properties that
+					// its fields cannot tell the absence of, and the nested
+					// values, which are unmarshaled on their own afterwards,
+					// once each, so that their errors can be located.
+					target := "&" + varNamePlainStruct
+					if len(captures) > 0 {
+						out.Println("%s := struct {", varNameProps)
+						out.Indent(1)
+						out.Println("*Plain")
+						for _, c := range captures {
+							out.Println("%s %s `json:%q`", c.name, c.typ, c.jsonName)
+						}
+						out.Indent(-1)
+						out.Println("}{Plain: &%s}", varNamePlainStruct)
+						target = "&" + varNameProps
+					}
+					if g.config.EasyJSON {
+						// Unknown fields are checked by decodeEasyJSON
+						out.Println("in := jlexer.Lexer{Data: b}")
+						out.Println("(*%s)(&%s).decodeEasyJSON(&in)", decl.Name, varNamePlainStruct)
+						out.Println("if err := in.Error(); err != nil {")
+					} else if strict {
+						out.Println("dec := json.NewDecoder(bytes.NewReader(b))")
+						out.Println("dec.DisallowUnknownFields()")
+						out.Println("if err := dec.Decode(%s); err != nil {", target)
 					} else {
-						if f.SchemaType.MinItems != 0 || f.SchemaType.MaxItems != 0 {
-							validators = append(validators, &arrayValidator{
-								fieldName:  f.Name,
-								jsonName:   f.JSONName,
-								arrayDepth: arrayDepth,
-								minItems:   f.SchemaType.MinItems,
-								maxItems:   f.SchemaType.MaxItems,
-							})
+						out.Println("if err := json.Unmarshal(b, %s); err != nil {", target)
+					}
+					out.Indent(1)
+					out.Println("return err")
+					out.Indent(-1)
+					out.Println("}")
+					for _, c := range captures {
+						if c.field != "" {
+							out.Println("if %s.%s != nil {", varNameProps, c.name)
+							out.Indent(1)
+							out.Println("%s.%s = *%s.%s", varNamePlainStruct, c.field, varNameProps, c.name)
+							out.Indent(-1)
+							out.Println("}")
+						}
+					}
+					if props.decoded {
+						for _, v := range validators {
+							if v.desc().beforeJSONUnmarshal {
+								v.generate(out, fail)
+							}
 						}
 					}
+					nestedFail := returnNestedError
+					if g.config.AggregateErrors {
+						nestedFail = appendNestedError
+					}
+					for _, f := range nested {
+						if g.config.EasyJSON {
+							out.Println(`if v, ok := %s["%s"]; ok {`, varNameRawMap, f.jsonName)
+						} else {
+							out.Println("if v := %s.%sRaw; v != nil {", varNameProps, f.fieldName)
+						}
+						out.Indent(1)
+						generateDecodeNested(out, f, "v", varNamePlainStruct+"."+f.fieldName, nestedFail)
+						out.Indent(-1)
+						out.Println("}")
+					}
+
+					for _, v := range validators {
+						if !v.desc().beforeJSONUnmarshal {
+							v.generate(out, fail)
+						}
+					}
+					if g.config.AggregateErrors && hasError {
+						generateReturnErrors(out)
+					}
+					if extras {
+						for _, f := range structType.Fields {
+							if f.JSONName != "" {
+								out.Println("delete(%s, %q)", varNameRawMap, f.JSONName)
+							}
+						}
+						out.Println("if len(%s) > 0 {", varNameRawMap)
+						out.Indent(1)
+						out.Println("%s.%s = make(map[string]interface{}, len(%s))",
+							varNamePlainStruct, fieldNameExtras, varNameRawMap)
+						out.Println("for k, v := range %s {", varNameRawMap)
+						out.Indent(1)
+						out.Println("var value interface{}")
+						out.Println("if err := json.Unmarshal(v, &value); err != nil {")
+						out.Indent(1)
+						out.Println("return err")
+						out.Indent(-1)
+						out.Println("}")
+						out.Println("%s.%s[k] = value", varNamePlainStruct, fieldNameExtras)
+						out.Indent(-1)
+						out.Println("}")
+						out.Indent(-1)
+						out.Println("}")
+					}
+
+					out.Println("*j = %s(%s)", decl.Name, varNamePlainStruct)
+					out.Println("return nil")
+					out.Indent(-1)
+					out.Println("}")
+				},
+			})
+		}
+	}
+
+	return &codegen.NamedType{Decl: &decl}, nil
+}
+
+// capturedProperty is a field of the struct that UnmarshalJSON decodes an
+// object into, besides the embedded plain struct, which holds a property.
+type capturedProperty struct {
+	jsonName, name, typ string
+	// field is the name of the struct's field that the property is copied
+	// to, if it is held as a pointer.
+	field string
+}
+
+// captureProperties returns the properties that the UnmarshalJSON method of a
+// struct decodes separately from its fields: the nested values, and, unless
+// a validator needs all of the object's properties, the properties that must
+// be told to be absent or null although their fields cannot be nil. Then the
+// object is decoded in a single pass, without a raw map, which props is set up
+// for.
+func (g *schemaGenerator) captureProperties(props *objectProperties, structType *codegen.StructType,
+	validators []validator, nested []nestedField, extras bool) []capturedProperty {
+	if g.config.EasyJSON {
+		return nil
+	}
+
+	decoded := !extras
+	lookedUp := map[string]bool{}
+	for _, v := range validators {
+		switch v := v.(type) {
+		case *requiredValidator:
+			decoded = decoded && !v.nullable
+			lookedUp[v.jsonName] = true
+		case *defaultValidator:
+			lookedUp[v.jsonName] = true
+		default:
+			decoded = decoded && !v.desc().usesRawMap
+		}
+	}
+	props.decoded = decoded
+	props.fields = map[string]codegen.StructField{}
+	props.captured = map[string]string{}
+
+	var captures []capturedProperty
+	for _, f := range structType.Fields {
+		props.fields[f.JSONName] = f
+		if isNestedField(nested, f) {
+			name := f.Name + "Raw"
+			props.captured[f.JSONName] = name
+			captures = append(captures, capturedProperty{jsonName: f.JSONName, name: name, typ: "json.RawMessage"})
+		} else if decoded && lookedUp[f.JSONName] && !isNilable(f.Type) {
+			props.captured[f.JSONName] = f.Name
+			captures = append(captures, capturedProperty{
+				jsonName: f.JSONName,
+				name:     f.Name,
+				typ:      "*" + typeString(f.Type),
+				field:    f.Name,
+			})
+		}
+	}
+	return captures
+}
+
+// generateValidateMethod declares a Validate method, or the validateFields
+// method of a struct, that checks the given constraints against the receiver.
+func (g *schemaGenerator) generateValidateMethod(
+	declName, method string, underlying codegen.Type, constraints []validator) {
+	g.declareValidationError()
+	if method == methodNameValidate {
+		g.validatedDecls[g.output.declsByName[declName]] = true
+	}
+	g.output.file.Package.AddDecl(&codegen.Method{
+		Owner: declName,
+		Impl: func(out *codegen.Emitter) {
+			if method == methodNameValidate {
+				out.Comment("Validate checks that the value satisfies the constraints declared in the schema.")
+			} else {
+				out.Comment(method + " checks that the fields satisfy the constraints declared in the schema, " +
+					"but not the values nested in them.")
+			}
+			out.Println("func (%s *%s) %s() error {", varNameReceiver, declName, method)
+			out.Indent(1)
+			if underlying != nil {
+				out.Print("%s := ", varNameValue)
+				underlying.Generate(out)
+				out.Println("(*%s)", varNameReceiver)
+			}
+			fail := returnError
+			if g.config.AggregateErrors {
+				fail = appendError
+				out.Println("var %s %s", varNameErrors, typeNameValidationErrors)
+			}
+			for _, v := range constraints {
+				v.generate(out, fail)
+			}
+			if g.config.AggregateErrors {
+				generateReturnErrors(out)
+			}
+			out.Println("return nil")
+			out.Indent(-1)
+			out.Println("}")
+		},
+	})
+}
+
+// declareValidationError declares the type of the errors returned by the
+// generated validation code, if it has not been declared already.
+func (g *schemaGenerator) declareValidationError() {
+	if _, ok := g.output.declsByName[typeNameValidationError]; ok {
+		return
+	}
+
+	stringType := codegen.PrimitiveType{Type: "string"}
+	decl := codegen.TypeDecl{
+		Name:    typeNameValidationError,
+		Comment: "ValidationError is returned when a value does not conform to the schema it was generated from.",
+		Type: &codegen.StructType{
+			Fields: []codegen.StructField{
+				{
+					Name:    "Path",
+					Type:    stringType,
+					Comment: "Path is the JSON Pointer of the invalid value, relative to the value being validated.",
+				},
+				{
+					Name:    "Keyword",
+					Type:    stringType,
+					Comment: "Keyword is the schema keyword that the value violates.",
+				},
+				{
+					Name:    "Message",
+					Type:    stringType,
+					Comment: "Message describes the violation.",
+				},
+			},
+		},
+	}
+	g.output.declsByName[decl.Name] = &decl
+	g.output.file.Package.AddImport("fmt", "")
+	g.output.addHelper(typeNameValidationError, &decl, &codegen.Method{
+		Owner: typeNameValidationError,
+		Impl: func(out *codegen.Emitter) {
+			out.Comment("Error implements error.")
+			out.Println("func (e *%s) Error() string {", typeNameValidationError)
+			out.Indent(1)
+			out.Println(`if e.Path == "" {`)
+			out.Indent(1)
+			out.Println("return e.Message")
+			out.Indent(-1)
+			out.Println("}")
+			out.Println(`return fmt.Sprintf("%%s: %%s", e.Path, e.Message)`)
+			out.Indent(-1)
+			out.Println("}")
+		},
+	})
+
+	if g.config.AggregateErrors {
+		g.declareValidationErrors()
+	}
+}
+
+// declareValidationErrors declares the type collecting every error found by
+// the generated validation code, when errors are aggregated.
+func (g *schemaGenerator) declareValidationErrors() {
+	g.output.file.Package.AddImport("strings", "")
+	g.output.addHelper(typeNameValidationErrors, &codegen.TypeDecl{
+		Name:    typeNameValidationErrors,
+		Comment: "ValidationErrors is returned when a value violates one or more constraints of the schema it was generated from.",
+		Type:    &codegen.ArrayType{Type: &codegen.PointerType{Type: codegen.CustomNameType{Type: typeNameValidationError}}},
+	}, &codegen.Method{
+		Owner: typeNameValidationErrors,
+		Impl: func(out *codegen.Emitter) {
+			out.Comment("Error implements error.")
+			out.Println("func (e %s) Error() string {", typeNameValidationErrors)
+			out.Indent(1)
+			out.Println("msgs := make([]string, len(e))")
+			out.Println("for i, err := range e {")
+			out.Indent(1)
+			out.Println("msgs[i] = err.Error()")
+			out.Indent(-1)
+			out.Println("}")
+			out.Println(`return strings.Join(msgs, "; ")`)
+			out.Indent(-1)
+			out.Println("}")
+			out.Newline()
+			out.Comment("Unwrap returns the individual errors.")
+			out.Println("func (e %s) Unwrap() []error {", typeNameValidationErrors)
+			out.Indent(1)
+			out.Println("errs := make([]error, len(e))")
+			out.Println("for i, err := range e {")
+			out.Indent(1)
+			out.Println("errs[i] = err")
+			out.Indent(-1)
+			out.Println("}")
+			out.Println("return errs")
+			out.Indent(-1)
+			out.Println("}")
+		},
+	})
+}
+
+// declarePrefixValidationError declares the function that prepends the
+// location of a nested value to the paths of its validation errors, and when
+// errors are aggregated, the function that appends them to the others, if
+// they have not been declared already.
+func (g *schemaGenerator) declarePrefixValidationError() {
+	if g.output.funcsByName[funcNamePrefixValidationError] {
+		return
+	}
+	g.output.funcsByName[funcNamePrefixValidationError] = true
+	g.declareValidationError()
+	g.output.addHelper(funcNamePrefixValidationError, &codegen.Method{
+		Impl: func(out *codegen.Emitter) {
+			out.Comment(fmt.Sprintf("%s returns err with path, the JSON Pointer of a nested value, "+
+				"prepended to the paths of the validation errors in it, or err itself if it holds none.",
+				funcNamePrefixValidationError))
+			out.Println("func %s(err error, path string) error {", funcNamePrefixValidationError)
+			out.Indent(1)
+			out.Println("switch e := err.(type) {")
+			out.Println("case *%s:", typeNameValidationError)
+			out.Indent(1)
+			out.Println("prefixed := *e")
+			out.Println("prefixed.Path = path + e.Path")
+			out.Println("return &prefixed")
+			out.Indent(-1)
+			if g.config.AggregateErrors {
+				out.Println("case %s:", typeNameValidationErrors)
+				out.Indent(1)
+				out.Println("prefixed := make(%s, len(e))", typeNameValidationErrors)
+				out.Println("for i, verr := range e {")
+				out.Indent(1)
+				out.Println("p := *verr")
+				out.Println("p.Path = path + verr.Path")
+				out.Println("prefixed[i] = &p")
+				out.Indent(-1)
+				out.Println("}")
+				out.Println("return prefixed")
+				out.Indent(-1)
+			}
+			out.Println("}")
+			out.Println("return err")
+			out.Indent(-1)
+			out.Println("}")
+		},
+	})
+	if g.config.AggregateErrors {
+		g.output.addHelper(funcNameAppendValidationError, &codegen.Method{
+			Impl: func(out *codegen.Emitter) {
+				out.Comment(fmt.Sprintf("%s appends the validation errors in err, a nested value's error, "+
+					"to errs, with path prepended to their paths. If err holds none, it is returned instead.",
+					funcNameAppendValidationError))
+				out.Println("func %s(%s %s, err error, path string) (%s, error) {", funcNameAppendValidationError,
+					varNameErrors, typeNameValidationErrors, typeNameValidationErrors)
+				out.Indent(1)
+				out.Println("switch e := %s(err, path).(type) {", funcNamePrefixValidationError)
+				out.Println("case *%s:", typeNameValidationError)
+				out.Indent(1)
+				out.Println("return append(%s, e), nil", varNameErrors)
+				out.Indent(-1)
+				out.Println("case %s:", typeNameValidationErrors)
+				out.Indent(1)
+				out.Println("return append(%s, e...), nil", varNameErrors)
+				out.Indent(-1)
+				out.Println("}")
+				out.Println("return %s, err", varNameErrors)
+				out.Indent(-1)
+				out.Println("}")
+			},
+		})
+	}
+}
+
+// generateReturnErrors emits a statement that returns the aggregated errors,
+// if there are any.
+func generateReturnErrors(out *codegen.Emitter) {
+	out.Println("if len(%s) > 0 {", varNameErrors)
+	out.Indent(1)
+	out.Println("return %s", varNameErrors)
+	out.Indent(-1)
+	out.Println("}")
+}
+
+// generateGetters declares a getter for each field of a struct, which is safe
+// to call on a nil receiver, and which dereferences pointers to primitive
+// values, returning the zero value if they are nil.
+func (g *schemaGenerator) generateGetters(declName string, structType *codegen.StructType) {
+	for _, f := range structType.Fields {
+		f := f
+		t, deref := f.Type, false
+		if p, ok := t.(*codegen.PointerType); ok && isPrimitiveType(p.Type) {
+			t, deref = p.Type, true
+		}
+		g.output.file.Package.AddDecl(&codegen.Method{
+			Owner: declName,
+			Impl: func(out *codegen.Emitter) {
+				out.Comment(fmt.Sprintf("%s returns the value of the %q field, or its zero value "+
+					"if the field or the receiver is nil.", getterName(f.Name), f.JSONName))
+				out.Print("func (%s *%s) %s() ", varNameReceiver, declName, getterName(f.Name))
+				t.Generate(out)
+				out.Println(" {")
+				out.Indent(1)
+				value := fmt.Sprintf("%s.%s", varNameReceiver, f.Name)
+				if deref {
+					out.Println("if %s != nil && %s != nil {", varNameReceiver, value)
+					out.Indent(1)
+					out.Println("return *%s", value)
+				} else {
+					out.Println("if %s != nil {", varNameReceiver)
+					out.Indent(1)
+					out.Println("return %s", value)
+				}
+				out.Indent(-1)
+				out.Println("}")
+				out.Println("return %s", zeroValue(t))
+				out.Indent(-1)
+				out.Println("}")
+			},
+		})
+	}
+}
+
+// generateFieldNameConstants declares a constant with the JSON name of each
+// field of a struct, for code that refers to properties by name, such as
+// queries and patches.
+func (g *schemaGenerator) generateFieldNameConstants(declName string, structType *codegen.StructType) {
+	for _, f := range structType.Fields {
+		if f.JSONName == "" {
+			continue
+		}
+		name := declName + f.Name + "JSON"
+		if _, ok := g.output.declsByName[name]; ok {
+			g.warnf(f.SchemaType, WarningSkipped,
+				"Type %s conflicts with the constant for the %q field of %s; not declaring it",
+				name, f.JSONName, declName)
+			continue
+		}
+		g.output.file.Package.AddDecl(&codegen.Constant{
+			Name:  name,
+			Value: f.JSONName,
+			Owner: declName,
+		})
+	}
+}
+
+// generateBuilder declares a builder for a struct, with a setter for each
+// field and a Build method that checks that the required fields have been
+// set, and that the value is valid. Builders of structs with defaults start
+// from the value that the struct's constructor returns, and the Build method
+// returns the constructor's error, if any.
+func (g *schemaGenerator) generateBuilder(decl *codegen.TypeDecl, structType *codegen.StructType, hasValidate bool) {
+	g.declareValidationError()
+	declName := decl.Name
+	builderName := declName + "Builder"
+	defaulted := hasDefaults(structType, nil)
+	fallible := g.fallibleConstructors[decl]
+	g.output.file.Package.AddDecl(&codegen.Method{
+		Owner: declName,
+		Impl: func(out *codegen.Emitter) {
+			out.Comment(fmt.Sprintf("%s builds a %s.", builderName, declName))
+			out.Println("type %s struct {", builderName)
+			out.Indent(1)
+			out.Println("value %s", declName)
+			out.Println("set   map[string]bool")
+			if fallible {
+				out.Println("err   error")
+			}
+			out.Indent(-1)
+			out.Println("}")
+			out.Newline()
+
+			out.Comment(fmt.Sprintf("New%s returns a builder for a %s.", builderName, declName))
+			out.Println("func New%s() *%s {", builderName, builderName)
+			out.Indent(1)
+			if fallible {
+				out.Println("value, err := New%s()", declName)
+				out.Println("if err != nil {")
+				out.Indent(1)
+				out.Println("return &%s{set: map[string]bool{}, err: err}", builderName)
+				out.Indent(-1)
+				out.Println("}")
+				out.Println("return &%s{value: *value, set: map[string]bool{}}", builderName)
+			} else if defaulted {
+				out.Println("return &%s{value: *New%s(), set: map[string]bool{}}", builderName, declName)
+			} else {
+				out.Println("return &%s{set: map[string]bool{}}", builderName)
+			}
+			out.Indent(-1)
+			out.Println("}")
+
+			for _, f := range structType.Fields {
+				setter := f.Name
+				if setter == "Build" {
+					setter = "SetBuild"
+				}
+				out.Newline()
+				out.Comment(fmt.Sprintf("%s sets the %q field.", setter, f.JSONName))
+				t, value := f.Type, "v"
+				if p, ok := t.(*codegen.PointerType); ok {
+					t, value = p.Type, "&v"
+				}
+				out.Print("func (b *%s) %s(v ", builderName, setter)
+				t.Generate(out)
+				out.Println(") *%s {", builderName)
+				out.Indent(1)
+				out.Println("b.value.%s = %s", f.Name, value)
+				out.Println("b.set[%q] = true", f.JSONName)
+				out.Println("return b")
+				out.Indent(-1)
+				out.Println("}")
+			}
+
+			out.Newline()
+			out.Comment(fmt.Sprintf("Build returns the %s, or an error if a required field "+
+				"has not been set or the value is invalid.", declName))
+			out.Println("func (b *%s) Build() (*%s, error) {", builderName, declName)
+			out.Indent(1)
+			if fallible {
+				out.Println("if b.err != nil {")
+				out.Indent(1)
+				out.Println("return nil, b.err")
+				out.Indent(-1)
+				out.Println("}")
+			}
+			for _, name := range structType.RequiredJSONFields {
+				out.Println("if !b.set[%q] {", name)
+				out.Indent(1)
+				out.Println("return nil, %s", validationError(jsonPointer(name, nil), "required", "required"))
+				out.Indent(-1)
+				out.Println("}")
+			}
+			out.Println("v := b.value")
+			if hasValidate {
+				out.Println("if err := v.Validate(); err != nil {")
+				out.Indent(1)
+				out.Println("return nil, err")
+				out.Indent(-1)
+				out.Println("}")
+			}
+			out.Println("return &v, nil")
+			out.Indent(-1)
+			out.Println("}")
+		},
+	})
+}
+
+// generateConstructor declares a NewX function that returns a value with the
+// defaults declared in the schema, including those of nested objects that are
+// not pointers. Defaults that are not expressed as literals are decoded from
+// JSON, and the function returns the errors of decoding them, as those of
+// nested constructors.
+func (g *schemaGenerator) generateConstructor(decl *codegen.TypeDecl, structType *codegen.StructType) error {
+	type fieldValue struct {
+		name, literal, json string
+		// constructor is the constructor of a nested struct that returns an
+		// error.
+		constructor string
+	}
+	var values []fieldValue
+	fallible := false
+	for _, f := range structType.Fields {
+		if f.DefaultValue != nil {
+			v, err := g.newDefaultValidator(f)
+			if err != nil {
+				return err
+			}
+			values = append(values, fieldValue{name: f.Name, literal: v.literal, json: v.json})
+			fallible = fallible || v.literal == ""
+		} else if nt, ok := f.Type.(*codegen.NamedType); ok && isDefaultedStruct(nt) {
+			if g.fallibleConstructors[nt.Decl] {
+				values = append(values, fieldValue{name: f.Name, constructor: constructorName(nt)})
+				fallible = true
+			} else {
+				values = append(values, fieldValue{
+					name:    f.Name,
+					literal: fmt.Sprintf("*%s()", constructorName(nt)),
+				})
+			}
+		}
+	}
+	g.fallibleConstructors[decl] = fallible
+
+	for _, v := range values {
+		if v.json != "" {
+			g.output.file.Package.AddImport("encoding/json", "")
+			break
+		}
+	}
+	declName := decl.Name
+	g.output.file.Package.AddDecl(&codegen.Method{
+		Owner: declName,
+		Impl: func(out *codegen.Emitter) {
+			if fallible {
+				out.Comment(fmt.Sprintf("New%s returns a %s with the defaults declared in the schema, "+
+					"or an error if a default is not a valid value of its field.", declName, declName))
+				out.Println("func New%s() (*%s, error) {", declName, declName)
+			} else {
+				out.Comment(fmt.Sprintf("New%s returns a %s with the defaults declared in the schema.",
+					declName, declName))
+				out.Println("func New%s() *%s {", declName, declName)
+			}
+			out.Indent(1)
+			out.Println("v := &%s{", declName)
+			out.Indent(1)
+			for _, v := range values {
+				if v.literal != "" {
+					out.Println("%s: %s,", v.name, v.literal)
+				}
+			}
+			out.Indent(-1)
+			out.Println("}")
+			for _, v := range values {
+				if v.constructor != "" {
+					out.Println("if nested, err := %s(); err != nil {", v.constructor)
+					out.Indent(1)
+					out.Println("return nil, err")
+					out.Indent(-1)
+					out.Println("} else {")
+					out.Indent(1)
+					out.Println("v.%s = *nested", v.name)
+					out.Indent(-1)
+					out.Println("}")
+				} else if v.json != "" {
+					out.Println("if err := json.Unmarshal([]byte(%s), &v.%s); err != nil {",
+						strconv.Quote(v.json), v.name)
+					out.Indent(1)
+					out.Println("return nil, err")
+					out.Indent(-1)
+					out.Println("}")
+				}
+			}
+			if fallible {
+				out.Println("return v, nil")
+			} else {
+				out.Println("return v")
+			}
+			out.Indent(-1)
+			out.Println("}")
+		},
+	})
+	return nil
+}
+
+func (g *schemaGenerator) newDefaultValidator(f codegen.StructField) (*defaultValidator, error) {
+	v := &defaultValidator{
+		jsonName:  f.JSONName,
+		fieldName: f.Name,
+	}
+	if literal, ok := g.literal(f.Type, f.DefaultValue); ok {
+		v.literal = literal
+		return v, nil
+	}
+	b, err := json.Marshal(f.DefaultValue)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode default value of field %q: %w", f.JSONName, err)
+	}
+	v.json = string(b)
+	return v, nil
+}
+
+// literal returns a Go expression of type t for a value decoded from JSON, or
+// false if the value cannot be expressed as a literal of that type.
+func (g *schemaGenerator) literal(t codegen.Type, value interface{}) (string, bool) {
+	if value == nil {
+		return "nil", t.IsNillable()
+	}
+
+	switch t := t.(type) {
+	case codegen.PrimitiveType:
+		return primitiveLiteral(t.Type, value)
+
+	case codegen.EmptyInterfaceType, *codegen.EmptyInterfaceType:
+		return litter.Sdump(floatNumbers(value)), true
+
+	case *codegen.PointerType:
+		switch t.Type.(type) {
+		case *codegen.ArrayType, codegen.ArrayType, *codegen.MapType, *codegen.NamedType:
+			if lit, ok := g.literal(t.Type, value); ok && strings.HasSuffix(lit, "}") {
+				return "&" + lit, true
+			}
+		}
+		return "", false
 
-					t = v.Type
-				}
+	case *codegen.ArrayType, codegen.ArrayType:
+		at, _ := asArrayType(t)
+		values, ok := value.([]interface{})
+		if !ok {
+			return "", false
+		}
+		var sb strings.Builder
+		sb.WriteString(typeString(t) + "{\n")
+		for _, v := range values {
+			lit, ok := g.literal(at.Type, v)
+			if !ok {
+				return "", false
 			}
+			sb.WriteString(lit + ",\n")
 		}
+		sb.WriteString("}")
+		return sb.String(), true
 
-		if len(validators) > 0 {
-			for _, v := range validators {
-				if v.desc().hasError {
-					g.output.file.Package.AddImport("fmt", "")
-					break
-				}
+	case *codegen.MapType:
+		values, ok := value.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		if p, ok := t.KeyType.(codegen.PrimitiveType); !ok || p.Type != "string" {
+			return "", false
+		}
+		var sb strings.Builder
+		sb.WriteString(typeString(t) + "{\n")
+		for _, k := range sortedKeys(values) {
+			lit, ok := g.literal(t.ValueType, values[k])
+			if !ok {
+				return "", false
 			}
+			sb.WriteString(strconv.Quote(k) + ": " + lit + ",\n")
+		}
+		sb.WriteString("}")
+		return sb.String(), true
 
-			g.output.file.Package.AddImport("encoding/json", "")
-			g.output.file.Package.AddDecl(&codegen.Method{
-				Impl: func(out *codegen.Emitter) {
-					out.Comment("UnmarshalJSON implements json.Unmarshaler.")
-					out.Println("func (j *%s) UnmarshalJSON(b []byte) error {", decl.Name)
-					out.Indent(1)
-					out.Println("var %s map[string]interface{}", varNameRawMap)
-					out.Println("if err := json.Unmarshal(b, &%s); err != nil { return err }",
-						varNameRawMap)
-					for _, v := range validators {
-						if v.desc().beforeJSONUnmarshal {
-							v.generate(out)
-						}
-					}
+	case *codegen.NamedType:
+		return g.namedLiteral(t, value)
+	}
+	return "", false
+}
 
-					out.Println("type Plain %s", decl.Name)
-					out.Println("var %s Plain", varNamePlainStruct)
-					out.Println("if err := json.Unmarshal(b, &%s); err != nil { return err }",
-						varNamePlainStruct)
+// namedLiteral returns a literal of a named type: a composite literal for
+// structs, an enum constant if one is declared for the value, or else a
+// conversion of the literal of the underlying type.
+func (g *schemaGenerator) namedLiteral(t *codegen.NamedType, value interface{}) (string, bool) {
+	if t.Decl.Type == nil {
+		return "", false
+	}
+	name := typeString(t)
 
-					for _, v := range validators {
-						if !v.desc().beforeJSONUnmarshal {
-							v.generate(out)
-						}
-					}
+	if st, ok := t.Decl.Type.(*codegen.StructType); ok {
+		values, ok := value.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		var sb strings.Builder
+		sb.WriteString(name + "{\n")
+		matched := 0
+		for _, f := range st.Fields {
+			v, ok := values[f.JSONName]
+			if !ok {
+				continue
+			}
+			lit, ok := g.literal(f.Type, v)
+			if !ok {
+				return "", false
+			}
+			sb.WriteString(f.Name + ": " + lit + ",\n")
+			matched++
+		}
+		if matched != len(values) {
+			return "", false
+		}
+		sb.WriteString("}")
+		return sb.String(), true
+	}
 
-					out.Println("*j = %s(%s)", decl.Name, varNamePlainStruct)
-					out.Println("return nil")
-					out.Indent(-1)
-					out.Println("}")
-				},
+	if s, ok := value.(string); ok && t.Package == nil {
+		constName := g.makeEnumConstantName(t.Decl.Name, s)
+		for _, d := range g.output.file.Package.Decls {
+			if c, ok := d.(*codegen.Constant); ok && c.Name == constName && c.Value == s {
+				return constName, true
+			}
+		}
+	}
+
+	lit, ok := g.literal(t.Decl.Type, value)
+	if !ok {
+		return "", false
+	}
+	switch t.Decl.Type.(type) {
+	case *codegen.ArrayType, codegen.ArrayType, *codegen.MapType:
+		return name + strings.TrimPrefix(lit, typeString(t.Decl.Type)), true
+	}
+	return fmt.Sprintf("%s(%s)", name, lit), true
+}
+
+// newPropertyNamesValidator returns a validator for the "propertyNames"
+// keyword of an object, given an expression that evaluates to its map. Only
+// the string length and pattern constraints of property names are supported.
+func (g *schemaGenerator) newPropertyNamesValidator(
+	declName, value string, t *schemas.Type) *propertyNamesValidator {
+	names := t.PropertyNames
+	if names == nil {
+		return nil
+	}
+	rest := *names
+	rest.MinLength, rest.MaxLength, rest.Pattern, rest.Type = 0, nil, "", nil
+	if !isEmptySchema(&rest) {
+		g.warnf(names, WarningNotValidated, "Only minLength, maxLength and pattern are supported with "+
+			"\"propertyNames\" on %s; other constraints will not be validated", declName)
+	}
+
+	v := &propertyNamesValidator{
+		value:     value,
+		minLength: names.MinLength,
+		maxLength: names.MaxLength,
+	}
+	if names.Pattern != "" {
+		if _, err := regexp.Compile(names.Pattern); err != nil {
+			g.warnf(names, WarningNotValidated, "Pattern of property names of %s is not a valid Go regular expression; "+
+				"it will not be validated: %s", declName, err)
+		} else {
+			v.pattern = names.Pattern
+			v.patternVar = "propertyNamesPattern" + declName
+			g.output.file.Package.AddImport("regexp", "")
+			g.output.addVar(&codegen.Var{
+				Name:  v.patternVar,
+				Value: codegen.Expr(fmt.Sprintf("regexp.MustCompile(%q)", names.Pattern)),
+				Owner: declName,
 			})
 		}
 	}
+	if v.minLength != 0 || v.maxLength != nil {
+		g.output.file.Package.AddImport("unicode/utf8", "")
+	} else if v.patternVar == "" {
+		return nil
+	}
+	return v
+}
 
-	return &codegen.NamedType{Decl: &decl}, nil
+// newNotValidator returns a validator for the "not" keyword of an object,
+// which is supported when it prohibits a combination of properties, or any of
+// several combinations, by declaring them required.
+func (g *schemaGenerator) newNotValidator(not *schemas.Type) *notValidator {
+	if isRequiredOnly(not) {
+		return &notValidator{prohibited: [][]string{not.Required}}
+	}
+	rest := *not
+	rest.AnyOf = nil
+	if len(not.AnyOf) > 0 && isEmptySchema(&rest) {
+		v := &notValidator{}
+		for _, t := range not.AnyOf {
+			if !isRequiredOnly(t) {
+				v = nil
+				break
+			}
+			v.prohibited = append(v.prohibited, t.Required)
+		}
+		if v != nil {
+			return v
+		}
+	}
+	g.warnf(not, WarningNotValidated,
+		"Only prohibited properties are supported with \"not\" on objects; it will not be validated")
+	return nil
+}
+
+// newPropertyNotValidator returns a validator for the "not" keyword of a
+// property, which is supported when it prohibits the property altogether, or
+// prohibits some of its values with "enum".
+func (g *schemaGenerator) newPropertyNotValidator(declName string, f codegen.StructField) *notValidator {
+	not := f.SchemaType.Not
+	if isEmptySchema(not) {
+		return &notValidator{prohibited: [][]string{{f.JSONName}}}
+	}
+	rest := *not
+	rest.Enum, rest.Type = nil, nil
+	if len(not.Enum) > 0 && isEmptySchema(&rest) {
+		v := &notValidator{
+			jsonName:  f.JSONName,
+			valuesVar: "notValues" + declName + f.Name,
+		}
+		g.output.file.Package.AddImport("reflect", "")
+		g.output.addVar(&codegen.Var{
+			Name:  v.valuesVar,
+			Value: not.Enum,
+			Owner: declName,
+		})
+		return v
+	}
+	g.warnf(f.SchemaType, WarningNotValidated, "Only prohibited values are supported with \"not\" on field %q; "+
+		"it will not be validated", f.JSONName)
+	return nil
+}
+
+func (g *schemaGenerator) newNumericValidator(f codegen.StructField) *numericValidator {
+	st := f.SchemaType
+	if st == nil {
+		return nil
+	}
+
+	v := &numericValidator{
+		jsonName:         f.JSONName,
+		fieldName:        f.Name,
+		minimum:          st.Minimum,
+		maximum:          st.Maximum,
+		exclusiveMinimum: st.ExclusiveMinimum.Bound(st.Minimum),
+		exclusiveMaximum: st.ExclusiveMaximum.Bound(st.Maximum),
+		multipleOf:       st.MultipleOf,
+	}
+	if v.exclusiveMinimum == v.minimum {
+		// Draft 4 boolean form, which makes minimum exclusive
+		v.minimum = nil
+	}
+	if v.exclusiveMaximum == v.maximum {
+		v.maximum = nil
+	}
+	if v.minimum == nil && v.maximum == nil && v.exclusiveMinimum == nil &&
+		v.exclusiveMaximum == nil && v.multipleOf == nil {
+		return nil
+	}
+
+	t := f.Type
+	if p, ok := t.(*codegen.PointerType); ok {
+		v.isPointer = true
+		t = p.Type
+	}
+	p, ok := t.(codegen.PrimitiveType)
+	if !ok {
+		return nil
+	}
+	switch p.Type {
+	case "int", "int32", "int64", "uint32", "uint64", "float64":
+		v.goType = p.Type
+	case typeJSONNumber:
+		g.warnf(f.SchemaType, WarningNotValidated, "Numeric constraints of field %q are not validated for %s",
+			f.JSONName, typeJSONNumber)
+		return nil
+	default:
+		return nil
+	}
+
+	if v.usesMath() {
+		g.output.file.Package.AddImport("math", "")
+	}
+	return v
+}
+
+// generateMapValidation declares a Validate method for a map type, and an
+// UnmarshalJSON method that calls it.
+func (g *schemaGenerator) generateMapValidation(declName string, constraints ...validator) {
+	g.generateValidateMethod(declName, methodNameValidate, nil, constraints)
+	g.generateValidatingUnmarshal(declName)
+}
+
+// valueConstraints returns the validators for the constraints of a named
+// slice, string or number type, such as the root type of a schema that is
+// not an object, against the value that its Validate method converts the
+// receiver to.
+func (g *schemaGenerator) valueConstraints(declName string, t *schemas.Type, theType codegen.Type) []validator {
+	// The value is validated as a field without a name would be.
+	f := codegen.StructField{Type: theType, SchemaType: t}
+	switch goType := theType.(type) {
+	case codegen.PrimitiveType:
+		var constraints []validator
+		if v := g.newNumericValidator(f); v != nil {
+			constraints = append(constraints, v)
+		}
+		if v := g.newStringValidator(declName, f); v != nil {
+			constraints = append(constraints, v)
+		}
+		return constraints
+	case codegen.ArrayType:
+		var constraints []validator
+		st, arrayType, arrayDepth := t, &goType, 1
+		for st != nil && arrayType != nil {
+			if st.MinItems != 0 || st.MaxItems != 0 || st.UniqueItems {
+				constraints = append(constraints, g.newArrayValidator(f, st, arrayType, arrayDepth))
+			}
+			st, arrayDepth = st.Items.All(), arrayDepth+1
+			switch elemType := arrayType.Type.(type) {
+			case codegen.ArrayType:
+				arrayType = &elemType
+			case *codegen.ArrayType:
+				arrayType = elemType
+			default:
+				arrayType = nil
+			}
+		}
+		return constraints
+	default:
+		return nil
+	}
+}
+
+// generateValueValidation declares a Validate method for a named slice,
+// string or number type, which converts the receiver to its underlying type
+// for the constraints to check, and an UnmarshalJSON method that calls it.
+func (g *schemaGenerator) generateValueValidation(declName string, underlying codegen.Type, constraints ...validator) {
+	g.generateValidateMethod(declName, methodNameValidate, underlying, constraints)
+	g.generateValidatingUnmarshal(declName)
+}
+
+// generateValidatingUnmarshal declares an UnmarshalJSON method for a type
+// that is not a struct, which calls its Validate method.
+func (g *schemaGenerator) generateValidatingUnmarshal(declName string) {
+	g.checkedDecls[g.output.declsByName[declName]] = true
+	g.output.file.Package.AddImport("encoding/json", "")
+	g.output.file.Package.AddDecl(&codegen.Method{
+		Owner: declName,
+		Impl: func(out *codegen.Emitter) {
+			out.Comment("UnmarshalJSON implements json.Unmarshaler.")
+			out.Println("func (j *%s) UnmarshalJSON(b []byte) error {", declName)
+			out.Indent(1)
+			out.Println("type Plain %s", declName)
+			out.Println("var %s Plain", varNamePlainStruct)
+			out.Println("if err := json.Unmarshal(b, &%s); err != nil { return err }", varNamePlainStruct)
+			(&validateMethodValidator{declName: declName}).generate(out, returnError)
+			out.Println("*j = %s(%s)", declName, varNamePlainStruct)
+			out.Println("return nil")
+			out.Indent(-1)
+			out.Println("}")
+		},
+	})
+}
+
+func (g *schemaGenerator) newArrayValidator(
+	f codegen.StructField, st *schemas.Type, t *codegen.ArrayType, arrayDepth int) *arrayValidator {
+	v := &arrayValidator{
+		fieldName:   f.Name,
+		jsonName:    f.JSONName,
+		arrayDepth:  arrayDepth,
+		minItems:    st.MinItems,
+		maxItems:    st.MaxItems,
+		uniqueItems: st.UniqueItems,
+	}
+	if v.uniqueItems {
+		if isComparableType(t.Type) {
+			v.elemType = t.Type
+		} else {
+			g.output.file.Package.AddImport("reflect", "")
+		}
+	}
+	return v
+}
+
+func (g *schemaGenerator) newStringValidator(declName string, f codegen.StructField) *stringValidator {
+	st := f.SchemaType
+	if st == nil || (st.MinLength == 0 && st.MaxLength == nil && st.Pattern == "") {
+		return nil
+	}
+
+	v := &stringValidator{
+		jsonName:  f.JSONName,
+		fieldName: f.Name,
+		minLength: st.MinLength,
+		maxLength: st.MaxLength,
+	}
+	t := f.Type
+	if p, ok := t.(*codegen.PointerType); ok {
+		v.isPointer = true
+		t = p.Type
+	}
+	if p, ok := t.(codegen.PrimitiveType); !ok || p.Type != "string" {
+		return nil
+	}
+
+	if st.Pattern != "" {
+		if _, err := regexp.Compile(st.Pattern); err != nil {
+			g.warnf(f.SchemaType, WarningNotValidated, "Pattern of field %q is not a valid Go regular expression; "+
+				"it will not be validated: %s", f.JSONName, err)
+		} else {
+			v.pattern = st.Pattern
+			v.patternVar = "pattern" + declName + f.Name
+			g.output.file.Package.AddImport("regexp", "")
+			g.output.addVar(&codegen.Var{
+				Name:  v.patternVar,
+				Value: codegen.Expr(fmt.Sprintf("regexp.MustCompile(%q)", st.Pattern)),
+				Owner: declName,
+			})
+		}
+	}
+	if v.minLength != 0 || v.maxLength != nil {
+		g.output.file.Package.AddImport("unicode/utf8", "")
+	} else if v.patternVar == "" {
+		return nil
+	}
+	return v
+}
+
+func (g *schemaGenerator) newFormatValidator(f codegen.StructField) *formatValidator {
+	if f.SchemaType == nil {
+		return nil
+	}
+
+	var format string
+	switch f.SchemaType.Format {
+	case formatEmail, formatIDNEmail:
+		format = formatEmail
+		g.output.file.Package.AddImport("net/mail", "")
+	case formatHostname:
+		format = formatHostname
+		g.output.file.Package.AddImport("regexp", "")
+		if _, ok := g.output.varsByName[varNameHostnamePattern]; !ok {
+			v := &codegen.Var{
+				Name:  varNameHostnamePattern,
+				Value: codegen.Expr(fmt.Sprintf("regexp.MustCompile(%q)", hostnamePattern)),
+			}
+			g.output.varsByName[v.Name] = v
+			g.output.addHelper(v.Name, v)
+		}
+	case formatRegex:
+		format = formatRegex
+		g.output.file.Package.AddImport("regexp", "")
+	default:
+		return nil
+	}
+
+	v := &formatValidator{
+		jsonName:  f.JSONName,
+		fieldName: f.Name,
+		format:    format,
+	}
+	t := f.Type
+	if p, ok := t.(*codegen.PointerType); ok {
+		v.isPointer = true
+		t = p.Type
+	}
+	if p, ok := t.(codegen.PrimitiveType); !ok || p.Type != "string" {
+		g.warnf(f.SchemaType, WarningNotValidated,
+			"Format %q on field %q is only validated for string types", format, f.JSONName)
+		return nil
+	}
+	return v
 }
 
 func (g *schemaGenerator) generateType(
-	t *schemas.Type, scope nameScope) (codegen.Type, error) {
+	t *schemas.Type, scope nameScope) (goType codegen.Type, err error) {
+	defer g.fallBackOnError(t, &goType, &err)
+
 	var typeIndex = 0
 	var typeShouldBePointer bool
 
+	t, err = g.flattenAllOf(t)
+	if err != nil {
+		return nil, err
+	}
+
+	goType, err = g.customType(t)
+	if err != nil {
+		return nil, err
+	}
+	if goType != nil {
+		return goType, nil
+	}
 	if ext := t.GoJSONSchemaExtension; ext != nil {
 		for _, pkg := range ext.Imports {
 			g.output.file.Package.AddImport(pkg, "")
@@ -552,29 +2742,54 @@ func (g *schemaGenerator) generateType(
 	if t.Ref != "" {
 		return g.generateReferencedType(t.Ref)
 	}
-	if len(t.Type) == 0 {
-		return codegen.EmptyInterfaceType{}, nil
+	typeNames := t.Type
+	if len(typeNames) == 0 {
+		implied := impliedTypeName(t)
+		if implied == "" {
+			return codegen.EmptyInterfaceType{}, nil
+		}
+		typeNames = schemas.TypeList{implied}
 	}
-	if len(t.Type) == 2 {
-		for i, t := range t.Type {
+	if len(typeNames) == 2 {
+		for i, t := range typeNames {
 			if t == "null" {
 				typeShouldBePointer = true
 				continue
 			}
 			typeIndex = i
 		}
-	} else if len(t.Type) != 1 {
+	} else if len(typeNames) != 1 {
 		// TODO: Support validation for properties with multiple types
-		g.warner("Property has multiple types; will be represented as interface{} with no validation")
+		g.warnf(t, WarningUnsupported, "Property has multiple types; will be represented as interface{} with no validation")
 		return codegen.EmptyInterfaceType{}, nil
 	}
 
-	switch t.Type[typeIndex] {
+	if mapped := g.formatMappingType(t); mapped != nil {
+		if typeShouldBePointer {
+			return codegen.WrapTypeInPointer(mapped), nil
+		}
+		return mapped, nil
+	}
+
+	switch typeNames[typeIndex] {
 	case schemas.TypeNameArray:
 		if t.Items == nil {
-			return nil, errors.New("array property must have 'items' set to a type")
+			// Items may be anything, as with "items": true.
+			return codegen.ArrayType{Type: codegen.EmptyInterfaceType{}}, nil
+		}
+		if t.Items.IsTuple() {
+			g.warnf(t, WarningUnsupported, "Arrays with an array of item schemas are not supported; generating []interface{}")
+			return codegen.ArrayType{Type: codegen.EmptyInterfaceType{}}, nil
 		}
-		elemType, err := g.generateType(t.Items, scope.add("Elem"))
+		items := t.Items.All()
+		generateElem := g.generateType
+		if impliedTypeName(items) == schemas.TypeNameObject ||
+			(len(items.Type) == 1 && items.Type[0] == schemas.TypeNameObject) {
+			// Objects are declared, as they are in fields, so that they are
+			// validated rather than generated as anonymous structs.
+			generateElem = g.generateTypeInline
+		}
+		elemType, err := generateElem(items, scope.add("Elem"))
 		if err != nil {
 			return nil, err
 		}
@@ -584,7 +2799,24 @@ func (g *schemaGenerator) generateType(
 	case schemas.TypeNameNull:
 		return codegen.EmptyInterfaceType{}, nil
 	default:
-		return codegen.PrimitiveTypeFromJSONSchemaType(t.Type[typeIndex], typeShouldBePointer)
+		return g.primitiveType(t, typeNames[typeIndex], typeShouldBePointer)
+	}
+}
+
+// impliedTypeName returns the type that a subschema without one is
+// generated as: "object" if it declares properties, or "array" if it
+// declares items, since such schemas are almost always meant for objects or
+// arrays, or else "".
+func impliedTypeName(t *schemas.Type) string {
+	switch {
+	case len(t.Type) > 0:
+		return ""
+	case len(t.Properties) > 0:
+		return schemas.TypeNameObject
+	case t.Items != nil:
+		return schemas.TypeNameArray
+	default:
+		return ""
 	}
 }
 
@@ -593,31 +2825,19 @@ func (g *schemaGenerator) generateStructType(
 	scope nameScope) (codegen.Type, error) {
 	if len(t.Properties) == 0 {
 		if len(t.Required) > 0 {
-			g.warner("Object type with no properties has required fields; " +
+			g.warnf(t, WarningNotValidated, "Object type with no properties has required fields; "+
 				"skipping validation code for them since we don't know their types")
 		}
 		valueType := codegen.Type(codegen.EmptyInterfaceType{})
 
-		if t.AdditionalProperties != nil {
-			switch (*t.AdditionalProperties).(type) {
-			case map[string]interface{}:
-				jsonData, err := json.Marshal(t.AdditionalProperties)
-				if err != nil {
-					return nil, err
-				}
-				casted := &schemas.Type{}
-				err = json.Unmarshal(jsonData, &casted)
-				if err != nil {
-					return nil, err
-				}
-				valueType, err = g.generateType(casted, nil)
-				if err != nil {
-					return nil, err
-				}
-			case bool:
-				break
-			default:
-				return nil, errors.Errorf("unknown type of field additionalProperties: %T", t.AdditionalProperties)
+		additional, err := additionalPropertiesSchema(t)
+		if err != nil {
+			return nil, err
+		}
+		if additional != nil {
+			valueType, err = g.generateType(additional, nil)
+			if err != nil {
+				return nil, err
 			}
 		}
 		return &codegen.MapType{
@@ -632,27 +2852,59 @@ func (g *schemaGenerator) generateStructType(
 	}
 
 	uniqueNames := make(map[string]int, len(t.Properties))
+	// Fields are not named after the methods that structs may get.
+	methods := g.structMethodNames()
+	for name := range methods {
+		uniqueNames[name] = 1
+	}
+
+	props := make(map[string]*schemas.Type, len(t.Properties))
+	for name, prop := range t.Properties {
+		flattened, err := g.flattenAllOf(prop)
+		if err != nil {
+			return nil, err
+		}
+		props[name] = flattened
+	}
+	// With getters, fields are not named after the getters of other fields
+	// either.
+	if g.config.Getters {
+		for name, prop := range props {
+			fieldName, err := g.propertyFieldName(name, prop)
+			if err != nil {
+				return nil, err
+			}
+			methods[getterName(fieldName)] = true
+			uniqueNames[getterName(fieldName)] = 1
+		}
+	}
 
 	var structType codegen.StructType
 	for _, name := range sortPropertiesByName(t.Properties) {
-		prop := t.Properties[name]
+		prop := props[name]
 		isRequired := requiredNames[name]
 
-		fieldName := g.identifierize(name)
 		if ext := prop.GoJSONSchemaExtension; ext != nil {
 			for _, pkg := range ext.Imports {
 				g.output.file.Package.AddImport(pkg, "")
 			}
-			if ext.Identifier != nil {
-				fieldName = *ext.Identifier
-			}
+		}
+		fieldName, err := g.propertyFieldName(name, prop)
+		if err != nil {
+			return nil, err
 		}
 
 		if count, ok := uniqueNames[fieldName]; ok {
 			uniqueNames[fieldName] = count + 1
-			fieldName = fmt.Sprintf("%s_%d", fieldName, count+1)
-			g.warner(fmt.Sprintf("Field %q maps to a field by the same name declared "+
-				"in the same struct; it will be declared as %s", name, fieldName))
+			renamed := fmt.Sprintf("%s_%d", fieldName, count+1)
+			if methods[fieldName] && count == 1 {
+				g.warnf(prop, WarningRenamed, "Field %q maps to the name of the %s method of the struct; "+
+					"it will be declared as %s", name, fieldName, renamed)
+			} else {
+				g.warnf(prop, WarningRenamed, "Field %q maps to a field by the same name declared "+
+					"in the same struct; it will be declared as %s", name, renamed)
+			}
+			fieldName = renamed
 		} else {
 			uniqueNames[fieldName] = 1
 		}
@@ -664,42 +2916,156 @@ func (g *schemaGenerator) generateStructType(
 			SchemaType: prop,
 		}
 
-		if isRequired {
-			structField.Tags = fmt.Sprintf(`json:"%s" yaml:"%s"`, name, name)
-		} else {
-			structField.Tags = fmt.Sprintf(`json:"%s,omitempty" yaml:"%s,omitempty"`, name, name)
+		structField.Tags = g.structFieldTags(name, g.omitEmpty(prop, isRequired))
+
+		if structField.Comment == "" {
+			structField.Comment = fmt.Sprintf("%s corresponds to the JSON schema field %q.",
+				structField.Name, name)
+		}
+		if summary := constraintSummary(prop); summary != "" {
+			structField.Comment = strings.TrimRight(structField.Comment, "\n") + "\n\n" + summary
+		}
+
+		// Nullable properties are pointers if they are optional, as long as
+		// they have no default, or if they are required and
+		// NullableRequiredPointers is set.
+		if isNullableType(prop) &&
+			((isRequired && g.config.NullableRequiredPointers) || (!isRequired && prop.Default == nil)) {
+			structField.Type, err = g.generateNullableTypeInline(prop, scope.add(structField.Name))
+		} else {
+			structField.Type, err = g.generateTypeInline(prop, scope.add(structField.Name))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not generate type for field %q: %w", name, err)
+		}
+
+		if prop.Default != nil {
+			structField.DefaultValue = prop.Default
+			// json.Number values keep the exact text of the default.
+			if g.config.JSONNumber {
+				structField.DefaultValue = prop.DefaultNumbers()
+			}
+		} else if isRequired {
+			structType.RequiredJSONFields = append(structType.RequiredJSONFields, structField.JSONName)
+		} else if !g.config.OptionalValueTypes {
+			// Optional, so must be pointer
+			if !structField.Type.IsNillable() {
+				structField.Type = codegen.WrapTypeInPointer(structField.Type)
+			}
+		}
+
+		structType.AddField(structField)
+	}
+
+	if g.config.CaptureExtras {
+		conflict := false
+		for _, f := range structType.Fields {
+			conflict = conflict || f.Name == fieldNameExtras
+		}
+		if conflict {
+			g.warnf(t, WarningSkipped, "Struct has a field named %s; not capturing extra properties",
+				fieldNameExtras)
+		} else {
+			structType.AddField(codegen.StructField{
+				Name: fieldNameExtras,
+				Type: &codegen.MapType{
+					KeyType:   codegen.PrimitiveType{Type: "string"},
+					ValueType: codegen.EmptyInterfaceType{},
+				},
+				Comment: fmt.Sprintf("%s holds the properties that the schema does not declare.",
+					fieldNameExtras),
+				Tags: g.structFieldTags("-", false),
+			})
+		}
+	}
+	return &structType, nil
+}
+
+// generateNullableTypeInline generates a pointer to the non-null type of a
+// type that is either null or one other type.
+func (g *schemaGenerator) generateNullableTypeInline(
+	t *schemas.Type,
+	scope nameScope) (codegen.Type, error) {
+	nonNull := *t
+	nonNull.Type = nil
+	for _, name := range t.Type {
+		if name != schemas.TypeNameNull {
+			nonNull.Type = append(nonNull.Type, name)
+		}
+	}
+
+	theType, err := g.generateTypeInline(&nonNull, scope)
+	if err != nil {
+		return nil, err
+	}
+	if theType.IsNillable() {
+		return theType, nil
+	}
+	return codegen.WrapTypeInPointer(theType), nil
+}
+
+func (g *schemaGenerator) isNullableRequiredField(structType *codegen.StructType, jsonName string) bool {
+	if !g.config.NullableRequiredPointers {
+		return false
+	}
+	for _, f := range structType.Fields {
+		if f.JSONName == jsonName {
+			return f.SchemaType != nil && isNullableType(f.SchemaType)
 		}
+	}
+	return false
+}
 
-		if structField.Comment == "" {
-			structField.Comment = fmt.Sprintf("%s corresponds to the JSON schema field %q.",
-				structField.Name, name)
-		}
+func (g *schemaGenerator) omitEmpty(prop *schemas.Type, isRequired bool) bool {
+	if prop.OmitEmpty != nil {
+		return *prop.OmitEmpty
+	}
+	switch g.config.OmitEmpty {
+	case OmitEmptyAlways:
+		return true
+	case OmitEmptyNever:
+		return false
+	default:
+		return !isRequired
+	}
+}
 
-		var err error
-		structField.Type, err = g.generateTypeInline(prop, scope.add(structField.Name))
-		if err != nil {
-			return nil, fmt.Errorf("could not generate type for field %q: %s", name, err)
-		}
+func (g *schemaGenerator) structFieldTags(name string, omitEmpty bool) string {
+	value := name
+	if omitEmpty {
+		value += ",omitempty"
+	}
 
-		if prop.Default != nil {
-			structField.DefaultValue = prop.Default
-		} else if isRequired {
-			structType.RequiredJSONFields = append(structType.RequiredJSONFields, structField.JSONName)
-		} else {
-			// Optional, so must be pointer
-			if !structField.Type.IsNillable() {
-				structField.Type = codegen.WrapTypeInPointer(structField.Type)
-			}
+	keys := []string{"json", "yaml"}
+	for _, k := range g.config.ExtraTags {
+		if k != "" && !contains(keys, k) {
+			keys = append(keys, k)
 		}
+	}
 
-		structType.AddField(structField)
+	tags := make([]string, len(keys))
+	for i, k := range keys {
+		tags[i] = k + ":" + strconv.Quote(value)
 	}
-	return &structType, nil
+	return strings.Join(tags, " ")
 }
 
 func (g *schemaGenerator) generateTypeInline(
 	t *schemas.Type,
-	scope nameScope) (codegen.Type, error) {
+	scope nameScope) (goType codegen.Type, err error) {
+	defer g.fallBackOnError(t, &goType, &err)
+
+	t, err = g.flattenAllOf(t)
+	if err != nil {
+		return nil, err
+	}
+	goType, err = g.customType(t)
+	if err != nil {
+		return nil, err
+	}
+	if goType != nil {
+		return goType, nil
+	}
 	if t.Enum == nil && t.Ref == "" {
 		if ext := t.GoJSONSchemaExtension; ext != nil {
 			for _, pkg := range ext.Imports {
@@ -711,34 +3077,213 @@ func (g *schemaGenerator) generateTypeInline(
 		}
 
 		if len(t.Type) > 1 {
-			g.warner("Property has multiple types; will be represented as interface{} with no validation")
+			g.warnf(t, WarningUnsupported,
+				"Property has multiple types; will be represented as interface{} with no validation")
 			return codegen.EmptyInterfaceType{}, nil
 		}
-		if len(t.Type) == 0 {
+		typeName := impliedTypeName(t)
+		if len(t.Type) == 1 {
+			typeName = t.Type[0]
+		} else if typeName == "" {
 			return codegen.EmptyInterfaceType{}, nil
 		}
 
-		if schemas.IsPrimitiveType(t.Type[0]) {
-			return codegen.PrimitiveTypeFromJSONSchemaType(t.Type[0], false)
+		if mapped := g.formatMappingType(t); mapped != nil {
+			return mapped, nil
+		}
+
+		if schemas.IsPrimitiveType(typeName) {
+			return g.primitiveType(t, typeName, false)
 		}
 
-		if t.Type[0] == schemas.TypeNameArray {
+		if typeName == schemas.TypeNameArray {
 			var theType codegen.Type
 			if t.Items == nil {
 				theType = codegen.EmptyInterfaceType{}
+			} else if t.Items.IsTuple() {
+				g.warnf(t, WarningUnsupported,
+					"Arrays with an array of item schemas are not supported; generating []interface{}")
+				theType = codegen.EmptyInterfaceType{}
 			} else {
 				var err error
-				theType, err = g.generateTypeInline(t.Items, scope.add("Elem"))
+				theType, err = g.generateTypeInline(t.Items.All(), scope.add("Elem"))
 				if err != nil {
 					return nil, err
 				}
 			}
 			return &codegen.ArrayType{Type: theType}, nil
 		}
+
+		if typeName == schemas.TypeNameObject && g.config.SharedInlineTypes {
+			return g.generateSharedInlineType(t, scope)
+		}
 	}
 	return g.generateDeclaredType(t, scope)
 }
 
+// generateSharedInlineType declares a type for an object schema declared
+// inline, unless one has been declared for an identical schema in the same
+// file already, which is then used instead, so that the same shape repeated
+// across a schema is one type.
+func (g *schemaGenerator) generateSharedInlineType(t *schemas.Type, scope nameScope) (codegen.Type, error) {
+	shape, err := hashSchema(g.schemaFileName, t)
+	if err != nil {
+		return nil, err
+	}
+	if decl, ok := g.output.declsByShape[shape]; ok {
+		return &codegen.NamedType{Decl: decl}, nil
+	}
+	goType, err := g.generateDeclaredType(t, scope)
+	if err != nil {
+		return nil, err
+	}
+	if nt, ok := goType.(*codegen.NamedType); ok && nt.Package == nil {
+		g.output.declsByShape[shape] = nt.Decl
+	}
+	return goType, nil
+}
+
+// fallBackOnError replaces an error in generating the type of a subschema
+// with interface{}, and a warning, with Config.Lenient. It is deferred with
+// pointers to the results of the function that generates the type, so that
+// the subschemas within the subschema that can be generated still are.
+func (g *schemaGenerator) fallBackOnError(t *schemas.Type, goType *codegen.Type, err *error) {
+	if *err == nil || !g.config.Lenient || g.context().Err() != nil {
+		return
+	}
+	g.warnf(t, WarningUnsupported, "Cannot generate a type (%s); will be represented as interface{} with no validation", *err)
+	*goType, *err = codegen.EmptyInterfaceType{}, nil
+}
+
+func (g *schemaGenerator) formatMappingType(t *schemas.Type) codegen.Type {
+	if t.Format == "" {
+		return nil
+	}
+	for _, m := range g.config.FormatMappings {
+		if m.Format == t.Format {
+			return g.qualifiedGoType(m.GoType)
+		}
+	}
+	return nil
+}
+
+// primitiveType returns the Go type for a primitive JSON Schema type, choosing
+// the integer type according to the configuration.
+func (g *schemaGenerator) primitiveType(
+	t *schemas.Type, typeName string, pointer bool) (codegen.Type, error) {
+	isNumeric := typeName == schemas.TypeNameInteger || typeName == schemas.TypeNameNumber
+	if isNumeric && g.config.JSONNumber && t.Enum == nil {
+		g.output.file.Package.AddImport("encoding/json", "")
+		var result codegen.Type = codegen.PrimitiveType{Type: typeJSONNumber}
+		if pointer {
+			result = codegen.WrapTypeInPointer(result)
+		}
+		return result, nil
+	}
+	if typeName != schemas.TypeNameInteger {
+		return codegen.PrimitiveTypeFromJSONSchemaType(typeName, pointer)
+	}
+
+	var result codegen.Type = codegen.PrimitiveType{Type: g.integerType(t)}
+	if pointer {
+		result = codegen.WrapTypeInPointer(result)
+	}
+	return result, nil
+}
+
+func (g *schemaGenerator) integerType(t *schemas.Type) string {
+	defaultType := g.config.DefaultIntegerType
+	if defaultType == "" {
+		defaultType = "int"
+	}
+	if !g.config.IntegerTypeFromBounds || (t.Minimum == nil && t.Maximum == nil) {
+		return defaultType
+	}
+
+	// Bounds beyond those of int64 and uint64 fit neither.
+	unsigned := t.Minimum != nil && *t.Minimum >= 0
+	if (t.Minimum != nil && *t.Minimum < -1<<63) ||
+		(t.Maximum != nil && *t.Maximum >= 1<<64) ||
+		(!unsigned && t.Maximum != nil && *t.Maximum >= 1<<63) {
+		g.warnf(t, WarningUnsupported, "Integer bounds are outside the range of int64 and uint64; "+
+			"declaring it as %s", defaultType)
+		return defaultType
+	}
+
+	if unsigned {
+		if t.Maximum != nil && *t.Maximum <= math.MaxUint32 {
+			return "uint32"
+		}
+		return "uint64"
+	}
+	if t.Minimum != nil && *t.Minimum >= math.MinInt32 &&
+		t.Maximum != nil && *t.Maximum <= math.MaxInt32 {
+		return "int32"
+	}
+	return "int64"
+}
+
+// customType returns the type that the first of the TypeHooks to return one
+// overrides a schema with, or else the type set with the "x-go-type"
+// extension, if any.
+func (g *schemaGenerator) customType(t *schemas.Type) (codegen.Type, error) {
+	for _, hook := range g.config.TypeHooks {
+		goType, imports, err := hook(t)
+		if err != nil {
+			return nil, err
+		}
+		if goType != nil {
+			for _, imp := range imports {
+				g.output.file.Package.AddImport(imp.QualifiedName, imp.Name)
+			}
+			return goType, nil
+		}
+	}
+	return g.extensionGoType(t), nil
+}
+
+// extensionGoType returns the type set with the "x-go-type" extension, if any.
+func (g *schemaGenerator) extensionGoType(t *schemas.Type) codegen.Type {
+	if t.GoType == "" {
+		return nil
+	}
+	if imp := t.GoTypeImport; imp != nil && imp.Path != "" {
+		g.output.file.Package.AddImport(imp.Path, imp.Name)
+		return &codegen.CustomNameType{Type: t.GoType}
+	}
+	return g.qualifiedGoType(t.GoType)
+}
+
+// qualifiedGoType turns a qualified name such as "example.com/pkg.Type" into
+// a type reference, importing its package.
+func (g *schemaGenerator) qualifiedGoType(name string) codegen.Type {
+	i := strings.LastIndex(name, ".")
+	if i == -1 || i < strings.LastIndex(name, "/") {
+		return &codegen.CustomNameType{Type: name}
+	}
+	pkg := name[0:i]
+	g.output.file.Package.AddImport(pkg, "")
+	return &codegen.CustomNameType{Type: assumedPackageName(pkg) + name[i:]}
+}
+
+// assumedPackageName returns the name that the package with an import path is
+// assumed to have, as goimports assumes it: the last element of the path other
+// than a major version, without a go- prefix, up to the first character that
+// identifiers cannot have, as in gopkg.in/yaml.v3.
+func assumedPackageName(importPath string) string {
+	name := path.Base(importPath)
+	if majorVersionElement.MatchString(name) && path.Dir(importPath) != "." {
+		name = path.Base(path.Dir(importPath))
+	}
+	name = strings.TrimPrefix(name, "go-")
+	if i := strings.IndexFunc(name, func(r rune) bool {
+		return r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
 func (g *schemaGenerator) generateEnumType(
 	t *schemas.Type, scope nameScope) (codegen.Type, error) {
 	if len(t.Enum) == 0 {
@@ -749,14 +3294,14 @@ func (g *schemaGenerator) generateEnumType(
 	var enumType codegen.Type
 	if len(t.Type) == 1 {
 		var err error
-		if enumType, err = codegen.PrimitiveTypeFromJSONSchemaType(t.Type[0], false); err != nil {
+		if enumType, err = g.primitiveType(t, t.Type[0], false); err != nil {
 			return nil, err
 		}
 		wrapInStruct = t.Type[0] == schemas.TypeNameNull // Null uses interface{}, which cannot have methods
 	} else {
 		if len(t.Type) > 1 {
 			// TODO: Support multiple types
-			g.warner("Enum defined with multiple types; ignoring it and using enum values instead")
+			g.warnf(t, WarningUnsupported, "Enum defined with multiple types; ignoring it and using enum values instead")
 		}
 
 		var primitiveType string
@@ -788,8 +3333,8 @@ func (g *schemaGenerator) generateEnumType(
 		}
 		enumType = codegen.PrimitiveType{Type: primitiveType}
 	}
-	if wrapInStruct {
-		g.warner("Enum field wrapped in struct in order to store values of multiple types")
+	if wrapInStruct && !g.config.OnlyModels {
+		g.warnf(t, WarningEnumWrapped, "Enum field wrapped in struct in order to store values of multiple types")
 		enumType = &codegen.StructType{
 			Fields: []codegen.StructField{
 				{
@@ -800,27 +3345,103 @@ func (g *schemaGenerator) generateEnumType(
 		}
 	}
 
+	// Numbers that the enum's type cannot hold are left out, so that they are
+	// rejected, rather than declared in code that does not compile
+	values := t.Enum
+	if prim, ok := enumType.(codegen.PrimitiveType); ok {
+		values = nil
+		for _, v := range t.Enum {
+			if f, ok := v.(float64); ok && !representable(prim.Type, f) {
+				g.warnf(t, WarningUnsupported, "Enum value %v cannot be represented by %s; it is rejected",
+					v, prim.Type)
+				continue
+			}
+			values = append(values, v)
+		}
+	}
+
 	enumDecl := codegen.TypeDecl{
-		Name: g.output.uniqueTypeName(scope.string()),
-		Type: enumType,
+		Name:    g.uniqueTypeName(t, scope.string()),
+		Comment: g.withSourceComment("", t),
+		Type:    enumType,
 	}
 	g.output.file.Package.AddDecl(&enumDecl)
+	if g.config.RoundTripTests || g.config.FuzzTests {
+		g.addTestCases(enumDecl.Name, t, enumType)
+	}
+	if g.config.Docs {
+		g.addDocEntry(enumDecl.Name, t, enumType)
+	}
+	if g.config.RandomValues {
+		g.generateRandomEnum(&enumDecl, enumType, values, wrapInStruct)
+	}
 
 	g.output.declsByName[enumDecl.Name] = &enumDecl
 
+	// String enums get their helpers even with OnlyModels, since they are not
+	// for validating values when unmarshaling them.
+	if !g.config.OnlyModels {
+		g.checkedDecls[&enumDecl] = true
+		g.generateEnumMethods(&enumDecl, enumType, values, wrapInStruct)
+	}
+	if prim, ok := enumType.(codegen.PrimitiveType); ok && prim.Type == "string" {
+		g.generateStringEnumMethods(&enumDecl, values)
+	}
+	if !g.config.OnlyModels {
+		if prim, ok := enumType.(codegen.PrimitiveType); ok && g.config.SQL {
+			g.generateEnumSQLMethods(&enumDecl, prim, values)
+		}
+		if prim, ok := enumType.(codegen.PrimitiveType); ok && g.config.ValidateOnMarshal {
+			g.generateEnumMarshal(&enumDecl, prim, values)
+		}
+	}
+
+	// TODO: May be aliased string type
+	if prim, ok := enumType.(codegen.PrimitiveType); ok && prim.Type == "string" {
+		for _, v := range t.Enum {
+			if s, ok := v.(string); ok {
+				// TODO: Make sure the name is unique across scope
+				g.output.file.Package.AddDecl(&codegen.Constant{
+					Name:  g.makeEnumConstantName(enumDecl.Name, s),
+					Type:  &codegen.NamedType{Decl: &enumDecl},
+					Value: s,
+					Owner: enumDecl.Name,
+				})
+			}
+		}
+	}
+
+	return &codegen.NamedType{Decl: &enumDecl}, nil
+}
+
+// generateEnumMethods declares the enum's values and the methods that
+// validate them when unmarshaling.
+func (g *schemaGenerator) generateEnumMethods(
+	enumDecl *codegen.TypeDecl, enumType codegen.Type, values []interface{}, wrapInStruct bool) {
 	valueConstant := &codegen.Var{
 		Name:  "enumValues_" + enumDecl.Name,
-		Value: t.Enum,
+		Value: values,
+		Owner: enumDecl.Name,
 	}
 	g.output.file.Package.AddDecl(valueConstant)
 
+	var prim *codegen.PrimitiveType
+	if p, ok := enumType.(codegen.PrimitiveType); ok && !wrapInStruct {
+		prim = &p
+	}
+	check := g.newEnumCheck(enumDecl, prim, values)
+
 	if wrapInStruct {
 		g.output.file.Package.AddImport("encoding/json", "")
 		g.output.file.Package.AddDecl(&codegen.Method{
+			Owner: enumDecl.Name,
 			Impl: func(out *codegen.Emitter) {
 				out.Comment("MarshalJSON implements json.Marshaler.")
 				out.Println("func (j *%s) MarshalJSON() ([]byte, error) {", enumDecl.Name)
 				out.Indent(1)
+				if g.config.ValidateOnMarshal {
+					emitEnumCheck(out, check, "j.Value", returnMarshalError)
+				}
 				out.Println("return json.Marshal(j.Value)")
 				out.Indent(-1)
 				out.Println("}")
@@ -828,10 +3449,10 @@ func (g *schemaGenerator) generateEnumType(
 		})
 	}
 
-	g.output.file.Package.AddImport("fmt", "")
-	g.output.file.Package.AddImport("reflect", "")
+	g.declareValidationError()
 	g.output.file.Package.AddImport("encoding/json", "")
 	g.output.file.Package.AddDecl(&codegen.Method{
+		Owner: enumDecl.Name,
 		Impl: func(out *codegen.Emitter) {
 			out.Comment("UnmarshalJSON implements json.Unmarshaler.")
 			out.Println("func (j *%s) UnmarshalJSON(b []byte) error {", enumDecl.Name)
@@ -844,43 +3465,310 @@ func (g *schemaGenerator) generateEnumType(
 				varName += ".Value"
 			}
 			out.Println("if err := json.Unmarshal(b, &%s); err != nil { return err }", varName)
-			out.Println("var ok bool")
-			out.Println("for _, expected := range %s {", valueConstant.Name)
-			out.Println("if reflect.DeepEqual(%s, expected) { ok = true; break }", varName)
-			out.Println("}")
-			out.Println("if !ok {")
-			out.Println(`return fmt.Errorf("invalid value (expected one of %%#v): %%#v", %s, %s)`,
-				valueConstant.Name, varName)
-			out.Println("}")
+			emitEnumCheck(out, check, varName, returnError)
 			out.Println(`*j = %s(v)`, enumDecl.Name)
 			out.Println(`return nil`)
 			out.Indent(-1)
 			out.Println("}")
 		},
 	})
+}
 
-	// TODO: May be aliased string type
-	if prim, ok := enumType.(codegen.PrimitiveType); ok && prim.Type == "string" {
-		for _, v := range t.Enum {
-			if s, ok := v.(string); ok {
-				// TODO: Make sure the name is unique across scope
-				g.output.file.Package.AddDecl(&codegen.Constant{
-					Name:  g.makeEnumConstantName(enumDecl.Name, s),
-					Type:  &codegen.NamedType{Decl: &enumDecl},
-					Value: s,
-				})
-			}
+// enumLookupThreshold is the number of values above which enums are checked
+// by looking values up in a map, rather than with a switch.
+const enumLookupThreshold = 16
+
+// enumCheck describes how to check that a value is one of the values of an
+// enum.
+type enumCheck struct {
+	// valuesVar is the variable holding the enum's values.
+	valuesVar string
+	// cases are the Go literals of the values of a primitive enum, or nil if
+	// the enum is not primitive.
+	cases []string
+	// lookupVar is the variable holding a set of the values of a primitive
+	// enum, if it has more than enumLookupThreshold values.
+	lookupVar string
+}
+
+// newEnumCheck returns the check for values of an enum. If prim is nil, the
+// enum is not primitive, and its values are compared with reflect.DeepEqual.
+func (g *schemaGenerator) newEnumCheck(
+	enumDecl *codegen.TypeDecl, prim *codegen.PrimitiveType, values []interface{}) enumCheck {
+	check := enumCheck{valuesVar: "enumValues_" + enumDecl.Name}
+	if prim == nil {
+		g.output.file.Package.AddImport("reflect", "")
+		return check
+	}
+
+	check.cases = enumCases(*prim, values)
+	if len(check.cases) > enumLookupThreshold {
+		check.lookupVar = "enumLookup_" + enumDecl.Name
+		elems := make([]string, len(check.cases))
+		for i, c := range check.cases {
+			elems[i] = c + ": {},\n"
 		}
+		g.output.addVar(&codegen.Var{
+			Name: check.lookupVar,
+			Value: codegen.Expr(fmt.Sprintf("map[%s]struct{}{\n%s}",
+				prim.Type, strings.Join(elems, ""))),
+			Owner: enumDecl.Name,
+		})
 	}
+	return check
+}
 
-	return &codegen.NamedType{Decl: &enumDecl}, nil
+// emitEnumCheck emits code that fails unless value is one of the values of an
+// enum. The value must be of the enum's primitive type, if it has one.
+func emitEnumCheck(out *codegen.Emitter, check enumCheck, value string, fail failFunc) {
+	err := validationError(jsonPointer("", nil), "enum",
+		"invalid value (expected one of %#v): %#v", check.valuesVar, value)
+	if check.lookupVar != "" {
+		out.Println("if _, ok := %s[%s]; !ok {", check.lookupVar, value)
+		out.Indent(1)
+		fail(out, err)
+		out.Indent(-1)
+		out.Println("}")
+		return
+	}
+	if check.cases == nil {
+		valuesVar := check.valuesVar
+		out.Println("var ok bool")
+		out.Println("for _, expected := range %s {", valuesVar)
+		out.Println("if reflect.DeepEqual(%s, expected) { ok = true; break }", value)
+		out.Println("}")
+		out.Println("if !ok {")
+		out.Indent(1)
+		fail(out, err)
+		out.Indent(-1)
+		out.Println("}")
+		return
+	}
+
+	out.Println("switch %s {", value)
+	if len(check.cases) > 0 {
+		out.Println("case %s:", strings.Join(check.cases, ", "))
+	}
+	out.Println("default:")
+	out.Indent(1)
+	fail(out, err)
+	out.Indent(-1)
+	out.Println("}")
+}
+
+// generateStringEnumMethods declares String and IsValid methods for a string
+// enum, and a function for parsing it from a string. The enum also implements
+// encoding.TextMarshaler and encoding.TextUnmarshaler, so that it can be used
+// as a map key and with decoders that go through text. Other enums don't, as
+// json.Marshal would then encode their values as strings. With OnlyModels,
+// the enum does not implement encoding.TextUnmarshaler, which encoding/json
+// would check its values with, and parsing fails with a plain error.
+func (g *schemaGenerator) generateStringEnumMethods(enumDecl *codegen.TypeDecl, values []interface{}) {
+	check := g.newEnumCheck(enumDecl, &codegen.PrimitiveType{Type: "string"}, values)
+	if g.config.OnlyModels {
+		g.output.addVar(&codegen.Var{
+			Name:  check.valuesVar,
+			Value: values,
+			Owner: enumDecl.Name,
+		})
+		g.output.file.Package.AddImport("fmt", "")
+	}
+
+	g.output.file.Package.AddDecl(&codegen.Method{
+		Owner: enumDecl.Name,
+		Impl: func(out *codegen.Emitter) {
+			out.Comment("String implements fmt.Stringer.")
+			out.Println("func (j %s) String() string {", enumDecl.Name)
+			out.Indent(1)
+			out.Println("return string(j)")
+			out.Indent(-1)
+			out.Println("}")
+			out.Newline()
+
+			out.Comment("IsValid reports whether the value is one of the values allowed by the schema.")
+			out.Println("func (j %s) IsValid() bool {", enumDecl.Name)
+			out.Indent(1)
+			if check.lookupVar != "" {
+				out.Println("_, ok := %s[string(j)]", check.lookupVar)
+				out.Println("return ok")
+			} else {
+				out.Println("switch j {")
+				out.Println("case %s:", strings.Join(check.cases, ", "))
+				out.Indent(1)
+				out.Println("return true")
+				out.Indent(-1)
+				out.Println("}")
+				out.Println("return false")
+			}
+			out.Indent(-1)
+			out.Println("}")
+			out.Newline()
+
+			out.Comment(fmt.Sprintf("Parse%s returns the %s value of s, or an error if it is not one "+
+				"of the values allowed by the schema.", enumDecl.Name, enumDecl.Name))
+			out.Println("func Parse%s(s string) (%s, error) {", enumDecl.Name, enumDecl.Name)
+			out.Indent(1)
+			out.Println("if v := %s(s); v.IsValid() {", enumDecl.Name)
+			out.Indent(1)
+			out.Println("return v, nil")
+			out.Indent(-1)
+			out.Println("}")
+			if g.config.OnlyModels {
+				out.Println("return \"\", fmt.Errorf(\"invalid value (expected one of %%#v): %%#v\", %s, s)",
+					check.valuesVar)
+			} else {
+				out.Println("return \"\", %s", validationError(jsonPointer("", nil), "enum",
+					"invalid value (expected one of %#v): %#v", check.valuesVar, "s"))
+			}
+			out.Indent(-1)
+			out.Println("}")
+		},
+	})
+	if g.config.OnlyModels {
+		return
+	}
+
+	g.output.file.Package.AddDecl(&codegen.Method{
+		Owner: enumDecl.Name,
+		Impl: func(out *codegen.Emitter) {
+			out.Comment("MarshalText implements encoding.TextMarshaler.")
+			out.Println("func (j %s) MarshalText() ([]byte, error) {", enumDecl.Name)
+			out.Indent(1)
+			out.Println("return []byte(j), nil")
+			out.Indent(-1)
+			out.Println("}")
+			out.Newline()
+
+			out.Comment("UnmarshalText implements encoding.TextUnmarshaler.")
+			out.Println("func (j *%s) UnmarshalText(text []byte) error {", enumDecl.Name)
+			out.Indent(1)
+			out.Println("v, err := Parse%s(string(text))", enumDecl.Name)
+			out.Println("if err != nil {")
+			out.Indent(1)
+			out.Println("return err")
+			out.Indent(-1)
+			out.Println("}")
+			out.Println("*j = v")
+			out.Println("return nil")
+			out.Indent(-1)
+			out.Println("}")
+		},
+	})
 }
 
 type output struct {
-	file          *codegen.File
+	file *codegen.File
+	// schemaIDs are the IDs of the schemas mapped to the output.
+	schemaIDs     map[string]bool
 	declsByName   map[string]*codegen.TypeDecl
 	declsBySchema map[*schemas.Type]*codegen.TypeDecl
-	warner        func(string)
+	// declsByShape are the types declared for inline object schemas, by the
+	// hash of the schema and its file.
+	declsByShape map[string]*codegen.TypeDecl
+	varsByName   map[string]*codegen.Var
+	funcsByName  map[string]bool
+	// helpers are the declarations that the generated code shares, by the
+	// name of the helper they belong to. They are declared once per package.
+	helpers       map[codegen.Decl]string
+	deepCopyDecls map[*codegen.TypeDecl]bool
+	randomDecls   map[*codegen.TypeDecl]bool
+	equalDecls    map[*codegen.TypeDecl]bool
+	easyJSONDecls map[*codegen.TypeDecl]bool
+	// testCases are the documents that the generated tests unmarshal, in the
+	// order that their types were declared.
+	testCases []testCase
+	// fuzzTypes are the names of the types that get fuzz targets.
+	fuzzTypes []string
+	// docs are the types described in the Markdown reference, in the order
+	// that they were declared.
+	docs []docEntry
+	// topLevel are the types declared for definitions and the roots of
+	// schemas, which get files of their own with Config.SplitFiles.
+	topLevel map[*codegen.TypeDecl]bool
+	// sources are the schemas generated into the output, by file name.
+	sources map[string]*schemas.Schema
+}
+
+// sortedSchemaIDs returns the IDs of the schemas mapped to the output, other
+// than the empty one.
+func (o *output) sortedSchemaIDs() []string {
+	var ids []string
+	for id := range o.schemaIDs {
+		if id != "" {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// markTopLevel records the type declared for a definition, or the root of a
+// schema, as top-level.
+func (o *output) markTopLevel(t codegen.Type) {
+	if nt, ok := t.(*codegen.NamedType); ok && nt.Package == nil && nt.Decl != nil {
+		o.topLevel[nt.Decl] = true
+	}
+}
+
+// addHelper declares the declarations of a helper that the generated code
+// shares.
+func (o *output) addHelper(name string, decls ...codegen.Decl) {
+	for _, d := range decls {
+		o.helpers[d] = name
+		o.file.Package.AddDecl(d)
+	}
+}
+
+func (o *output) addVar(v *codegen.Var) {
+	if _, ok := o.varsByName[v.Name]; ok {
+		return
+	}
+	o.varsByName[v.Name] = v
+	o.file.Package.AddDecl(v)
+}
+
+// structMethodNames returns the names of the methods that the generated
+// structs may get, which their fields are not named after.
+func (g *schemaGenerator) structMethodNames() map[string]bool {
+	names := map[string]bool{}
+	if !g.config.OnlyModels {
+		names[methodNameValidate] = true
+	}
+	if g.config.DeepCopy {
+		names["DeepCopy"] = true
+		names["DeepCopyInto"] = true
+	}
+	return names
+}
+
+// propertyFieldName returns the name of the field that a property maps to,
+// before it is made unique.
+func (g *schemaGenerator) propertyFieldName(name string, prop *schemas.Type) (string, error) {
+	fieldName := g.identifierize(name)
+	if ext := prop.GoJSONSchemaExtension; ext != nil && ext.Identifier != nil {
+		fieldName = *ext.Identifier
+	}
+	if custom, err := goName(prop); err != nil {
+		return "", fmt.Errorf("could not generate field for property %q: %w", name, err)
+	} else if custom != "" {
+		fieldName = custom
+	}
+	return fieldName, nil
+}
+
+// getterName returns the name of the getter of a field.
+func getterName(fieldName string) string {
+	return "Get" + fieldName
+}
+
+// uniqueTypeName returns a name for a type declared for t, which is the
+// name given unless a type has been declared with it already.
+func (g *schemaGenerator) uniqueTypeName(t *schemas.Type, name string) string {
+	unique := g.output.uniqueTypeName(name)
+	if unique != name {
+		g.warnf(t, WarningRenamed, "Multiple types map to the name %q; declaring duplicate as %q instead", name, unique)
+	}
+	return unique
 }
 
 func (o *output) uniqueTypeName(name string) string {
@@ -891,8 +3779,6 @@ func (o *output) uniqueTypeName(name string) string {
 	for {
 		suffixed := fmt.Sprintf("%s_%d", name, count)
 		if _, ok := o.declsByName[suffixed]; !ok {
-			o.warner(fmt.Sprintf(
-				"Multiple types map to the name %q; declaring duplicate as %q instead", name, suffixed))
 			return suffixed
 		}
 		count++
@@ -927,11 +3813,71 @@ func (ns nameScope) add(s string) nameScope {
 }
 
 var (
-	varNamePlainStruct = "plain"
-	varNameRawMap      = "raw"
+	varNamePlainStruct     = "plain"
+	varNameRawMap          = "raw"
+	varNameHostnamePattern = "hostnamePattern"
+	varNameReceiver        = "j"
+	varNameValue           = "value"
+	varNameErrors          = "errs"
+	varNameProps           = "props"
+)
+
+const typeJSONNumber = "json.Number"
+
+// fieldNameExtras is the name of the field holding the properties that a
+// struct's schema does not declare.
+const fieldNameExtras = "AdditionalProperties"
+
+const (
+	typeNameValidationError  = "ValidationError"
+	typeNameValidationErrors = "ValidationErrors"
+)
+
+// funcNamePrefixValidationError is the name of the function that locates the
+// validation errors of nested values, and funcNameAppendValidationError that
+// of the function that aggregates them.
+const (
+	funcNamePrefixValidationError = "prefixValidationError"
+	funcNameAppendValidationError = "appendValidationError"
+)
+
+// Names of the methods that validate the generated types. The validateFields
+// method of a struct checks the constraints of its fields, so that
+// UnmarshalJSON does not validate its nested values or count its properties
+// again, and Validate calls it and does those too.
+const (
+	methodNameValidate       = "Validate"
+	methodNameValidateFields = "validateFields"
+)
+
+const (
+	formatEmail    = "email"
+	formatIDNEmail = "idn-email"
+	formatHostname = "hostname"
+	formatRegex    = "regex"
 )
 
-func fileExists(fileName string) bool {
-	_, err := os.Stat(fileName)
+// hostnamePattern matches a hostname as defined by RFC 1123, section 2.1.
+const hostnamePattern = `^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`
+
+func (g *Generator) fileExists(fileName string) bool {
+	var err error
+	if g.config.FileSystem != nil {
+		_, err = fs.Stat(g.config.FileSystem, fileName)
+	} else {
+		_, err = os.Stat(fileName)
+	}
 	return err == nil || !os.IsNotExist(err)
 }
+
+// canonicalFileName returns the name under which a schema file is cached, so
+// that it is only loaded once however it is referred to.
+func (g *Generator) canonicalFileName(fileName string) (string, error) {
+	if g.config.FileSystem != nil {
+		if _, err := fs.Stat(g.config.FileSystem, fileName); err != nil {
+			return "", err
+		}
+		return path.Clean(fileName), nil
+	}
+	return filepath.EvalSymlinks(fileName)
+}
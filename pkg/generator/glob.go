@@ -0,0 +1,94 @@
+package generator
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Glob returns the names of the files that match a pattern, in lexical
+// order. Patterns are as filepath.Match takes, except that a "**" path
+// element matches any number of directories, including none, such as in
+// "schemas/**/*.json". Unlike filepath.Glob, directories are not returned.
+func Glob(pattern string) ([]string, error) {
+	slashed := path.Clean(filepath.ToSlash(pattern))
+	elems := strings.Split(slashed, "/")
+	// Only the directory that the pattern has no wildcards within is walked,
+	// and only as deep as the pattern goes, if it has no "**".
+	i := 0
+	for i < len(elems)-1 && !strings.ContainsAny(elems[i], "*?[") {
+		i++
+	}
+	root := strings.Join(elems[:i], "/")
+	switch {
+	case root == "" && strings.HasPrefix(slashed, "/"):
+		root = "/"
+	case root == "":
+		root = "."
+	}
+	maxDepth := len(elems) - i
+	if strings.Contains(slashed, "**") {
+		maxDepth = -1
+	}
+
+	var matches []string
+	err := filepath.WalkDir(filepath.FromSlash(root), func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if name == filepath.FromSlash(root) && errors.Is(err, fs.ErrNotExist) {
+				// Nothing matches.
+				return fs.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() {
+			if maxDepth >= 0 && name != filepath.FromSlash(root) && depth(root, name) >= maxDepth {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if matchGlob(slashed, path.Clean(filepath.ToSlash(name))) {
+			matches = append(matches, name)
+		}
+		return nil
+	})
+	return matches, err
+}
+
+// depth returns the number of path elements of name below root.
+func depth(root, name string) int {
+	rel, err := filepath.Rel(filepath.FromSlash(root), name)
+	if err != nil {
+		return 0
+	}
+	return strings.Count(filepath.ToSlash(rel), "/") + 1
+}
+
+// matchGlob reports whether a slash-separated name matches a pattern, as
+// path.Match does, except that a "**" element matches any number of
+// elements, including none.
+func matchGlob(pattern, name string) bool {
+	return matchGlobElems(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchGlobElems(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchGlobElems(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
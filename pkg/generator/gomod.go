@@ -0,0 +1,77 @@
+package generator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// inferPackageName returns the import path of the package that an output
+// file is written to, from the module path in the nearest go.mod file in its
+// directory or those above it. Relative names are relative to the current
+// directory, as Write("") takes them, and standard output stands for the
+// current directory itself.
+func inferPackageName(outputName string) (string, error) {
+	if outputName == "" {
+		return "", errors.New("the package of types that are not written cannot be inferred")
+	}
+	dir := "."
+	if outputName != "-" {
+		dir = filepath.Dir(outputName)
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for modDir := dir; ; {
+		goMod := filepath.Join(modDir, "go.mod")
+		b, err := os.ReadFile(goMod)
+		if err == nil {
+			module := modulePath(b)
+			if module == "" {
+				return "", fmt.Errorf("no module directive in %s", goMod)
+			}
+			rel, err := filepath.Rel(modDir, dir)
+			if err != nil {
+				return "", err
+			}
+			if rel == "." {
+				return module, nil
+			}
+			return module + "/" + filepath.ToSlash(rel), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		parent := filepath.Dir(modDir)
+		if parent == modDir {
+			return "", fmt.Errorf("no go.mod file found in %s or the directories above it", dir)
+		}
+		modDir = parent
+	}
+}
+
+// modulePath returns the module path declared by the contents of a go.mod
+// file, or "" if it declares none.
+func modulePath(goMod []byte) string {
+	for _, line := range bytes.Split(goMod, []byte("\n")) {
+		if i := bytes.Index(line, []byte("//")); i != -1 {
+			line = line[:i]
+		}
+		fields := bytes.Fields(line)
+		if len(fields) != 2 || string(fields[0]) != "module" {
+			continue
+		}
+		module := string(fields[1])
+		if unquoted, err := strconv.Unquote(module); err == nil {
+			module = unquoted
+		}
+		return module
+	}
+	return ""
+}
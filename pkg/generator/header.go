@@ -0,0 +1,111 @@
+package generator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"strings"
+
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/codegen"
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/schemas"
+)
+
+const modulePathGenerator = "github.com/lets-dev-it-out/go-jsonschema"
+
+// FileHeaderData is what Config.FileHeader is executed with for each file.
+type FileHeaderData struct {
+	// FileName is the name of the file, or "-" for standard output.
+	FileName string
+	// Package is the qualified name of the Go package of the file.
+	Package string
+	// SchemaIDs are the IDs of the schemas that the file was generated from,
+	// sorted, as in GeneratedFile.
+	SchemaIDs []string
+	// Sources are the schema files, or URLs, that the file was generated
+	// from, sorted by name.
+	Sources []HeaderSource
+	// Version is the version of the generator, as its module was built, or
+	// "(devel)" if it is not known.
+	Version string
+}
+
+// HeaderSource is a schema file, or URL, in a FileHeaderData.
+type HeaderSource struct {
+	// Name is the name of the file, as given or as referred to, or its URL.
+	Name string
+	// ID is the $id of the schema, if it has one.
+	ID string
+	// SHA256 is the hex-encoded SHA-256 hash of the document the schema was
+	// read from, or "" for schemas that were not read from one, such as
+	// those given to DoSchema.
+	SHA256 string
+}
+
+// recordSourceHash records the hash of the document that a schema was parsed
+// from, for the file headers.
+func (g *Generator) recordSourceHash(schema *schemas.Schema, document []byte) {
+	sum := sha256.Sum256(document)
+	g.sourceHashesMu.Lock()
+	defer g.sourceHashesMu.Unlock()
+	g.sourceHashes[schema] = hex.EncodeToString(sum[:])
+}
+
+// fileHeader returns the header of a file generated for an output, executing
+// Config.FileHeader, or "" if there is none.
+func (g *Generator) fileHeader(file *codegen.File, o *output) string {
+	if g.header == nil {
+		return ""
+	}
+	data := FileHeaderData{
+		FileName:  file.FileName,
+		Package:   file.Package.QualifiedName,
+		SchemaIDs: o.sortedSchemaIDs(),
+		Version:   generatorVersion(),
+	}
+	g.sourceHashesMu.Lock()
+	for name, schema := range o.sources {
+		data.Sources = append(data.Sources, HeaderSource{
+			Name:   name,
+			ID:     schema.ID,
+			SHA256: g.sourceHashes[schema],
+		})
+	}
+	g.sourceHashesMu.Unlock()
+	sort.Slice(data.Sources, func(i, j int) bool {
+		return data.Sources[i].Name < data.Sources[j].Name
+	})
+
+	var sb strings.Builder
+	if err := g.header.Execute(&sb, data); err != nil {
+		g.warn(Warning{
+			Code:    WarningSkipped,
+			Message: fmt.Sprintf("Not adding the file header to %s: %s", file.FileName, err),
+		})
+		return ""
+	}
+	return sb.String()
+}
+
+// generatorVersion returns the version of this module in the running binary.
+func generatorVersion() string {
+	version := ""
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if info.Main.Path == modulePathGenerator {
+			version = info.Main.Version
+		}
+		for _, dep := range info.Deps {
+			if dep.Path == modulePathGenerator {
+				version = dep.Version
+				if dep.Replace != nil {
+					version = dep.Replace.Version
+				}
+			}
+		}
+	}
+	if version == "" {
+		return "(devel)"
+	}
+	return version
+}
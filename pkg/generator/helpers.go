@@ -0,0 +1,117 @@
+package generator
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/codegen"
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/schemas"
+)
+
+// helpersFileName is the name of the file that the helpers of a package are
+// declared in, when more than one output file is generated into it.
+const helpersFileName = "jsonschema_helpers.go"
+
+// packageHelpers is the file holding the helpers that the output files in a
+// package share.
+type packageHelpers struct {
+	file *codegen.File
+	// output holds the schema IDs and sources of the outputs, for the file
+	// header.
+	output *output
+}
+
+// sharedHelpers moves the helpers of the outputs that are generated into the
+// same package as another output to a file of their own, so that each is
+// declared once per package. It returns the helper files, in the order of
+// their first outputs, and the files of the outputs that helpers were moved
+// out of, which are copies and which only import what they use. If a package
+// has an output file with the name of the helper file, the helpers are
+// declared in that output instead.
+func (g *Generator) sharedHelpers(outputs []*output) ([]*packageHelpers, map[*output]*codegen.File) {
+	type packageKey struct {
+		dir, name string
+	}
+	byPackage := map[packageKey][]*output{}
+	var keys []packageKey
+	for _, o := range outputs {
+		if o.file.FileName == "-" {
+			continue
+		}
+		key := packageKey{filepath.Dir(o.file.FileName), o.file.Package.QualifiedName}
+		if _, ok := byPackage[key]; !ok {
+			keys = append(keys, key)
+		}
+		byPackage[key] = append(byPackage[key], o)
+	}
+
+	var helpers []*packageHelpers
+	stripped := map[*output]*codegen.File{}
+	for _, key := range keys {
+		pkgOutputs := byPackage[key]
+		if len(pkgOutputs) < 2 {
+			continue
+		}
+		h := &packageHelpers{
+			file: &codegen.File{
+				FileName: filepath.Join(key.dir, helpersFileName),
+				Package:  codegen.Package{QualifiedName: key.name},
+			},
+			output: &output{schemaIDs: map[string]bool{}, sources: map[string]*schemas.Schema{}},
+		}
+		declared := map[string]*output{}
+		for _, o := range pkgOutputs {
+			file := &codegen.File{
+				FileName: o.file.FileName,
+				Header:   o.file.Header,
+				Package: codegen.Package{
+					QualifiedName: o.file.Package.QualifiedName,
+					Comment:       o.file.Package.Comment,
+					Imports:       append([]codegen.Import(nil), o.file.Package.Imports...),
+				},
+			}
+			for _, d := range o.file.Package.Decls {
+				name, ok := o.helpers[d]
+				if !ok {
+					file.Package.AddDecl(d)
+					continue
+				}
+				if first, ok := declared[name]; ok && first != o {
+					continue
+				}
+				declared[name] = o
+				h.file.Package.AddDecl(d)
+				for _, i := range o.file.Package.Imports {
+					h.file.Package.AddImport(i.QualifiedName, i.Name)
+				}
+			}
+			stripped[o] = file
+			for id := range o.schemaIDs {
+				h.output.schemaIDs[id] = true
+			}
+			for name, schema := range o.sources {
+				h.output.sources[name] = schema
+			}
+		}
+		if len(h.file.Package.Decls) == 0 {
+			continue
+		}
+		if home, ok := g.outputs[h.file.FileName]; ok {
+			g.warn(Warning{
+				Code:    WarningSkipped,
+				Message: fmt.Sprintf("Declaring the shared helpers in %s, since it is an output file", h.file.FileName),
+			})
+			file := stripped[home]
+			if file == nil {
+				file = stripped[pkgOutputs[0]]
+			}
+			file.Package.Decls = append(file.Package.Decls, h.file.Package.Decls...)
+			for _, i := range h.file.Package.Imports {
+				file.Package.AddImport(i.QualifiedName, i.Name)
+			}
+			continue
+		}
+		helpers = append(helpers, h)
+	}
+	return helpers, stripped
+}
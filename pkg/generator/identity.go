@@ -0,0 +1,117 @@
+package generator
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/schemas"
+)
+
+// sharedDefinition is a definition in a schema file that another refers to,
+// as shareDefinition records it.
+type sharedDefinition struct {
+	schema   *schemas.Schema
+	fileName string
+	def      *schemas.Type
+}
+
+// shareDefinition returns the schema, file name and definition to generate a
+// type for a definition that a schema file refers to in another file.
+//
+// A definition is identified by the $id of its schema and its pointer, so if
+// the same one has been referred to in another file already, such as another
+// copy of a shared definitions file, that file's definition is returned, so
+// that one type is declared, in that file's output, and imported by the
+// others. Definitions in schemas without an $id, or that differ from the one
+// first referred to with the same $id and pointer, are returned as they are:
+// schemas with different definitions sometimes share an $id to share their
+// output. Definitions whose $refs to other files resolve to different files
+// differ too, even if the $refs are written the same.
+func (g *Generator) shareDefinition(
+	schema *schemas.Schema, fileName, pointer string, def *schemas.Type) (*schemas.Schema, string, *schemas.Type) {
+	id := strings.TrimSuffix(schema.ID, "#")
+	if id == "" {
+		return schema, fileName, def
+	}
+	key := id + "#" + pointer
+	shared, ok := g.sharedDefinitions[key]
+	if !ok {
+		g.sharedDefinitions[key] = sharedDefinition{schema: schema, fileName: fileName, def: def}
+		return schema, fileName, def
+	}
+	if shared.schema == schema || !reflect.DeepEqual(shared.def, def) {
+		return schema, fileName, def
+	}
+	first, err := g.resolvedDefinition(shared.def, shared.fileName)
+	if err != nil {
+		return schema, fileName, def
+	}
+	this, err := g.resolvedDefinition(def, fileName)
+	if err != nil || !reflect.DeepEqual(first, this) {
+		return schema, fileName, def
+	}
+	return shared.schema, shared.fileName, shared.def
+}
+
+// resolvedDefinition returns a definition as a JSON value, with the file
+// names in its $refs resolved against the file it is in. It fails if one of
+// them cannot be resolved.
+func (g *Generator) resolvedDefinition(def *schemas.Type, fileName string) (interface{}, error) {
+	b, err := json.Marshal(def)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	if err := json.Unmarshal(b, &value); err != nil {
+		return nil, err
+	}
+	var resolveErr error
+	var resolve func(value interface{})
+	resolve = func(value interface{}) {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			for key, item := range v {
+				ref, ok := item.(string)
+				if key != "$ref" || !ok {
+					resolve(item)
+					continue
+				}
+				refFile, fragment := ref, ""
+				if i := strings.IndexRune(ref, '#'); i != -1 {
+					refFile, fragment = ref[:i], ref[i:]
+				}
+				if refFile == "" {
+					continue
+				}
+				if u, ok := schemaURL(refFile, fileName); ok {
+					refFile = u
+				} else if qualified, err := g.resolveFileName(refFile, fileName); err == nil {
+					refFile = qualified
+				} else {
+					resolveErr = err
+				}
+				v[key] = refFile + fragment
+			}
+		case []interface{}:
+			for _, item := range v {
+				resolve(item)
+			}
+		}
+	}
+	resolve(value)
+	if resolveErr != nil {
+		return nil, resolveErr
+	}
+	return value, nil
+}
+
+// definitionPointer returns the JSON Pointer of one of the definitions that
+// AllDefinitions returns, in "definitions" or in "$defs".
+func definitionPointer(schema *schemas.Schema, name string) string {
+	escaped := strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+	if _, ok := schema.Definitions[name]; ok {
+		return "/definitions/" + escaped
+	}
+	return "/$defs/" + escaped
+}
@@ -0,0 +1,142 @@
+package generator
+
+import (
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// schemaMapping returns the mapping by ID for a schema ID: the first one whose
+// SchemaID is the ID itself, or else the most specific of those whose
+// SchemaID is a pattern that matches it, with "{name}" in its PackageName and
+// OutputName replaced by the name of the schema. It returns false if there
+// is none.
+func (g *Generator) schemaMapping(id string) (SchemaMapping, bool) {
+	for _, m := range g.config.SchemaMappings {
+		if m.FilePath == "" && m.SchemaID == id {
+			return m, true
+		}
+	}
+	if id == "" {
+		return SchemaMapping{}, false
+	}
+
+	var best *SchemaMapping
+	for i, m := range g.config.SchemaMappings {
+		if m.FilePath != "" || !strings.Contains(m.SchemaID, "*") || !matchSchemaIDPattern(m.SchemaID, id) {
+			continue
+		}
+		if best == nil || moreSpecificPattern(m.SchemaID, best.SchemaID) {
+			best = &g.config.SchemaMappings[i]
+		}
+	}
+	if best == nil {
+		return SchemaMapping{}, false
+	}
+	m := *best
+	name := schemaIDName(id)
+	m.PackageName = strings.ReplaceAll(m.PackageName, "{name}", name)
+	m.OutputName = strings.ReplaceAll(m.OutputName, "{name}", name)
+	return m, true
+}
+
+// fileMapping returns the mapping by file path for a schema file: the first
+// one whose FilePath is the file's, or else the most specific of those whose
+// FilePath is a pattern that matches it. It returns false if there is none.
+func (g *Generator) fileMapping(fileName string) (SchemaMapping, bool) {
+	if fileName == "" {
+		return SchemaMapping{}, false
+	}
+	var best *SchemaMapping
+	for i, m := range g.config.SchemaMappings {
+		if m.FilePath == "" || !g.matchFilePath(m.FilePath, fileName) {
+			continue
+		}
+		if !strings.ContainsAny(m.FilePath, "*?[") {
+			return m, true
+		}
+		if best == nil || moreSpecificPattern(m.FilePath, best.FilePath) {
+			best = &g.config.SchemaMappings[i]
+		}
+	}
+	if best == nil {
+		return SchemaMapping{}, false
+	}
+	return *best, true
+}
+
+// rootTypeOverride returns the name from Config.RootTypeOverrides for the
+// root type of the schema in a file, using the most specific pattern if more
+// than one matches.
+func (g *Generator) rootTypeOverride(fileName string) (string, bool) {
+	if fileName == "" {
+		return "", false
+	}
+	var best string
+	found := false
+	for p := range g.config.RootTypeOverrides {
+		if g.matchFilePath(p, fileName) && (!found || moreSpecificPattern(p, best)) {
+			best, found = p, true
+		}
+	}
+	if !found {
+		return "", false
+	}
+	return g.config.RootTypeOverrides[best], true
+}
+
+// matchFilePath reports whether the name of a schema file matches a path or
+// pattern, either as they are or, for files on disk, as absolute paths.
+func (g *Generator) matchFilePath(pattern, fileName string) bool {
+	if g.config.FileSystem != nil || isHTTPURL(fileName) {
+		return matchGlob(path.Clean(pattern), path.Clean(fileName))
+	}
+	if matchGlob(path.Clean(filepath.ToSlash(pattern)), path.Clean(filepath.ToSlash(fileName))) {
+		return true
+	}
+	absPattern, err := filepath.Abs(pattern)
+	if err != nil {
+		return false
+	}
+	absFileName, err := filepath.Abs(fileName)
+	if err != nil {
+		return false
+	}
+	return matchGlob(filepath.ToSlash(absPattern), filepath.ToSlash(absFileName))
+}
+
+// matchSchemaIDPattern reports whether a schema ID matches a pattern, in
+// which each "*" matches any text, including slashes.
+func matchSchemaIDPattern(pattern, id string) bool {
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return regexp.MustCompile("^" + strings.Join(parts, ".*") + "$").MatchString(id)
+}
+
+// moreSpecificPattern reports whether pattern a is more specific than b: it
+// has more text besides its wildcards, or as much and sorts first.
+func moreSpecificPattern(a, b string) bool {
+	la := len(a) - strings.Count(a, "*")
+	lb := len(b) - strings.Count(b, "*")
+	if la != lb {
+		return la > lb
+	}
+	return a < b
+}
+
+// schemaIDName returns the name that "{name}" stands for in mappings for a
+// schema ID: the last element of its path, without extensions, so that
+// "https://example.com/schemas/person.schema.json" is named "person".
+func schemaIDName(id string) string {
+	if i := strings.IndexAny(id, "?#"); i != -1 {
+		id = id[:i]
+	}
+	name := path.Base(strings.TrimSuffix(id, "/"))
+	if i := strings.Index(name, "."); i > 0 {
+		name = name[:i]
+	}
+	return name
+}
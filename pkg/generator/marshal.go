@@ -0,0 +1,335 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/codegen"
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/schemas"
+)
+
+const (
+	funcNameMarshalOrdered = "marshalOrdered"
+	funcNameAppendExtras   = "appendExtras"
+)
+
+// generateMarshal declares a MarshalJSON method for a struct or map, which
+// checks that the given required fields are set and, if the type has a
+// Validate method, that its constraints are satisfied. If order is non-nil,
+// the properties of the struct are written in that order. If extras is true,
+// the properties held by the struct's extras field are written too. Structs
+// with easyjson methods are written with those, which already follow the
+// order. It reports whether a method was declared.
+func (g *schemaGenerator) generateMarshal(
+	decl *codegen.TypeDecl, required []codegen.StructField, hasValidate bool, order []string, extras bool) bool {
+	_, isStruct := decl.Type.(*codegen.StructType)
+	easyJSON := g.config.EasyJSON && isStruct
+	if easyJSON {
+		order = nil
+	}
+	validates := len(required) > 0 || hasValidate
+	if !validates && order == nil && !extras {
+		return false
+	}
+	declName := decl.Name
+	aggregate := g.config.AggregateErrors && len(required) > 0
+
+	comment := "MarshalJSON implements json.Marshaler"
+	if validates {
+		g.declareValidationError()
+		comment += ", and checks that the value satisfies the constraints declared in the schema"
+	}
+	comment += "."
+	if order != nil {
+		g.declareMarshalOrdered()
+		comment += " Properties are written in the order that the schema declares them."
+	}
+	if extras {
+		g.declareAppendExtras()
+	}
+
+	if !easyJSON {
+		g.output.file.Package.AddImport("encoding/json", "")
+	}
+	g.output.file.Package.AddDecl(&codegen.Method{
+		Owner: declName,
+		Impl: func(out *codegen.Emitter) {
+			out.Comment(comment)
+			out.Println("func (%s %s) MarshalJSON() ([]byte, error) {", varNameReceiver, declName)
+			out.Indent(1)
+			fail := returnMarshalError
+			if aggregate {
+				fail = appendError
+				out.Println("var %s %s", varNameErrors, typeNameValidationErrors)
+			}
+			for _, f := range required {
+				out.Println("if %s.%s == nil {", varNameReceiver, f.Name)
+				out.Indent(1)
+				fail(out, validationError(jsonPointer(f.JSONName, nil), "required", "required"))
+				out.Indent(-1)
+				out.Println("}")
+			}
+			if hasValidate {
+				out.Println("if err := %s.Validate(); err != nil {", varNameReceiver)
+				out.Indent(1)
+				if aggregate {
+					out.Println("verrs, ok := err.(%s)", typeNameValidationErrors)
+					out.Println("if !ok {")
+					out.Indent(1)
+					out.Println("return nil, err")
+					out.Indent(-1)
+					out.Println("}")
+					out.Println("%s = append(%s, verrs...)", varNameErrors, varNameErrors)
+				} else {
+					out.Println("return nil, err")
+				}
+				out.Indent(-1)
+				out.Println("}")
+			}
+			if aggregate {
+				out.Println("if len(%s) > 0 {", varNameErrors)
+				out.Indent(1)
+				out.Println("return nil, %s", varNameErrors)
+				out.Indent(-1)
+				out.Println("}")
+			}
+			var marshal string
+			if easyJSON {
+				out.Println("w := jwriter.Writer{}")
+				out.Println("%s.encodeEasyJSON(&w)", varNameReceiver)
+				marshal = "w.BuildBytes()"
+			} else {
+				out.Println("type Plain %s", declName)
+				marshal = fmt.Sprintf("json.Marshal(Plain(%s))", varNameReceiver)
+			}
+			if order != nil {
+				quoted := make([]string, len(order))
+				for i, name := range order {
+					quoted[i] = fmt.Sprintf("%q", name)
+				}
+				marshal = fmt.Sprintf("%s(Plain(%s), []string{%s})",
+					funcNameMarshalOrdered, varNameReceiver, strings.Join(quoted, ", "))
+			}
+			if extras {
+				out.Println("b, err := %s", marshal)
+				out.Println("if err != nil {")
+				out.Indent(1)
+				out.Println("return nil, err")
+				out.Indent(-1)
+				out.Println("}")
+				out.Println("return %s(b, %s.%s)", funcNameAppendExtras, varNameReceiver, fieldNameExtras)
+			} else {
+				out.Println("return %s", marshal)
+			}
+			out.Indent(-1)
+			out.Println("}")
+		},
+	})
+	return true
+}
+
+// propertyOrder returns the JSON names of the fields of a struct generated
+// from t, in the order that the schema declares them.
+func propertyOrder(t *schemas.Type, structType *codegen.StructType) []string {
+	fields := make(map[string]bool, len(structType.Fields))
+	for _, f := range structType.Fields {
+		if f.JSONName != "" {
+			fields[f.JSONName] = true
+		}
+	}
+	order := make([]string, 0, len(structType.Fields))
+	for _, name := range t.PropertyOrder {
+		if fields[name] {
+			order = append(order, name)
+			delete(fields, name)
+		}
+	}
+	// Properties missing from PropertyOrder, such as of schemas that were not
+	// parsed from a document, follow in the order of the struct's fields
+	for _, f := range structType.Fields {
+		if fields[f.JSONName] {
+			order = append(order, f.JSONName)
+			delete(fields, f.JSONName)
+		}
+	}
+	return order
+}
+
+// declareMarshalOrdered declares the function that marshals a struct with its
+// properties in a given order.
+func (g *schemaGenerator) declareMarshalOrdered() {
+	if g.output.funcsByName[funcNameMarshalOrdered] {
+		return
+	}
+	g.output.funcsByName[funcNameMarshalOrdered] = true
+	g.output.file.Package.AddImport("bytes", "")
+	g.output.file.Package.AddImport("encoding/json", "")
+	g.output.addHelper(funcNameMarshalOrdered, &codegen.Method{
+		Impl: func(out *codegen.Emitter) {
+			out.Comment(fmt.Sprintf("%s marshals v, which must marshal to a JSON object, with its "+
+				"properties in the given order.", funcNameMarshalOrdered))
+			out.Println("func %s(v interface{}, order []string) ([]byte, error) {", funcNameMarshalOrdered)
+			out.Indent(1)
+			out.Println("b, err := json.Marshal(v)")
+			out.Println("if err != nil {")
+			out.Indent(1)
+			out.Println("return nil, err")
+			out.Indent(-1)
+			out.Println("}")
+			out.Println("var props map[string]json.RawMessage")
+			out.Println("if err := json.Unmarshal(b, &props); err != nil {")
+			out.Indent(1)
+			out.Println("return nil, err")
+			out.Indent(-1)
+			out.Println("}")
+			out.Println("var buf bytes.Buffer")
+			out.Println("buf.WriteByte('{')")
+			out.Println("for _, name := range order {")
+			out.Indent(1)
+			out.Println("value, ok := props[name]")
+			out.Println("if !ok {")
+			out.Indent(1)
+			out.Println("continue")
+			out.Indent(-1)
+			out.Println("}")
+			out.Println("if buf.Len() > 1 {")
+			out.Indent(1)
+			out.Println("buf.WriteByte(',')")
+			out.Indent(-1)
+			out.Println("}")
+			out.Println("key, err := json.Marshal(name)")
+			out.Println("if err != nil {")
+			out.Indent(1)
+			out.Println("return nil, err")
+			out.Indent(-1)
+			out.Println("}")
+			out.Println("buf.Write(key)")
+			out.Println("buf.WriteByte(':')")
+			out.Println("buf.Write(value)")
+			out.Indent(-1)
+			out.Println("}")
+			out.Println("buf.WriteByte('}')")
+			out.Println("return buf.Bytes(), nil")
+			out.Indent(-1)
+			out.Println("}")
+		},
+	})
+}
+
+// requiredNillableFields returns the required fields of a struct that can be
+// left unset, because their types are nillable. Fields that may be null are
+// excluded.
+func (g *schemaGenerator) requiredNillableFields(structType *codegen.StructType) []codegen.StructField {
+	var fields []codegen.StructField
+	for _, name := range structType.RequiredJSONFields {
+		if g.isNullableRequiredField(structType, name) {
+			continue
+		}
+		for _, f := range structType.Fields {
+			if f.JSONName == name && f.Type.IsNillable() {
+				fields = append(fields, f)
+			}
+		}
+	}
+	return fields
+}
+
+// generateEnumMarshal declares a MarshalJSON method for an enum, which checks
+// that the value is one of the values allowed by the schema.
+func (g *schemaGenerator) generateEnumMarshal(
+	enumDecl *codegen.TypeDecl, enumType codegen.PrimitiveType, values []interface{}) {
+	check := g.newEnumCheck(enumDecl, &enumType, values)
+	g.output.file.Package.AddImport("encoding/json", "")
+	g.output.file.Package.AddDecl(&codegen.Method{
+		Owner: enumDecl.Name,
+		Impl: func(out *codegen.Emitter) {
+			out.Comment("MarshalJSON implements json.Marshaler, and checks that the value is one of " +
+				"the values allowed by the schema.")
+			out.Println("func (%s %s) MarshalJSON() ([]byte, error) {", varNameReceiver, enumDecl.Name)
+			out.Indent(1)
+			value := fmt.Sprintf("%s(%s)", enumType.Type, varNameReceiver)
+			emitEnumCheck(out, check, value, returnMarshalError)
+			out.Println("return json.Marshal(%s)", value)
+			out.Indent(-1)
+			out.Println("}")
+		},
+	})
+}
+
+// returnMarshalError emits a statement that returns the error from a
+// MarshalJSON method.
+func returnMarshalError(out *codegen.Emitter, err string) {
+	out.Println("return nil, %s", err)
+}
+
+// declareAppendExtras declares the function that adds the properties held by
+// a struct's extras field to its marshaled JSON object.
+func (g *schemaGenerator) declareAppendExtras() {
+	if g.output.funcsByName[funcNameAppendExtras] {
+		return
+	}
+	g.output.funcsByName[funcNameAppendExtras] = true
+	g.output.file.Package.AddImport("bytes", "")
+	g.output.file.Package.AddImport("encoding/json", "")
+	g.output.file.Package.AddImport("sort", "")
+	g.output.addHelper(funcNameAppendExtras, &codegen.Method{
+		Impl: func(out *codegen.Emitter) {
+			out.Comment(fmt.Sprintf("%s adds the properties in extras that b, a JSON object, does not "+
+				"already have, sorted by name.", funcNameAppendExtras))
+			out.Println("func %s(b []byte, extras map[string]interface{}) ([]byte, error) {",
+				funcNameAppendExtras)
+			out.Indent(1)
+			out.Println("if len(extras) == 0 {")
+			out.Indent(1)
+			out.Println("return b, nil")
+			out.Indent(-1)
+			out.Println("}")
+			out.Println("var props map[string]json.RawMessage")
+			out.Println("if err := json.Unmarshal(b, &props); err != nil {")
+			out.Indent(1)
+			out.Println("return nil, err")
+			out.Indent(-1)
+			out.Println("}")
+			out.Println("names := make([]string, 0, len(extras))")
+			out.Println("for name := range extras {")
+			out.Indent(1)
+			out.Println("if _, ok := props[name]; !ok {")
+			out.Indent(1)
+			out.Println("names = append(names, name)")
+			out.Indent(-1)
+			out.Println("}")
+			out.Indent(-1)
+			out.Println("}")
+			out.Println("sort.Strings(names)")
+			out.Println("buf := bytes.NewBuffer(b[:len(b)-1])")
+			out.Println("for _, name := range names {")
+			out.Indent(1)
+			out.Println("if buf.Len() > 1 {")
+			out.Indent(1)
+			out.Println("buf.WriteByte(',')")
+			out.Indent(-1)
+			out.Println("}")
+			out.Println("key, err := json.Marshal(name)")
+			out.Println("if err != nil {")
+			out.Indent(1)
+			out.Println("return nil, err")
+			out.Indent(-1)
+			out.Println("}")
+			out.Println("value, err := json.Marshal(extras[name])")
+			out.Println("if err != nil {")
+			out.Indent(1)
+			out.Println("return nil, err")
+			out.Indent(-1)
+			out.Println("}")
+			out.Println("buf.Write(key)")
+			out.Println("buf.WriteByte(':')")
+			out.Println("buf.Write(value)")
+			out.Indent(-1)
+			out.Println("}")
+			out.Println("buf.WriteByte('}')")
+			out.Println("return buf.Bytes(), nil")
+			out.Indent(-1)
+			out.Println("}")
+		},
+	})
+}
@@ -0,0 +1,68 @@
+package generator
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"strings"
+
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/schemas"
+)
+
+// metaSchemaJSON is the meta-schema that schemas are checked against before
+// they are parsed: the draft-07 one, which the other drafts' schemas mostly
+// conform to, extended with the keywords of other drafts that the generator
+// reads, since it only warns about those.
+//
+//go:embed metaschema.json
+var metaSchemaJSON []byte
+
+var metaSchema = mustParseMetaSchema()
+
+func mustParseMetaSchema() *schemas.Schema {
+	schema, err := schemas.FromJSONReader(bytes.NewReader(metaSchemaJSON))
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}
+
+// SchemaError is returned for a schema that is not valid against the
+// JSON Schema meta-schema, with the ways in which it isn't, such as a type
+// that is not one of the JSON types.
+type SchemaError struct {
+	Errors []DocumentError
+}
+
+func (e *SchemaError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		message := err.Error() + " (" + err.Keyword + ")"
+		if err.Line > 0 {
+			message = fmt.Sprintf("line %d, column %d, at %s", err.Line, err.Column, message)
+		}
+		messages = append(messages, message)
+	}
+	return "schema is not valid against the meta-schema: " + strings.Join(messages, "; ")
+}
+
+// checkMetaSchema checks a schema document against the meta-schema, so
+// that mistakes in it are reported with their paths, rather than as the
+// errors that decoding it into a schemas.Schema gives.
+func (g *Generator) checkMetaSchema(b []byte) error {
+	doc, err := schemas.DecodeDocument(b)
+	if err != nil {
+		return err
+	}
+	v := &documentValidator{Generator: g}
+	if err := v.validate((*schemas.Type)(metaSchema.ObjectAsType), metaSchema, "", doc, ""); err != nil {
+		return err
+	}
+	if len(v.errs) > 0 {
+		for i := range v.errs {
+			v.errs[i].Line, v.errs[i].Column, _ = schemas.PositionOf(b, v.errs[i].Path)
+		}
+		return &SchemaError{Errors: v.errs}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package generator
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/codegen"
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/schemas"
+)
+
+// packageDoc is the doc.go file of Config.PackageDocs for the outputs in a
+// directory.
+type packageDoc struct {
+	file *codegen.File
+	// output holds the schema IDs and sources of the outputs, for the file
+	// header.
+	output *output
+}
+
+// packageDocs returns the doc.go files for the directories that outputs are
+// written to, in the order of their first outputs. Standard output gets
+// none.
+func (g *Generator) packageDocs(outputs []*output) []*packageDoc {
+	var docs []*packageDoc
+	byFileName := map[string]*packageDoc{}
+	for _, o := range outputs {
+		if o.file.FileName == "-" {
+			g.warn(Warning{Code: WarningSkipped, Message: "Not generating a package doc for standard output"})
+			continue
+		}
+		fileName := filepath.Join(filepath.Dir(o.file.FileName), "doc.go")
+		if _, ok := g.outputs[fileName]; ok {
+			g.warn(Warning{
+				Code:    WarningSkipped,
+				Message: fmt.Sprintf("Not generating a package doc, since %s is an output file", fileName),
+			})
+			continue
+		}
+		doc, ok := byFileName[fileName]
+		if !ok {
+			doc = &packageDoc{
+				file: &codegen.File{
+					FileName: fileName,
+					Package:  codegen.Package{QualifiedName: o.file.Package.QualifiedName},
+				},
+				output: &output{schemaIDs: map[string]bool{}, sources: map[string]*schemas.Schema{}},
+			}
+			byFileName[fileName] = doc
+			docs = append(docs, doc)
+		}
+		for id := range o.schemaIDs {
+			doc.output.schemaIDs[id] = true
+		}
+		for name, schema := range o.sources {
+			doc.output.sources[name] = schema
+		}
+	}
+	for _, doc := range docs {
+		doc.file.Package.Comment = packageComment(doc.file.Package.Name(), doc.output.sources)
+	}
+	return docs
+}
+
+// packageComment returns the package comment of a package generated from
+// schemas, by file name: the title and description of each, and its $id,
+// which godoc links to if it is a URL.
+func packageComment(pkgName string, sources map[string]*schemas.Schema) string {
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	title := func(name string) string {
+		if t := strings.TrimSpace(sources[name].Title); t != "" {
+			return t
+		}
+		return filepath.Base(name)
+	}
+	describe := func(sb *strings.Builder, schema *schemas.Schema) {
+		if d := strings.TrimSpace(schema.Description); d != "" {
+			fmt.Fprintf(sb, "\n\n%s", d)
+		}
+		if schema.ID != "" {
+			fmt.Fprintf(sb, "\n\nIts $id is %s.", schema.ID)
+		}
+	}
+
+	var sb strings.Builder
+	if len(names) == 1 {
+		fmt.Fprintf(&sb, "Package %s holds the types generated from the JSON Schema %q.", pkgName, title(names[0]))
+		describe(&sb, sources[names[0]])
+		return sb.String()
+	}
+	fmt.Fprintf(&sb, "Package %s holds the types generated from these JSON Schemas.", pkgName)
+	for _, name := range names {
+		fmt.Fprintf(&sb, "\n\n# %s", title(name))
+		describe(&sb, sources[name])
+	}
+	return sb.String()
+}
@@ -0,0 +1,130 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/schemas"
+)
+
+// DoFiles generates types for the schemas in many files, or at HTTP or HTTPS
+// URLs, as DoFileContext and DoURL do for each in turn. Up to
+// Config.Parallelism of them are read, parsed and checked against the
+// meta-schema at a time, which is most of the work for large schemas.
+//
+// Types are then generated from them one file at a time, in the order given,
+// so that the output does not depend on which files were parsed first. Files
+// that an earlier file refers to have had their types generated already, and
+// are skipped.
+func (g *Generator) DoFiles(ctx context.Context, fileNames ...string) error {
+	defer g.withContext(ctx)()
+
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	type result struct {
+		schema *schemas.Schema
+		err    error
+		done   chan struct{}
+	}
+	results := make([]result, len(fileNames))
+	for i := range results {
+		results[i].done = make(chan struct{})
+	}
+
+	parallelism := g.config.Parallelism
+	if parallelism <= 0 {
+		parallelism = runtime.GOMAXPROCS(0)
+	}
+	// Workers only parse, which reads nothing but the configuration; the
+	// generator itself is only changed by this goroutine.
+	jobs := make(chan int)
+	for w := 0; w < parallelism && w < len(fileNames); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i].schema, results[i].err = g.parseAny(fileNames[i])
+				close(results[i].done)
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for i := range fileNames {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for i, fileName := range fileNames {
+		select {
+		case <-results[i].done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if err := results[i].err; err != nil {
+			return err
+		}
+		if err := g.addParsed(fileName, results[i].schema); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseAny reads and parses the schema in a file or at a URL for DoFiles.
+// It may be called by many goroutines at once, so it must not change the
+// generator.
+func (g *Generator) parseAny(fileName string) (*schemas.Schema, error) {
+	if isHTTPURL(fileName) {
+		schema, err := g.fetchSchema(fileName)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching %s: %w", fileName, err)
+		}
+		return schema, nil
+	}
+	if fileName == "-" {
+		schema, err := g.parse(fileName, os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing from standard input: %w", err)
+		}
+		return schema, nil
+	}
+	schema, err := g.parseFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing from file %s: %w", fileName, err)
+	}
+	return schema, nil
+}
+
+// addParsed generates types for a schema parsed by parseAny, unless its file
+// has been loaded already, and records it as loaded, so that references to
+// it from the files after it do not read it again.
+func (g *Generator) addParsed(fileName string, schema *schemas.Schema) error {
+	if fileName == "-" {
+		return g.addFile(fileName, schema)
+	}
+	qualified := fileName
+	if !isHTTPURL(fileName) {
+		var err error
+		if qualified, err = g.canonicalFileName(fileName); err != nil {
+			return err
+		}
+	}
+	if _, ok := g.schemaCacheByFileName[qualified]; ok {
+		return nil
+	}
+	g.schemaCacheByFileName[qualified] = schema
+	return g.addFile(fileName, schema)
+}
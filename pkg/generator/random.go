@@ -0,0 +1,743 @@
+package generator
+
+import (
+	"fmt"
+	"math"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/codegen"
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/schemas"
+)
+
+const funcNameRandomRunes = "randomRunes"
+
+// defaultRandomRange is how far from a bound random numbers go when a
+// schema declares only one, or from zero when it declares neither.
+const defaultRandomRange = 1000
+
+// randomFuncName returns the name of the function that returns random values
+// of a declared type.
+func randomFuncName(typeName string) string {
+	return "Generate" + typeName
+}
+
+// generateRandom declares a function that returns a random value of a type,
+// which is valid against the schema it was generated from, for
+// property-based tests of the code that consumes it.
+func (g *schemaGenerator) generateRandom(decl *codegen.TypeDecl, t *schemas.Type) {
+	g.output.randomDecls[decl] = true
+	g.output.file.Package.AddImport("math/rand", "")
+
+	// The function is emitted once now, and discarded, so that the imports
+	// and helpers that it needs are declared before the file is generated,
+	// and it is emitted again without warnings once all the types that it
+	// may call the generators of are declared.
+	g.emitRandomFunc(codegen.NewEmitter(80), decl, t)
+	quiet := g.withoutWarnings()
+	g.output.file.Package.AddDecl(&codegen.Method{
+		Owner: decl.Name,
+		Impl: func(out *codegen.Emitter) {
+			quiet.emitRandomFunc(out, decl, t)
+		},
+	})
+}
+
+func (g *schemaGenerator) emitRandomFunc(out *codegen.Emitter, decl *codegen.TypeDecl, t *schemas.Type) {
+	name := randomFuncName(decl.Name)
+	out.Comment(fmt.Sprintf("%s returns a random %s that is valid against its schema.", name, decl.Name))
+	out.Println("func %s(r *rand.Rand) %s {", name, decl.Name)
+	out.Indent(1)
+	if structType, ok := decl.Type.(*codegen.StructType); ok {
+		out.Println("var v %s", decl.Name)
+		g.emitRandomFields(out, "v", structType, t)
+		out.Println("return v")
+	} else {
+		out.Println("var v %s", typeString(decl.Type))
+		g.emitRandom(out, "v", decl.Type, t, 0)
+		out.Println("return %s(v)", decl.Name)
+	}
+	out.Indent(-1)
+	out.Println("}")
+}
+
+// withoutWarnings returns a copy of the generator that discards warnings.
+func (g *schemaGenerator) withoutWarnings() *schemaGenerator {
+	generator := *g.Generator
+	generator.warner = nil
+	quiet := *g
+	quiet.Generator = &generator
+	return &quiet
+}
+
+// generateRandomEnum declares a function that returns one of the values of
+// an enum at random.
+func (g *schemaGenerator) generateRandomEnum(
+	enumDecl *codegen.TypeDecl, enumType codegen.Type, values []interface{}, wrapInStruct bool) {
+	var literals []string
+	if prim, ok := enumType.(codegen.PrimitiveType); ok && !wrapInStruct {
+		literals = enumCases(prim, values)
+	} else {
+		for _, v := range values {
+			literals = append(literals, interfaceLiteral(v))
+		}
+	}
+	if len(literals) == 0 {
+		g.warnf(nil, WarningSkipped, "Not generating %s, since no enum value has the type of %s",
+			randomFuncName(enumDecl.Name), enumDecl.Name)
+		return
+	}
+
+	g.output.randomDecls[enumDecl] = true
+	g.output.file.Package.AddImport("math/rand", "")
+	g.output.file.Package.AddDecl(&codegen.Method{
+		Owner: enumDecl.Name,
+		Impl: func(out *codegen.Emitter) {
+			name := randomFuncName(enumDecl.Name)
+			out.Comment(fmt.Sprintf("%s returns one of the values of %s at random.", name, enumDecl.Name))
+			out.Println("func %s(r *rand.Rand) %s {", name, enumDecl.Name)
+			out.Indent(1)
+			out.Println("switch r.Intn(%d) {", len(literals))
+			for i, literal := range literals[:len(literals)-1] {
+				out.Println("case %d:", i)
+				out.Indent(1)
+				out.Println("return %s", enumLiteral(enumDecl.Name, literal, wrapInStruct))
+				out.Indent(-1)
+			}
+			out.Println("default:")
+			out.Indent(1)
+			out.Println("return %s", enumLiteral(enumDecl.Name, literals[len(literals)-1], wrapInStruct))
+			out.Indent(-1)
+			out.Println("}")
+			out.Indent(-1)
+			out.Println("}")
+		},
+	})
+}
+
+func enumLiteral(typeName, literal string, wrapInStruct bool) string {
+	if wrapInStruct {
+		return fmt.Sprintf("%s{Value: %s}", typeName, literal)
+	}
+	return literal
+}
+
+// interfaceLiteral returns a Go literal for a primitive value decoded from
+// JSON, as it would be decoded into an interface{}.
+func interfaceLiteral(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return strconv.Quote(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return fmt.Sprintf("float64(%s)", formatFloat(v))
+	default:
+		return "nil"
+	}
+}
+
+// emitRandomFields emits code that sets the fields of a struct to random
+// values. Optional fields that can be omitted are set half of the time, or
+// a quarter of the time if they are recursive, so that trees stay small,
+// unless omitting them would be invalid because of minItems, minProperties
+// or the minProperties of the struct.
+func (g *schemaGenerator) emitRandomFields(
+	out *codegen.Emitter, dst string, structType *codegen.StructType, schema *schemas.Type) {
+	setAll := schema != nil && schema.MinProperties > len(structType.RequiredJSONFields)
+	for _, f := range structType.Fields {
+		if f.SchemaType == nil || f.JSONName == "" {
+			continue
+		}
+		field := dst + "." + f.Name
+		if setAll || contains(structType.RequiredJSONFields, f.JSONName) || !f.Type.IsNillable() ||
+			f.SchemaType.MinItems > 0 || f.SchemaType.MinProperties > 0 {
+			g.emitRandom(out, field, f.Type, f.SchemaType, 0)
+			continue
+		}
+		odds := 2
+		if isRecursive(f.Type) {
+			odds = 4
+		}
+		out.Println("if r.Intn(%d) == 0 {", odds)
+		out.Indent(1)
+		g.emitRandom(out, field, f.Type, f.SchemaType, 0)
+		out.Indent(-1)
+		out.Println("}")
+	}
+}
+
+// emitRandom emits code that assigns a random value of type t, which is valid
+// against the schema t, to dst. Values of types that can't be generated,
+// such as types mapped to Go types from other packages, are left zero.
+// depth numbers the variables of nested loops.
+func (g *schemaGenerator) emitRandom(
+	out *codegen.Emitter, dst string, t codegen.Type, schema *schemas.Type, depth int) {
+	if schema == nil {
+		schema = &schemas.Type{}
+	}
+	if at, ok := asArrayType(t); ok {
+		t = at
+	}
+
+	switch x := t.(type) {
+	case *codegen.NamedType:
+		if g.hasRandom(x) {
+			var qualifier string
+			if x.Package != nil {
+				qualifier = x.Package.Name() + "."
+			}
+			out.Println("%s = %s%s(r)", dst, qualifier, randomFuncName(x.Decl.Name))
+		}
+
+	case *codegen.PointerType:
+		out.Println("%s = new(%s)", dst, typeString(x.Type))
+		if _, ok := x.Type.(*codegen.NamedType); ok || isPrimitiveType(x.Type) {
+			g.emitRandom(out, "*"+dst, x.Type, schema, depth)
+		} else {
+			g.emitRandom(out, "(*"+dst+")", x.Type, schema, depth)
+		}
+
+	case *codegen.ArrayType:
+		g.emitRandomArray(out, dst, x, schema, depth)
+
+	case *codegen.MapType:
+		g.emitRandomMap(out, dst, x, schema, depth)
+
+	case *codegen.StructType:
+		g.emitRandomFields(out, dst, x, schema)
+
+	case codegen.PrimitiveType:
+		if expr, ok := g.randomPrimitive(x.Type, schema); ok {
+			out.Println("%s = %s", dst, expr)
+		}
+	}
+}
+
+// emitRandomArray emits code that assigns a random slice to dst, with
+// between minItems and maxItems items, or up to three more than minItems.
+// If the schema requires unique items, items are drawn until they are,
+// comparing them as JSON unless their type is comparable, giving up after
+// ten times as many draws as items.
+func (g *schemaGenerator) emitRandomArray(
+	out *codegen.Emitter, dst string, t *codegen.ArrayType, schema *schemas.Type, depth int) {
+	lo, hi := schema.MinItems, schema.MinItems+3
+	if isRecursive(t.Type) {
+		hi = schema.MinItems + 1
+	}
+	if schema.MaxItems != 0 && schema.MaxItems < hi {
+		hi = schema.MaxItems
+	}
+	if hi < lo {
+		hi = lo
+	}
+
+	i := fmt.Sprintf("i%d", depth)
+	if schema.UniqueItems {
+		item, seen, key := fmt.Sprintf("item%d", depth), fmt.Sprintf("seen%d", depth), fmt.Sprintf("key%d", depth)
+		keyType := "string"
+		if isComparableType(t.Type) {
+			keyType = typeString(t.Type)
+		} else {
+			g.output.file.Package.AddImport("encoding/json", "")
+		}
+		out.Println("%s = make(%s, 0, %s)", dst, typeString(t), randomIntn(lo, hi))
+		out.Println("for %s, %s := 0, map[%s]bool{}; len(%s) < cap(%s) && %s < 10*cap(%s); %s++ {",
+			i, seen, keyType, dst, dst, i, dst, i)
+		out.Indent(1)
+		out.Println("var %s %s", item, typeString(t.Type))
+		g.emitRandom(out, item, t.Type, schema.Items.All(), depth+1)
+		if isComparableType(t.Type) {
+			key = item
+		} else {
+			out.Println("b, _ := json.Marshal(%s)", item)
+			out.Println("%s := string(b)", key)
+		}
+		out.Println("if !%s[%s] {", seen, key)
+		out.Indent(1)
+		out.Println("%s[%s] = true", seen, key)
+		out.Println("%s = append(%s, %s)", dst, dst, item)
+		out.Indent(-1)
+		out.Println("}")
+		out.Indent(-1)
+		out.Println("}")
+		return
+	}
+
+	out.Println("%s = make(%s, %s)", dst, typeString(t), randomIntn(lo, hi))
+	if !g.canRandom(t.Type) {
+		return
+	}
+	out.Println("for %s := range %s {", i, dst)
+	out.Indent(1)
+	g.emitRandom(out, fmt.Sprintf("%s[%s]", dst, i), t.Type, schema.Items.All(), depth+1)
+	out.Indent(-1)
+	out.Println("}")
+}
+
+// emitRandomMap emits code that assigns a random map to dst, with between
+// minProperties and maxProperties entries, or up to two more than
+// minProperties. The keys are key0, key1 and so on.
+func (g *schemaGenerator) emitRandomMap(
+	out *codegen.Emitter, dst string, t *codegen.MapType, schema *schemas.Type, depth int) {
+	valueSchema, err := additionalPropertiesSchema(schema)
+	if err != nil {
+		g.warnf(schema, WarningSkipped, "Not generating random values of %s: %s", typeString(t), err)
+		return
+	}
+	lo, hi := schema.MinProperties, schema.MinProperties+2
+	if isRecursive(t.ValueType) {
+		hi = schema.MinProperties + 1
+	}
+	if schema.MaxProperties != 0 && schema.MaxProperties < hi {
+		hi = schema.MaxProperties
+	}
+	if hi < lo {
+		hi = lo
+	}
+
+	g.output.file.Package.AddImport("fmt", "")
+	n, i, value := fmt.Sprintf("n%d", depth), fmt.Sprintf("i%d", depth), fmt.Sprintf("value%d", depth)
+	out.Println("%s = %s{}", dst, typeString(t))
+	out.Println("for %s, %s := 0, %s; %s < %s; %s++ {", i, n, randomIntn(lo, hi), i, n, i)
+	out.Indent(1)
+	out.Println("var %s %s", value, typeString(t.ValueType))
+	g.emitRandom(out, value, t.ValueType, valueSchema, depth+1)
+	out.Println(`%s[fmt.Sprintf("key%%d", %s)] = %s`, dst, i, value)
+	out.Indent(-1)
+	out.Println("}")
+}
+
+// randomIntn returns an expression evaluating to a random int from lo to hi.
+func randomIntn(lo, hi int) string {
+	if lo == hi {
+		return strconv.Itoa(lo)
+	}
+	if lo == 0 {
+		return fmt.Sprintf("r.Intn(%d)", hi+1)
+	}
+	return fmt.Sprintf("%d + r.Intn(%d)", lo, hi-lo+1)
+}
+
+// randomPrimitive returns an expression evaluating to a random value of a
+// primitive type that is valid against a schema, or false if the type is
+// not one that values can be generated for.
+func (g *schemaGenerator) randomPrimitive(typeName string, schema *schemas.Type) (string, bool) {
+	switch typeName {
+	case "bool":
+		return "r.Intn(2) == 0", true
+	case "string":
+		return g.randomString(schema), true
+	case "float64", "float32":
+		return conversion(typeName, "float64", g.randomNumber(schema)), true
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		return conversion(typeName, "int64", g.randomInteger(schema, strings.HasPrefix(typeName, "u"))), true
+	case typeJSONNumber:
+		g.output.file.Package.AddImport("strconv", "")
+		if contains(schema.Type, schemas.TypeNameInteger) {
+			return fmt.Sprintf("%s(strconv.FormatInt(%s, 10))", typeJSONNumber, g.randomInteger(schema, false)), true
+		}
+		return fmt.Sprintf("%s(strconv.FormatFloat(%s, 'g', -1, 64))", typeJSONNumber, g.randomNumber(schema)), true
+	default:
+		return "", false
+	}
+}
+
+func conversion(typeName, exprType, expr string) string {
+	if typeName == exprType {
+		return expr
+	}
+	return fmt.Sprintf("%s(%s)", typeName, expr)
+}
+
+// randomString returns an expression evaluating to a random string that
+// matches the pattern of a schema, or that has its format, or else that has
+// between minLength and maxLength lowercase letters.
+func (g *schemaGenerator) randomString(schema *schemas.Type) string {
+	if schema.Pattern != "" {
+		if expr, ok := randomPatternMatch(schema.Pattern); ok {
+			g.declareRandomRunes()
+			return expr
+		}
+		g.warnf(schema, WarningRandomValues, "Random strings for pattern %q may not match it", schema.Pattern)
+	}
+
+	switch schema.Format {
+	case formatEmail, formatIDNEmail:
+		g.output.file.Package.AddImport("fmt", "")
+		return `fmt.Sprintf("user%d@example.com", r.Intn(1000))`
+	case formatHostname, "idn-hostname":
+		g.output.file.Package.AddImport("fmt", "")
+		return `fmt.Sprintf("host%d.example.com", r.Intn(1000))`
+	case "uri", "iri", "uri-reference", "iri-reference":
+		g.output.file.Package.AddImport("fmt", "")
+		return `fmt.Sprintf("https://example.com/%d", r.Intn(1000))`
+	case "uuid":
+		g.output.file.Package.AddImport("fmt", "")
+		return `fmt.Sprintf("%08x-%04x-4%03x-%x%03x-%012x", ` +
+			`r.Uint32(), r.Intn(1<<16), r.Intn(1<<12), 8+r.Intn(4), r.Intn(1<<12), r.Int63n(1<<48))`
+	case "ipv4":
+		g.output.file.Package.AddImport("fmt", "")
+		return `fmt.Sprintf("%d.%d.%d.%d", r.Intn(256), r.Intn(256), r.Intn(256), r.Intn(256))`
+	case "date-time", "date", "time":
+		g.output.file.Package.AddImport("time", "")
+		layout := map[string]string{
+			"date-time": "time.RFC3339",
+			"date":      `"2006-01-02"`,
+			"time":      `"15:04:05Z07:00"`,
+		}[schema.Format]
+		// Times up to the year 2100.
+		return fmt.Sprintf("time.Unix(r.Int63n(4102444800), 0).UTC().Format(%s)", layout)
+	case formatRegex:
+		return `"^[a-z]+$"`
+	}
+
+	lo, hi := schema.MinLength, schema.MinLength+10
+	if schema.MaxLength != nil && *schema.MaxLength < hi {
+		hi = *schema.MaxLength
+	}
+	if hi < lo {
+		hi = lo
+	}
+	g.declareRandomRunes()
+	return fmt.Sprintf("%s(r, 'a', 'z', %s)", funcNameRandomRunes, randomIntn(lo, hi))
+}
+
+// numberRange returns the bounds of the numbers that are valid against a
+// schema, and whether they are exclusive. A missing bound is
+// defaultRandomRange away from the other one, or from zero.
+func numberRange(schema *schemas.Type) (lo, hi float64, loExclusive, hiExclusive bool) {
+	hasLo, hasHi := false, false
+	if schema.Minimum != nil && (schema.ExclusiveMinimum == nil || !schema.ExclusiveMinimum.Exclusive) {
+		lo, hasLo = *schema.Minimum, true
+	}
+	if v := schema.ExclusiveMinimum.Bound(schema.Minimum); v != nil && (!hasLo || *v >= lo) {
+		lo, hasLo, loExclusive = *v, true, true
+	}
+	if schema.Maximum != nil && (schema.ExclusiveMaximum == nil || !schema.ExclusiveMaximum.Exclusive) {
+		hi, hasHi = *schema.Maximum, true
+	}
+	if v := schema.ExclusiveMaximum.Bound(schema.Maximum); v != nil && (!hasHi || *v <= hi) {
+		hi, hasHi, hiExclusive = *v, true, true
+	}
+
+	switch {
+	case !hasLo && !hasHi:
+		lo, hi = -defaultRandomRange, defaultRandomRange
+	case !hasLo:
+		lo = hi - defaultRandomRange
+	case !hasHi:
+		hi = lo + defaultRandomRange
+	}
+	return lo, hi, loExclusive, hiExclusive
+}
+
+// randomInteger returns an int64 expression evaluating to a random integer
+// that is valid against the bounds and multipleOf of a schema.
+func (g *schemaGenerator) randomInteger(schema *schemas.Type, unsigned bool) string {
+	loBound, hiBound, loExclusive, hiExclusive := numberRange(schema)
+	lo, hi := math.Ceil(loBound), math.Floor(hiBound)
+	if loExclusive && lo == loBound {
+		lo++
+	}
+	if hiExclusive && hi == hiBound {
+		hi--
+	}
+	if unsigned && lo < 0 {
+		lo = 0
+	}
+	// Keep the range, and so the argument of Int63n, from overflowing.
+	lo, hi = math.Max(lo, -(1<<53)), math.Min(hi, 1<<53)
+
+	step := 1.0
+	if m := schema.MultipleOf; m != nil && *m >= 1 && *m == math.Trunc(*m) {
+		step = *m
+		lo, hi = math.Ceil(lo/step), math.Floor(hi/step)
+	}
+	if hi < lo {
+		g.warnf(schema, WarningRandomValues,
+			"Random integers may be invalid, since no integer satisfies the bounds of %s", constraintSummary(schema))
+		hi = lo
+	}
+
+	var expr string
+	switch {
+	case lo == hi:
+		expr = fmt.Sprintf("int64(%s)", formatFloat(lo))
+	case lo == 0:
+		expr = fmt.Sprintf("r.Int63n(%s)", formatFloat(hi+1))
+	default:
+		expr = fmt.Sprintf("%s + r.Int63n(%s)", formatFloat(lo), formatFloat(hi-lo+1))
+	}
+	if step != 1 {
+		expr = fmt.Sprintf("(%s) * %s", expr, formatFloat(step))
+	}
+	return expr
+}
+
+// randomNumber returns a float64 expression evaluating to a random number
+// that is valid against the bounds and multipleOf of a schema.
+func (g *schemaGenerator) randomNumber(schema *schemas.Type) string {
+	lo, hi, loExclusive, hiExclusive := numberRange(schema)
+	if m := schema.MultipleOf; m != nil && *m > 0 {
+		klo, khi := math.Ceil(lo / *m), math.Floor(hi / *m)
+		if loExclusive && klo**m == lo {
+			klo++
+		}
+		if hiExclusive && khi**m == hi {
+			khi--
+		}
+		klo, khi = math.Max(klo, -(1<<53)), math.Min(khi, 1<<53)
+		if khi < klo {
+			g.warnf(schema, WarningRandomValues,
+				"Random numbers may be invalid, since no multiple satisfies the bounds of %s", constraintSummary(schema))
+			khi = klo
+		}
+		if klo == khi {
+			return formatFloat(klo * *m)
+		}
+		return fmt.Sprintf("float64(%s + r.Int63n(%s)) * %s", formatFloat(klo), formatFloat(khi-klo+1), formatFloat(*m))
+	}
+
+	if lo == hi {
+		return formatFloat(lo)
+	}
+	// Float64 is in [0, 1), so an exclusive lower bound is avoided by
+	// counting down from the upper bound, as long as that is inclusive.
+	if loExclusive && !hiExclusive {
+		return fmt.Sprintf("%s - r.Float64()*%s", formatFloat(hi), formatFloat(hi-lo))
+	}
+	if lo == 0 {
+		return fmt.Sprintf("r.Float64() * %s", formatFloat(hi))
+	}
+	return fmt.Sprintf("%s + r.Float64()*%s", formatFloat(lo), formatFloat(hi-lo))
+}
+
+// randomPatternMatch returns an expression evaluating to a random string that
+// matches a pattern, or false if the pattern is not one that it can build
+// strings for. Each character class becomes random characters from the
+// first of its printable ranges, alternations take their first alternative,
+// and repetitions are as short as they can be, except that repeated
+// character classes get up to two more characters.
+func randomPatternMatch(pattern string) (string, bool) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", false
+	}
+
+	var parts []string
+	var literal strings.Builder
+	flush := func() {
+		if literal.Len() > 0 {
+			parts = append(parts, strconv.Quote(literal.String()))
+			literal.Reset()
+		}
+	}
+	addRunes := func(class *syntax.Regexp, count string) bool {
+		lo, hi, ok := printableRange(class)
+		if !ok {
+			return false
+		}
+		flush()
+		parts = append(parts, fmt.Sprintf("%s(r, %s, %s, %s)",
+			funcNameRandomRunes, strconv.QuoteRune(lo), strconv.QuoteRune(hi), count))
+		return true
+	}
+
+	var build func(re *syntax.Regexp) bool
+	build = func(re *syntax.Regexp) bool {
+		switch re.Op {
+		case syntax.OpLiteral:
+			literal.WriteString(string(re.Rune))
+		case syntax.OpCharClass, syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+			return addRunes(re, "1")
+		case syntax.OpCapture:
+			return build(re.Sub[0])
+		case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+			least, most := re.Min, re.Max
+			switch re.Op {
+			case syntax.OpStar:
+				least, most = 0, -1
+			case syntax.OpPlus:
+				least, most = 1, -1
+			case syntax.OpQuest:
+				least, most = 0, 1
+			}
+			if sub := re.Sub[0]; isCharClass(sub) {
+				extra := 2
+				if most != -1 && most-least < extra {
+					extra = most - least
+				}
+				return addRunes(sub, randomIntn(least, least+extra))
+			}
+			for i := 0; i < least; i++ {
+				if !build(re.Sub[0]) {
+					return false
+				}
+			}
+		case syntax.OpConcat:
+			for _, sub := range re.Sub {
+				if !build(sub) {
+					return false
+				}
+			}
+		case syntax.OpAlternate:
+			return build(re.Sub[0])
+		case syntax.OpNoMatch:
+			return false
+		}
+		// Empty matches and anchors add nothing.
+		return true
+	}
+	if !build(re) {
+		return "", false
+	}
+	flush()
+	if len(parts) == 0 {
+		return `""`, true
+	}
+	return strings.Join(parts, " + "), true
+}
+
+func isCharClass(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpCharClass, syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return true
+	default:
+		return false
+	}
+}
+
+// printableRange returns the first range of printable ASCII characters that
+// a character class matches, or the first range it matches if there is none.
+func printableRange(class *syntax.Regexp) (rune, rune, bool) {
+	if class.Op != syntax.OpCharClass {
+		return 'a', 'z', true
+	}
+	if len(class.Rune) == 0 {
+		return 0, 0, false
+	}
+	for i := 0; i+1 < len(class.Rune); i += 2 {
+		lo, hi := class.Rune[i], class.Rune[i+1]
+		if lo < '!' {
+			lo = '!'
+		}
+		if hi > '~' {
+			hi = '~'
+		}
+		if lo <= hi {
+			return lo, hi, true
+		}
+	}
+	lo := class.Rune[0]
+	return lo, lo, unicode.IsPrint(lo)
+}
+
+// declareRandomRunes declares the function that random strings are built
+// from.
+func (g *schemaGenerator) declareRandomRunes() {
+	if g.output.funcsByName[funcNameRandomRunes] {
+		return
+	}
+	g.output.funcsByName[funcNameRandomRunes] = true
+	g.output.addHelper(funcNameRandomRunes, &codegen.Method{
+		Impl: func(out *codegen.Emitter) {
+			out.Comment(fmt.Sprintf("%s returns a string of n random characters from lo to hi.",
+				funcNameRandomRunes))
+			out.Println("func %s(r *rand.Rand, lo, hi rune, n int) string {", funcNameRandomRunes)
+			out.Indent(1)
+			out.Println("s := make([]rune, n)")
+			out.Println("for i := range s {")
+			out.Indent(1)
+			out.Println("s[i] = lo + rune(r.Intn(int(hi-lo)+1))")
+			out.Indent(-1)
+			out.Println("}")
+			out.Println("return string(s)")
+			out.Indent(-1)
+			out.Println("}")
+		},
+	})
+}
+
+// canRandom reports whether emitRandom emits code that assigns values of t,
+// rather than leaving them zero.
+func (g *schemaGenerator) canRandom(t codegen.Type) bool {
+	if at, ok := asArrayType(t); ok {
+		t = at
+	}
+	switch x := t.(type) {
+	case *codegen.NamedType:
+		return g.hasRandom(x)
+	case *codegen.PointerType, *codegen.ArrayType, *codegen.MapType, *codegen.StructType:
+		return true
+	case codegen.PrimitiveType:
+		return isRandomPrimitive(x.Type)
+	default:
+		return false
+	}
+}
+
+// isRandomPrimitive reports whether randomPrimitive can generate values of a
+// primitive type.
+func isRandomPrimitive(typeName string) bool {
+	switch typeName {
+	case "bool", "string", "float64", "float32", typeJSONNumber,
+		"int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		return true
+	default:
+		return false
+	}
+}
+
+// hasRandom reports whether values of t can be generated at random. Types
+// declared in other packages are assumed to have generators if they are
+// declared from schemas.
+func (g *schemaGenerator) hasRandom(t *codegen.NamedType) bool {
+	if t.Package != nil {
+		return t.Decl.Type != nil
+	}
+	return g.output.randomDecls[t.Decl]
+}
+
+// isRecursive reports whether values of t may hold values of a named type
+// that may hold values of itself, such as the nodes of a tree, in which case
+// fewer optional values are generated so that random values stay small.
+func isRecursive(t codegen.Type) bool {
+	reachable := map[*codegen.TypeDecl]bool{}
+	reachableDecls(t, reachable)
+	for decl := range reachable {
+		fromDecl := map[*codegen.TypeDecl]bool{}
+		reachableDecls(decl.Type, fromDecl)
+		if fromDecl[decl] {
+			return true
+		}
+	}
+	return false
+}
+
+// reachableDecls adds the declarations of the named types that values of t
+// may hold to seen.
+func reachableDecls(t codegen.Type, seen map[*codegen.TypeDecl]bool) {
+	if at, ok := asArrayType(t); ok {
+		t = at
+	}
+	switch x := t.(type) {
+	case *codegen.NamedType:
+		if !seen[x.Decl] {
+			seen[x.Decl] = true
+			reachableDecls(x.Decl.Type, seen)
+		}
+	case *codegen.PointerType:
+		reachableDecls(x.Type, seen)
+	case *codegen.ArrayType:
+		reachableDecls(x.Type, seen)
+	case *codegen.MapType:
+		reachableDecls(x.ValueType, seen)
+	case *codegen.StructType:
+		for _, f := range x.Fields {
+			reachableDecls(f.Type, seen)
+		}
+	}
+}
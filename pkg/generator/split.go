@@ -0,0 +1,161 @@
+package generator
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/codegen"
+)
+
+// splitFile returns the files that an output's file is split into with
+// Config.SplitFiles. Each top-level type goes in a file with the types that
+// it refers to, unless they are top-level themselves or another top-level
+// type that sorts before it refers to them too, and with the declarations
+// that those types own. The rest stay in the output file itself, which is
+// left out if nothing does.
+func (g *Generator) splitFile(o *output, file *codegen.File) []*codegen.File {
+	pkg := &file.Package
+	var roots []*codegen.TypeDecl
+	declared := map[*codegen.TypeDecl]bool{}
+	byName := map[string]*codegen.TypeDecl{}
+	for _, d := range pkg.Decls {
+		if td, ok := d.(*codegen.TypeDecl); ok {
+			declared[td] = true
+			byName[td.Name] = td
+			if o.topLevel[td] {
+				roots = append(roots, td)
+			}
+		}
+	}
+	if len(roots) == 0 {
+		return []*codegen.File{file}
+	}
+	sort.Slice(roots, func(i, j int) bool {
+		return roots[i].Name < roots[j].Name
+	})
+
+	// Nested types go with the first top-level type that reaches them.
+	rootOf := map[*codegen.TypeDecl]*codegen.TypeDecl{}
+	for _, root := range roots {
+		rootOf[root] = root
+	}
+	for _, root := range roots {
+		queue := []*codegen.TypeDecl{root}
+		for len(queue) > 0 {
+			td := queue[0]
+			queue = queue[1:]
+			for _, ref := range codegen.ReferencedTypeDecls(td.Type) {
+				if _, ok := rootOf[ref]; !ok && declared[ref] {
+					rootOf[ref] = root
+					queue = append(queue, ref)
+				}
+			}
+		}
+	}
+
+	chunkOf := map[*codegen.TypeDecl]int{}
+	var names []string
+	base := strings.TrimSuffix(file.FileName, ".go")
+	if g.config.TypesPerFile > 0 {
+		for i, root := range roots {
+			chunk := i / g.config.TypesPerFile
+			chunkOf[root] = chunk
+			if chunk == len(names) {
+				names = append(names, fmt.Sprintf("%s_%d.go", base, chunk+1))
+			}
+		}
+	} else {
+		taken := map[string]bool{}
+		for i, root := range roots {
+			name := base + "_" + strings.ToLower(root.Name) + ".go"
+			for n := 2; taken[name]; n++ {
+				name = fmt.Sprintf("%s_%s_%d.go", base, strings.ToLower(root.Name), n)
+			}
+			taken[name] = true
+			chunkOf[root] = i
+			names = append(names, name)
+		}
+	}
+
+	files := make([]*codegen.File, len(names))
+	for i, name := range names {
+		files[i] = &codegen.File{
+			FileName: name,
+			Package: codegen.Package{
+				QualifiedName: pkg.QualifiedName,
+				Imports:       append([]codegen.Import(nil), pkg.Imports...),
+			},
+		}
+	}
+	rest := &codegen.File{
+		FileName: file.FileName,
+		Package: codegen.Package{
+			QualifiedName: pkg.QualifiedName,
+			Comment:       pkg.Comment,
+			Imports:       append([]codegen.Import(nil), pkg.Imports...),
+		},
+	}
+	for _, d := range pkg.Decls {
+		var td *codegen.TypeDecl
+		switch d := d.(type) {
+		case *codegen.TypeDecl:
+			td = d
+		case codegen.Owned:
+			td = byName[d.OwnerName()]
+		}
+		if root, ok := rootOf[td]; ok && td != nil {
+			files[chunkOf[root]].Package.AddDecl(d)
+		} else {
+			rest.Package.AddDecl(d)
+		}
+	}
+	if len(rest.Package.Decls) > 0 || rest.Package.Comment != "" {
+		files = append([]*codegen.File{rest}, files...)
+	}
+	return files
+}
+
+// usedImports returns the imports that a generated file uses, as the files
+// that an output is split into start with all of the output's imports.
+// Imports whose package names cannot be told from their paths are kept.
+func usedImports(file *ast.File, imports []codegen.Import) []codegen.Import {
+	used := map[string]bool{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok {
+				used[id.Name] = true
+			}
+		}
+		return true
+	})
+
+	var kept []codegen.Import
+	for _, i := range imports {
+		if name, ok := importName(i); !ok || used[name] {
+			kept = append(kept, i)
+		}
+	}
+	return kept
+}
+
+var majorVersionElement = regexp.MustCompile(`^v[0-9]+$`)
+
+// importName returns the name that an import is used by, if it can be told
+// from the import alone: its alias, or else the last element of its path
+// other than a major version.
+func importName(i codegen.Import) (string, bool) {
+	if i.Name != "" {
+		return i.Name, i.Name != "_" && i.Name != "."
+	}
+	p := i.QualifiedName
+	name := path.Base(p)
+	if majorVersionElement.MatchString(name) && path.Dir(p) != "." {
+		name = path.Base(path.Dir(p))
+	}
+	return name, token.IsIdentifier(name)
+}
@@ -0,0 +1,102 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/codegen"
+)
+
+// generateEnumSQLMethods declares Scan and Value methods for an enum, so that
+// it implements sql.Scanner and driver.Valuer. Scanned values are validated
+// like unmarshaled ones, and values like marshaled ones.
+//
+// Fields with a format get no such methods: they are strings, which
+// database/sql stores as they are, unless a FormatMapping gives them a type
+// from another package, which cannot be given methods here.
+func (g *schemaGenerator) generateEnumSQLMethods(
+	enumDecl *codegen.TypeDecl, enumType codegen.PrimitiveType, values []interface{}) {
+	var check enumCheck
+	if enumType.Type != "string" {
+		check = g.newEnumCheck(enumDecl, &enumType, values)
+	}
+
+	var valueExpr string
+	switch enumType.Type {
+	case "string":
+		valueExpr = "string(j)"
+	case "bool":
+		valueExpr = "bool(j)"
+	case "float32", "float64":
+		valueExpr = "float64(j)"
+	default:
+		valueExpr = "int64(j)"
+	}
+
+	g.output.file.Package.AddImport("database/sql/driver", "")
+	g.output.file.Package.AddImport("fmt", "")
+	g.output.file.Package.AddDecl(&codegen.Method{
+		Owner: enumDecl.Name,
+		Impl: func(out *codegen.Emitter) {
+			out.Comment("Scan implements sql.Scanner.")
+			out.Println("func (j *%s) Scan(src interface{}) error {", enumDecl.Name)
+			out.Indent(1)
+			if enumType.Type == "string" {
+				out.Println("switch v := src.(type) {")
+				out.Println("case string:")
+				out.Indent(1)
+				out.Println("return j.UnmarshalText([]byte(v))")
+				out.Indent(-1)
+				out.Println("case []byte:")
+				out.Indent(1)
+				out.Println("return j.UnmarshalText(v)")
+				out.Indent(-1)
+				out.Println("default:")
+				out.Indent(1)
+				out.Println("return fmt.Errorf(\"cannot scan %%T into %s\", src)", enumDecl.Name)
+				out.Indent(-1)
+				out.Println("}")
+			} else {
+				out.Println("var b []byte")
+				out.Println("switch v := src.(type) {")
+				out.Println("case string:")
+				out.Indent(1)
+				out.Println("b = []byte(v)")
+				out.Indent(-1)
+				out.Println("case []byte:")
+				out.Indent(1)
+				out.Println("b = v")
+				out.Indent(-1)
+				out.Println("case int64, float64, bool:")
+				out.Indent(1)
+				out.Println("b = []byte(fmt.Sprint(v))")
+				out.Indent(-1)
+				out.Println("default:")
+				out.Indent(1)
+				out.Println("return fmt.Errorf(\"cannot scan %%T into %s\", src)", enumDecl.Name)
+				out.Indent(-1)
+				out.Println("}")
+				out.Println("return j.UnmarshalJSON(b)")
+			}
+			out.Indent(-1)
+			out.Println("}")
+			out.Newline()
+
+			out.Comment("Value implements driver.Valuer.")
+			out.Println("func (j %s) Value() (driver.Value, error) {", enumDecl.Name)
+			out.Indent(1)
+			if enumType.Type == "string" {
+				out.Println("if !j.IsValid() {")
+				out.Indent(1)
+				out.Println("return nil, %s", validationError(jsonPointer("", nil), "enum",
+					"invalid value (expected one of %#v): %#v", "enumValues_"+enumDecl.Name, "j"))
+				out.Indent(-1)
+				out.Println("}")
+			} else {
+				emitEnumCheck(out, check, fmt.Sprintf("%s(%s)", enumType.Type, varNameReceiver), returnMarshalError)
+			}
+			out.Println("return %s, nil", valueExpr)
+			out.Indent(-1)
+			out.Println("}")
+		},
+	})
+}
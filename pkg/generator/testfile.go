@@ -0,0 +1,210 @@
+package generator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/codegen"
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/schemas"
+)
+
+// testCase is a JSON document that a generated test unmarshals into a type.
+// Round-trip tests check that marshaling it again is stable, and fuzz
+// targets use it as seed input.
+type testCase struct {
+	name     string
+	typeName string
+	data     string
+}
+
+// addTestCases records the examples and the default declared by the schema
+// of a type as test cases. Structs whose properties declare defaults, and
+// which have no required properties, are also tested with an empty object,
+// to which the defaults are applied. Structs and enums are recorded as fuzz
+// targets, since they are the types that validate their input.
+func (g *schemaGenerator) addTestCases(declName string, t *schemas.Type, theType codegen.Type) {
+	add := func(name string, value interface{}) {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(value); err != nil {
+			g.warnf(t, WarningSkipped, "Not generating a test case for %s: %s", name, err)
+			return
+		}
+		g.output.testCases = append(g.output.testCases, testCase{
+			name:     name,
+			typeName: declName,
+			data:     strings.TrimSuffix(buf.String(), "\n"),
+		})
+	}
+
+	for i, example := range t.Examples {
+		add(fmt.Sprintf("%s/examples/%d", declName, i), example)
+	}
+	if t.Default != nil {
+		add(declName+"/default", t.Default)
+	}
+	st, isStruct := theType.(*codegen.StructType)
+	if isStruct && len(st.RequiredJSONFields) == 0 && t.MinProperties == 0 && hasDefaults(st, nil) {
+		add(declName+"/defaults", map[string]interface{}{})
+	}
+	if isStruct || t.Enum != nil {
+		g.output.fuzzTypes = append(g.output.fuzzTypes, declName)
+	}
+}
+
+// hasTests returns whether a test file should be generated for an output.
+func (g *Generator) hasTests(o *output) bool {
+	return (g.config.RoundTripTests && len(o.testCases) > 0) ||
+		(g.config.FuzzTests && len(o.fuzzTypes) > 0)
+}
+
+// testFile returns a test file to be written next to the file of an output,
+// with a table-driven round-trip test of its test cases and a fuzz target
+// for each of its structs and enums, depending on the configuration.
+func (g *Generator) testFile(o *output) *codegen.File {
+	base := strings.TrimSuffix(o.file.FileName, ".go")
+	file := &codegen.File{
+		FileName: base + "_test.go",
+		Package:  codegen.Package{QualifiedName: o.file.Package.QualifiedName},
+	}
+	roundTrip := g.config.RoundTripTests && len(o.testCases) > 0
+	if roundTrip {
+		file.Package.AddImport("bytes", "")
+	}
+	file.Package.AddImport("encoding/json", "")
+	file.Package.AddImport("testing", "")
+
+	if roundTrip {
+		file.Package.AddDecl(roundTripTest("TestRoundTrip"+g.identifierize(filepath.Base(base)), o.testCases))
+	}
+	if g.config.FuzzTests {
+		for _, typeName := range o.fuzzTypes {
+			var seeds []testCase
+			for _, c := range o.testCases {
+				if c.typeName == typeName {
+					seeds = append(seeds, c)
+				}
+			}
+			file.Package.AddDecl(fuzzTarget(typeName, seeds))
+		}
+	}
+	return file
+}
+
+// roundTripTest returns a table-driven test that unmarshals each test case,
+// and checks that marshaling the value, unmarshaling the result and
+// marshaling it again gives the same JSON.
+func roundTripTest(testName string, cases []testCase) *codegen.Method {
+	return &codegen.Method{
+		Impl: func(out *codegen.Emitter) {
+			out.Comment(fmt.Sprintf("%s unmarshals the examples and defaults declared in the schema, "+
+				"and checks that marshaling them is stable.", testName))
+			out.Println("func %s(t *testing.T) {", testName)
+			out.Indent(1)
+			out.Println("tests := []struct {")
+			out.Indent(1)
+			out.Println("name  string")
+			out.Println("data  string")
+			out.Println("value func() interface{}")
+			out.Indent(-1)
+			out.Println("}{")
+			out.Indent(1)
+			for _, c := range cases {
+				out.Println("{")
+				out.Indent(1)
+				out.Println("name:  %q,", c.name)
+				out.Println("data:  %s,", goStringLiteral(c.data))
+				out.Println("value: func() interface{} { return new(%s) },", c.typeName)
+				out.Indent(-1)
+				out.Println("},")
+			}
+			out.Indent(-1)
+			out.Println("}")
+			out.Println("for _, tt := range tests {")
+			out.Indent(1)
+			out.Println("t.Run(tt.name, func(t *testing.T) {")
+			out.Indent(1)
+			out.Println("v := tt.value()")
+			out.Println("if err := json.Unmarshal([]byte(tt.data), v); err != nil {")
+			out.Indent(1)
+			out.Println(`t.Fatalf("unmarshaling %%s: %%v", tt.data, err)`)
+			out.Indent(-1)
+			out.Println("}")
+			out.Println("b1, err := json.Marshal(v)")
+			out.Println("if err != nil {")
+			out.Indent(1)
+			out.Println(`t.Fatalf("marshaling: %%v", err)`)
+			out.Indent(-1)
+			out.Println("}")
+			out.Println("w := tt.value()")
+			out.Println("if err := json.Unmarshal(b1, w); err != nil {")
+			out.Indent(1)
+			out.Println(`t.Fatalf("unmarshaling %%s: %%v", b1, err)`)
+			out.Indent(-1)
+			out.Println("}")
+			out.Println("b2, err := json.Marshal(w)")
+			out.Println("if err != nil {")
+			out.Indent(1)
+			out.Println(`t.Fatalf("marshaling: %%v", err)`)
+			out.Indent(-1)
+			out.Println("}")
+			out.Println("if !bytes.Equal(b1, b2) {")
+			out.Indent(1)
+			out.Println(`t.Errorf("marshaled %%s after a round trip, want %%s", b2, b1)`)
+			out.Indent(-1)
+			out.Println("}")
+			out.Indent(-1)
+			out.Println("})")
+			out.Indent(-1)
+			out.Println("}")
+			out.Indent(-1)
+			out.Println("}")
+		},
+	}
+}
+
+// fuzzTarget returns a fuzz target that unmarshals arbitrary input into a
+// type, seeded with its test cases. Unmarshaling must not panic, and any
+// value that it accepts must be accepted again after being marshaled.
+func fuzzTarget(typeName string, seeds []testCase) *codegen.Method {
+	funcName := "Fuzz" + typeName + "Unmarshal"
+	return &codegen.Method{
+		Impl: func(out *codegen.Emitter) {
+			out.Comment(fmt.Sprintf("%s checks that unmarshaling arbitrary input into %s does not panic, "+
+				"and that the values it accepts are accepted again after being marshaled.", funcName, typeName))
+			out.Println("func %s(f *testing.F) {", funcName)
+			out.Indent(1)
+			for _, c := range seeds {
+				out.Println("f.Add([]byte(%s))", goStringLiteral(c.data))
+			}
+			out.Println("f.Fuzz(func(t *testing.T, data []byte) {")
+			out.Indent(1)
+			out.Println("var v %s", typeName)
+			out.Println("if err := json.Unmarshal(data, &v); err != nil {")
+			out.Indent(1)
+			out.Println("return")
+			out.Indent(-1)
+			out.Println("}")
+			out.Println("b, err := json.Marshal(&v)")
+			out.Println("if err != nil {")
+			out.Indent(1)
+			out.Println(`t.Fatalf("marshaling: %%v", err)`)
+			out.Indent(-1)
+			out.Println("}")
+			out.Println("var w %s", typeName)
+			out.Println("if err := json.Unmarshal(b, &w); err != nil {")
+			out.Indent(1)
+			out.Println(`t.Fatalf("unmarshaling %%s: %%v", b, err)`)
+			out.Indent(-1)
+			out.Println("}")
+			out.Indent(-1)
+			out.Println("})")
+			out.Indent(-1)
+			out.Println("}")
+		},
+	}
+}
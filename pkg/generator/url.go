@@ -0,0 +1,99 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/schemas"
+)
+
+// DoURL generates types for the schema at an HTTP or HTTPS URL, such as one
+// on schemastore.org. The schemas that it refers to by relative references,
+// or by URL, are fetched too.
+func (g *Generator) DoURL(ctx context.Context, schemaURL string) error {
+	defer g.withContext(ctx)()
+
+	schema, err := g.fetchSchema(schemaURL)
+	if err != nil {
+		return fmt.Errorf("error fetching %s: %w", schemaURL, err)
+	}
+	g.schemaCacheByFileName[schemaURL] = schema
+	return g.addFile(schemaURL, schema)
+}
+
+// loadSchemaFromURL fetches a schema that another one refers to, unless it
+// has been loaded already.
+func (g *Generator) loadSchemaFromURL(schemaURL string) (*schemas.Schema, error) {
+	if schema, ok := g.schemaCacheByFileName[schemaURL]; ok {
+		return schema, nil
+	}
+
+	schema, err := g.fetchSchema(schemaURL)
+	if err != nil {
+		return nil, err
+	}
+	g.schemaCacheByFileName[schemaURL] = schema
+
+	if err = g.addReferencedFile(schemaURL, schema); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+func (g *Generator) fetchSchema(schemaURL string) (*schemas.Schema, error) {
+	body, err := g.fetch(schemaURL)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = body.Close()
+	}()
+	return g.parse(strings.SplitN(schemaURL, "?", 2)[0], body)
+}
+
+// fetch returns the body of a successful GET request for a URL.
+func (g *Generator) fetch(u string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(g.context(), http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// schemaURL returns the URL of a schema that is referred to from another
+// one, if either the reference or the referring schema's file name is an
+// HTTP or HTTPS URL.
+func schemaURL(ref, parentFileName string) (string, bool) {
+	if isHTTPURL(ref) {
+		return ref, true
+	}
+	if !isHTTPURL(parentFileName) {
+		return "", false
+	}
+	base, err := url.Parse(parentFileName)
+	if err != nil {
+		return "", false
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return "", false
+	}
+	return base.ResolveReference(u).String(), true
+}
+
+func isHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -2,8 +2,13 @@ package generator
 
 import (
 	"crypto/sha256"
+	"encoding/json"
 	"fmt"
+	"math"
+	"reflect"
 	"sort"
+	"strconv"
+	"strings"
 	"unicode"
 
 	"github.com/lets-dev-it-out/go-jsonschema/pkg/codegen"
@@ -24,6 +29,21 @@ func hashArrayOfValues(values []interface{}) string {
 	return fmt.Sprintf("%x", h.Sum(nil))
 }
 
+// hashSchema returns the hash of a schema in a file, which is the same for
+// schemas with the same keywords in the same file, where their references
+// resolve to the same schemas.
+func hashSchema(fileName string, t *schemas.Type) (string, error) {
+	b, err := json.Marshal(t)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	h.Write([]byte(fileName))
+	h.Write([]byte{0})
+	h.Write(b)
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
 func splitIdentifierByCaseAndSeparators(s string) []string {
 	if len(s) == 0 {
 		return nil
@@ -100,3 +120,434 @@ func isNamedType(t codegen.Type) bool {
 	}
 	return false
 }
+
+// isComparableType reports whether values of t can be compared with ==
+// without risk of a run-time panic.
+func isComparableType(t codegen.Type) bool {
+	switch x := t.(type) {
+	case codegen.PrimitiveType:
+		return true
+	case *codegen.PointerType:
+		return false
+	case *codegen.NamedType:
+		return x.Decl.Type != nil && isComparableType(x.Decl.Type)
+	default:
+		return false
+	}
+}
+
+// isNullableType reports whether t is declared as null or exactly one other type.
+func isNullableType(t *schemas.Type) bool {
+	return len(t.Type) == 2 && contains(t.Type, schemas.TypeNameNull) &&
+		!(t.Type[0] == schemas.TypeNameNull && t.Type[1] == schemas.TypeNameNull)
+}
+
+// constraintSummary describes the validation keywords of a schema, such as
+// "Minimum: 1, Maximum: 65535", for the doc comment of a field. It returns
+// an empty string if the schema declares none.
+func constraintSummary(t *schemas.Type) string {
+	var parts []string
+	add := func(format string, args ...interface{}) {
+		parts = append(parts, fmt.Sprintf(format, args...))
+	}
+
+	if t.Minimum != nil && (t.ExclusiveMinimum == nil || !t.ExclusiveMinimum.Exclusive) {
+		add("Minimum: %s", formatNumber(*t.Minimum))
+	}
+	if v := t.ExclusiveMinimum.Bound(t.Minimum); v != nil {
+		add("Exclusive minimum: %s", formatNumber(*v))
+	}
+	if t.Maximum != nil && (t.ExclusiveMaximum == nil || !t.ExclusiveMaximum.Exclusive) {
+		add("Maximum: %s", formatNumber(*t.Maximum))
+	}
+	if v := t.ExclusiveMaximum.Bound(t.Maximum); v != nil {
+		add("Exclusive maximum: %s", formatNumber(*v))
+	}
+	if t.MultipleOf != nil {
+		add("Multiple of: %s", formatNumber(*t.MultipleOf))
+	}
+	if t.MinLength != 0 {
+		add("Min length: %d", t.MinLength)
+	}
+	if t.MaxLength != nil {
+		add("Max length: %d", *t.MaxLength)
+	}
+	if t.Pattern != "" {
+		add("Pattern: %s", t.Pattern)
+	}
+	if t.Format != "" {
+		add("Format: %s", t.Format)
+	}
+	if t.MinItems != 0 {
+		add("Min items: %d", t.MinItems)
+	}
+	if t.MaxItems != 0 {
+		add("Max items: %d", t.MaxItems)
+	}
+	if t.UniqueItems {
+		add("Unique items")
+	}
+	if t.MinProperties != 0 {
+		add("Min properties: %d", t.MinProperties)
+	}
+	if t.MaxProperties != 0 {
+		add("Max properties: %d", t.MaxProperties)
+	}
+	if len(t.Enum) > 0 {
+		values := make([]string, 0, len(t.Enum))
+		for _, v := range t.Enum {
+			if len(values) == maxSummaryEnumValues {
+				values = append(values, fmt.Sprintf("and %d more", len(t.Enum)-maxSummaryEnumValues))
+				break
+			}
+			if b, err := json.Marshal(v); err == nil {
+				values = append(values, string(b))
+			}
+		}
+		add("Enum: %s", strings.Join(values, ", "))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// maxSummaryEnumValues is the number of enum values that constraintSummary
+// lists before it elides the rest.
+const maxSummaryEnumValues = 10
+
+// formatNumber formats a number from a schema without an exponent.
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// isEmptySchema reports whether t declares no keywords at all.
+func isEmptySchema(t *schemas.Type) bool {
+	return reflect.DeepEqual(*t, schemas.Type{})
+}
+
+// isRequiredOnly reports whether t declares nothing but required properties.
+func isRequiredOnly(t *schemas.Type) bool {
+	rest := *t
+	rest.Required = nil
+	return len(t.Required) > 0 && isEmptySchema(&rest)
+}
+
+// hasNestedDefaults reports whether t is an object type, or a pointer to one,
+// that declares defaults for any of its properties, or for the properties of
+// objects nested within it, and that can be unmarshaled from an empty object
+// because it has no required properties.
+func hasNestedDefaults(t codegen.Type, seen map[*codegen.TypeDecl]bool) bool {
+	if p, ok := t.(*codegen.PointerType); ok {
+		t = p.Type
+	}
+	nt, ok := t.(*codegen.NamedType)
+	if !ok || nt.Decl.Type == nil || seen[nt.Decl] {
+		return false
+	}
+	st, ok := nt.Decl.Type.(*codegen.StructType)
+	if !ok || len(st.RequiredJSONFields) > 0 {
+		return false
+	}
+	if seen == nil {
+		seen = map[*codegen.TypeDecl]bool{}
+	}
+	seen[nt.Decl] = true
+	for _, f := range st.Fields {
+		if f.DefaultValue != nil || hasNestedDefaults(f.Type, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasDefaults reports whether a struct declares defaults for any of its
+// fields, or for the fields of structs nested within it that are not
+// pointers, so that a constructor is generated for it.
+func hasDefaults(st *codegen.StructType, seen map[*codegen.StructType]bool) bool {
+	if seen[st] {
+		return false
+	}
+	if seen == nil {
+		seen = map[*codegen.StructType]bool{}
+	}
+	seen[st] = true
+	for _, f := range st.Fields {
+		if f.DefaultValue != nil {
+			return true
+		}
+		if nt, ok := f.Type.(*codegen.NamedType); ok && nt.Package == nil && nt.Decl.Type != nil {
+			if nested, ok := nt.Decl.Type.(*codegen.StructType); ok && hasDefaults(nested, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isDefaultedStruct reports whether t is a struct with a generated
+// constructor.
+func isDefaultedStruct(t *codegen.NamedType) bool {
+	if t.Package != nil || t.Decl.Type == nil {
+		return false
+	}
+	st, ok := t.Decl.Type.(*codegen.StructType)
+	return ok && hasDefaults(st, nil)
+}
+
+func constructorName(t *codegen.NamedType) string {
+	return "New" + t.Decl.Name
+}
+
+// asArrayType returns t as an array type, which is declared either as a value
+// or as a pointer.
+func asArrayType(t codegen.Type) (*codegen.ArrayType, bool) {
+	switch x := t.(type) {
+	case *codegen.ArrayType:
+		return x, true
+	case codegen.ArrayType:
+		return &x, true
+	default:
+		return nil, false
+	}
+}
+
+// isPrimitiveType reports whether t is a primitive type, or a named type
+// declared as one.
+func isPrimitiveType(t codegen.Type) bool {
+	switch x := t.(type) {
+	case codegen.PrimitiveType:
+		return true
+	case *codegen.NamedType:
+		return x.Decl.Type != nil && isPrimitiveType(x.Decl.Type)
+	default:
+		return false
+	}
+}
+
+// zeroValue returns an expression evaluating to the zero value of t.
+func zeroValue(t codegen.Type) string {
+	if t.IsNillable() {
+		return "nil"
+	}
+	switch x := t.(type) {
+	case codegen.PrimitiveType:
+		switch x.Type {
+		case "string", typeJSONNumber:
+			return `""`
+		case "bool":
+			return "false"
+		case "int", "int32", "int64", "uint32", "uint64", "float64":
+			return "0"
+		}
+	case *codegen.NamedType:
+		if x.Decl.Type != nil && isPrimitiveType(x.Decl.Type) {
+			return zeroValue(x.Decl.Type)
+		}
+		if _, ok := x.Decl.Type.(*codegen.StructType); ok {
+			return typeString(x) + "{}"
+		}
+	}
+	return fmt.Sprintf("*new(%s)", typeString(t))
+}
+
+// primitiveLiteral returns a Go literal of a primitive type for a value
+// decoded from JSON, or false if the value does not have that type.
+func primitiveLiteral(typeName string, value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return strconv.Quote(v), typeName == "string"
+	case bool:
+		return strconv.FormatBool(v), typeName == "bool"
+	case json.Number:
+		switch typeName {
+		case typeJSONNumber:
+			return fmt.Sprintf("%s(%q)", typeJSONNumber, v.String()), true
+		case "int", "int32", "int64":
+			if i, err := strconv.ParseInt(v.String(), 10, 64); err == nil {
+				return strconv.FormatInt(i, 10), true
+			}
+		case "uint32", "uint64":
+			if u, err := strconv.ParseUint(v.String(), 10, 64); err == nil {
+				return strconv.FormatUint(u, 10), true
+			}
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return "", false
+		}
+		return primitiveLiteral(typeName, f)
+	case float64:
+		switch typeName {
+		case "float64":
+			return strconv.FormatFloat(v, 'f', -1, 64), true
+		case typeJSONNumber:
+			return fmt.Sprintf("%s(%q)", typeJSONNumber, strconv.FormatFloat(v, 'f', -1, 64)), true
+		case "int", "int32", "int64":
+			return strconv.FormatInt(int64(v), 10), v == math.Trunc(v)
+		case "uint32", "uint64":
+			return strconv.FormatUint(uint64(v), 10), v == math.Trunc(v) && v >= 0
+		}
+	}
+	return "", false
+}
+
+// floatNumbers returns a value decoded from JSON with the json.Number values
+// in it converted to float64, as they are decoded into interface{} values.
+func floatNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+	case []interface{}:
+		values := make([]interface{}, len(v))
+		for i, item := range v {
+			values[i] = floatNumbers(item)
+		}
+		return values
+	case map[string]interface{}:
+		values := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			values[k] = floatNumbers(item)
+		}
+		return values
+	}
+	return value
+}
+
+// typeString returns the Go source of a type.
+func typeString(t codegen.Type) string {
+	out := codegen.NewEmitter(80)
+	t.Generate(out)
+	return out.String()
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// hasExtrasField reports whether a struct has the field that holds the
+// properties that its schema does not declare.
+func hasExtrasField(structType *codegen.StructType) bool {
+	for _, f := range structType.Fields {
+		if f.Name == fieldNameExtras && f.JSONName == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsAdditionalProperties reports whether an object schema explicitly
+// allows properties that it does not declare, with "additionalProperties"
+// set to true or to a schema.
+func allowsAdditionalProperties(t *schemas.Type) bool {
+	if t.AdditionalProperties == nil {
+		return false
+	}
+	allowed, ok := (*t.AdditionalProperties).(bool)
+	return !ok || allowed
+}
+
+// additionalPropertiesSchema returns the schema that additionalProperties
+// declares for the properties of an object that it doesn't name, or nil if it
+// is a boolean or absent. It may be a map decoded from JSON, or a *schemas.Type
+// built in code.
+func additionalPropertiesSchema(t *schemas.Type) (*schemas.Type, error) {
+	if t.AdditionalProperties == nil {
+		return nil, nil
+	}
+	switch v := (*t.AdditionalProperties).(type) {
+	case *schemas.Type:
+		return v, nil
+	case map[string]interface{}:
+		jsonData, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		casted := &schemas.Type{}
+		if err := json.Unmarshal(jsonData, casted); err != nil {
+			return nil, err
+		}
+		return casted, nil
+	case bool:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown type of field additionalProperties: %T", v)
+	}
+}
+
+// enumCases returns the Go literals of the values of an enum that its
+// primitive type can represent, without duplicates.
+func enumCases(t codegen.PrimitiveType, values []interface{}) []string {
+	cases := []string{}
+	seen := map[string]bool{}
+	for _, v := range values {
+		var literal string
+		switch v := v.(type) {
+		case string:
+			if t.Type == "string" {
+				literal = fmt.Sprintf("%q", v)
+			}
+		case bool:
+			if t.Type == "bool" {
+				literal = strconv.FormatBool(v)
+			}
+		case float64:
+			switch {
+			case !representable(t.Type, v):
+			case t.Type == "float64" || t.Type == "float32":
+				literal = strconv.FormatFloat(v, 'g', -1, 64)
+			case strings.HasPrefix(t.Type, "uint") || strings.HasPrefix(t.Type, "int"):
+				literal = strconv.FormatFloat(v, 'f', -1, 64)
+			}
+		}
+		if literal != "" && !seen[literal] {
+			seen[literal] = true
+			cases = append(cases, literal)
+		}
+	}
+	return cases
+}
+
+// representable reports whether a number is a value of a Go numeric type,
+// exactly if it is an integer type. int and uint are taken to have 64 bits.
+// Numbers are representable by other types, as they are not checked.
+func representable(goType string, v float64) bool {
+	switch goType {
+	case "float64":
+		return true
+	case "float32":
+		return math.Abs(v) <= math.MaxFloat32
+	}
+	unsigned := strings.HasPrefix(goType, "uint")
+	if !unsigned && !strings.HasPrefix(goType, "int") {
+		return true
+	}
+	bits := 64
+	if size := strings.TrimPrefix(strings.TrimPrefix(goType, "u"), "int"); size != "" {
+		n, err := strconv.Atoi(size)
+		if err != nil {
+			return true
+		}
+		bits = n
+	}
+	if v != math.Trunc(v) {
+		return false
+	}
+	if unsigned {
+		return v >= 0 && v < math.Ldexp(1, bits)
+	}
+	return v >= -math.Ldexp(1, bits-1) && v < math.Ldexp(1, bits-1)
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
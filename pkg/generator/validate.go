@@ -0,0 +1,432 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/mail"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/schemas"
+)
+
+var hostnameRegexp = regexp.MustCompile(hostnamePattern)
+
+// DocumentError is a way in which a document fails to conform to a schema,
+// as reported by Validate.
+type DocumentError struct {
+	// Path is the JSON Pointer of the invalid value within the document.
+	Path string
+	// Keyword is the schema keyword that the value violates, e.g. "required".
+	Keyword string
+	Message string
+	// Line and Column are the 1-based position of the value in the document,
+	// for the errors in schemas that are checked against the meta-schema, or
+	// zero if the position is not known.
+	Line   int
+	Column int
+}
+
+func (e DocumentError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// Validate checks a document, as decoded by encoding/json, against the schema
+// in a file, at an HTTP or HTTPS URL, or in standard input if the name is
+// "-", and returns all the ways in which it doesn't conform. The schema is
+// loaded, and types are generated for it, as DoFile does, so that the
+// warnings about the schema are the ones that generating code from it gives.
+// Formats are only checked if ValidateFormats is set, as in generated code.
+func (g *Generator) Validate(schemaFileName string, document interface{}) ([]DocumentError, error) {
+	return g.ValidateContext(context.Background(), schemaFileName, document)
+}
+
+// ValidateContext is Validate with a context, which the schema and the
+// schemas that it refers to are fetched with if they are at URLs.
+func (g *Generator) ValidateContext(
+	ctx context.Context, schemaFileName string, document interface{},
+) ([]DocumentError, error) {
+	defer g.withContext(ctx)()
+
+	var schema *schemas.Schema
+	var err error
+	if schemaFileName == "-" {
+		if schema, err = g.parse(schemaFileName, os.Stdin); err != nil {
+			return nil, fmt.Errorf("error parsing from standard input: %w", err)
+		}
+		if err = g.addFile(schemaFileName, schema); err != nil {
+			return nil, err
+		}
+	} else {
+		if schema, err = g.loadSchemaFromFile(schemaFileName, ""); err != nil {
+			return nil, err
+		}
+		if u, ok := schemaURL(schemaFileName, ""); ok {
+			schemaFileName = u
+		} else if schemaFileName, err = g.resolveFileName(schemaFileName, ""); err != nil {
+			return nil, err
+		}
+	}
+	if schema.ObjectAsType == nil {
+		return nil, errors.New("schema has no root")
+	}
+
+	v := &documentValidator{Generator: g}
+	if err := v.validate((*schemas.Type)(schema.ObjectAsType), schema, schemaFileName, document, ""); err != nil {
+		return nil, err
+	}
+	return v.errs, nil
+}
+
+type documentValidator struct {
+	*Generator
+	errs []DocumentError
+}
+
+func (v *documentValidator) fail(path, keyword, format string, args ...interface{}) {
+	v.errs = append(v.errs, DocumentError{Path: path, Keyword: keyword, Message: fmt.Sprintf(format, args...)})
+}
+
+// check reports whether a value conforms to a schema, without reporting how
+// it doesn't, for the subschemas of allOf, anyOf, oneOf and not.
+func (v *documentValidator) check(
+	t *schemas.Type, schema *schemas.Schema, fileName string, value interface{}, path string,
+) (bool, error) {
+	sub := &documentValidator{Generator: v.Generator}
+	if err := sub.validate(t, schema, fileName, value, path); err != nil {
+		return false, err
+	}
+	return len(sub.errs) == 0, nil
+}
+
+// validate checks a value against a type in the schema that was loaded from
+// a file, which references within it are resolved against.
+func (v *documentValidator) validate(
+	t *schemas.Type, schema *schemas.Schema, fileName string, value interface{}, path string,
+) error {
+	if t.Ref != "" {
+		def, defSchema, defFileName, err := v.resolveRef(t.Ref, schema, fileName)
+		if err != nil {
+			return err
+		}
+		return v.validate(def, defSchema, defFileName, value, path)
+	}
+
+	if len(t.Type) > 0 && !matchesType(t.Type, value) {
+		v.fail(path, "type", "expected %s, got %s", strings.Join(t.Type, " or "), jsonTypeName(value))
+		return nil
+	}
+	if len(t.Enum) > 0 {
+		found := false
+		for _, e := range t.Enum {
+			found = found || reflect.DeepEqual(e, value)
+		}
+		if !found {
+			v.fail(path, "enum", "invalid value (expected one of %v): %v", t.Enum, value)
+		}
+	}
+
+	switch value := value.(type) {
+	case float64:
+		v.validateNumber(t, value, path)
+	case string:
+		if err := v.validateString(t, value, path); err != nil {
+			return err
+		}
+	case []interface{}:
+		if err := v.validateArray(t, schema, fileName, value, path); err != nil {
+			return err
+		}
+	case map[string]interface{}:
+		if err := v.validateObject(t, schema, fileName, value, path); err != nil {
+			return err
+		}
+	}
+
+	for _, sub := range t.AllOf {
+		if err := v.validate(sub, schema, fileName, value, path); err != nil {
+			return err
+		}
+	}
+	if len(t.AnyOf) > 0 {
+		matched := false
+		for _, sub := range t.AnyOf {
+			ok, err := v.check(sub, schema, fileName, value, path)
+			if err != nil {
+				return err
+			}
+			matched = matched || ok
+		}
+		if !matched {
+			v.fail(path, "anyOf", "value does not match any of the schemas in anyOf")
+		}
+	}
+	if len(t.OneOf) > 0 {
+		matches := 0
+		for _, sub := range t.OneOf {
+			ok, err := v.check(sub, schema, fileName, value, path)
+			if err != nil {
+				return err
+			}
+			if ok {
+				matches++
+			}
+		}
+		if matches != 1 {
+			v.fail(path, "oneOf", "value matches %d of the schemas in oneOf, instead of exactly one", matches)
+		}
+	}
+	if t.Not != nil {
+		ok, err := v.check(t.Not, schema, fileName, value, path)
+		if err != nil {
+			return err
+		}
+		if ok {
+			v.fail(path, "not", "value must not match the schema in not")
+		}
+	}
+	return nil
+}
+
+func (v *documentValidator) validateNumber(t *schemas.Type, value float64, path string) {
+	if t.MultipleOf != nil && *t.MultipleOf != 0 {
+		if q := value / *t.MultipleOf; q != math.Trunc(q) {
+			v.fail(path, "multipleOf", "must be a multiple of %s", formatFloat(*t.MultipleOf))
+		}
+	}
+	if t.Maximum != nil && value > *t.Maximum {
+		v.fail(path, "maximum", "must be less than or equal to %s", formatFloat(*t.Maximum))
+	}
+	if bound := t.ExclusiveMaximum.Bound(t.Maximum); bound != nil && value >= *bound {
+		v.fail(path, "exclusiveMaximum", "must be less than %s", formatFloat(*bound))
+	}
+	if t.Minimum != nil && value < *t.Minimum {
+		v.fail(path, "minimum", "must be greater than or equal to %s", formatFloat(*t.Minimum))
+	}
+	if bound := t.ExclusiveMinimum.Bound(t.Minimum); bound != nil && value <= *bound {
+		v.fail(path, "exclusiveMinimum", "must be greater than %s", formatFloat(*bound))
+	}
+}
+
+func (v *documentValidator) validateString(t *schemas.Type, value string, path string) error {
+	length := utf8.RuneCountInString(value)
+	if t.MinLength > 0 && length < t.MinLength {
+		v.fail(path, "minLength", "length must be at least %d", t.MinLength)
+	}
+	if t.MaxLength != nil && length > *t.MaxLength {
+		v.fail(path, "maxLength", "length must be at most %d", *t.MaxLength)
+	}
+	if t.Pattern != "" {
+		re, err := regexp.Compile(t.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", t.Pattern, err)
+		}
+		if !re.MatchString(value) {
+			v.fail(path, "pattern", "must match pattern %q", t.Pattern)
+		}
+	}
+	if v.config.ValidateFormats {
+		switch t.Format {
+		case formatEmail, formatIDNEmail:
+			if addr, err := mail.ParseAddress(value); err != nil || addr.Address != value {
+				v.fail(path, "format", "invalid email address: %q", value)
+			}
+		case formatHostname:
+			if len(value) > 253 || !hostnameRegexp.MatchString(value) {
+				v.fail(path, "format", "invalid hostname: %q", value)
+			}
+		case formatRegex:
+			if _, err := regexp.Compile(value); err != nil {
+				v.fail(path, "format", "invalid regular expression: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+func (v *documentValidator) validateArray(
+	t *schemas.Type, schema *schemas.Schema, fileName string, value []interface{}, path string,
+) error {
+	if t.MinItems > 0 && len(value) < t.MinItems {
+		v.fail(path, "minItems", "must have at least %d items", t.MinItems)
+	}
+	if t.MaxItems > 0 && len(value) > t.MaxItems {
+		v.fail(path, "maxItems", "must have at most %d items", t.MaxItems)
+	}
+	if t.UniqueItems {
+	unique:
+		for i := range value {
+			for j := 0; j < i; j++ {
+				if reflect.DeepEqual(value[i], value[j]) {
+					v.fail(path, "uniqueItems", "items %d and %d are equal", j, i)
+					break unique
+				}
+			}
+		}
+	}
+	for i, item := range value {
+		if itemSchema := t.Items.At(i, t.AdditionalItems); itemSchema != nil {
+			if err := v.validate(itemSchema, schema, fileName, item, path+"/"+strconv.Itoa(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (v *documentValidator) validateObject(
+	t *schemas.Type, schema *schemas.Schema, fileName string, value map[string]interface{}, path string,
+) error {
+	if t.MinProperties > 0 && len(value) < t.MinProperties {
+		v.fail(path, "minProperties", "must have at least %d properties", t.MinProperties)
+	}
+	if t.MaxProperties > 0 && len(value) > t.MaxProperties {
+		v.fail(path, "maxProperties", "must have at most %d properties", t.MaxProperties)
+	}
+	for _, name := range t.Required {
+		if _, ok := value[name]; !ok {
+			v.fail(path, "required", "field %s: required", name)
+		}
+	}
+
+	additional, err := additionalPropertiesSchema(t)
+	if err != nil {
+		return err
+	}
+	patterns := make(map[string]*regexp.Regexp, len(t.PatternProperties))
+	for pattern := range t.PatternProperties {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		patterns[pattern] = re
+	}
+
+	names := make([]string, 0, len(value))
+	for name := range value {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		propPath := path + "/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+		if t.PropertyNames != nil {
+			if err := v.validate(t.PropertyNames, schema, fileName, name, propPath); err != nil {
+				return err
+			}
+		}
+
+		declared := false
+		if prop, ok := t.Properties[name]; ok {
+			declared = true
+			if err := v.validate(prop, schema, fileName, value[name], propPath); err != nil {
+				return err
+			}
+		}
+		for pattern, re := range patterns {
+			if re.MatchString(name) {
+				declared = true
+				if err := v.validate(t.PatternProperties[pattern], schema, fileName, value[name], propPath); err != nil {
+					return err
+				}
+			}
+		}
+		switch {
+		case declared:
+		case additional != nil:
+			if err := v.validate(additional, schema, fileName, value[name], propPath); err != nil {
+				return err
+			}
+		case t.AdditionalProperties != nil && !allowsAdditionalProperties(t):
+			v.fail(path, "additionalProperties", "additional property %q is not allowed", name)
+		}
+
+		if dep, ok := t.Dependencies[name]; ok {
+			if err := v.validate(dep, schema, fileName, value, path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resolveRef returns the type that a reference in the schema loaded from a
+// file points to, along with the schema and file that the type is in.
+func (g *Generator) resolveRef(
+	ref string, schema *schemas.Schema, fileName string,
+) (*schemas.Type, *schemas.Schema, string, error) {
+	var refFileName, scope string
+	if i := strings.IndexRune(ref, '#'); i == -1 {
+		refFileName = ref
+	} else {
+		refFileName, scope = ref[0:i], ref[i+1:]
+		if scope != "" && !strings.HasPrefix(scope, "/") {
+			return nil, nil, "", fmt.Errorf("%w; must be a JSON Pointer within file: %q", ErrUnsupportedRef, ref)
+		}
+	}
+
+	if refFileName != "" {
+		var err error
+		schema, err = g.loadSchemaFromFile(refFileName, fileName)
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("could not follow $ref %q to file %q: %w", ref, refFileName, err)
+		}
+		if u, ok := schemaURL(refFileName, fileName); ok {
+			fileName = u
+		} else if fileName, err = g.resolveFileName(refFileName, fileName); err != nil {
+			return nil, nil, "", err
+		}
+	}
+
+	if schema.ObjectAsType == nil && scope == "" {
+		return nil, nil, "", fmt.Errorf("schema referred to by %q has no root", ref)
+	}
+	def, err := schemas.Resolve(schema, scope)
+	if err != nil {
+		return nil, nil, "", resolveError(ref, scope, err)
+	}
+	return def, schema, fileName, nil
+}
+
+// matchesType reports whether a value decoded by encoding/json is of one of
+// the types in a list.
+func matchesType(types schemas.TypeList, value interface{}) bool {
+	name := jsonTypeName(value)
+	for _, t := range types {
+		if t == name || (t == schemas.TypeNameNumber && name == schemas.TypeNameInteger) {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonTypeName(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return schemas.TypeNameNull
+	case bool:
+		return schemas.TypeNameBoolean
+	case float64:
+		if value == math.Trunc(value) && !math.IsInf(value, 0) {
+			return schemas.TypeNameInteger
+		}
+		return schemas.TypeNameNumber
+	case string:
+		return schemas.TypeNameString
+	case []interface{}:
+		return schemas.TypeNameArray
+	case map[string]interface{}:
+		return schemas.TypeNameObject
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
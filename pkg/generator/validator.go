@@ -1,23 +1,43 @@
 package generator
 
 import (
+	"bytes"
 	"fmt"
+	"math"
 	"reflect"
+	"strconv"
 	"strings"
 
 	"github.com/lets-dev-it-out/go-jsonschema/pkg/codegen"
-	"github.com/pkg/errors"
-	"github.com/sanity-io/litter"
 )
 
 type validator interface {
-	generate(out *codegen.Emitter)
+	generate(out *codegen.Emitter, fail failFunc)
 	desc() *validatorDesc
 }
 
+// failFunc emits the statement that handles a failed validation, given an
+// expression evaluating to the error.
+type failFunc func(out *codegen.Emitter, err string)
+
+// returnError emits a statement that returns the error, stopping at the first
+// failed validation.
+func returnError(out *codegen.Emitter, err string) {
+	out.Println("return %s", err)
+}
+
+// appendError emits a statement that collects the error, so that every failed
+// validation is reported.
+func appendError(out *codegen.Emitter, err string) {
+	out.Println("%s = append(%s, %s)", varNameErrors, varNameErrors, err)
+}
+
 type validatorDesc struct {
 	hasError            bool
 	beforeJSONUnmarshal bool
+	// usesRawMap is set by validators that look at the object's properties
+	// before they are unmarshaled into the struct, as json.RawMessage values.
+	usesRawMap bool
 }
 
 var (
@@ -25,17 +45,76 @@ var (
 	_ validator = new(nullTypeValidator)
 	_ validator = new(defaultValidator)
 	_ validator = new(arrayValidator)
+	_ validator = new(formatValidator)
+	_ validator = new(numericValidator)
+	_ validator = new(validateMethodValidator)
+	_ validator = new(stringValidator)
+	_ validator = new(propertiesValidator)
+	_ validator = new(notValidator)
+	_ validator = new(propertyNamesValidator)
 )
 
+// objectProperties is where the UnmarshalJSON method of a struct looks up
+// whether the properties of the object were present: in the raw map, or, when
+// the object is decoded in a single pass, in the struct that it is decoded
+// into, which holds the properties that the struct's fields cannot tell the
+// absence of as pointers, and the nested values as json.RawMessage.
+type objectProperties struct {
+	// decoded is set when the object is decoded in a single pass.
+	decoded bool
+	// fields are the struct's fields, by JSON name.
+	fields map[string]codegen.StructField
+	// captured are the names of the fields of the decoded struct that hold
+	// properties, by JSON name.
+	captured map[string]string
+}
+
+// absentOrNull returns the condition of an if statement that holds if a
+// property was absent or null.
+func (p *objectProperties) absentOrNull(jsonName string) string {
+	if p.usesRawMap() {
+		return fmt.Sprintf(`v, ok := %s["%s"]; !ok || string(v) == "null"`, varNameRawMap, jsonName)
+	}
+	if name, ok := p.captured[jsonName]; ok {
+		if strings.HasSuffix(name, "Raw") {
+			return fmt.Sprintf(`v := %s.%s; v == nil || string(v) == "null"`, varNameProps, name)
+		}
+		return fmt.Sprintf("%s.%s == nil", varNameProps, name)
+	}
+	return fmt.Sprintf("%s.%s == nil", varNamePlainStruct, p.fields[jsonName].Name)
+}
+
+// usesRawMap reports whether the properties are looked up in the raw map.
+func (p *objectProperties) usesRawMap() bool {
+	return p == nil || !p.decoded
+}
+
+// isNilable reports whether a field is nil after its property is unmarshaled
+// if, and only if, the property is absent or null. Named types are not, as
+// their UnmarshalJSON methods may be called with null.
+func isNilable(t codegen.Type) bool {
+	switch t.(type) {
+	case *codegen.PointerType, *codegen.ArrayType, codegen.ArrayType, *codegen.MapType, codegen.MapType,
+		codegen.EmptyInterfaceType, codegen.NullType:
+		return true
+	}
+	return false
+}
+
 type requiredValidator struct {
 	jsonName string
-	declName string
+	nullable bool
+	props    *objectProperties
 }
 
-func (v *requiredValidator) generate(out *codegen.Emitter) {
-	out.Println(`if v, ok := %s["%s"]; !ok || v == nil {`, varNameRawMap, v.jsonName)
+func (v *requiredValidator) generate(out *codegen.Emitter, fail failFunc) {
+	if v.nullable {
+		out.Println(`if _, ok := %s["%s"]; !ok {`, varNameRawMap, v.jsonName)
+	} else {
+		out.Println("if %s {", v.props.absentOrNull(v.jsonName))
+	}
 	out.Indent(1)
-	out.Println(`return fmt.Errorf("field %s in %s: required")`, v.jsonName, v.declName)
+	fail(out, validationError(jsonPointer(v.jsonName, nil), "required", "required"))
 	out.Indent(-1)
 	out.Println("}")
 }
@@ -44,6 +123,7 @@ func (v *requiredValidator) desc() *validatorDesc {
 	return &validatorDesc{
 		hasError:            true,
 		beforeJSONUnmarshal: true,
+		usesRawMap:          v.nullable || v.props.usesRawMap(),
 	}
 }
 
@@ -53,27 +133,20 @@ type nullTypeValidator struct {
 	arrayDepth int
 }
 
-func (v *nullTypeValidator) generate(out *codegen.Emitter) {
+func (v *nullTypeValidator) generate(out *codegen.Emitter, fail failFunc) {
 	value := fmt.Sprintf("%s.%s", varNamePlainStruct, v.fieldName)
-	fieldName := v.jsonName
 	var indexes []string
 	for i := 0; i < v.arrayDepth; i++ {
 		index := fmt.Sprintf("i%d", i)
 		indexes = append(indexes, index)
 		out.Println(`for %s := range %s {`, index, value)
 		value += fmt.Sprintf("[%s]", index)
-		fieldName += "[%d]"
 		out.Indent(1)
 	}
 
-	fieldName = fmt.Sprintf(`"%s"`, fieldName)
-	if len(indexes) > 0 {
-		fieldName = fmt.Sprintf(`fmt.Sprintf(%s, %s)`, fieldName, strings.Join(indexes, ", "))
-	}
-
 	out.Println(`if %s != nil {`, value)
 	out.Indent(1)
-	out.Println(`return fmt.Errorf("field %%s: must be null", %s)`, fieldName)
+	fail(out, validationError(jsonPointer(v.jsonName, indexes), "type", "must be null"))
 	out.Indent(-1)
 	out.Println("}")
 
@@ -90,87 +163,79 @@ func (v *nullTypeValidator) desc() *validatorDesc {
 	}
 }
 
+// defaultValidator assigns the default value of a field that is absent or
+// null. The value is either a typed Go literal or, for values that cannot be
+// expressed as one, JSON that is unmarshaled into the field.
 type defaultValidator struct {
-	jsonName         string
-	fieldName        string
-	defaultValueType codegen.Type
-	defaultValue     interface{}
+	jsonName  string
+	fieldName string
+	literal   string
+	json      string
+	props     *objectProperties
 }
 
-func (v *defaultValidator) generate(out *codegen.Emitter) {
-	defaultValue, err := v.tryDumpDefaultSlice(out.MaxLineLength())
-	if err != nil {
-		// fallback to sdump in case we couldn't dump it properly
-		defaultValue = litter.Sdump(v.defaultValue)
-	}
-
-	out.Println(`if v, ok := %s["%s"]; !ok || v == nil {`, varNameRawMap, v.jsonName)
+func (v *defaultValidator) generate(out *codegen.Emitter, fail failFunc) {
+	out.Println("if %s {", v.props.absentOrNull(v.jsonName))
 	out.Indent(1)
-	out.Println(`%s.%s = %s`, varNamePlainStruct, v.fieldName, defaultValue)
+	if v.literal != "" {
+		out.Println(`%s.%s = %s`, varNamePlainStruct, v.fieldName, v.literal)
+	} else {
+		out.Println(`if err := json.Unmarshal([]byte(%s), &%s.%s); err != nil {`,
+			strconv.Quote(v.json), varNamePlainStruct, v.fieldName)
+		out.Indent(1)
+		out.Println("return err")
+		out.Indent(-1)
+		out.Println("}")
+	}
 	out.Indent(-1)
 	out.Println("}")
 }
 
-func (v *defaultValidator) tryDumpDefaultSlice(maxLineLen uint) (string, error) {
-	tmpEmitter := codegen.NewEmitter(maxLineLen)
-	v.defaultValueType.Generate(tmpEmitter)
-	tmpEmitter.Println("{")
-
-	kind := reflect.ValueOf(v.defaultValue).Kind()
-	switch kind {
-	case reflect.Slice:
-		for _, value := range v.defaultValue.([]interface{}) {
-			tmpEmitter.Println("%s,", litter.Sdump(value))
-		}
-	default:
-		return "", errors.New("didn't find a slice to dump")
-	}
-
-	tmpEmitter.Print("}")
-	return tmpEmitter.String(), nil
-}
-
 func (v *defaultValidator) desc() *validatorDesc {
 	return &validatorDesc{
 		hasError:            false,
 		beforeJSONUnmarshal: false,
+		usesRawMap:          v.props.usesRawMap(),
 	}
 }
 
+// arrayValidator checks the item constraints of an array field, or of the
+// arrays nested arrayDepth-1 levels within it. It is generated into the
+// Validate method, against the receiver, or against the value of a named
+// slice type if fieldName is empty.
 type arrayValidator struct {
-	jsonName   string
-	fieldName  string
-	arrayDepth int
-	minItems   int
-	maxItems   int
+	jsonName    string
+	fieldName   string
+	arrayDepth  int
+	minItems    int
+	maxItems    int
+	uniqueItems bool
+	// elemType is set when uniqueItems is set and the items are comparable
+	// with ==; otherwise uniqueness is checked with reflect.DeepEqual.
+	elemType codegen.Type
 }
 
-func (v *arrayValidator) generate(out *codegen.Emitter) {
-	if v.minItems == 0 && v.maxItems == 0 {
+func (v *arrayValidator) generate(out *codegen.Emitter, fail failFunc) {
+	if v.minItems == 0 && v.maxItems == 0 && !v.uniqueItems {
 		return
 	}
 
-	value := fmt.Sprintf("%s.%s", varNamePlainStruct, v.fieldName)
-	fieldName := v.jsonName
+	value := fieldValue(v.fieldName)
 	var indexes []string
 	for i := 1; i < v.arrayDepth; i++ {
 		index := fmt.Sprintf("i%d", i)
 		indexes = append(indexes, index)
 		out.Println(`for %s := range %s {`, index, value)
 		value += fmt.Sprintf("[%s]", index)
-		fieldName += "[%d]"
 		out.Indent(1)
 	}
-
-	fieldName = fmt.Sprintf(`"%s"`, fieldName)
-	if len(indexes) > 0 {
-		fieldName = fmt.Sprintf(`fmt.Sprintf(%s, %s)`, fieldName, strings.Join(indexes, ", "))
-	}
+	path := jsonPointer(v.jsonName, indexes)
 
 	if v.minItems != 0 {
 		out.Println(`if len(%s) < %d {`, value, v.minItems)
 		out.Indent(1)
-		out.Println(`return fmt.Errorf("field %%s length: must be >= %%d", %s, %d)`, fieldName, v.minItems)
+		fail(out, validationError(path, "minItems",
+			fmt.Sprintf("number of items must be >= %d", v.minItems)))
 		out.Indent(-1)
 		out.Println("}")
 	}
@@ -178,11 +243,48 @@ func (v *arrayValidator) generate(out *codegen.Emitter) {
 	if v.maxItems != 0 {
 		out.Println(`if len(%s) > %d {`, value, v.maxItems)
 		out.Indent(1)
-		out.Println(`return fmt.Errorf("field %%s length: must be <= %%d", %s, %d)`, fieldName, v.maxItems)
+		fail(out, validationError(path, "maxItems",
+			fmt.Sprintf("number of items must be <= %d", v.maxItems)))
 		out.Indent(-1)
 		out.Println("}")
 	}
 
+	if v.uniqueItems {
+		if v.elemType != nil {
+			out.Println("{")
+			out.Indent(1)
+			out.Print("seen := make(map[")
+			v.elemType.Generate(out)
+			out.Println("]struct{}, len(%s))", value)
+			out.Println(`for _, item := range %s {`, value)
+			out.Indent(1)
+			out.Println(`if _, ok := seen[item]; ok {`)
+			out.Indent(1)
+			fail(out, validationError(path, "uniqueItems", "items must be unique"))
+			out.Indent(-1)
+			out.Println("}")
+			out.Println("seen[item] = struct{}{}")
+			out.Indent(-1)
+			out.Println("}")
+			out.Indent(-1)
+			out.Println("}")
+		} else {
+			out.Println(`for a := range %s {`, value)
+			out.Indent(1)
+			out.Println(`for b := a + 1; b < len(%s); b++ {`, value)
+			out.Indent(1)
+			out.Println(`if reflect.DeepEqual(%s[a], %s[b]) {`, value, value)
+			out.Indent(1)
+			fail(out, validationError(path, "uniqueItems", "items must be unique"))
+			out.Indent(-1)
+			out.Println("}")
+			out.Indent(-1)
+			out.Println("}")
+			out.Indent(-1)
+			out.Println("}")
+		}
+	}
+
 	for i := 1; i < v.arrayDepth; i++ {
 		out.Indent(-1)
 		out.Println("}")
@@ -195,3 +297,865 @@ func (v *arrayValidator) desc() *validatorDesc {
 		beforeJSONUnmarshal: false,
 	}
 }
+
+type formatValidator struct {
+	jsonName  string
+	fieldName string
+	format    string
+	isPointer bool
+}
+
+func (v *formatValidator) generate(out *codegen.Emitter, fail failFunc) {
+	value := fmt.Sprintf("%s.%s", varNamePlainStruct, v.fieldName)
+	if v.isPointer {
+		out.Println(`if %s != nil {`, value)
+		out.Indent(1)
+		value = "*" + value
+	}
+	path := jsonPointer(v.jsonName, nil)
+
+	switch v.format {
+	case formatEmail:
+		// ParseAddress also accepts display names and comments, such as
+		// "Jane <jane@example.com>", which are not plain addresses.
+		out.Println(`if addr, err := mail.ParseAddress(%s); err != nil || addr.Address != %s {`, value, value)
+		out.Indent(1)
+		fail(out, validationError(path, "format", "invalid email address: %q", value))
+		out.Indent(-1)
+		out.Println("}")
+	case formatHostname:
+		out.Println(`if len(%s) > 253 || !%s.MatchString(%s) {`, value, varNameHostnamePattern, value)
+		out.Indent(1)
+		fail(out, validationError(path, "format", "invalid hostname: %q", value))
+		out.Indent(-1)
+		out.Println("}")
+	case formatRegex:
+		out.Println(`if _, err := regexp.Compile(%s); err != nil {`, value)
+		out.Indent(1)
+		fail(out, validationError(path, "format", "invalid regular expression: %v", "err"))
+		out.Indent(-1)
+		out.Println("}")
+	}
+
+	if v.isPointer {
+		out.Indent(-1)
+		out.Println("}")
+	}
+}
+
+func (v *formatValidator) desc() *validatorDesc {
+	return &validatorDesc{
+		hasError:            true,
+		beforeJSONUnmarshal: false,
+	}
+}
+
+// numericValidator checks the numeric constraints of a field. It is generated
+// into the Validate method, against the receiver, or against the value of a
+// named number type if fieldName is empty.
+type numericValidator struct {
+	jsonName         string
+	fieldName        string
+	isPointer        bool
+	goType           string
+	minimum          *float64
+	maximum          *float64
+	exclusiveMinimum *float64
+	exclusiveMaximum *float64
+	multipleOf       *float64
+}
+
+func (v *numericValidator) generate(out *codegen.Emitter, fail failFunc) {
+	value := fieldValue(v.fieldName)
+	if v.isPointer {
+		out.Println(`if %s != nil {`, value)
+		out.Indent(1)
+		value = "*" + value
+	}
+
+	number := value
+	if v.goType != "float64" {
+		number = fmt.Sprintf("float64(%s)", value)
+	}
+
+	v.generateBound(out, fail, number, v.minimum, "minimum", "<", ">=")
+	v.generateBound(out, fail, number, v.exclusiveMinimum, "exclusiveMinimum", "<=", ">")
+	v.generateBound(out, fail, number, v.maximum, "maximum", ">", "<=")
+	v.generateBound(out, fail, number, v.exclusiveMaximum, "exclusiveMaximum", ">=", "<")
+
+	if m := v.multipleOf; m != nil {
+		if v.goType != "float64" && *m == math.Trunc(*m) {
+			out.Println(`if %s%%%s != 0 {`, value, formatFloat(*m))
+		} else {
+			out.Println(`if q := %s / %s; math.Abs(q-math.Round(q)) > 1e-9 {`, number, formatFloat(*m))
+		}
+		out.Indent(1)
+		fail(out, validationError(jsonPointer(v.jsonName, nil), "multipleOf",
+			"must be a multiple of "+formatFloat(*m)))
+		out.Indent(-1)
+		out.Println("}")
+	}
+
+	if v.isPointer {
+		out.Indent(-1)
+		out.Println("}")
+	}
+}
+
+func (v *numericValidator) generateBound(
+	out *codegen.Emitter, fail failFunc, number string, bound *float64, keyword, failOp, requiredOp string) {
+	if bound == nil {
+		return
+	}
+	out.Println(`if %s %s %s {`, number, failOp, formatFloat(*bound))
+	out.Indent(1)
+	fail(out, validationError(jsonPointer(v.jsonName, nil), keyword,
+		fmt.Sprintf("must be %s %s", requiredOp, formatFloat(*bound))))
+	out.Indent(-1)
+	out.Println("}")
+}
+
+// usesMath reports whether the generated code needs the math package.
+func (v *numericValidator) usesMath() bool {
+	return v.multipleOf != nil && (v.goType == "float64" || *v.multipleOf != math.Trunc(*v.multipleOf))
+}
+
+func (v *numericValidator) desc() *validatorDesc {
+	return &validatorDesc{
+		hasError:            true,
+		beforeJSONUnmarshal: false,
+	}
+}
+
+// stringValidator checks the length and pattern constraints of a string
+// field. It is generated into the Validate method, against the receiver, or
+// against the value of a named string type if fieldName is empty.
+type stringValidator struct {
+	jsonName   string
+	fieldName  string
+	isPointer  bool
+	minLength  int
+	maxLength  *int
+	pattern    string
+	patternVar string
+}
+
+func (v *stringValidator) generate(out *codegen.Emitter, fail failFunc) {
+	value := fieldValue(v.fieldName)
+	if v.isPointer {
+		out.Println(`if %s != nil {`, value)
+		out.Indent(1)
+		value = "*" + value
+	}
+
+	if v.minLength != 0 {
+		out.Println(`if utf8.RuneCountInString(%s) < %d {`, value, v.minLength)
+		out.Indent(1)
+		fail(out, validationError(jsonPointer(v.jsonName, nil), "minLength",
+			fmt.Sprintf("length must be >= %d", v.minLength)))
+		out.Indent(-1)
+		out.Println("}")
+	}
+
+	if v.maxLength != nil {
+		out.Println(`if utf8.RuneCountInString(%s) > %d {`, value, *v.maxLength)
+		out.Indent(1)
+		fail(out, validationError(jsonPointer(v.jsonName, nil), "maxLength",
+			fmt.Sprintf("length must be <= %d", *v.maxLength)))
+		out.Indent(-1)
+		out.Println("}")
+	}
+
+	if v.patternVar != "" {
+		out.Println(`if !%s.MatchString(%s) {`, v.patternVar, value)
+		out.Indent(1)
+		fail(out, validationError(jsonPointer(v.jsonName, nil), "pattern",
+			fmt.Sprintf("must match pattern %q", v.pattern)))
+		out.Indent(-1)
+		out.Println("}")
+	}
+
+	if v.isPointer {
+		out.Indent(-1)
+		out.Println("}")
+	}
+}
+
+func (v *stringValidator) desc() *validatorDesc {
+	return &validatorDesc{
+		hasError:            true,
+		beforeJSONUnmarshal: false,
+	}
+}
+
+// propertiesValidator checks the number of properties of an object, given
+// an expression that evaluates to the object's map.
+type propertiesValidator struct {
+	value string
+	// fields are the fields of the struct whose properties are counted, if
+	// value is empty: those that the struct marshals, which leaves out the
+	// fields with omitempty that hold empty values, and the additional
+	// properties if they are captured.
+	fields              []codegen.StructField
+	minProperties       int
+	maxProperties       int
+	beforeJSONUnmarshal bool
+}
+
+func (v *propertiesValidator) generate(out *codegen.Emitter, fail failFunc) {
+	count := fmt.Sprintf("len(%s)", v.value)
+	if v.value == "" {
+		count = "properties"
+		var always int
+		for _, f := range v.fields {
+			if f.JSONName != "" && marshaledCondition(varNameReceiver+"."+f.Name, f) == "" {
+				always++
+			}
+		}
+		out.Println("%s := %d", count, always)
+		for _, f := range v.fields {
+			if f.JSONName == "" {
+				if f.Name == fieldNameExtras {
+					out.Println("%s += len(%s.%s)", count, varNameReceiver, f.Name)
+				}
+			} else if cond := marshaledCondition(varNameReceiver+"."+f.Name, f); cond != "" {
+				out.Println("if %s {", cond)
+				out.Indent(1)
+				out.Println("%s++", count)
+				out.Indent(-1)
+				out.Println("}")
+			}
+		}
+	}
+
+	if v.minProperties != 0 {
+		out.Println(`if %s < %d {`, count, v.minProperties)
+		out.Indent(1)
+		fail(out, validationError(jsonPointer("", nil), "minProperties",
+			fmt.Sprintf("number of properties must be >= %d", v.minProperties)))
+		out.Indent(-1)
+		out.Println("}")
+	}
+
+	if v.maxProperties != 0 {
+		out.Println(`if %s > %d {`, count, v.maxProperties)
+		out.Indent(1)
+		fail(out, validationError(jsonPointer("", nil), "maxProperties",
+			fmt.Sprintf("number of properties must be <= %d", v.maxProperties)))
+		out.Indent(-1)
+		out.Println("}")
+	}
+}
+
+func (v *propertiesValidator) desc() *validatorDesc {
+	return &validatorDesc{
+		hasError:            true,
+		beforeJSONUnmarshal: v.beforeJSONUnmarshal,
+		usesRawMap:          v.value == varNameRawMap,
+	}
+}
+
+// marshaledCondition returns the condition under which encoding/json marshals
+// the property of a field, given the expression of its value, or "" if it
+// always does: fields without omitempty are marshaled, as null if they are
+// nil, while those with it are left out if they hold an empty value of their
+// kind.
+func marshaledCondition(value string, f codegen.StructField) string {
+	name, opts, _ := strings.Cut(reflect.StructTag(f.Tags).Get("json"), ",")
+	if name == "-" || !strings.Contains(","+opts+",", ",omitempty,") {
+		return ""
+	}
+	return nonEmptyCondition(value, f.Type)
+}
+
+// nonEmptyCondition returns the condition under which a value of a type is
+// not empty, as omitempty tells, or "" if it never is, as for structs.
+func nonEmptyCondition(value string, t codegen.Type) string {
+	switch t := t.(type) {
+	case *codegen.PointerType, codegen.EmptyInterfaceType, codegen.NullType:
+		return value + " != nil"
+	case *codegen.ArrayType, codegen.ArrayType, *codegen.MapType, codegen.MapType:
+		return fmt.Sprintf("len(%s) > 0", value)
+	case codegen.PrimitiveType:
+		switch t.Type {
+		case "string", typeJSONNumber:
+			return value + ` != ""`
+		case "bool":
+			return value
+		default:
+			return value + " != 0"
+		}
+	case *codegen.NamedType:
+		if t.Package == nil && t.Decl.Type != nil {
+			return nonEmptyCondition(value, t.Decl.Type)
+		}
+	}
+	return ""
+}
+
+// propertyNamesValidator checks the length and pattern constraints of the
+// names of an object's properties, given an expression that evaluates to the
+// object's map.
+type propertyNamesValidator struct {
+	value               string
+	minLength           int
+	maxLength           *int
+	pattern             string
+	patternVar          string
+	beforeJSONUnmarshal bool
+}
+
+func (v *propertyNamesValidator) generate(out *codegen.Emitter, fail failFunc) {
+	out.Println("for k := range %s {", v.value)
+	out.Indent(1)
+
+	if v.minLength != 0 {
+		out.Println(`if utf8.RuneCountInString(k) < %d {`, v.minLength)
+		out.Indent(1)
+		fail(out, validationError(jsonPointer("", nil), "propertyNames",
+			fmt.Sprintf("length of property name %%q must be >= %d", v.minLength), "k"))
+		out.Indent(-1)
+		out.Println("}")
+	}
+
+	if v.maxLength != nil {
+		out.Println(`if utf8.RuneCountInString(k) > %d {`, *v.maxLength)
+		out.Indent(1)
+		fail(out, validationError(jsonPointer("", nil), "propertyNames",
+			fmt.Sprintf("length of property name %%q must be <= %d", *v.maxLength), "k"))
+		out.Indent(-1)
+		out.Println("}")
+	}
+
+	if v.patternVar != "" {
+		out.Println(`if !%s.MatchString(k) {`, v.patternVar)
+		out.Indent(1)
+		fail(out, validationError(jsonPointer("", nil), "propertyNames",
+			fmt.Sprintf("property name %%q must match pattern %s", strings.ReplaceAll(strconv.Quote(v.pattern), "%", "%%")), "k"))
+		out.Indent(-1)
+		out.Println("}")
+	}
+
+	out.Indent(-1)
+	out.Println("}")
+}
+
+func (v *propertyNamesValidator) desc() *validatorDesc {
+	return &validatorDesc{
+		hasError:            true,
+		beforeJSONUnmarshal: v.beforeJSONUnmarshal,
+		usesRawMap:          v.value == varNameRawMap,
+	}
+}
+
+// notValidator checks the raw object against the supported forms of the
+// "not" keyword: properties that must not be present, alone or together, and
+// values that a property must not have.
+type notValidator struct {
+	// prohibited holds sets of properties that must not all be present.
+	prohibited [][]string
+	// jsonName and valuesVar name a property and the variable holding the
+	// values it must not have.
+	jsonName  string
+	valuesVar string
+}
+
+func (v *notValidator) generate(out *codegen.Emitter, fail failFunc) {
+	for _, names := range v.prohibited {
+		for _, name := range names {
+			out.Println(`if _, ok := %s["%s"]; ok {`, varNameRawMap, name)
+			out.Indent(1)
+		}
+		if len(names) == 1 {
+			fail(out, validationError(jsonPointer(names[0], nil), "not", "must not be present"))
+		} else {
+			quoted := make([]string, len(names))
+			for i, name := range names {
+				quoted[i] = strconv.Quote(name)
+			}
+			fail(out, validationError(jsonPointer("", nil), "not",
+				fmt.Sprintf("properties %s must not be present together", strings.Join(quoted, ", "))))
+		}
+		for range names {
+			out.Indent(-1)
+			out.Println("}")
+		}
+	}
+
+	if v.valuesVar != "" {
+		out.Println(`if b, ok := %s["%s"]; ok {`, varNameRawMap, v.jsonName)
+		out.Indent(1)
+		out.Println("var v interface{}")
+		out.Println("if err := json.Unmarshal(b, &v); err != nil {")
+		out.Indent(1)
+		out.Println("return err")
+		out.Indent(-1)
+		out.Println("}")
+		out.Println("for _, prohibited := range %s {", v.valuesVar)
+		out.Indent(1)
+		out.Println("if reflect.DeepEqual(v, prohibited) {")
+		out.Indent(1)
+		fail(out, validationError(jsonPointer(v.jsonName, nil), "not", "must not be %#v", "v"))
+		out.Indent(-1)
+		out.Println("}")
+		out.Indent(-1)
+		out.Println("}")
+		out.Indent(-1)
+		out.Println("}")
+	}
+}
+
+func (v *notValidator) desc() *validatorDesc {
+	return &validatorDesc{
+		hasError:            true,
+		beforeJSONUnmarshal: true,
+		usesRawMap:          true,
+	}
+}
+
+// validateMethodValidator calls the generated Validate method on the
+// unmarshaled value. When errors are aggregated, those returned by Validate
+// are collected rather than returned.
+type validateMethodValidator struct {
+	declName string
+	// method is the name of the method to call, Validate if empty, and value
+	// the expression of the value to call it on, the plain struct if empty.
+	method    string
+	value     string
+	aggregate bool
+}
+
+func (v *validateMethodValidator) generate(out *codegen.Emitter, fail failFunc) {
+	method, value := v.method, v.value
+	if method == "" {
+		method = methodNameValidate
+	}
+	if value == "" {
+		value = fmt.Sprintf("(*%s)(&%s)", v.declName, varNamePlainStruct)
+	}
+	out.Println(`if err := %s.%s(); err != nil {`, value, method)
+	out.Indent(1)
+	if v.aggregate {
+		out.Println("verrs, ok := err.(%s)", typeNameValidationErrors)
+		out.Println("if !ok {")
+		out.Indent(1)
+		out.Println("return err")
+		out.Indent(-1)
+		out.Println("}")
+		out.Println("%s = append(%s, verrs...)", varNameErrors, varNameErrors)
+	} else {
+		out.Println("return err")
+	}
+	out.Indent(-1)
+	out.Println("}")
+}
+
+func (v *validateMethodValidator) desc() *validatorDesc {
+	return &validatorDesc{
+		hasError:            false,
+		beforeJSONUnmarshal: false,
+	}
+}
+
+// validationError returns an expression constructing a ValidationError for
+// the value at path, which is an expression evaluating to a JSON Pointer. If
+// args are given, message is a format string for them.
+func validationError(path, keyword, message string, args ...string) string {
+	msg := strconv.Quote(message)
+	if len(args) > 0 {
+		msg = fmt.Sprintf("fmt.Sprintf(%s, %s)", msg, strings.Join(args, ", "))
+	}
+	return fmt.Sprintf("&%s{Path: %s, Keyword: %q, Message: %s}", typeNameValidationError, path, keyword, msg)
+}
+
+// jsonPointer returns an expression evaluating to the JSON Pointer of a
+// property, or of the item at the given indexes within it. An empty name
+// denotes the value itself.
+func jsonPointer(jsonName string, indexes []string) string {
+	var pointer string
+	if jsonName != "" {
+		pointer = "/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(jsonName)
+	}
+	if len(indexes) == 0 {
+		return strconv.Quote(pointer)
+	}
+	pointer = strings.ReplaceAll(pointer, "%", "%%") + strings.Repeat("/%d", len(indexes))
+	return fmt.Sprintf("fmt.Sprintf(%q, %s)", pointer, strings.Join(indexes, ", "))
+}
+
+// fieldValue returns an expression evaluating to a field of the receiver of
+// a Validate method, or with an empty name, to the value of a named type
+// that its Validate method converts the receiver to.
+func fieldValue(fieldName string) string {
+	if fieldName == "" {
+		return varNameValue
+	}
+	return fmt.Sprintf("%s.%s", varNameReceiver, fieldName)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// nestedField is a field of a struct that holds values of a type that
+// validates itself, either directly or within slices and maps.
+type nestedField struct {
+	fieldName string
+	jsonName  string
+	// fieldType is the type of the field.
+	fieldType codegen.Type
+	// containers are the slices ('[') and maps ('{') that the values are
+	// in, outermost first.
+	containers []byte
+	decl       *codegen.TypeDecl
+	// pointer is set if the values are pointers to the type.
+	pointer bool
+}
+
+// nestedFields returns the nested fields of a struct whose values are of types
+// that are checked, as reported by the function.
+func nestedFields(structType *codegen.StructType, checked func(*codegen.TypeDecl) bool) []nestedField {
+	var fields []nestedField
+	for _, f := range structType.Fields {
+		if nested, ok := findNestedField(f, checked); ok && f.JSONName != "" {
+			fields = append(fields, nested)
+		}
+	}
+	return fields
+}
+
+// isNestedField reports whether a struct field is one of the nested fields.
+func isNestedField(nested []nestedField, f codegen.StructField) bool {
+	for _, n := range nested {
+		if n.fieldName == f.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// nestedInMaps reports whether any of the nested fields holds values in maps.
+func nestedInMaps(fields []nestedField) bool {
+	for _, f := range fields {
+		if bytes.IndexByte(f.containers, '{') >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// findNestedField returns the nested field for a struct field whose values
+// are of a type declared in the same package that is checked, as reported by
+// the function, if it has one.
+func findNestedField(f codegen.StructField, checked func(*codegen.TypeDecl) bool) (nestedField, bool) {
+	nested := nestedField{fieldName: f.Name, jsonName: f.JSONName, fieldType: f.Type}
+	t := f.Type
+	for {
+		nested.pointer = false
+		if p, ok := t.(*codegen.PointerType); ok {
+			t, nested.pointer = p.Type, true
+		}
+		switch x := t.(type) {
+		case *codegen.NamedType:
+			if x.Package != nil {
+				return nestedField{}, false
+			}
+			if checked(x.Decl) {
+				nested.decl = x.Decl
+				return nested, true
+			}
+			switch x.Decl.Type.(type) {
+			case *codegen.ArrayType, codegen.ArrayType, *codegen.MapType, codegen.MapType:
+				// Named slices and maps without validation of their own are
+				// looked through.
+				t = x.Decl.Type
+			default:
+				return nestedField{}, false
+			}
+		case *codegen.ArrayType:
+			nested.containers = append(nested.containers, '[')
+			t = x.Type
+		case codegen.ArrayType:
+			nested.containers = append(nested.containers, '[')
+			t = x.Type
+		case *codegen.MapType:
+			nested.containers = append(nested.containers, '{')
+			t = x.ValueType
+		case codegen.MapType:
+			nested.containers = append(nested.containers, '{')
+			t = x.ValueType
+		default:
+			return nestedField{}, false
+		}
+	}
+}
+
+// generateDecodeNested emits the statements that unmarshal the value of a
+// nested field from its raw JSON into dst. Each value of the nested type is
+// unmarshaled once, on its own, so that its errors can be failed with its
+// location prepended to their paths; the values in maps are unmarshaled in
+// the order of their keys, so that which errors are reported doesn't vary.
+func generateDecodeNested(out *codegen.Emitter, f nestedField, raw, dst string, fail nestedFailFunc) {
+	pointer := "/" + strings.NewReplacer("~", "~0", "/", "~1", "%", "%%").Replace(f.jsonName)
+	generateDecodeValue(out, f, f.fieldType, raw, dst, 0, pointer, nil, fail)
+}
+
+// nestedFailFunc emits the statement that handles an error of a nested value,
+// given an expression evaluating to the JSON Pointer of the value.
+type nestedFailFunc func(out *codegen.Emitter, path string)
+
+// returnNestedError emits a statement that returns the error of a nested
+// value with its location prepended to its paths.
+func returnNestedError(out *codegen.Emitter, path string) {
+	out.Println("return %s(err, %s)", funcNamePrefixValidationError, path)
+}
+
+// appendNestedError emits a statement that appends the errors of a nested
+// value, with its location prepended to their paths, to the aggregated errors,
+// or returns its error if it is not a validation error.
+func appendNestedError(out *codegen.Emitter, path string) {
+	out.Println("if %s, err = %s(%s, err, %s); err != nil {", varNameErrors, funcNameAppendValidationError,
+		varNameErrors, path)
+	out.Indent(1)
+	out.Println("return err")
+	out.Indent(-1)
+	out.Println("}")
+}
+
+// generateDecodeValue emits the statements that unmarshal a value of type t,
+// within the containers of a nested field from the given depth on, from raw
+// into dst. The format and args give the JSON Pointer of the value.
+func generateDecodeValue(out *codegen.Emitter, f nestedField, t codegen.Type, raw, dst string, depth int,
+	format string, args []string, fail nestedFailFunc) {
+	if depth == len(f.containers) {
+		out.Println("if err := json.Unmarshal(%s, &%s); err != nil {", raw, dst)
+		out.Indent(1)
+		fail(out, pointerExpression(format, args))
+		out.Indent(-1)
+		out.Println("}")
+		return
+	}
+
+	pointer := false
+	if p, ok := t.(*codegen.PointerType); ok {
+		t, pointer = p.Type, true
+	}
+	container := typeString(t)
+	if nt, ok := t.(*codegen.NamedType); ok {
+		t = nt.Decl.Type
+	}
+	var elemType codegen.Type
+	switch x := t.(type) {
+	case *codegen.ArrayType:
+		elemType = x.Type
+	case codegen.ArrayType:
+		elemType = x.Type
+	case *codegen.MapType:
+		elemType = x.ValueType
+	case codegen.MapType:
+		elemType = x.ValueType
+	}
+
+	elems, elem := fmt.Sprintf("elems%d", depth), fmt.Sprintf("elem%d", depth)
+	if f.containers[depth] == '[' {
+		out.Println("var %s []json.RawMessage", elems)
+	} else {
+		out.Println("var %s map[string]json.RawMessage", elems)
+	}
+	out.Println("if err := json.Unmarshal(%s, &%s); err != nil {", raw, elems)
+	out.Indent(1)
+	out.Println("return err")
+	out.Indent(-1)
+	out.Println("}")
+	out.Println("if %s != nil {", elems)
+	out.Indent(1)
+	if pointer {
+		out.Println("%s = new(%s)", dst, container)
+		dst = "(*" + dst + ")"
+	}
+	out.Println("%s = make(%s, len(%s))", dst, container, elems)
+	out.Indent(-1)
+	out.Println("}")
+
+	if f.containers[depth] == '[' {
+		key := fmt.Sprintf("i%d", depth)
+		out.Println("for %s, %s := range %s {", key, elem, elems)
+		out.Indent(1)
+		generateDecodeValue(out, f, elemType, elem, fmt.Sprintf("%s[%s]", dst, key), depth+1,
+			format+"/%d", append(args, key), fail)
+		out.Indent(-1)
+		out.Println("}")
+		return
+	}
+
+	key, keys := fmt.Sprintf("k%d", depth), fmt.Sprintf("keys%d", depth)
+	value := fmt.Sprintf("%s%d", varNameValue, depth)
+	out.Println("%s := make([]string, 0, len(%s))", keys, elems)
+	out.Println("for %s := range %s {", key, elems)
+	out.Indent(1)
+	out.Println("%s = append(%s, %s)", keys, keys, key)
+	out.Indent(-1)
+	out.Println("}")
+	out.Println("sort.Strings(%s)", keys)
+	out.Println("for _, %s := range %s {", key, keys)
+	out.Indent(1)
+	out.Println("var %s %s", value, typeString(elemType))
+	generateDecodeValue(out, f, elemType, fmt.Sprintf("%s[%s]", elems, key), value, depth+1,
+		format+"/%s", append(args, fmt.Sprintf(`strings.NewReplacer("~", "~0", "/", "~1").Replace(%s)`, key)), fail)
+	out.Println("%s[%s] = %s", dst, key, value)
+	out.Indent(-1)
+	out.Println("}")
+}
+
+// pointerExpression returns an expression evaluating to a JSON Pointer, given
+// as a format string and the expressions of its args.
+func pointerExpression(format string, args []string) string {
+	if len(args) == 0 {
+		return strconv.Quote(strings.ReplaceAll(format, "%%", "%"))
+	}
+	return fmt.Sprintf("fmt.Sprintf(%q, %s)", format, strings.Join(args, ", "))
+}
+
+// nestedValidateValidator calls the Validate methods of the values of the
+// fields of a struct whose types have them, including those in slices and
+// maps, and fails with their errors with the location of the values prepended
+// to their paths. Only the values of named slices and maps that do not have
+// Validate methods of their own are looked into.
+type nestedValidateValidator struct {
+	fields    []codegen.StructField
+	validated func(*codegen.TypeDecl) bool
+	fail      nestedFailFunc
+}
+
+func (v *nestedValidateValidator) generate(out *codegen.Emitter, _ failFunc) {
+	for _, f := range v.fields {
+		pointer := "/" + strings.NewReplacer("~", "~0", "/", "~1", "%", "%%").Replace(f.JSONName)
+		generateValidateValue(out, f.Type, fmt.Sprintf("%s.%s", varNameReceiver, f.Name), 0, pointer, nil,
+			v.validated, v.fail)
+	}
+}
+
+func (v *nestedValidateValidator) desc() *validatorDesc {
+	return &validatorDesc{
+		hasError:            true,
+		beforeJSONUnmarshal: false,
+	}
+}
+
+// validatedFields returns the fields of a struct that hold values of types
+// declared in the same package that have Validate methods, as reported by the
+// function.
+func validatedFields(structType *codegen.StructType, validated func(*codegen.TypeDecl) bool) []codegen.StructField {
+	var fields []codegen.StructField
+	for _, f := range structType.Fields {
+		if holds, _ := holdsValidated(f.Type, validated); holds && f.JSONName != "" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// holdsValidated reports whether values of type t are, or hold in pointers,
+// slices and maps, values of types that have Validate methods, and if so,
+// whether any of those are in maps.
+func holdsValidated(t codegen.Type, validated func(*codegen.TypeDecl) bool) (holds, inMap bool) {
+	switch x := t.(type) {
+	case *codegen.PointerType:
+		return holdsValidated(x.Type, validated)
+	case *codegen.NamedType:
+		if x.Package != nil || x.Decl == nil {
+			return false, false
+		}
+		if validated(x.Decl) {
+			return true, false
+		}
+		switch x.Decl.Type.(type) {
+		case *codegen.ArrayType, codegen.ArrayType, *codegen.MapType, codegen.MapType:
+			return holdsValidated(x.Decl.Type, validated)
+		}
+	case *codegen.ArrayType:
+		return holdsValidated(x.Type, validated)
+	case codegen.ArrayType:
+		return holdsValidated(x.Type, validated)
+	case *codegen.MapType:
+		holds, _ := holdsValidated(x.ValueType, validated)
+		return holds, holds
+	case codegen.MapType:
+		holds, _ := holdsValidated(x.ValueType, validated)
+		return holds, holds
+	}
+	return false, false
+}
+
+// generateValidateValue emits the statements that call the Validate methods
+// of value, an expression of type t, or of the values it holds at the given
+// depth of slices and maps. The format and args give the JSON Pointer of the
+// value.
+func generateValidateValue(
+	out *codegen.Emitter, t codegen.Type, value string, depth int, format string, args []string,
+	validated func(*codegen.TypeDecl) bool, fail nestedFailFunc) {
+	var elemType codegen.Type
+	var isMap bool
+	switch x := t.(type) {
+	case *codegen.PointerType:
+		out.Println("if %s != nil {", value)
+		out.Indent(1)
+		if named, ok := x.Type.(*codegen.NamedType); ok && validated(named.Decl) {
+			generateValidateValue(out, x.Type, value, depth, format, args, validated, fail)
+		} else {
+			generateValidateValue(out, x.Type, "(*"+value+")", depth, format, args, validated, fail)
+		}
+		out.Indent(-1)
+		out.Println("}")
+		return
+	case *codegen.NamedType:
+		if !validated(x.Decl) {
+			generateValidateValue(out, x.Decl.Type, value, depth, format, args, validated, fail)
+			return
+		}
+		out.Println("if err := %s.Validate(); err != nil {", value)
+		out.Indent(1)
+		fail(out, pointerExpression(format, args))
+		out.Indent(-1)
+		out.Println("}")
+		return
+	case *codegen.ArrayType:
+		elemType = x.Type
+	case codegen.ArrayType:
+		elemType = x.Type
+	case *codegen.MapType:
+		elemType, isMap = x.ValueType, true
+	case codegen.MapType:
+		elemType, isMap = x.ValueType, true
+	default:
+		return
+	}
+
+	elem := fmt.Sprintf("elem%d", depth)
+	var key string
+	if isMap {
+		key = fmt.Sprintf("k%d", depth)
+		format += "/%s"
+		args = append(args, fmt.Sprintf(`strings.NewReplacer("~", "~0", "/", "~1").Replace(%s)`, key))
+	} else {
+		key = fmt.Sprintf("i%d", depth)
+		format += "/%d"
+		args = append(args, key)
+	}
+	out.Println("for %s, %s := range %s {", key, elem, value)
+	out.Indent(1)
+	generateValidateValue(out, elemType, elem, depth+1, format, args, validated, fail)
+	out.Indent(-1)
+	out.Println("}")
+}
+
+// validatesInMaps reports whether any of the fields holds values with
+// Validate methods in maps.
+func validatesInMaps(fields []codegen.StructField, validated func(*codegen.TypeDecl) bool) bool {
+	for _, f := range fields {
+		if _, inMap := holdsValidated(f.Type, validated); inMap {
+			return true
+		}
+	}
+	return false
+}
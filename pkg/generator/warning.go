@@ -0,0 +1,147 @@
+package generator
+
+import (
+	"fmt"
+
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/schemas"
+)
+
+// Warning is a problem with a schema, or with the code generated for it,
+// that the generator works around instead of failing, such as a constraint
+// that is not validated or a type that had to be renamed.
+type Warning struct {
+	Code     WarningCode
+	Severity Severity
+	// SchemaFile is the name of the schema file that the warning is about,
+	// if it is about one.
+	SchemaFile string
+	// Pointer is the JSON Pointer of the subschema within SchemaFile that
+	// the warning is about. It is empty for the root, and for warnings about
+	// the file as a whole or whose subschema isn't known.
+	Pointer string
+	Message string
+}
+
+// String returns the message of a warning, preceded by the schema file and
+// pointer that it is about, if any.
+func (w Warning) String() string {
+	switch {
+	case w.SchemaFile == "":
+		return w.Message
+	case w.Pointer == "":
+		return fmt.Sprintf("%s: %s", w.SchemaFile, w.Message)
+	default:
+		return fmt.Sprintf("%s#%s: %s", w.SchemaFile, w.Pointer, w.Message)
+	}
+}
+
+// WarningCode identifies the kind of a warning, so that warnings can be
+// filtered without matching their messages.
+type WarningCode string
+
+const (
+	// WarningDraft is for keywords of a different draft than the one that the
+	// schema declares.
+	WarningDraft WarningCode = "draft"
+	// WarningUnsupported is for keywords and combinations of types that code
+	// is not generated for, so that a more general type is used instead.
+	WarningUnsupported WarningCode = "unsupported"
+	// WarningNotValidated is for constraints that are not validated by the
+	// generated code.
+	WarningNotValidated WarningCode = "not-validated"
+	// WarningRenamed is for types and fields declared with other names than
+	// their schemas give them, since the names are taken.
+	WarningRenamed WarningCode = "renamed"
+	// WarningEnumWrapped is for enums whose values are stored in a struct,
+	// since they have more than one type.
+	WarningEnumWrapped WarningCode = "enum-wrapped"
+	// WarningCycle is for references to a type from within itself, which are
+	// declared as pointers.
+	WarningCycle WarningCode = "cycle"
+	// WarningSkipped is for declarations, and files, that the configuration
+	// asks for but that are not generated, such as a method whose name is
+	// taken by a field.
+	WarningSkipped WarningCode = "skipped"
+	// WarningRandomValues is for random values that may not be valid
+	// against their schemas.
+	WarningRandomValues WarningCode = "random-values"
+	// WarningUnformatted is for generated code that could not be formatted.
+	WarningUnformatted WarningCode = "unformatted"
+)
+
+// Severity tells whether the code generated despite a warning still
+// represents its schema faithfully.
+type Severity string
+
+const (
+	// SeverityInfo is for warnings about how a schema is represented, such
+	// as a renamed type, where the generated code still accepts exactly the
+	// documents that the schema does.
+	SeverityInfo Severity = "info"
+	// SeverityWarning is for warnings about parts of a schema that the
+	// generated code does not represent or validate.
+	SeverityWarning Severity = "warning"
+)
+
+// severity returns the severity of the warnings with a code.
+func (c WarningCode) severity() Severity {
+	switch c {
+	case WarningRenamed, WarningEnumWrapped, WarningCycle:
+		return SeverityInfo
+	default:
+		return SeverityWarning
+	}
+}
+
+// MessageWarner adapts a function that takes the messages of warnings, as
+// Config.Warner did before warnings were structured, to a Warner. Each
+// message includes the schema file and pointer of its warning.
+func MessageWarner(fn func(message string)) func(Warning) {
+	return func(w Warning) {
+		fn(w.String())
+	}
+}
+
+// warn reports a warning, setting its severity from its code.
+func (g *Generator) warn(w Warning) {
+	if g.warner == nil {
+		return
+	}
+	w.Severity = w.Code.severity()
+	g.warner(w)
+}
+
+// warningsError returns the warnings reported with Config.FailOnWarning
+// since it was last called, as a *WarningsError, or nil if there are none.
+func (g *Generator) warningsError() error {
+	if len(g.failedWarnings) == 0 {
+		return nil
+	}
+	err := &WarningsError{Warnings: g.failedWarnings}
+	g.failedWarnings = nil
+	return err
+}
+
+// warnf reports a warning about a subschema of the schema being generated,
+// which may be nil if the warning is about no subschema in particular.
+func (g *schemaGenerator) warnf(t *schemas.Type, code WarningCode, format string, args ...interface{}) {
+	w := Warning{
+		Code:       code,
+		SchemaFile: g.schemaFileName,
+		Message:    fmt.Sprintf(format, args...),
+	}
+	w.Pointer, _ = g.pointerTo(t)
+	g.warn(w)
+}
+
+// pointerTo returns the JSON Pointer of a subschema within the schema being
+// generated. Types merged from allOf have the pointers of the types they
+// were merged from, which are part of the schema.
+func (g *schemaGenerator) pointerTo(t *schemas.Type) (string, bool) {
+	for original, flat := range g.flattened {
+		if flat == t {
+			t = original
+		}
+	}
+	return schemas.PointerTo(g.schema, t)
+}
@@ -0,0 +1,150 @@
+package generator
+
+import (
+	"errors"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/codegen"
+)
+
+// Write writes the files that Files returns to a directory, creating it
+// and the directories in it as needed. Absolute file names are kept as they
+// are, and files named "-" are written to standard output.
+//
+// Each file is written to a temporary file next to it first, which is then
+// renamed over it, so that it is never left half-written. Files that are
+// replaced keep their permissions. If Config.DeleteStaleFiles is set, Go
+// files generated by an earlier run in the directories written to, but not
+// by this one, are deleted. With Config.FailOnWarning, nothing is written
+// if generating the files reported warnings.
+func (g *Generator) Write(outputDir string) error {
+	files := g.Files()
+	if err := g.warningsError(); err != nil {
+		return err
+	}
+	written := map[string]bool{}
+	dirs := map[string]bool{}
+	for _, file := range files {
+		if file.Name == "-" {
+			if _, err := os.Stdout.Write(file.Content); err != nil {
+				return err
+			}
+			continue
+		}
+		path := outputPath(outputDir, file.Name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := writeFileAtomically(path, file.Content); err != nil {
+			return err
+		}
+		written[filepath.Clean(path)] = true
+		dirs[filepath.Dir(path)] = true
+	}
+
+	if !g.config.DeleteStaleFiles {
+		return nil
+	}
+	for dir := range dirs {
+		stale, err := staleFiles(dir, written)
+		if err != nil {
+			return err
+		}
+		for _, path := range stale {
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// outputPath returns the path that Write writes a file of Files to.
+func outputPath(outputDir, fileName string) string {
+	if filepath.IsAbs(fileName) {
+		return fileName
+	}
+	return filepath.Join(outputDir, fileName)
+}
+
+// writeFileAtomically replaces the contents of a file by renaming a
+// temporary file over it. A new file is created with mode 0644.
+func writeFileAtomically(path string, data []byte) error {
+	perm := fs.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// Only left behind if writing failed.
+		_ = os.Remove(tmp.Name())
+	}()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// staleFiles returns the Go files in a directory that were generated by
+// this tool, as their header comment tells, but not written this time.
+func staleFiles(dir string, written map[string]bool) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var stale []string
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if !entry.Type().IsRegular() || !strings.HasSuffix(entry.Name(), ".go") || written[path] {
+			continue
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if isGenerated(b) {
+			stale = append(stale, path)
+		}
+	}
+	return stale, nil
+}
+
+// isGenerated reports whether a Go file was generated by this tool, which
+// its comments before the package clause tell. Files that cannot be parsed
+// are not.
+func isGenerated(src []byte) bool {
+	file, err := parser.ParseFile(token.NewFileSet(), "", src, parser.PackageClauseOnly|parser.ParseComments)
+	if err != nil {
+		return false
+	}
+	for _, group := range file.Comments {
+		if group.Pos() > file.Package {
+			break
+		}
+		for _, c := range group.List {
+			if c.Text == "// "+codegen.GeneratedComment {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,162 @@
+// Package infer infers JSON schemas from sample documents, for APIs and
+// files whose formats aren't documented with a schema of their own.
+package infer
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/schemas"
+)
+
+// FromReader infers a schema from the JSON documents read from r, which may
+// hold any number of them, one after another, as in newline-delimited JSON.
+// Several files can be read with io.MultiReader. See Schema.
+func FromReader(r io.Reader) (*schemas.Schema, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	var samples []interface{}
+	for {
+		var sample interface{}
+		if err := dec.Decode(&sample); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		samples = append(samples, sample)
+	}
+	return Schema(samples...), nil
+}
+
+// Schema infers a schema that all the samples, which are values decoded by
+// encoding/json, are valid against. A value that is sometimes null is
+// nullable, numbers are integers unless some sample has a fraction, and
+// strings that are all RFC 3339 timestamps get the date-time format. The
+// properties of objects are merged, and are required if they are in every
+// sample, and the items of arrays are merged across all the samples.
+func Schema(samples ...interface{}) *schemas.Schema {
+	n := &node{}
+	for _, sample := range samples {
+		n.add(sample)
+	}
+	root := n.schema()
+	root.Version = schemas.Draft07.URI()
+	return &schemas.Schema{ObjectAsType: (*schemas.ObjectAsType)(root), Draft: schemas.Draft07}
+}
+
+// node accumulates what the samples of one value in a document have in
+// common.
+type node struct {
+	types map[string]bool
+
+	objects       int
+	properties    map[string]*node
+	propertyOrder []string
+	seen          map[string]int
+
+	items *node
+
+	strings   int
+	dateTimes int
+}
+
+func (n *node) add(value interface{}) {
+	if n.types == nil {
+		n.types = map[string]bool{}
+	}
+	switch v := value.(type) {
+	case nil:
+		n.types[schemas.TypeNameNull] = true
+	case bool:
+		n.types[schemas.TypeNameBoolean] = true
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			n.types[schemas.TypeNameInteger] = true
+		} else {
+			n.types[schemas.TypeNameNumber] = true
+		}
+	case float64:
+		if v == math.Trunc(v) {
+			n.types[schemas.TypeNameInteger] = true
+		} else {
+			n.types[schemas.TypeNameNumber] = true
+		}
+	case string:
+		n.types[schemas.TypeNameString] = true
+		n.strings++
+		if _, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			n.dateTimes++
+		}
+	case []interface{}:
+		n.types[schemas.TypeNameArray] = true
+		if n.items == nil {
+			n.items = &node{}
+		}
+		for _, item := range v {
+			n.items.add(item)
+		}
+	case map[string]interface{}:
+		n.types[schemas.TypeNameObject] = true
+		n.objects++
+		if n.properties == nil {
+			n.properties = map[string]*node{}
+			n.seen = map[string]int{}
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			prop, ok := n.properties[key]
+			if !ok {
+				prop = &node{}
+				n.properties[key] = prop
+				n.propertyOrder = append(n.propertyOrder, key)
+			}
+			prop.add(v[key])
+			n.seen[key]++
+		}
+	}
+}
+
+func (n *node) schema() *schemas.Type {
+	schema := &schemas.Type{}
+	if n.types[schemas.TypeNameNumber] {
+		delete(n.types, schemas.TypeNameInteger)
+	}
+	for _, name := range []string{
+		schemas.TypeNameObject,
+		schemas.TypeNameArray,
+		schemas.TypeNameString,
+		schemas.TypeNameInteger,
+		schemas.TypeNameNumber,
+		schemas.TypeNameBoolean,
+		schemas.TypeNameNull,
+	} {
+		if n.types[name] {
+			schema.Type = append(schema.Type, name)
+		}
+	}
+
+	if n.strings > 0 && n.dateTimes == n.strings {
+		schema.Format = "date-time"
+	}
+	if n.items != nil && n.items.types != nil {
+		schema.Items = schemas.SchemaItems(n.items.schema())
+	}
+	if n.properties != nil {
+		schema.Properties = map[string]*schemas.Type{}
+		for _, key := range n.propertyOrder {
+			schema.Properties[key] = n.properties[key].schema()
+			if n.seen[key] == n.objects {
+				schema.Required = append(schema.Required, key)
+			}
+		}
+		schema.PropertyOrder = n.propertyOrder
+	}
+	return schema
+}
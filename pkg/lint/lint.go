@@ -0,0 +1,290 @@
+// Package lint reports the constructs in a schema that the generator handles
+// poorly, so that they can be fixed in the schema rather than worked around
+// in the generated code.
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strings"
+
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/schemas"
+)
+
+// The rules that issues are reported under.
+const (
+	// RuleAnonymousUnion is an anyOf or oneOf that is neither a definition
+	// nor titled, so that its generated type has no meaningful name.
+	RuleAnonymousUnion = "anonymous-union"
+	// RuleMultipleTypes is a value with several types other than null, which
+	// is generated as interface{} without validation.
+	RuleMultipleTypes = "multiple-types"
+	// RuleMixedTypeEnum is an enum whose values are of different types, which
+	// is generated as interface{}.
+	RuleMixedTypeEnum = "mixed-type-enum"
+	// RuleOverlappingPatternProperties is a pair of patternProperties patterns
+	// that can match the same property name.
+	RuleOverlappingPatternProperties = "overlapping-pattern-properties"
+)
+
+// Issue is a construct in a schema that the generator handles poorly.
+type Issue struct {
+	// Path is the JSON Pointer of the construct within the schema.
+	Path    string `json:"path"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (i Issue) String() string {
+	path := i.Path
+	if path == "" {
+		path = "/"
+	}
+	return fmt.Sprintf("%s: %s (%s)", path, i.Message, i.Rule)
+}
+
+// Lint returns the issues in a schema, ordered by path.
+func Lint(schema *schemas.Schema) []Issue {
+	l := &linter{}
+	for _, key := range []string{"definitions", "$defs"} {
+		defs := schema.Definitions
+		if key == "$defs" {
+			defs = schema.Defs
+		}
+		for _, name := range sortedKeys(defs) {
+			l.lint("/"+key+"/"+escape(name), defs[name], true)
+		}
+	}
+	if schema.ObjectAsType != nil {
+		l.lint("", (*schemas.Type)(schema.ObjectAsType), true)
+	}
+	sort.SliceStable(l.issues, func(i, j int) bool {
+		return l.issues[i].Path < l.issues[j].Path
+	})
+	return l.issues
+}
+
+type linter struct {
+	issues []Issue
+}
+
+func (l *linter) add(path, rule, format string, args ...interface{}) {
+	l.issues = append(l.issues, Issue{Path: path, Rule: rule, Message: fmt.Sprintf(format, args...)})
+}
+
+// lint checks a type and the types nested in it. Named types are the root
+// and definitions, whose generated types are named after them.
+func (l *linter) lint(path string, t *schemas.Type, named bool) {
+	if t == nil {
+		return
+	}
+
+	if !named && t.Title == "" {
+		for _, union := range []struct {
+			keyword string
+			subs    []*schemas.Type
+		}{{"anyOf", t.AnyOf}, {"oneOf", t.OneOf}} {
+			if len(union.subs) > 0 {
+				l.add(path+"/"+union.keyword, RuleAnonymousUnion,
+					"%s without a title; move it into the definitions, or give it a title", union.keyword)
+			}
+		}
+	}
+
+	var types []string
+	for _, name := range t.Type {
+		if name != schemas.TypeNameNull {
+			types = append(types, name)
+		}
+	}
+	if len(types) > 1 {
+		l.add(path+"/type", RuleMultipleTypes,
+			"multiple types (%s) are generated as interface{} without validation", strings.Join(types, ", "))
+	}
+
+	if enumTypes := enumTypeNames(t.Enum); len(enumTypes) > 1 {
+		l.add(path+"/enum", RuleMixedTypeEnum,
+			"enum values of different types (%s) are generated as interface{}", strings.Join(enumTypes, ", "))
+	}
+
+	patterns := make([]string, 0, len(t.PatternProperties))
+	for pattern := range t.PatternProperties {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	for i, a := range patterns {
+		for _, b := range patterns[i+1:] {
+			if overlaps(a, b) {
+				l.add(path+"/patternProperties", RuleOverlappingPatternProperties,
+					"patterns %q and %q can match the same property", a, b)
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(t.Properties) {
+		l.lint(path+"/properties/"+escape(name), t.Properties[name], false)
+	}
+	for _, pattern := range patterns {
+		l.lint(path+"/patternProperties/"+escape(pattern), t.PatternProperties[pattern], false)
+	}
+	for _, name := range sortedKeys(t.Dependencies) {
+		l.lint(path+"/dependencies/"+escape(name), t.Dependencies[name], false)
+	}
+	for _, items := range []struct {
+		keyword string
+		items   *schemas.Items
+	}{{"items", t.Items}, {"additionalItems", t.AdditionalItems}} {
+		if items.items.IsTuple() {
+			for i, sub := range items.items.Tuple {
+				l.lint(fmt.Sprintf("%s/%s/%d", path, items.keyword, i), sub, false)
+			}
+		} else if items.items != nil {
+			l.lint(path+"/"+items.keyword, items.items.Schema, false)
+		}
+	}
+	l.lint(path+"/propertyNames", t.PropertyNames, false)
+	l.lint(path+"/not", t.Not, false)
+	l.lint(path+"/additionalProperties", additionalPropertiesSchema(t), false)
+	for _, union := range []struct {
+		keyword string
+		subs    []*schemas.Type
+	}{{"allOf", t.AllOf}, {"anyOf", t.AnyOf}, {"oneOf", t.OneOf}} {
+		for i, sub := range union.subs {
+			l.lint(fmt.Sprintf("%s/%s/%d", path, union.keyword, i), sub, false)
+		}
+	}
+}
+
+// additionalPropertiesSchema returns the schema that additionalProperties
+// declares, or nil if it is a boolean or absent.
+func additionalPropertiesSchema(t *schemas.Type) *schemas.Type {
+	if t.AdditionalProperties == nil {
+		return nil
+	}
+	switch v := (*t.AdditionalProperties).(type) {
+	case *schemas.Type:
+		return v
+	case map[string]interface{}:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil
+		}
+		var additional schemas.Type
+		if err := json.Unmarshal(b, &additional); err != nil {
+			return nil
+		}
+		return &additional
+	default:
+		return nil
+	}
+}
+
+// enumTypeNames returns the sorted JSON types of the values of an enum,
+// other than null.
+func enumTypeNames(values []interface{}) []string {
+	seen := map[string]bool{}
+	for _, v := range values {
+		switch v.(type) {
+		case string:
+			seen[schemas.TypeNameString] = true
+		case float64:
+			seen[schemas.TypeNameNumber] = true
+		case bool:
+			seen[schemas.TypeNameBoolean] = true
+		case []interface{}:
+			seen[schemas.TypeNameArray] = true
+		case map[string]interface{}:
+			seen[schemas.TypeNameObject] = true
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// overlaps reports whether two patterns can match the same string. Since
+// that can't be decided in general, it checks whether either pattern matches
+// a shortest string that the other one matches, which catches patterns that
+// are the same, or that are prefixes or generalizations of one another.
+func overlaps(a, b string) bool {
+	reA, errA := regexp.Compile(a)
+	reB, errB := regexp.Compile(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	if s, ok := shortestMatch(a); ok && reB.MatchString(s) {
+		return true
+	}
+	if s, ok := shortestMatch(b); ok && reA.MatchString(s) {
+		return true
+	}
+	return false
+}
+
+// shortestMatch returns a short string that a pattern matches, built by
+// taking the first alternative and the fewest repetitions of everything.
+func shortestMatch(pattern string) (string, bool) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", false
+	}
+	var sb strings.Builder
+	var build func(re *syntax.Regexp) bool
+	build = func(re *syntax.Regexp) bool {
+		switch re.Op {
+		case syntax.OpLiteral:
+			sb.WriteString(string(re.Rune))
+		case syntax.OpCharClass:
+			if len(re.Rune) == 0 {
+				return false
+			}
+			sb.WriteRune(re.Rune[0])
+		case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+			sb.WriteRune('a')
+		case syntax.OpCapture, syntax.OpPlus:
+			return build(re.Sub[0])
+		case syntax.OpRepeat:
+			for i := 0; i < re.Min; i++ {
+				if !build(re.Sub[0]) {
+					return false
+				}
+			}
+		case syntax.OpConcat:
+			for _, sub := range re.Sub {
+				if !build(sub) {
+					return false
+				}
+			}
+		case syntax.OpAlternate:
+			return build(re.Sub[0])
+		case syntax.OpNoMatch:
+			return false
+		}
+		// Empty matches, anchors, word boundaries, stars and quests add
+		// nothing.
+		return true
+	}
+	if !build(re) {
+		return "", false
+	}
+	return sb.String(), true
+}
+
+func escape(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
+
+func sortedKeys(m map[string]*schemas.Type) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,227 @@
+// Package reverse generates JSON schemas from Go types, the reverse of what
+// the generator does, so that schemas can be kept in sync with types that are
+// written by hand.
+package reverse
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/schemas"
+)
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Schema returns a JSON schema for the type of v, which is usually a struct
+// or a pointer to one. It follows encoding/json: properties are named by
+// their "json" tags, embedded structs without tags are flattened, and fields
+// that are unexported or tagged "-" are left out. Fields without "omitempty"
+// are required. Named structs, including the root, become definitions that
+// are referred to with "$ref", so that recursive types are described too.
+// Types that marshal themselves as text, such as time.Time, are strings.
+func Schema(v interface{}) (*schemas.Schema, error) {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return nil, fmt.Errorf("cannot generate a schema for nil")
+	}
+
+	r := &reflector{
+		names: map[reflect.Type]string{},
+		taken: map[string]bool{},
+		defs:  schemas.Definitions{},
+	}
+	root, err := r.schema(t)
+	if err != nil {
+		return nil, err
+	}
+	root.Version = schemas.Draft07.URI()
+	schema := &schemas.Schema{ObjectAsType: (*schemas.ObjectAsType)(root), Draft: schemas.Draft07}
+	if len(r.defs) > 0 {
+		schema.Definitions = r.defs
+	}
+	return schema, nil
+}
+
+type reflector struct {
+	names map[reflect.Type]string
+	taken map[string]bool
+	defs  schemas.Definitions
+}
+
+func (r *reflector) schema(t reflect.Type) (*schemas.Type, error) {
+	switch {
+	case t == timeType:
+		return &schemas.Type{Type: schemas.TypeList{schemas.TypeNameString}, Format: "date-time"}, nil
+	case t == rawMessageType:
+		return &schemas.Type{}, nil
+	case t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType):
+		return &schemas.Type{Type: schemas.TypeList{schemas.TypeNameString}}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &schemas.Type{Type: schemas.TypeList{schemas.TypeNameBoolean}}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &schemas.Type{Type: schemas.TypeList{schemas.TypeNameInteger}}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		minimum := 0.0
+		return &schemas.Type{Type: schemas.TypeList{schemas.TypeNameInteger}, Minimum: &minimum}, nil
+	case reflect.Float32, reflect.Float64:
+		return &schemas.Type{Type: schemas.TypeList{schemas.TypeNameNumber}}, nil
+	case reflect.String:
+		return &schemas.Type{Type: schemas.TypeList{schemas.TypeNameString}}, nil
+	case reflect.Interface:
+		return &schemas.Type{}, nil
+	case reflect.Ptr:
+		return r.schema(t.Elem())
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			// encoding/json marshals []byte as a base64 string.
+			return &schemas.Type{Type: schemas.TypeList{schemas.TypeNameString}}, nil
+		}
+		items, err := r.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		schema := &schemas.Type{Type: schemas.TypeList{schemas.TypeNameArray}, Items: schemas.SchemaItems(items)}
+		if t.Kind() == reflect.Array {
+			schema.MinItems, schema.MaxItems = t.Len(), t.Len()
+		}
+		return schema, nil
+	case reflect.Map:
+		switch t.Key().Kind() {
+		case reflect.String, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		default:
+			if !t.Key().Implements(textMarshalerType) {
+				return nil, fmt.Errorf("unsupported map key type %s", t.Key())
+			}
+		}
+		values, err := r.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		var additional interface{} = values
+		return &schemas.Type{Type: schemas.TypeList{schemas.TypeNameObject}, AdditionalProperties: &additional}, nil
+	case reflect.Struct:
+		return r.ref(t)
+	default:
+		return nil, fmt.Errorf("unsupported type %s", t)
+	}
+}
+
+// ref returns a reference to the definition of a struct, adding it if it
+// hasn't been added already. Anonymous structs are described inline.
+func (r *reflector) ref(t reflect.Type) (*schemas.Type, error) {
+	if t.Name() == "" {
+		return r.structSchema(t)
+	}
+	if name, ok := r.names[t]; ok {
+		return &schemas.Type{Ref: "#/definitions/" + name}, nil
+	}
+
+	name := t.Name()
+	for i := 2; r.taken[name]; i++ {
+		name = fmt.Sprintf("%s_%d", t.Name(), i)
+	}
+	r.taken[name] = true
+	r.names[t] = name
+
+	schema, err := r.structSchema(t)
+	if err != nil {
+		return nil, err
+	}
+	r.defs[name] = schema
+	return &schemas.Type{Ref: "#/definitions/" + name}, nil
+}
+
+func (r *reflector) structSchema(t reflect.Type) (*schemas.Type, error) {
+	schema := &schemas.Type{
+		Type:       schemas.TypeList{schemas.TypeNameObject},
+		Properties: map[string]*schemas.Type{},
+	}
+	if err := r.addFields(schema, t); err != nil {
+		return nil, err
+	}
+	if len(schema.Properties) == 0 {
+		schema.Properties = nil
+	}
+	return schema, nil
+}
+
+// addFields adds the properties for the fields of a struct, including those
+// of the structs embedded in it, to a schema. As with encoding/json, the
+// fields of a struct take precedence over those of the structs it embeds.
+func (r *reflector) addFields(schema *schemas.Type, t reflect.Type) error {
+	var embedded []reflect.Type
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, hasTag := f.Tag.Lookup("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && !hasTag {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded = append(embedded, ft)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		var prop *schemas.Type
+		if hasOption(opts, "string") {
+			prop = &schemas.Type{Type: schemas.TypeList{schemas.TypeNameString}}
+		} else {
+			var err error
+			if prop, err = r.schema(f.Type); err != nil {
+				return errors.Wrapf(err, "field %s.%s", t, f.Name)
+			}
+		}
+		if _, exists := schema.Properties[name]; exists {
+			continue
+		}
+		schema.Properties[name] = prop
+		if !hasOption(opts, "omitempty") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+
+	for _, ft := range embedded {
+		if err := r.addFields(schema, ft); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func hasOption(opts, option string) bool {
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,171 @@
+package schemas
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Draft is a JSON Schema dialect, as identified by the $schema keyword.
+type Draft int
+
+const (
+	DraftUnknown Draft = iota
+	Draft04
+	Draft06
+	Draft07
+	Draft201909
+	Draft202012
+)
+
+var draftsByURI = map[string]Draft{
+	"json-schema.org/draft-04/schema":      Draft04,
+	"json-schema.org/draft-06/schema":      Draft06,
+	"json-schema.org/draft-07/schema":      Draft07,
+	"json-schema.org/draft/2019-09/schema": Draft201909,
+	"json-schema.org/draft/2020-12/schema": Draft202012,
+}
+
+// DraftFromURI returns the draft identified by a $schema URI, or DraftUnknown
+// if it is not recognized.
+func DraftFromURI(uri string) Draft {
+	s := strings.TrimSuffix(uri, "#")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "http://"), "https://")
+	return draftsByURI[s]
+}
+
+// URI returns the $schema URI that identifies the draft, or "" if the draft
+// is unknown.
+func (d Draft) URI() string {
+	for uri, draft := range draftsByURI {
+		if draft == d {
+			if d == Draft04 || d == Draft06 || d == Draft07 {
+				return "http://" + uri + "#"
+			}
+			return "https://" + uri
+		}
+	}
+	return ""
+}
+
+func (d Draft) String() string {
+	switch d {
+	case Draft04:
+		return "draft-04"
+	case Draft06:
+		return "draft-06"
+	case Draft07:
+		return "draft-07"
+	case Draft201909:
+		return "2019-09"
+	case Draft202012:
+		return "2020-12"
+	default:
+		return "unknown draft"
+	}
+}
+
+// Warnings reports keywords of the schema that belong to a different draft
+// than the one declared by its $schema. Nothing is reported if the draft is
+// unknown.
+func (s *Schema) Warnings() []string {
+	d := s.Draft
+	if d == DraftUnknown {
+		return nil
+	}
+
+	var warnings []string
+	warn := func(format string, args ...interface{}) {
+		warnings = append(warnings, fmt.Sprintf("Schema is %s, but ", d)+fmt.Sprintf(format, args...))
+	}
+
+	if d == Draft04 && s.LegacyID == "" && s.ID != "" {
+		warn("uses \"$id\", which was introduced in draft-06 to replace \"id\"")
+	} else if d != Draft04 && s.LegacyID != "" && s.LegacyID == s.ID {
+		warn("uses \"id\", which was replaced by \"$id\" in draft-06")
+	}
+	if d >= Draft201909 && len(s.Definitions) > 0 {
+		warn("uses \"definitions\", which was replaced by \"$defs\" in 2019-09")
+	} else if d < Draft201909 && len(s.Defs) > 0 {
+		warn("uses \"$defs\", which was introduced in 2019-09 to replace \"definitions\"")
+	}
+
+	var boolBounds, numericBounds bool
+	s.walk(func(t *Type) {
+		for _, b := range []*ExclusiveBound{t.ExclusiveMinimum, t.ExclusiveMaximum} {
+			if b == nil {
+				continue
+			}
+			if b.Value != nil {
+				numericBounds = true
+			} else {
+				boolBounds = true
+			}
+		}
+	})
+	if d == Draft04 && numericBounds {
+		warn("uses numeric \"exclusiveMinimum\" or \"exclusiveMaximum\", which were introduced in draft-06")
+	} else if d != Draft04 && boolBounds {
+		warn("uses boolean \"exclusiveMinimum\" or \"exclusiveMaximum\", which were replaced by numbers in draft-06")
+	}
+
+	return warnings
+}
+
+// walk calls fn for the root type and each of its subschemas.
+func (s *Schema) walk(fn func(*Type)) {
+	if s.ObjectAsType != nil {
+		(*Type)(s.ObjectAsType).walk(fn)
+	}
+	defs := s.AllDefinitions()
+	for _, name := range sortedNames(defs) {
+		defs[name].walk(fn)
+	}
+}
+
+func (t *Type) walk(fn func(*Type)) {
+	if t == nil {
+		return
+	}
+	fn(t)
+	for _, name := range sortedNames(t.Properties) {
+		t.Properties[name].walk(fn)
+	}
+	for _, name := range sortedNames(t.PatternProperties) {
+		t.PatternProperties[name].walk(fn)
+	}
+	for _, name := range sortedNames(t.Dependencies) {
+		t.Dependencies[name].walk(fn)
+	}
+	for _, name := range sortedNames(t.Definitions) {
+		t.Definitions[name].walk(fn)
+	}
+	for _, subs := range [][]*Type{t.AllOf, t.AnyOf, t.OneOf} {
+		for _, sub := range subs {
+			sub.walk(fn)
+		}
+	}
+	t.Items.walk(fn)
+	t.AdditionalItems.walk(fn)
+	t.PropertyNames.walk(fn)
+	t.Not.walk(fn)
+}
+
+func (i *Items) walk(fn func(*Type)) {
+	if i == nil {
+		return
+	}
+	i.Schema.walk(fn)
+	for _, t := range i.Tuple {
+		t.walk(fn)
+	}
+}
+
+func sortedNames(m map[string]*Type) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
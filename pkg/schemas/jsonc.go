@@ -0,0 +1,84 @@
+package schemas
+
+// stripJSONC turns a JSON document with comments and trailing commas, as
+// editors such as VS Code accept, into plain JSON. Line and block comments and
+// commas that are followed by "}" or "]" are replaced with spaces, leaving
+// line breaks alone, so that the offsets in errors from the JSON decoder
+// still point into the original document. Plain JSON is returned unchanged.
+func stripJSONC(b []byte) []byte {
+	var out []byte
+	blank := func(from, to int) {
+		if out == nil {
+			out = append([]byte(nil), b...)
+		}
+		for i := from; i < to; i++ {
+			if out[i] != '\n' && out[i] != '\r' {
+				out[i] = ' '
+			}
+		}
+	}
+
+	skipString := func(i int) int {
+		for i++; i < len(b); i++ {
+			switch b[i] {
+			case '\\':
+				i++
+			case '"':
+				return i
+			}
+		}
+		return i
+	}
+
+	// Comments are blanked first, so that a comment between a comma and a
+	// closing bracket doesn't hide the comma.
+	for i := 0; i < len(b); i++ {
+		switch {
+		case b[i] == '"':
+			i = skipString(i)
+		case b[i] == '/' && i+1 < len(b) && b[i+1] == '/':
+			end := i + 2
+			for end < len(b) && b[end] != '\n' {
+				end++
+			}
+			blank(i, end)
+			i = end
+		case b[i] == '/' && i+1 < len(b) && b[i+1] == '*':
+			end := i + 2
+			for end+1 < len(b) && !(b[end] == '*' && b[end+1] == '/') {
+				end++
+			}
+			if end += 2; end > len(b) {
+				end = len(b)
+			}
+			blank(i, end)
+			i = end - 1
+		}
+	}
+	if out != nil {
+		b = out
+	}
+
+	for i := 0; i < len(b); i++ {
+		switch b[i] {
+		case '"':
+			i = skipString(i)
+		case ',':
+			next := i + 1
+			for next < len(b) && isJSONSpace(b[next]) {
+				next++
+			}
+			if next < len(b) && (b[next] == '}' || b[next] == ']') {
+				blank(i, i+1)
+			}
+		}
+	}
+	if out == nil {
+		return b
+	}
+	return out
+}
+
+func isJSONSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
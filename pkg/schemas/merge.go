@@ -0,0 +1,514 @@
+package schemas
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+)
+
+// Merge returns a schema that a value is valid against if and only if it is
+// valid against both a and b, as for {"allOf": [a, b]}, written as a single
+// schema where possible:
+//
+//   - The types are intersected, with integer being a kind of number.
+//   - Required properties are the union of both, and the properties of both
+//     are merged, the ones that both declare recursively. A property that
+//     only one declares must also be valid against the additionalProperties
+//     of the other.
+//   - Enums are intersected, and bounds such as minimum, maxLength and
+//     minItems take the tighter of the two.
+//
+// Keywords that can't be combined, such as two different patterns, are
+// kept in the allOf of the result, as are the allOf of a and b. Annotations
+// such as title and description are taken from a, or else from b.
+//
+// Neither schema may have a $ref, since Merge does not load the schemas
+// that they refer to. Merge returns an error if no value can be valid
+// against both, such as for types or enums with nothing in common. Neither a
+// nor b is modified.
+func Merge(a, b *Type) (*Type, error) {
+	if a == nil {
+		return b, nil
+	}
+	if b == nil {
+		return a, nil
+	}
+	if a.Ref != "" || b.Ref != "" {
+		return nil, fmt.Errorf("cannot merge a schema with $ref; resolve it first")
+	}
+
+	m := &merger{}
+	merged := *a
+	merged.AllOf = append(append([]*Type(nil), a.AllOf...), b.AllOf...)
+
+	types, err := mergeTypes(a.Type, b.Type)
+	if err != nil {
+		return nil, err
+	}
+	merged.Type = types
+	if merged.Enum, err = mergeEnums(a.Enum, b.Enum); err != nil {
+		return nil, err
+	}
+
+	merged.MultipleOf = m.multipleOf(a.MultipleOf, b.MultipleOf)
+	merged.Minimum, merged.ExclusiveMinimum = m.bound(a.Minimum, a.ExclusiveMinimum, b.Minimum, b.ExclusiveMinimum, true)
+	merged.Maximum, merged.ExclusiveMaximum = m.bound(a.Maximum, a.ExclusiveMaximum, b.Maximum, b.ExclusiveMaximum, false)
+	merged.MinLength = maxInt(a.MinLength, b.MinLength)
+	merged.MaxLength = minIntLimit(a.MaxLength, b.MaxLength)
+	merged.MinItems = maxInt(a.MinItems, b.MinItems)
+	merged.MaxItems = minLimit(a.MaxItems, b.MaxItems)
+	merged.MinProperties = maxInt(a.MinProperties, b.MinProperties)
+	merged.MaxProperties = minLimit(a.MaxProperties, b.MaxProperties)
+	merged.UniqueItems = a.UniqueItems || b.UniqueItems
+	merged.Pattern = m.either(a.Pattern, b.Pattern, &Type{Pattern: b.Pattern})
+	merged.Format = m.either(a.Format, b.Format, &Type{Format: b.Format})
+
+	if merged.Items, err = m.items(a.Items, b.Items, "items"); err != nil {
+		return nil, err
+	}
+	if merged.AdditionalItems, err = m.items(a.AdditionalItems, b.AdditionalItems, "additionalItems"); err != nil {
+		return nil, err
+	}
+	if merged.PropertyNames, err = Merge(a.PropertyNames, b.PropertyNames); err != nil {
+		return nil, err
+	}
+	if err := m.properties(&merged, a, b); err != nil {
+		return nil, err
+	}
+	if merged.Dependencies, err = mergeSchemaMaps(a.Dependencies, b.Dependencies); err != nil {
+		return nil, err
+	}
+
+	if a.Not != nil && b.Not != nil {
+		m.residue = append(m.residue, &Type{Not: b.Not})
+	} else if a.Not == nil {
+		merged.Not = b.Not
+	}
+	for _, union := range []struct {
+		dst  *[]*Type
+		a, b []*Type
+		kind func(subs []*Type) *Type
+	}{
+		{&merged.AnyOf, a.AnyOf, b.AnyOf, func(subs []*Type) *Type { return &Type{AnyOf: subs} }},
+		{&merged.OneOf, a.OneOf, b.OneOf, func(subs []*Type) *Type { return &Type{OneOf: subs} }},
+	} {
+		if len(union.a) > 0 && len(union.b) > 0 {
+			m.residue = append(m.residue, union.kind(union.b))
+		} else if len(union.a) == 0 {
+			*union.dst = union.b
+		}
+	}
+
+	merged.Definitions = mergeDefinitions(a.Definitions, b.Definitions)
+	merged.Extras = mergeExtras(a.Extras, b.Extras)
+	for _, s := range []struct {
+		dst *string
+		b   string
+	}{
+		{&merged.Version, b.Version},
+		{&merged.Title, b.Title},
+		{&merged.Description, b.Description},
+		{&merged.BinaryEncoding, b.BinaryEncoding},
+		{&merged.GoType, b.GoType},
+		{&merged.GoName, b.GoName},
+	} {
+		if *s.dst == "" {
+			*s.dst = s.b
+		}
+	}
+	if merged.Default == nil {
+		merged.Default = b.Default
+	}
+	if merged.Examples == nil {
+		merged.Examples = b.Examples
+	}
+	if merged.Media == nil {
+		merged.Media = b.Media
+	}
+	if merged.GoJSONSchemaExtension == nil {
+		merged.GoJSONSchemaExtension = b.GoJSONSchemaExtension
+	}
+	if merged.GoTypeImport == nil {
+		merged.GoTypeImport = b.GoTypeImport
+	}
+	if merged.OmitEmpty == nil {
+		merged.OmitEmpty = b.OmitEmpty
+	}
+
+	merged.AllOf = append(merged.AllOf, m.residue...)
+	if len(merged.AllOf) == 0 {
+		merged.AllOf = nil
+	}
+	return &merged, nil
+}
+
+// merger collects the keywords of the second schema that can't be combined
+// with those of the first.
+type merger struct {
+	residue []*Type
+}
+
+// either returns a, or b if a is unset, keeping the residue if both are set
+// and differ.
+func (m *merger) either(a, b string, residue *Type) string {
+	switch {
+	case a == "":
+		return b
+	case b != "" && a != b:
+		m.residue = append(m.residue, residue)
+	}
+	return a
+}
+
+func (m *merger) multipleOf(a, b *float64) *float64 {
+	switch {
+	case a == nil:
+		return b
+	case b == nil || *a == *b:
+		return a
+	case isMultiple(*a, *b):
+		return a
+	case isMultiple(*b, *a):
+		return b
+	}
+	m.residue = append(m.residue, &Type{MultipleOf: b})
+	return a
+}
+
+func isMultiple(value, of float64) bool {
+	q := value / of
+	return math.Abs(q-math.Round(q)) < 1e-9
+}
+
+// bound merges the inclusive and exclusive bounds of a and b, taking the
+// tighter of each, unless either exclusive bound is a draft 4 boolean that
+// modifies the inclusive one.
+func (m *merger) bound(
+	aInclusive *float64, aExclusive *ExclusiveBound, bInclusive *float64, bExclusive *ExclusiveBound, lower bool,
+) (*float64, *ExclusiveBound) {
+	pick := math.Min
+	if lower {
+		pick = math.Max
+	}
+	isBoolean := func(b *ExclusiveBound) bool {
+		return b != nil && b.Value == nil
+	}
+	if !isBoolean(aExclusive) && !isBoolean(bExclusive) {
+		var exclusive *ExclusiveBound
+		if value := tighter(aExclusive.Bound(nil), bExclusive.Bound(nil), pick); value != nil {
+			exclusive = &ExclusiveBound{Value: value}
+		}
+		return tighter(aInclusive, bInclusive, pick), exclusive
+	}
+
+	switch {
+	case bInclusive == nil && bExclusive == nil:
+	case aInclusive == nil && aExclusive == nil:
+		return bInclusive, bExclusive
+	case !reflect.DeepEqual(aInclusive, bInclusive) || !reflect.DeepEqual(aExclusive, bExclusive):
+		if lower {
+			m.residue = append(m.residue, &Type{Minimum: bInclusive, ExclusiveMinimum: bExclusive})
+		} else {
+			m.residue = append(m.residue, &Type{Maximum: bInclusive, ExclusiveMaximum: bExclusive})
+		}
+	}
+	return aInclusive, aExclusive
+}
+
+func (m *merger) items(a, b *Items, keyword string) (*Items, error) {
+	switch {
+	case a == nil:
+		return b, nil
+	case b == nil:
+		return a, nil
+	case !a.IsTuple() && !b.IsTuple():
+		merged, err := Merge(a.All(), b.All())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", keyword, err)
+		}
+		return &Items{Schema: merged}, nil
+	}
+	if keyword == "items" {
+		m.residue = append(m.residue, &Type{Items: b})
+	} else {
+		m.residue = append(m.residue, &Type{AdditionalItems: b})
+	}
+	return a, nil
+}
+
+// properties merges the properties, required properties, pattern properties
+// and additional properties of a and b into merged.
+func (m *merger) properties(merged, a, b *Type) error {
+	merged.Required = nil
+	seen := map[string]bool{}
+	for _, name := range append(append([]string(nil), a.Required...), b.Required...) {
+		if !seen[name] {
+			seen[name] = true
+			merged.Required = append(merged.Required, name)
+		}
+	}
+
+	aAdditional, _, err := additionalProperties(a)
+	if err != nil {
+		return err
+	}
+	bAdditional, _, err := additionalProperties(b)
+	if err != nil {
+		return err
+	}
+	// A property that one schema declares and the other doesn't is governed
+	// by the other's patternProperties and additionalProperties. The pattern
+	// properties are kept as they are, but the additional properties only
+	// apply to the properties that neither schema declares or matches, so
+	// they are merged into the property.
+	if (aAdditional != nil && hasNewPatterns(a, b)) || (bAdditional != nil && hasNewPatterns(b, a)) {
+		m.residue = append(m.residue, &Type{
+			Properties:           b.Properties,
+			PatternProperties:    b.PatternProperties,
+			AdditionalProperties: b.AdditionalProperties,
+		})
+		return nil
+	}
+
+	if len(a.Properties) > 0 || len(b.Properties) > 0 {
+		merged.Properties = map[string]*Type{}
+		merged.PropertyOrder = nil
+	}
+	add := func(name string, t *Type) {
+		if _, ok := merged.Properties[name]; !ok {
+			merged.PropertyOrder = append(merged.PropertyOrder, name)
+		}
+		merged.Properties[name] = t
+	}
+	for _, name := range propertyNames(a) {
+		prop := a.Properties[name]
+		if other, ok := b.Properties[name]; ok {
+			if prop, err = Merge(prop, other); err != nil {
+				return fmt.Errorf("property %q: %s", name, err)
+			}
+		} else if bAdditional != nil && !matchesPattern(b, name) {
+			if prop, err = Merge(prop, bAdditional); err != nil {
+				return fmt.Errorf("property %q: %s", name, err)
+			}
+		}
+		add(name, prop)
+	}
+	for _, name := range propertyNames(b) {
+		if _, ok := a.Properties[name]; ok {
+			continue
+		}
+		prop := b.Properties[name]
+		if aAdditional != nil && !matchesPattern(a, name) {
+			if prop, err = Merge(prop, aAdditional); err != nil {
+				return fmt.Errorf("property %q: %s", name, err)
+			}
+		}
+		add(name, prop)
+	}
+
+	if merged.PatternProperties, err = mergeSchemaMaps(a.PatternProperties, b.PatternProperties); err != nil {
+		return err
+	}
+
+	switch {
+	case aAdditional == nil:
+		merged.AdditionalProperties = b.AdditionalProperties
+	case bAdditional == nil:
+		merged.AdditionalProperties = a.AdditionalProperties
+	default:
+		additional, err := Merge(aAdditional, bAdditional)
+		if err != nil {
+			return fmt.Errorf("additionalProperties: %s", err)
+		}
+		var v interface{} = additional
+		merged.AdditionalProperties = &v
+	}
+	return nil
+}
+
+// hasNewPatterns reports whether b has patternProperties that a lacks, which
+// would exempt properties from the additionalProperties of a if they were
+// merged.
+func hasNewPatterns(a, b *Type) bool {
+	for pattern := range b.PatternProperties {
+		if _, ok := a.PatternProperties[pattern]; !ok {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesPattern(t *Type, name string) bool {
+	for pattern := range t.PatternProperties {
+		if re, err := regexp.Compile(pattern); err == nil && re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// propertyNames returns the names of the properties of a type, in the order
+// they were declared, followed by those that PropertyOrder lacks.
+func propertyNames(t *Type) []string {
+	names := make([]string, 0, len(t.Properties))
+	seen := map[string]bool{}
+	for _, name := range t.PropertyOrder {
+		if _, ok := t.Properties[name]; ok && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	for _, name := range sortedNames(t.Properties) {
+		if !seen[name] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func mergeTypes(a, b TypeList) (TypeList, error) {
+	if len(a) == 0 {
+		return b, nil
+	}
+	if len(b) == 0 {
+		return a, nil
+	}
+	var types TypeList
+	for _, x := range a {
+		for _, y := range b {
+			switch {
+			case x == y:
+				types = append(types, x)
+			case x == TypeNameInteger && y == TypeNameNumber:
+				types = append(types, x)
+			case x == TypeNameNumber && y == TypeNameInteger:
+				types = append(types, y)
+			}
+		}
+	}
+	if len(types) == 0 {
+		return nil, fmt.Errorf("types %v and %v have nothing in common", []string(a), []string(b))
+	}
+	return types, nil
+}
+
+func mergeEnums(a, b []interface{}) ([]interface{}, error) {
+	if a == nil {
+		return b, nil
+	}
+	if b == nil {
+		return a, nil
+	}
+	var values []interface{}
+	for _, x := range a {
+		for _, y := range b {
+			if reflect.DeepEqual(x, y) {
+				values = append(values, x)
+				break
+			}
+		}
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("enums %v and %v have no values in common", a, b)
+	}
+	return values, nil
+}
+
+// mergeSchemaMaps merges maps of schemas, such as patternProperties, whose
+// schemas all apply, merging the schemas that both maps have for a key.
+func mergeSchemaMaps(a, b map[string]*Type) (map[string]*Type, error) {
+	if len(a) == 0 {
+		return b, nil
+	}
+	if len(b) == 0 {
+		return a, nil
+	}
+	merged := make(map[string]*Type, len(a)+len(b))
+	for k, t := range a {
+		merged[k] = t
+	}
+	for k, t := range b {
+		if existing, ok := merged[k]; ok {
+			var err error
+			if t, err = Merge(existing, t); err != nil {
+				return nil, fmt.Errorf("%q: %s", k, err)
+			}
+		}
+		merged[k] = t
+	}
+	return merged, nil
+}
+
+// mergeDefinitions combines definitions, which are not constraints, taking
+// those of a where both define a name.
+func mergeDefinitions(a, b Definitions) Definitions {
+	if len(a) == 0 {
+		return b
+	}
+	if len(b) == 0 {
+		return a
+	}
+	merged := make(Definitions, len(a)+len(b))
+	for name, t := range b {
+		merged[name] = t
+	}
+	for name, t := range a {
+		merged[name] = t
+	}
+	return merged
+}
+
+func mergeExtras(a, b map[string]json.RawMessage) map[string]json.RawMessage {
+	if len(a) == 0 {
+		return b
+	}
+	if len(b) == 0 {
+		return a
+	}
+	merged := make(map[string]json.RawMessage, len(a)+len(b))
+	for k, v := range b {
+		merged[k] = v
+	}
+	for k, v := range a {
+		merged[k] = v
+	}
+	return merged
+}
+
+// tighter returns the tighter of two optional bounds, as chosen by pick.
+func tighter(a, b *float64, pick func(x, y float64) float64) *float64 {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	v := pick(*a, *b)
+	return &v
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// minIntLimit returns the tighter of two optional upper limits.
+func minIntLimit(a, b *int) *int {
+	if a == nil || (b != nil && *b < *a) {
+		return b
+	}
+	return a
+}
+
+// minLimit returns the tighter of two upper limits, where zero means that
+// there is no limit, as in Type.
+func minLimit(a, b int) int {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
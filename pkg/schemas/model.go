@@ -24,22 +24,31 @@
 package schemas
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
 )
 
 // Schema is the root schema.
 type Schema struct {
 	*ObjectAsType
-	ID          string      `json:"$id"` // RFC draft-wright-json-schema-01, section-9.2
-	LegacyID    string      `json:"id"`  // RFC draft-wright-json-schema-00, section 4.5
+	ID          string      `json:"$id,omitempty"` // RFC draft-wright-json-schema-01, section-9.2
+	LegacyID    string      `json:"id,omitempty"`  // RFC draft-wright-json-schema-00, section 4.5
 	Definitions Definitions `json:"definitions,omitempty"`
+	Defs        Definitions `json:"$defs,omitempty"` // JSON Schema 2019-09, section 8.2.5
+
+	// Draft is the dialect declared by $schema.
+	Draft Draft `json:"-"`
 }
 
 // UnmarshalJSON implements json.Unmarshaler for Schema struct
 func (s *Schema) UnmarshalJSON(data []byte) error {
 	var unmarshSchema unmarshalerSchema
 	if err := json.Unmarshal(data, &unmarshSchema); err != nil {
-		return err
+		return decodeError(data, err)
 	}
 
 	// fall back to id if $id is not present
@@ -47,14 +56,172 @@ func (s *Schema) UnmarshalJSON(data []byte) error {
 		unmarshSchema.ID = unmarshSchema.LegacyID
 	}
 
+	if unmarshSchema.ObjectAsType != nil {
+		unmarshSchema.Draft = DraftFromURI(unmarshSchema.Version)
+		if err := unmarshSchema.ObjectAsType.readPropertyOrder(data); err != nil {
+			return decodeError(data, err)
+		}
+		if err := unmarshSchema.ObjectAsType.readExtras(data, schemaKeywords); err != nil {
+			return decodeError(data, err)
+		}
+	}
+
 	*s = Schema(unmarshSchema)
 
 	return nil
 }
 
+// MarshalJSON implements json.Marshaler for Schema struct, writing the
+// keywords of the root type, with $id and id after $schema, and the
+// definitions last.
+func (s Schema) MarshalJSON() ([]byte, error) {
+	var root Type
+	if s.ObjectAsType != nil {
+		root = Type(*s.ObjectAsType)
+	}
+	if s.Definitions != nil {
+		root.Definitions = nil
+	}
+	b, err := root.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	members, err := objectMembers(b)
+	if err != nil {
+		return nil, err
+	}
+
+	var head []jsonMember
+	if len(members) > 0 && members[0].key == "$schema" {
+		head, members = []jsonMember{members[0]}, members[1:]
+	}
+	var tail []jsonMember
+	for _, m := range []struct {
+		key   string
+		value interface{}
+		set   bool
+	}{
+		{"$id", s.ID, s.ID != ""},
+		{"id", s.LegacyID, s.LegacyID != ""},
+		{"definitions", s.Definitions, s.Definitions != nil},
+		{"$defs", s.Defs, s.Defs != nil},
+	} {
+		if !m.set {
+			continue
+		}
+		value, err := json.Marshal(m.value)
+		if err != nil {
+			return nil, err
+		}
+		if m.key == "$id" || m.key == "id" {
+			head = append(head, jsonMember{key: m.key, value: value})
+		} else {
+			tail = append(tail, jsonMember{key: m.key, value: value})
+		}
+	}
+	return writeObject(append(append(head, members...), tail...)), nil
+}
+
+// AllDefinitions returns the definitions declared with either "definitions"
+// or "$defs". If both declare the same name, "definitions" takes precedence.
+func (s *Schema) AllDefinitions() Definitions {
+	if len(s.Defs) == 0 {
+		return s.Definitions
+	}
+	defs := make(Definitions, len(s.Definitions)+len(s.Defs))
+	for name, t := range s.Defs {
+		defs[name] = t
+	}
+	for name, t := range s.Definitions {
+		defs[name] = t
+	}
+	return defs
+}
+
 type unmarshalerSchema Schema
 type ObjectAsType Type
 
+// typeKeywords and schemaKeywords are the keywords that the fields of Type
+// and Schema hold.
+var (
+	typeKeywords   = jsonKeywords(reflect.TypeOf(Type{}))
+	schemaKeywords = jsonKeywords(reflect.TypeOf(Schema{}))
+)
+
+// jsonKeywords returns the names that the fields of a struct are decoded
+// from.
+func jsonKeywords(t reflect.Type) map[string]bool {
+	keywords := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			keywords[name] = true
+		}
+	}
+	return keywords
+}
+
+// readExtras sets Extras from the raw JSON object the type was unmarshaled
+// from, to the keywords that neither Type nor, if it is given, the struct
+// that the type was unmarshaled with have fields for. It sets DefaultJSON
+// too.
+func (t *ObjectAsType) readExtras(raw []byte, known map[string]bool) error {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return err
+	}
+	t.Extras = nil
+	t.DefaultJSON = nil
+	if value, ok := obj["default"]; ok {
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, value); err != nil {
+			return err
+		}
+		t.DefaultJSON = compact.Bytes()
+	}
+	for keyword, value := range obj {
+		if typeKeywords[keyword] || known[keyword] {
+			continue
+		}
+		if t.Extras == nil {
+			t.Extras = map[string]json.RawMessage{}
+		}
+		t.Extras[keyword] = value
+	}
+	return nil
+}
+
+// readPropertyOrder sets PropertyOrder from the raw JSON object the type was
+// unmarshaled from.
+func (t *ObjectAsType) readPropertyOrder(raw []byte) error {
+	if len(t.Properties) == 0 {
+		return nil
+	}
+	var obj struct {
+		Properties json.RawMessage `json:"properties"`
+	}
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(obj.Properties))
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	t.PropertyOrder = nil
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		t.PropertyOrder = append(t.PropertyOrder, key.(string))
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // TypeList is a list of type names.
 type TypeList []string
 
@@ -63,7 +230,7 @@ func (t *TypeList) UnmarshalJSON(b []byte) error {
 	if len(b) > 0 && b[0] == '[' {
 		var s []string
 		if err := json.Unmarshal(b, &s); err != nil {
-			return err
+			return decodeError(b, err)
 		}
 		*t = TypeList(s)
 		return nil
@@ -71,7 +238,7 @@ func (t *TypeList) UnmarshalJSON(b []byte) error {
 
 	var s string
 	if err := json.Unmarshal(b, &s); err != nil {
-		return err
+		return decodeError(b, err)
 	}
 	if s != "" {
 		*t = TypeList([]string{s})
@@ -81,6 +248,149 @@ func (t *TypeList) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// MarshalJSON implements json.Marshaler, writing a single type as a string.
+func (t TypeList) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// ExclusiveBound is the value of exclusiveMinimum or exclusiveMaximum. In
+// draft 4 it is a boolean that makes minimum or maximum exclusive; in later
+// drafts it is a number that is itself the exclusive bound.
+type ExclusiveBound struct {
+	Exclusive bool
+	Value     *float64
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (b *ExclusiveBound) UnmarshalJSON(raw []byte) error {
+	var exclusive bool
+	if err := json.Unmarshal(raw, &exclusive); err == nil {
+		*b = ExclusiveBound{Exclusive: exclusive}
+		return nil
+	}
+
+	var value float64
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return decodeError(raw, err)
+	}
+	*b = ExclusiveBound{Value: &value}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler, writing the bound as it was
+// declared: as a number, or as a boolean in draft 4.
+func (b ExclusiveBound) MarshalJSON() ([]byte, error) {
+	if b.Value != nil {
+		return json.Marshal(*b.Value)
+	}
+	return json.Marshal(b.Exclusive)
+}
+
+// Bound returns the exclusive bound, given the inclusive bound it may modify.
+func (b *ExclusiveBound) Bound(inclusive *float64) *float64 {
+	if b == nil {
+		return nil
+	}
+	if b.Value != nil {
+		return b.Value
+	}
+	if b.Exclusive {
+		return inclusive
+	}
+	return nil
+}
+
+// Items is the value of items or additionalItems: a schema that every item
+// must be valid against, an array of schemas that the items at the same
+// positions must be valid against, as in draft 4 to 2019-09, or a boolean.
+// Exactly one of its fields is set.
+type Items struct {
+	Schema *Type
+	Tuple  []*Type
+	Bool   *bool
+}
+
+// SchemaItems returns the Items that every item must be valid against t.
+func SchemaItems(t *Type) *Items {
+	return &Items{Schema: t}
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (i *Items) UnmarshalJSON(raw []byte) error {
+	switch trimmed := bytes.TrimLeft(raw, " \t\r\n"); {
+	case len(trimmed) > 0 && (trimmed[0] == 't' || trimmed[0] == 'f'):
+		var b bool
+		if err := json.Unmarshal(raw, &b); err != nil {
+			return decodeError(raw, err)
+		}
+		*i = Items{Bool: &b}
+	case len(trimmed) > 0 && trimmed[0] == '[':
+		var tuple []*Type
+		if err := json.Unmarshal(raw, &tuple); err != nil {
+			return decodeError(raw, err)
+		}
+		*i = Items{Tuple: tuple}
+	case len(trimmed) > 0 && trimmed[0] == '{':
+		var t Type
+		if err := json.Unmarshal(raw, &t); err != nil {
+			return decodeError(raw, err)
+		}
+		*i = Items{Schema: &t}
+	default:
+		return decodeError(raw, fmt.Errorf("expected a schema, an array of schemas or a boolean, got %s", raw))
+	}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (i Items) MarshalJSON() ([]byte, error) {
+	switch {
+	case i.Bool != nil:
+		return json.Marshal(*i.Bool)
+	case i.Tuple != nil:
+		return json.Marshal(i.Tuple)
+	default:
+		return json.Marshal(i.Schema)
+	}
+}
+
+// IsTuple reports whether the items are an array of schemas.
+func (i *Items) IsTuple() bool {
+	return i != nil && i.Tuple != nil
+}
+
+// All returns the schema that every item must be valid against, which for
+// true is {} and for false is {"not": {}}. It returns nil if i is nil or a
+// tuple.
+func (i *Items) All() *Type {
+	switch {
+	case i == nil:
+		return nil
+	case i.Bool != nil && *i.Bool:
+		return &Type{}
+	case i.Bool != nil:
+		return &Type{Not: &Type{}}
+	default:
+		return i.Schema
+	}
+}
+
+// At returns the schema that the item at an index must be valid against,
+// given the additionalItems that apply to items past the end of a tuple, or
+// nil if there is none.
+func (i *Items) At(index int, additional *Items) *Type {
+	if !i.IsTuple() {
+		return i.All()
+	}
+	if index < len(i.Tuple) {
+		return i.Tuple[index]
+	}
+	return additional.All()
+}
+
 // Definitions hold schema definitions.
 // http://json-schema.org/latest/json-schema-validation.html#rfc.section.5.26
 // RFC draft-wright-json-schema-validation-00, section 5.26
@@ -92,16 +402,16 @@ type Type struct {
 	Version string `json:"$schema,omitempty"` // section 6.1
 	Ref     string `json:"$ref,omitempty"`    // section 7
 	// RFC draft-wright-json-schema-validation-00, section 5
-	MultipleOf           int              `json:"multipleOf,omitempty"`           // section 5.1
-	Maximum              float64          `json:"maximum,omitempty"`              // section 5.2
-	ExclusiveMaximum     bool             `json:"exclusiveMaximum,omitempty"`     // section 5.3
-	Minimum              float64          `json:"minimum,omitempty"`              // section 5.4
-	ExclusiveMinimum     bool             `json:"exclusiveMinimum,omitempty"`     // section 5.5
-	MaxLength            int              `json:"maxLength,omitempty"`            // section 5.6
+	MultipleOf           *float64         `json:"multipleOf,omitempty"`           // section 5.1
+	Maximum              *float64         `json:"maximum,omitempty"`              // section 5.2
+	ExclusiveMaximum     *ExclusiveBound  `json:"exclusiveMaximum,omitempty"`     // section 5.3
+	Minimum              *float64         `json:"minimum,omitempty"`              // section 5.4
+	ExclusiveMinimum     *ExclusiveBound  `json:"exclusiveMinimum,omitempty"`     // section 5.5
+	MaxLength            *int             `json:"maxLength,omitempty"`            // section 5.6
 	MinLength            int              `json:"minLength,omitempty"`            // section 5.7
 	Pattern              string           `json:"pattern,omitempty"`              // section 5.8
-	AdditionalItems      *Type            `json:"additionalItems,omitempty"`      // section 5.9
-	Items                *Type            `json:"items,omitempty"`                // section 5.9
+	AdditionalItems      *Items           `json:"additionalItems,omitempty"`      // section 5.9
+	Items                *Items           `json:"items,omitempty"`                // section 5.9
 	MaxItems             int              `json:"maxItems,omitempty"`             // section 5.10
 	MinItems             int              `json:"minItems,omitempty"`             // section 5.11
 	UniqueItems          bool             `json:"uniqueItems,omitempty"`          // section 5.12
@@ -112,6 +422,7 @@ type Type struct {
 	PatternProperties    map[string]*Type `json:"patternProperties,omitempty"`    // section 5.17
 	AdditionalProperties *interface{}     `json:"additionalProperties,omitempty"` // section 5.18
 	Dependencies         map[string]*Type `json:"dependencies,omitempty"`         // section 5.19
+	PropertyNames        *Type            `json:"propertyNames,omitempty"`        // section 6.5.8
 	Enum                 []interface{}    `json:"enum,omitempty"`                 // section 5.20
 	Type                 TypeList         `json:"type,omitempty"`                 // section 5.21
 	AllOf                []*Type          `json:"allOf,omitempty"`                // section 5.22
@@ -127,10 +438,37 @@ type Type struct {
 	// RFC draft-wright-json-schema-hyperschema-00, section 4
 	Media          *Type  `json:"media,omitempty"`          // section 4.3
 	BinaryEncoding string `json:"binaryEncoding,omitempty"` // section 4.3
+	// RFC draft-handrews-json-schema-validation-01, section 10
+	Examples []interface{} `json:"examples,omitempty"` // section 10.4
 
 	// ExtGoCustomType is the name of a (qualified or not) custom Go type
 	// to use for the field.
 	GoJSONSchemaExtension *GoJSONSchemaExtension `json:"goJSONSchema,omitempty"`
+
+	// GoType is the name of an existing Go type to use instead of generating
+	// one. It may be qualified with its import path, e.g.
+	// "k8s.io/apimachinery/pkg/api/resource.Quantity".
+	GoType string `json:"x-go-type,omitempty"`
+	// GoTypeImport is the package to import for GoType, for when GoType is
+	// not qualified with its import path.
+	GoTypeImport *GoTypeImport `json:"x-go-type-import,omitempty"`
+	// GoName is the Go identifier to use for the property or definition,
+	// set with the "x-go-name" extension. Generating code fails if it is not
+	// a valid identifier.
+	GoName string `json:"x-go-name,omitempty"`
+	// OmitEmpty overrides whether the property's struct tags get
+	// "omitempty", set with the "x-omitempty" extension.
+	OmitEmpty *bool `json:"x-omitempty,omitempty"`
+
+	// PropertyOrder holds the names of Properties in the order they are
+	// declared in the schema document.
+	PropertyOrder []string `json:"-"`
+	// Extras holds the keywords that none of the other fields hold, such as
+	// "x-" vendor extensions, with their raw values.
+	Extras map[string]json.RawMessage `json:"-"`
+	// DefaultJSON holds the JSON text of Default, which keeps the numbers in
+	// it exactly as they are written.
+	DefaultJSON json.RawMessage `json:"-"`
 }
 
 // UnmarshalJSON accepts booleans as schemas where `true` is equivalent to `{}`
@@ -148,7 +486,13 @@ func (value *Type) UnmarshalJSON(raw []byte) error {
 
 	var obj ObjectAsType
 	if err := json.Unmarshal(raw, &obj); err != nil {
-		return err
+		return decodeError(raw, err)
+	}
+	if err := obj.readPropertyOrder(raw); err != nil {
+		return decodeError(raw, err)
+	}
+	if err := obj.readExtras(raw, nil); err != nil {
+		return decodeError(raw, err)
 	}
 
 	*value = Type(obj)
@@ -156,6 +500,129 @@ func (value *Type) UnmarshalJSON(raw []byte) error {
 	return nil
 }
 
+// MarshalJSON implements json.Marshaler, writing Extras along with the
+// other keywords, and properties in the order of PropertyOrder.
+func (value Type) MarshalJSON() ([]byte, error) {
+	b, err := json.Marshal(ObjectAsType(value))
+	if err != nil {
+		return nil, err
+	}
+	members, err := objectMembers(b)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, m := range members {
+		if m.key == "default" && value.exactDefault() {
+			members[i].value = value.DefaultJSON
+			continue
+		}
+		if m.key != "properties" || len(value.PropertyOrder) == 0 {
+			continue
+		}
+		properties, err := objectMembers(m.value)
+		if err != nil {
+			return nil, err
+		}
+		position := make(map[string]int, len(value.PropertyOrder))
+		for j, name := range value.PropertyOrder {
+			position[name] = j - len(value.PropertyOrder)
+		}
+		sort.SliceStable(properties, func(i, j int) bool {
+			return position[properties[i].key] < position[properties[j].key]
+		})
+		members[i].value = writeObject(properties)
+	}
+
+	extras := make([]string, 0, len(value.Extras))
+	for keyword := range value.Extras {
+		extras = append(extras, keyword)
+	}
+	sort.Strings(extras)
+	for _, keyword := range extras {
+		members = append(members, jsonMember{key: keyword, value: value.Extras[keyword]})
+	}
+	return writeObject(members), nil
+}
+
+// DefaultNumbers returns Default with the numbers in it as json.Number values,
+// which keep their exact text, or Default as it is if DefaultJSON does not
+// hold it.
+func (value *Type) DefaultNumbers() interface{} {
+	if !value.exactDefault() {
+		return value.Default
+	}
+	dec := json.NewDecoder(bytes.NewReader(value.DefaultJSON))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return value.Default
+	}
+	return v
+}
+
+// exactDefault reports whether DefaultJSON holds Default, which it doesn't if
+// Default has been changed since the type was unmarshaled.
+func (value *Type) exactDefault() bool {
+	if len(value.DefaultJSON) == 0 {
+		return false
+	}
+	var v interface{}
+	if err := json.Unmarshal(value.DefaultJSON, &v); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(v, value.Default)
+}
+
+// jsonMember is a member of a JSON object, with its raw value.
+type jsonMember struct {
+	key   string
+	value json.RawMessage
+}
+
+// objectMembers returns the members of a JSON object in order.
+func objectMembers(b []byte) ([]jsonMember, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	var members []jsonMember
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		members = append(members, jsonMember{key: key.(string), value: value})
+	}
+	return members, nil
+}
+
+// writeObject returns the JSON object with the given members.
+func writeObject(members []jsonMember) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range members {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(m.key)
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(m.value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes()
+}
+
+type GoTypeImport struct {
+	Path string `json:"path"`
+	Name string `json:"name,omitempty"`
+}
+
 type GoJSONSchemaExtension struct {
 	Type       *string  `json:"type,omitempty"`
 	Identifier *string  `json:"identifier,omitempty"`
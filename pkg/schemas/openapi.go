@@ -0,0 +1,240 @@
+package schemas
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/yamlutils"
+)
+
+const openAPISchemasPrefix = "#/components/schemas/"
+
+// FromOpenAPIFile parses an OpenAPI document from a file. See FromOpenAPIReader.
+func FromOpenAPIFile(fileName string) (*Schema, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	return FromOpenAPIReader(f)
+}
+
+// FromOpenAPIReader parses an OpenAPI 3.0 or 3.1 document, in JSON or YAML,
+// into a schema without a root type, whose definitions are the schemas in
+// components.schemas. References to "#/components/schemas/X" are rewritten to
+// point to the definitions, and the OpenAPI keywords that JSON Schema lacks
+// are translated:
+//
+//   - "nullable: true" adds "null" to the types of a schema.
+//   - "example" is added to the "examples" of a schema.
+//   - "discriminator" makes its property required and, if the property has no
+//     enum of its own, restricts it to the values of the discriminator's
+//     mapping, or to the names of the schemas referred to by "oneOf" or
+//     "anyOf".
+//
+// A document that isn't an OpenAPI document, such as a file of schemas that
+// one refers to, is parsed as a single schema with the same translations.
+func FromOpenAPIReader(r io.Reader) (*Schema, error) {
+	var doc yaml.MapSlice
+	if err := yaml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	var root interface{}
+	if _, ok := yamlutils.Value(doc, "openapi"); ok {
+		var schemas yaml.MapSlice
+		if components, ok := yamlutils.Value(doc, "components"); ok {
+			if m, ok := components.(yaml.MapSlice); ok {
+				if s, ok := yamlutils.Value(m, "schemas"); ok {
+					if schemas, ok = s.(yaml.MapSlice); !ok {
+						return nil, fmt.Errorf("components.schemas must be an object, not %T", s)
+					}
+				}
+			}
+		}
+		for i := range schemas {
+			schemas[i].Value = translateOpenAPISchema(schemas[i].Value)
+		}
+		root = yaml.MapSlice{{Key: "definitions", Value: schemas}}
+	} else {
+		root = translateOpenAPISchema(doc)
+	}
+
+	b, err := yamlutils.MarshalJSON(root)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := unmarshalSchema(b, false)
+	if err != nil {
+		return nil, err
+	}
+	if schema.ObjectAsType == nil {
+		schema.ObjectAsType = &ObjectAsType{}
+	}
+	return schema, nil
+}
+
+// translateOpenAPISchema translates the OpenAPI keywords of a schema, and of
+// the schemas nested within it, into JSON Schema.
+func translateOpenAPISchema(value interface{}) interface{} {
+	s, ok := value.(yaml.MapSlice)
+	if !ok {
+		return value
+	}
+
+	var nullable bool
+	var example interface{}
+	var discriminator yaml.MapSlice
+	result := make(yaml.MapSlice, 0, len(s))
+	for _, item := range s {
+		key, _ := item.Key.(string)
+		switch key {
+		case "nullable":
+			nullable, _ = item.Value.(bool)
+			continue
+		case "example":
+			example = item.Value
+			continue
+		case "discriminator":
+			discriminator, _ = item.Value.(yaml.MapSlice)
+			continue
+		case "$ref":
+			if ref, ok := item.Value.(string); ok {
+				item.Value = translateOpenAPIRef(ref)
+			}
+		case "properties", "patternProperties", "definitions", "$defs", "dependencies":
+			if m, ok := item.Value.(yaml.MapSlice); ok {
+				translated := make(yaml.MapSlice, len(m))
+				for i, prop := range m {
+					translated[i] = yaml.MapItem{Key: prop.Key, Value: translateOpenAPISchema(prop.Value)}
+				}
+				item.Value = translated
+			}
+		case "items", "prefixItems", "allOf", "anyOf", "oneOf":
+			if a, ok := item.Value.([]interface{}); ok {
+				translated := make([]interface{}, len(a))
+				for i, sub := range a {
+					translated[i] = translateOpenAPISchema(sub)
+				}
+				item.Value = translated
+			} else {
+				item.Value = translateOpenAPISchema(item.Value)
+			}
+		case "additionalItems", "additionalProperties", "propertyNames", "not", "contains", "if", "then", "else":
+			item.Value = translateOpenAPISchema(item.Value)
+		}
+		result = append(result, item)
+	}
+
+	if nullable {
+		result = makeNullable(result)
+	}
+	if example != nil {
+		examples, _ := yamlutils.Value(result, "examples")
+		list, _ := examples.([]interface{})
+		result = yamlutils.SetValue(result, "examples", append(list, example))
+	}
+	if discriminator != nil {
+		result = applyDiscriminator(result, discriminator)
+	}
+	return result
+}
+
+// translateOpenAPIRef rewrites a reference to a schema in components.schemas,
+// in the same document or another one, into a reference to a definition.
+func translateOpenAPIRef(ref string) string {
+	if i := strings.Index(ref, openAPISchemasPrefix); i != -1 {
+		return ref[:i] + "#/definitions/" + ref[i+len(openAPISchemasPrefix):]
+	}
+	return ref
+}
+
+// makeNullable adds "null" to the types of a schema. A schema without a type
+// is left alone, since "nullable" has no effect on it in OpenAPI 3.0.
+func makeNullable(s yaml.MapSlice) yaml.MapSlice {
+	t, ok := yamlutils.Value(s, "type")
+	if !ok {
+		return s
+	}
+	var types []interface{}
+	switch t := t.(type) {
+	case string:
+		types = []interface{}{t}
+	case []interface{}:
+		types = t
+	default:
+		return s
+	}
+	for _, name := range types {
+		if name == TypeNameNull {
+			return s
+		}
+	}
+	return yamlutils.SetValue(s, "type", append(types, TypeNameNull))
+}
+
+// applyDiscriminator makes the property named by a discriminator required,
+// and restricts it to the discriminator's values, if they are known.
+func applyDiscriminator(s, discriminator yaml.MapSlice) yaml.MapSlice {
+	v, _ := yamlutils.Value(discriminator, "propertyName")
+	propertyName, ok := v.(string)
+	if !ok || propertyName == "" {
+		return s
+	}
+
+	required, _ := yamlutils.Value(s, "required")
+	names, _ := required.([]interface{})
+	isRequired := false
+	for _, name := range names {
+		isRequired = isRequired || name == propertyName
+	}
+	if !isRequired {
+		s = yamlutils.SetValue(s, "required", append(names, propertyName))
+	}
+
+	var values []interface{}
+	if mapping, ok := yamlutils.Value(discriminator, "mapping"); ok {
+		m, _ := mapping.(yaml.MapSlice)
+		for _, item := range m {
+			values = append(values, fmt.Sprint(item.Key))
+		}
+	} else {
+		for _, key := range []string{"oneOf", "anyOf"} {
+			subs, _ := yamlutils.Value(s, key)
+			list, _ := subs.([]interface{})
+			for _, sub := range list {
+				m, _ := sub.(yaml.MapSlice)
+				ref, _ := yamlutils.Value(m, "$ref")
+				if ref, ok := ref.(string); ok && strings.Contains(ref, "#/definitions/") {
+					values = append(values, ref[strings.LastIndex(ref, "/")+1:])
+				}
+			}
+		}
+	}
+	if len(values) == 0 {
+		return s
+	}
+
+	props, _ := yamlutils.Value(s, "properties")
+	m, _ := props.(yaml.MapSlice)
+	for i, item := range m {
+		prop, ok := item.Value.(yaml.MapSlice)
+		if item.Key != propertyName || !ok {
+			continue
+		}
+		if _, ok := yamlutils.Value(prop, "enum"); ok {
+			break
+		}
+		if _, ok := yamlutils.Value(prop, "$ref"); ok {
+			break
+		}
+		m[i].Value = yamlutils.SetValue(prop, "enum", values)
+	}
+	return s
+}
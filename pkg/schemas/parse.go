@@ -1,6 +1,7 @@
 package schemas
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -25,12 +26,15 @@ func FromJSONFile(fileName string) (*Schema, error) {
 	return FromJSONReader(f)
 }
 
+// FromJSONReader parses a schema written in JSON. Errors in decoding it are
+// returned as a *ParseError, with the line, column and JSON Pointer of the
+// value that caused them.
 func FromJSONReader(r io.Reader) (*Schema, error) {
-	var schema Schema
-	if err := json.NewDecoder(r).Decode(&schema); err != nil {
+	b, err := io.ReadAll(r)
+	if err != nil {
 		return nil, err
 	}
-	return &schema, nil
+	return unmarshalSchema(b, true)
 }
 
 func FromYAMLFile(fileName string) (*Schema, error) {
@@ -45,24 +49,92 @@ func FromYAMLFile(fileName string) (*Schema, error) {
 }
 
 func FromYAMLReader(r io.Reader) (*Schema, error) {
-	// Marshal to JSON first because YAML decoder doesn't understand JSON tags
-	var m map[string]interface{}
+	// Marshal to JSON first because YAML decoder doesn't understand JSON tags.
+	// Decoding into a MapSlice keeps the order in which properties are declared.
+	var m yaml.MapSlice
 	if err := yaml.NewDecoder(r).Decode(&m); err != nil {
 		return nil, err
 	}
-	yamlutils.FixMapKeys(m)
 
-	b, err := json.Marshal(m)
+	b, err := yamlutils.MarshalJSON(m)
 	if err != nil {
 		return nil, err
 	}
+	return unmarshalSchema(b, false)
+}
+
+// unmarshalSchema decodes a schema from JSON, returning errors as a
+// *ParseError. When lines is false, the JSON was converted from another
+// format, so that only the JSON Pointers in errors are meaningful.
+func unmarshalSchema(b []byte, lines bool) (*Schema, error) {
 	var schema Schema
-	if err = json.Unmarshal(b, &schema); err != nil {
-		return nil, err
+	if err := json.Unmarshal(b, &schema); err != nil {
+		return nil, positionError(b, err, lines)
 	}
 	return &schema, nil
 }
 
+// FromFile parses a schema from a file, which may be written in JSON or YAML.
+func FromFile(fileName string) (*Schema, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	return FromReader(f)
+}
+
+// FromReader parses a schema that may be written in JSON or YAML. Documents
+// that start with "{", after any byte order mark and whitespace, are parsed
+// as JSON, and all others as YAML. JSON may contain comments and trailing
+// commas, as in the JSONC files written with VS Code.
+func FromReader(r io.Reader) (*Schema, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	b = bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))
+	if stripped := stripJSONC(b); isJSON(stripped) {
+		return FromJSONReader(bytes.NewReader(stripped))
+	}
+	return FromYAMLReader(bytes.NewReader(b))
+}
+
+// DecodeDocument decodes a schema document, which may be written in JSON or
+// YAML as with FromReader, into the values that encoding/json decodes JSON
+// into, so that it can be checked before it is parsed. Syntax errors in JSON
+// are returned as a *ParseError.
+func DecodeDocument(b []byte) (interface{}, error) {
+	b = bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))
+	stripped := stripJSONC(b)
+	lines := isJSON(stripped)
+	if lines {
+		b = stripped
+	} else {
+		var m yaml.MapSlice
+		if err := yaml.Unmarshal(b, &m); err != nil {
+			return nil, err
+		}
+		var err error
+		if b, err = yamlutils.MarshalJSON(m); err != nil {
+			return nil, err
+		}
+	}
+	var doc interface{}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, positionError(b, err, lines)
+	}
+	return doc, nil
+}
+
+// isJSON reports whether a document looks like a JSON object.
+func isJSON(b []byte) bool {
+	b = bytes.TrimLeft(b, " \t\r\n")
+	return len(b) > 0 && b[0] == '{'
+}
+
 type Loader struct {
 	workingDir string
 }
@@ -0,0 +1,233 @@
+package schemas
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrNotExist is wrapped by the errors that Resolve returns for pointers to
+// subschemas that do not exist.
+var ErrNotExist = errors.New("does not exist")
+
+// Resolve returns the subschema of a schema at a JSON Pointer, such as
+// "/definitions/Address" or "/properties/tags/items". The empty pointer, and
+// "/", refer to the root. Pointers taken from the fragments of URIs must be
+// unescaped first.
+//
+// Pointers may go through the keywords whose values are schemas, maps of
+// schemas or arrays of schemas, including "definitions" and "$defs" at the
+// root, where both refer to the definitions declared with either keyword.
+func Resolve(schema *Schema, pointer string) (*Type, error) {
+	var tokens []string
+	if pointer != "" && pointer != "/" {
+		if !strings.HasPrefix(pointer, "/") {
+			return nil, fmt.Errorf("invalid JSON Pointer %q: must start with /", pointer)
+		}
+		tokens = strings.Split(pointer[1:], "/")
+		for i, token := range tokens {
+			tokens[i] = unescapePointer(token)
+		}
+	}
+
+	t := (*Type)(schema.ObjectAsType)
+	if len(tokens) >= 2 && (strings.EqualFold(tokens[0], "definitions") || tokens[0] == "$defs") {
+		def, ok := schema.AllDefinitions()[tokens[1]]
+		if !ok {
+			return nil, fmt.Errorf("definition %q %w in schema", tokens[1], ErrNotExist)
+		}
+		t, tokens = def, tokens[2:]
+	} else if t == nil {
+		return nil, fmt.Errorf("schema has no root")
+	}
+
+	for len(tokens) > 0 {
+		next, consumed, err := child(t, tokens)
+		if err != nil {
+			return nil, fmt.Errorf("cannot resolve %q: %w", pointer, err)
+		}
+		if next == nil {
+			return nil, fmt.Errorf("cannot resolve %q: %q %w", pointer,
+				"/"+strings.Join(tokens[:consumed], "/"), ErrNotExist)
+		}
+		t, tokens = next, tokens[consumed:]
+	}
+	return t, nil
+}
+
+// PointerTo returns the JSON Pointer of a subschema of a schema, which is the
+// same *Type that Resolve returns for it; the pointer of the root is empty.
+// It returns false if the type is not part of the schema, such as a copy of
+// one of its subschemas.
+func PointerTo(schema *Schema, t *Type) (string, bool) {
+	if t == nil {
+		return "", false
+	}
+	if pointer, ok := (*Type)(schema.ObjectAsType).pointerTo(t, ""); ok {
+		return pointer, true
+	}
+	for _, keyword := range []string{"definitions", "$defs"} {
+		defs := schema.Definitions
+		if keyword == "$defs" {
+			defs = schema.Defs
+		}
+		for _, name := range sortedNames(defs) {
+			if pointer, ok := defs[name].pointerTo(t, "/"+keyword+"/"+escapePointer(name)); ok {
+				return pointer, true
+			}
+		}
+	}
+	return "", false
+}
+
+func (t *Type) pointerTo(target *Type, pointer string) (string, bool) {
+	if t == nil {
+		return "", false
+	}
+	if t == target {
+		return pointer, true
+	}
+	for keyword, subs := range map[string]map[string]*Type{
+		"properties":        t.Properties,
+		"patternProperties": t.PatternProperties,
+		"dependencies":      t.Dependencies,
+		"definitions":       t.Definitions,
+	} {
+		for _, name := range sortedNames(subs) {
+			if p, ok := subs[name].pointerTo(target, pointer+"/"+keyword+"/"+escapePointer(name)); ok {
+				return p, true
+			}
+		}
+	}
+	for keyword, subs := range map[string][]*Type{"allOf": t.AllOf, "anyOf": t.AnyOf, "oneOf": t.OneOf} {
+		for i, sub := range subs {
+			if p, ok := sub.pointerTo(target, pointer+"/"+keyword+"/"+strconv.Itoa(i)); ok {
+				return p, true
+			}
+		}
+	}
+	for keyword, items := range map[string]*Items{"items": t.Items, "additionalItems": t.AdditionalItems} {
+		if items == nil {
+			continue
+		}
+		if p, ok := items.Schema.pointerTo(target, pointer+"/"+keyword); ok {
+			return p, true
+		}
+		for i, sub := range items.Tuple {
+			if p, ok := sub.pointerTo(target, pointer+"/"+keyword+"/"+strconv.Itoa(i)); ok {
+				return p, true
+			}
+		}
+	}
+	if t.AdditionalProperties != nil {
+		if v, ok := (*t.AdditionalProperties).(*Type); ok {
+			if p, ok := v.pointerTo(target, pointer+"/additionalProperties"); ok {
+				return p, true
+			}
+		}
+	}
+	for keyword, sub := range map[string]*Type{"not": t.Not, "propertyNames": t.PropertyNames, "media": t.Media} {
+		if p, ok := sub.pointerTo(target, pointer+"/"+keyword); ok {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// child returns the subschema of a type at the first one or two tokens of a
+// pointer, and how many tokens it took.
+func child(t *Type, tokens []string) (*Type, int, error) {
+	keyword := tokens[0]
+	switch keyword {
+	case "not":
+		return t.Not, 1, nil
+	case "propertyNames":
+		return t.PropertyNames, 1, nil
+	case "media":
+		return t.Media, 1, nil
+	case "additionalProperties":
+		return additionalProperties(t)
+	case "items", "additionalItems":
+		items := t.Items
+		if keyword == "additionalItems" {
+			items = t.AdditionalItems
+		}
+		if !items.IsTuple() {
+			return items.All(), 1, nil
+		}
+		if len(tokens) < 2 {
+			return nil, 1, fmt.Errorf("%s is an array of schemas, and needs an index", keyword)
+		}
+		sub, err := index(items.Tuple, tokens[1])
+		return sub, 2, err
+	case "allOf", "anyOf", "oneOf":
+		subs := map[string][]*Type{"allOf": t.AllOf, "anyOf": t.AnyOf, "oneOf": t.OneOf}[keyword]
+		if len(tokens) < 2 {
+			return nil, 1, fmt.Errorf("%s is an array of schemas, and needs an index", keyword)
+		}
+		sub, err := index(subs, tokens[1])
+		return sub, 2, err
+	case "properties", "patternProperties", "dependencies", "definitions":
+		subs := map[string]map[string]*Type{
+			"properties":        t.Properties,
+			"patternProperties": t.PatternProperties,
+			"dependencies":      t.Dependencies,
+			"definitions":       t.Definitions,
+		}[keyword]
+		if len(tokens) < 2 {
+			return nil, 1, fmt.Errorf("%s is a map of schemas, and needs a name", keyword)
+		}
+		return subs[tokens[1]], 2, nil
+	default:
+		return nil, 1, fmt.Errorf("%q is not a keyword whose value is a schema", keyword)
+	}
+}
+
+// additionalProperties returns the schema that additionalProperties
+// declares, which is decoded as a map rather than a Type.
+func additionalProperties(t *Type) (*Type, int, error) {
+	if t.AdditionalProperties == nil {
+		return nil, 1, nil
+	}
+	switch v := (*t.AdditionalProperties).(type) {
+	case *Type:
+		return v, 1, nil
+	case bool:
+		if v {
+			return &Type{}, 1, nil
+		}
+		return &Type{Not: &Type{}}, 1, nil
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, 1, err
+		}
+		var sub Type
+		if err := json.Unmarshal(b, &sub); err != nil {
+			return nil, 1, err
+		}
+		return &sub, 1, nil
+	}
+}
+
+func index(subs []*Type, token string) (*Type, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 {
+		return nil, fmt.Errorf("%q is not an array index", token)
+	}
+	if i >= len(subs) {
+		return nil, nil
+	}
+	return subs[i], nil
+}
+
+// escapePointer escapes a name for use as a token of a JSON Pointer.
+func escapePointer(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
+
+func unescapePointer(token string) string {
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+}
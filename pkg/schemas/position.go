@@ -0,0 +1,212 @@
+package schemas
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ParseError is an error in decoding a schema, with the position of the
+// value that caused it. Line and Column are 1-based, and zero for schemas
+// written in YAML, whose positions are lost in converting them to JSON.
+type ParseError struct {
+	Line   int
+	Column int
+	// Pointer is the JSON Pointer of the value within the document.
+	Pointer string
+	Err     error
+}
+
+func (e *ParseError) Error() string {
+	var where []string
+	if e.Line > 0 {
+		where = append(where, fmt.Sprintf("line %d, column %d", e.Line, e.Column))
+	}
+	if e.Pointer != "" {
+		where = append(where, "at "+e.Pointer)
+	}
+	if len(where) == 0 {
+		return e.Err.Error()
+	}
+	return strings.Join(where, ", ") + ": " + e.Err.Error()
+}
+
+// offsetError is an error in decoding a part of a document, raw, that the
+// decoder passed to an UnmarshalJSON method, at an offset within that part.
+// Since the decoder passes slices of the document it decodes, the offset can
+// be turned into one within the whole document.
+type offsetError struct {
+	raw    []byte
+	offset int64
+	err    error
+}
+
+func (e *offsetError) Error() string {
+	return e.err.Error()
+}
+
+// decodeError attributes an error in decoding raw to a position within it:
+// where the decoder failed, or else the start of raw. Errors that come from
+// decoding a value nested in raw are already attributed, and kept as they are.
+func decodeError(raw []byte, err error) error {
+	var offset int64
+	switch e := err.(type) {
+	case *offsetError:
+		return err
+	case *json.UnmarshalTypeError:
+		offset = e.Offset
+	case *json.SyntaxError:
+		offset = e.Offset - 1
+	}
+	return &offsetError{raw: raw, offset: offset, err: err}
+}
+
+// positionError returns the ParseError for an error in decoding b as JSON.
+// When lines is false, b was converted from YAML, so only the pointer is
+// known.
+func positionError(b []byte, err error, lines bool) error {
+	var offset int64
+	switch e := err.(type) {
+	case *offsetError:
+		base, ok := subsliceOffset(b, e.raw)
+		if !ok {
+			return e.err
+		}
+		offset = int64(base) + e.offset
+		err = e.err
+	case *json.UnmarshalTypeError:
+		offset = e.Offset
+	case *json.SyntaxError:
+		offset = e.Offset - 1
+	default:
+		return err
+	}
+
+	spans, _ := valueSpans(b)
+	var found *valueSpan
+	for i := range spans {
+		if s := &spans[i]; s.start <= offset && (s.end < 0 || offset <= s.end) {
+			found = s
+		}
+	}
+	perr := &ParseError{Err: err}
+	if found != nil {
+		perr.Pointer = found.pointer
+		if _, ok := err.(*json.SyntaxError); !ok {
+			offset = found.start
+		}
+	}
+	if lines {
+		perr.Line, perr.Column = lineColumn(b, offset)
+	}
+	return perr
+}
+
+// PositionOf returns the 1-based line and column at which the value with a
+// JSON Pointer starts in a schema document. It returns false if the document
+// is not JSON, after comments and trailing commas are removed, or doesn't
+// have such a value.
+func PositionOf(b []byte, pointer string) (line, column int, ok bool) {
+	b = stripJSONC(bytes.TrimPrefix(b, []byte("\xef\xbb\xbf")))
+	if !isJSON(b) {
+		return 0, 0, false
+	}
+	spans, _ := valueSpans(b)
+	for _, s := range spans {
+		if s.pointer == pointer {
+			line, column = lineColumn(b, s.start)
+			return line, column, true
+		}
+	}
+	return 0, 0, false
+}
+
+// valueSpan is the range of bytes of a value in a JSON document. The end is
+// -1 if the document ends, or is invalid, before the value does.
+type valueSpan struct {
+	pointer    string
+	start, end int64
+}
+
+// valueSpans returns the spans of all the values in a JSON document, with
+// each value before the values nested in it. If the document is invalid, the
+// spans of the values up to the error are returned with it.
+func valueSpans(b []byte) ([]valueSpan, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var spans []valueSpan
+	var walk func(pointer string) error
+	walk = func(pointer string) error {
+		start := dec.InputOffset()
+		for start < int64(len(b)) && strings.IndexByte(" \t\r\n:,", b[start]) >= 0 {
+			start++
+		}
+		index := len(spans)
+		spans = append(spans, valueSpan{pointer: pointer, start: start, end: -1})
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'):
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				name, _ := key.(string)
+				if err := walk(pointer + "/" + escapePointer(name)); err != nil {
+					return err
+				}
+			}
+			if _, err := dec.Token(); err != nil {
+				return err
+			}
+		case json.Delim('['):
+			for i := 0; dec.More(); i++ {
+				if err := walk(pointer + "/" + strconv.Itoa(i)); err != nil {
+					return err
+				}
+			}
+			if _, err := dec.Token(); err != nil {
+				return err
+			}
+		}
+		spans[index].end = dec.InputOffset()
+		return nil
+	}
+	return spans, walk("")
+}
+
+// lineColumn returns the 1-based line and column of an offset in a document,
+// counting columns in characters.
+func lineColumn(b []byte, offset int64) (line, column int) {
+	if offset > int64(len(b)) {
+		offset = int64(len(b))
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	before := b[:offset]
+	lineStart := bytes.LastIndexByte(before, '\n') + 1
+	return bytes.Count(before, []byte("\n")) + 1, utf8.RuneCount(before[lineStart:]) + 1
+}
+
+// subsliceOffset returns the offset of sub within b, if sub is a slice of
+// the same array as b. The decoder limits the capacity of the slices it
+// passes, so their elements are compared instead; this only happens for
+// errors.
+func subsliceOffset(b, sub []byte) (int, bool) {
+	if len(sub) == 0 {
+		return 0, false
+	}
+	for i := range b {
+		if &b[i] == &sub[0] {
+			return i, true
+		}
+	}
+	return 0, false
+}
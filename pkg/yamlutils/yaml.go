@@ -1,5 +1,13 @@
 package yamlutils
 
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
 // FixMapKeys fixes non-string keys that occur in nested YAML unmarshaling results.
 func FixMapKeys(m map[string]interface{}) {
 	for k, v := range m {
@@ -25,3 +33,75 @@ func fixMapKeysIn(value interface{}) interface{} {
 		return value
 	}
 }
+
+// MarshalJSON marshals a value decoded from YAML into a yaml.MapSlice as JSON,
+// keeping the keys of each mapping in the order they appear in the document.
+func MarshalJSON(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeJSON(buf *bytes.Buffer, value interface{}) error {
+	switch t := value.(type) {
+	case yaml.MapSlice:
+		buf.WriteByte('{')
+		for i, item := range t {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, err := json.Marshal(fmt.Sprint(item.Key))
+			if err != nil {
+				return err
+			}
+			buf.Write(key)
+			buf.WriteByte(':')
+			if err := writeJSON(buf, item.Value); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, elem := range t {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSON(buf, elem); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	default:
+		b, err := json.Marshal(fixMapKeysIn(value))
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+	}
+	return nil
+}
+
+// Value returns the value of a key in a mapping decoded into a yaml.MapSlice.
+func Value(s yaml.MapSlice, key string) (interface{}, bool) {
+	for _, item := range s {
+		if item.Key == key {
+			return item.Value, true
+		}
+	}
+	return nil, false
+}
+
+// SetValue sets the value of a key in a mapping decoded into a yaml.MapSlice,
+// appending the key if the mapping doesn't have it.
+func SetValue(s yaml.MapSlice, key string, value interface{}) yaml.MapSlice {
+	for i, item := range s {
+		if item.Key == key {
+			s[i].Value = value
+			return s
+		}
+	}
+	return append(s, yaml.MapItem{Key: key, Value: value})
+}
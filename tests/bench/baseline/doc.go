@@ -0,0 +1,6 @@
+// Package baseline holds the code that the generator generated from
+// ../../data/bench before UnmarshalJSON decoded objects in a single pass,
+// when it decoded each object into a map as well as into its struct. It is
+// not regenerated, so that the benchmarks in package tests can compare the
+// current output with it.
+package baseline
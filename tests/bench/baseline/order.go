@@ -0,0 +1,275 @@
+// Code generated by github.com/lets-dev-it-out/go-jsonschema, DO NOT EDIT.
+
+package baseline
+
+import "unicode/utf8"
+import "fmt"
+import "encoding/json"
+import "regexp"
+
+// String implements fmt.Stringer.
+func (j LineStatus) String() string {
+	return string(j)
+}
+
+// IsValid reports whether the value is one of the values allowed by the schema.
+func (j LineStatus) IsValid() bool {
+	switch j {
+	case "pending", "shipped", "delivered":
+		return true
+	}
+	return false
+}
+
+// ParseLineStatus returns the LineStatus value of s, or an error if it is not one
+// of the values allowed by the schema.
+func ParseLineStatus(s string) (LineStatus, error) {
+	if v := LineStatus(s); v.IsValid() {
+		return v, nil
+	}
+	return "", &ValidationError{Path: "", Keyword: "enum", Message: fmt.Sprintf("invalid value (expected one of %#v): %#v", enumValues_LineStatus, s)}
+}
+
+var enumValues_LineStatus = []interface{}{
+	"pending",
+	"shipped",
+	"delivered",
+}
+
+// ValidationError is returned when a value does not conform to the schema it was
+// generated from.
+type ValidationError struct {
+	// Path is the JSON Pointer of the invalid value, relative to the value being
+	// validated.
+	Path string
+
+	// Keyword is the schema keyword that the value violates.
+	Keyword string
+
+	// Message describes the violation.
+	Message string
+}
+
+// Error implements error.
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// Validate checks that the value satisfies the constraints declared in the schema.
+func (j *Address) Validate() error {
+	if utf8.RuneCountInString(j.Street) < 1 {
+		return &ValidationError{Path: "/street", Keyword: "minLength", Message: "length must be >= 1"}
+	}
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *Address) UnmarshalJSON(b []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	if v, ok := raw["city"]; !ok || v == nil {
+		return &ValidationError{Path: "/city", Keyword: "required", Message: "required"}
+	}
+	if v, ok := raw["street"]; !ok || v == nil {
+		return &ValidationError{Path: "/street", Keyword: "required", Message: "required"}
+	}
+	type Plain Address
+	var plain Plain
+	if err := json.Unmarshal(b, &plain); err != nil {
+		return err
+	}
+	if v, ok := raw["country"]; !ok || v == nil {
+		plain.Country = "NL"
+	}
+	if err := (*Address)(&plain).Validate(); err != nil {
+		return err
+	}
+	*j = Address(plain)
+	return nil
+}
+
+type LineAttributes map[string]string
+
+const LineStatusPending LineStatus = "pending"
+
+// MarshalText implements encoding.TextMarshaler.
+func (j LineStatus) MarshalText() ([]byte, error) {
+	return []byte(j), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (j *LineStatus) UnmarshalText(text []byte) error {
+	v, err := ParseLineStatus(string(text))
+	if err != nil {
+		return err
+	}
+	*j = v
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *LineStatus) UnmarshalJSON(b []byte) error {
+	var v string
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	switch v {
+	case "pending", "shipped", "delivered":
+	default:
+		return &ValidationError{Path: "", Keyword: "enum", Message: fmt.Sprintf("invalid value (expected one of %#v): %#v", enumValues_LineStatus, v)}
+	}
+	*j = LineStatus(v)
+	return nil
+}
+
+// NewAddress returns a Address with the defaults declared in the schema.
+func NewAddress() *Address {
+	v := &Address{
+		Country: "NL",
+	}
+	return v
+}
+
+type Address struct {
+	// City corresponds to the JSON schema field "city".
+	City string `json:"city" yaml:"city"`
+
+	// Country corresponds to the JSON schema field "country".
+	Country string `json:"country,omitempty" yaml:"country,omitempty"`
+
+	// Street corresponds to the JSON schema field "street".
+	Street string `json:"street" yaml:"street"`
+}
+
+type LineStatus string
+
+const LineStatusShipped LineStatus = "shipped"
+const LineStatusDelivered LineStatus = "delivered"
+
+type Line struct {
+	// Attributes corresponds to the JSON schema field "attributes".
+	Attributes LineAttributes `json:"attributes,omitempty" yaml:"attributes,omitempty"`
+
+	// Price corresponds to the JSON schema field "price".
+	Price *float64 `json:"price,omitempty" yaml:"price,omitempty"`
+
+	// Quantity corresponds to the JSON schema field "quantity".
+	Quantity int `json:"quantity" yaml:"quantity"`
+
+	// Sku corresponds to the JSON schema field "sku".
+	Sku string `json:"sku" yaml:"sku"`
+
+	// Status corresponds to the JSON schema field "status".
+	Status *LineStatus `json:"status,omitempty" yaml:"status,omitempty"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *Order) UnmarshalJSON(b []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	if v, ok := raw["id"]; !ok || v == nil {
+		return &ValidationError{Path: "/id", Keyword: "required", Message: "required"}
+	}
+	if v, ok := raw["lines"]; !ok || v == nil {
+		return &ValidationError{Path: "/lines", Keyword: "required", Message: "required"}
+	}
+	type Plain Order
+	var plain Plain
+	if err := json.Unmarshal(b, &plain); err != nil {
+		return err
+	}
+	*j = Order(plain)
+	return nil
+}
+
+// Validate checks that the value satisfies the constraints declared in the schema.
+func (j *Line) Validate() error {
+	if j.Price != nil {
+		if *j.Price < 0 {
+			return &ValidationError{Path: "/price", Keyword: "minimum", Message: "must be >= 0"}
+		}
+	}
+	if float64(j.Quantity) < 1 {
+		return &ValidationError{Path: "/quantity", Keyword: "minimum", Message: "must be >= 1"}
+	}
+	if !patternLineSku.MatchString(j.Sku) {
+		return &ValidationError{Path: "/sku", Keyword: "pattern", Message: "must match pattern \"^[A-Z]{3}-[0-9]+$\""}
+	}
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *Line) UnmarshalJSON(b []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	if v, ok := raw["quantity"]; !ok || v == nil {
+		return &ValidationError{Path: "/quantity", Keyword: "required", Message: "required"}
+	}
+	if v, ok := raw["sku"]; !ok || v == nil {
+		return &ValidationError{Path: "/sku", Keyword: "required", Message: "required"}
+	}
+	type Plain Line
+	var plain Plain
+	if err := json.Unmarshal(b, &plain); err != nil {
+		return err
+	}
+	if err := (*Line)(&plain).Validate(); err != nil {
+		return err
+	}
+	*j = Line(plain)
+	return nil
+}
+
+type OrderCustomer struct {
+	// Billing corresponds to the JSON schema field "billing".
+	Billing *Address `json:"billing,omitempty" yaml:"billing,omitempty"`
+
+	// Name corresponds to the JSON schema field "name".
+	Name string `json:"name" yaml:"name"`
+
+	// Shipping corresponds to the JSON schema field "shipping".
+	Shipping *Address `json:"shipping,omitempty" yaml:"shipping,omitempty"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *OrderCustomer) UnmarshalJSON(b []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	if v, ok := raw["name"]; !ok || v == nil {
+		return &ValidationError{Path: "/name", Keyword: "required", Message: "required"}
+	}
+	type Plain OrderCustomer
+	var plain Plain
+	if err := json.Unmarshal(b, &plain); err != nil {
+		return err
+	}
+	*j = OrderCustomer(plain)
+	return nil
+}
+
+type Order struct {
+	// Customer corresponds to the JSON schema field "customer".
+	Customer *OrderCustomer `json:"customer,omitempty" yaml:"customer,omitempty"`
+
+	// Id corresponds to the JSON schema field "id".
+	Id string `json:"id" yaml:"id"`
+
+	// Lines corresponds to the JSON schema field "lines".
+	Lines []Line `json:"lines" yaml:"lines"`
+
+	// Notes corresponds to the JSON schema field "notes".
+	Notes []string `json:"notes,omitempty" yaml:"notes,omitempty"`
+}
+
+var patternLineSku = regexp.MustCompile("^[A-Z]{3}-[0-9]+$")
@@ -0,0 +1,6 @@
+// Package bench holds code generated from ../data/bench for the benchmarks
+// in package tests. Regenerate it after changing the generator so that the
+// benchmarks measure the current output.
+package bench
+
+//go:generate go run ../../cmd/gojsonschema --resolve-extension .json -p github.com/lets-dev-it-out/go-jsonschema/tests/bench -o order.go ../data/bench/order.json
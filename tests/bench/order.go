@@ -0,0 +1,384 @@
+// Code generated by github.com/lets-dev-it-out/go-jsonschema, DO NOT EDIT.
+
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"unicode/utf8"
+)
+
+type Address struct {
+	// City corresponds to the JSON schema field "city".
+	City string `json:"city" yaml:"city"`
+
+	// Country corresponds to the JSON schema field "country".
+	Country string `json:"country,omitempty" yaml:"country,omitempty"`
+
+	// Street corresponds to the JSON schema field "street".
+	//
+	// Min length: 1
+	Street string `json:"street" yaml:"street"`
+}
+
+// Validate checks that the value satisfies the constraints declared in the schema.
+func (j *Address) Validate() error {
+	if utf8.RuneCountInString(j.Street) < 1 {
+		return &ValidationError{Path: "/street", Keyword: "minLength", Message: "length must be >= 1"}
+	}
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *Address) UnmarshalJSON(b []byte) error {
+	type Plain Address
+	var plain Plain
+	props := struct {
+		*Plain
+		City    *string `json:"city"`
+		Country *string `json:"country"`
+		Street  *string `json:"street"`
+	}{Plain: &plain}
+	if err := json.Unmarshal(b, &props); err != nil {
+		return err
+	}
+	if props.City != nil {
+		plain.City = *props.City
+	}
+	if props.Country != nil {
+		plain.Country = *props.Country
+	}
+	if props.Street != nil {
+		plain.Street = *props.Street
+	}
+	if props.City == nil {
+		return &ValidationError{Path: "/city", Keyword: "required", Message: "required"}
+	}
+	if props.Street == nil {
+		return &ValidationError{Path: "/street", Keyword: "required", Message: "required"}
+	}
+	if props.Country == nil {
+		plain.Country = "NL"
+	}
+	if err := (*Address)(&plain).Validate(); err != nil {
+		return err
+	}
+	*j = Address(plain)
+	return nil
+}
+
+type LineAttributes map[string]string
+
+type LineStatus string
+
+const LineStatusPending LineStatus = "pending"
+const LineStatusShipped LineStatus = "shipped"
+const LineStatusDelivered LineStatus = "delivered"
+
+var enumValues_LineStatus = []interface{}{
+	"pending",
+	"shipped",
+	"delivered",
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *LineStatus) UnmarshalJSON(b []byte) error {
+	var v string
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	switch v {
+	case "pending", "shipped", "delivered":
+	default:
+		return &ValidationError{Path: "", Keyword: "enum", Message: fmt.Sprintf("invalid value (expected one of %#v): %#v", enumValues_LineStatus, v)}
+	}
+	*j = LineStatus(v)
+	return nil
+}
+
+// String implements fmt.Stringer.
+func (j LineStatus) String() string {
+	return string(j)
+}
+
+// IsValid reports whether the value is one of the values allowed by the schema.
+func (j LineStatus) IsValid() bool {
+	switch j {
+	case "pending", "shipped", "delivered":
+		return true
+	}
+	return false
+}
+
+// ParseLineStatus returns the LineStatus value of s, or an error if it is not one
+// of the values allowed by the schema.
+func ParseLineStatus(s string) (LineStatus, error) {
+	if v := LineStatus(s); v.IsValid() {
+		return v, nil
+	}
+	return "", &ValidationError{Path: "", Keyword: "enum", Message: fmt.Sprintf("invalid value (expected one of %#v): %#v", enumValues_LineStatus, s)}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (j LineStatus) MarshalText() ([]byte, error) {
+	return []byte(j), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (j *LineStatus) UnmarshalText(text []byte) error {
+	v, err := ParseLineStatus(string(text))
+	if err != nil {
+		return err
+	}
+	*j = v
+	return nil
+}
+
+type Line struct {
+	// Attributes corresponds to the JSON schema field "attributes".
+	Attributes LineAttributes `json:"attributes,omitempty" yaml:"attributes,omitempty"`
+
+	// Price corresponds to the JSON schema field "price".
+	//
+	// Minimum: 0
+	Price *float64 `json:"price,omitempty" yaml:"price,omitempty"`
+
+	// Quantity corresponds to the JSON schema field "quantity".
+	//
+	// Minimum: 1
+	Quantity int `json:"quantity" yaml:"quantity"`
+
+	// Sku corresponds to the JSON schema field "sku".
+	//
+	// Pattern: ^[A-Z]{3}-[0-9]+$
+	Sku string `json:"sku" yaml:"sku"`
+
+	// Status corresponds to the JSON schema field "status".
+	//
+	// Enum: "pending", "shipped", "delivered"
+	Status *LineStatus `json:"status,omitempty" yaml:"status,omitempty"`
+}
+
+var patternLineSku = regexp.MustCompile("^[A-Z]{3}-[0-9]+$")
+
+// Validate checks that the value satisfies the constraints declared in the schema.
+func (j *Line) Validate() error {
+	if j.Price != nil {
+		if *j.Price < 0 {
+			return &ValidationError{Path: "/price", Keyword: "minimum", Message: "must be >= 0"}
+		}
+	}
+	if float64(j.Quantity) < 1 {
+		return &ValidationError{Path: "/quantity", Keyword: "minimum", Message: "must be >= 1"}
+	}
+	if !patternLineSku.MatchString(j.Sku) {
+		return &ValidationError{Path: "/sku", Keyword: "pattern", Message: "must match pattern \"^[A-Z]{3}-[0-9]+$\""}
+	}
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *Line) UnmarshalJSON(b []byte) error {
+	type Plain Line
+	var plain Plain
+	props := struct {
+		*Plain
+		Quantity  *int            `json:"quantity"`
+		Sku       *string         `json:"sku"`
+		StatusRaw json.RawMessage `json:"status"`
+	}{Plain: &plain}
+	if err := json.Unmarshal(b, &props); err != nil {
+		return err
+	}
+	if props.Quantity != nil {
+		plain.Quantity = *props.Quantity
+	}
+	if props.Sku != nil {
+		plain.Sku = *props.Sku
+	}
+	if props.Quantity == nil {
+		return &ValidationError{Path: "/quantity", Keyword: "required", Message: "required"}
+	}
+	if props.Sku == nil {
+		return &ValidationError{Path: "/sku", Keyword: "required", Message: "required"}
+	}
+	if v := props.StatusRaw; v != nil {
+		if err := json.Unmarshal(v, &plain.Status); err != nil {
+			return prefixValidationError(err, "/status")
+		}
+	}
+	if err := (*Line)(&plain).Validate(); err != nil {
+		return err
+	}
+	*j = Line(plain)
+	return nil
+}
+
+type OrderCustomer struct {
+	// Billing corresponds to the JSON schema field "billing".
+	Billing *Address `json:"billing,omitempty" yaml:"billing,omitempty"`
+
+	// Name corresponds to the JSON schema field "name".
+	Name string `json:"name" yaml:"name"`
+
+	// Shipping corresponds to the JSON schema field "shipping".
+	Shipping *Address `json:"shipping,omitempty" yaml:"shipping,omitempty"`
+}
+
+// Validate checks that the value satisfies the constraints declared in the schema.
+func (j *OrderCustomer) Validate() error {
+	if j.Billing != nil {
+		if err := j.Billing.Validate(); err != nil {
+			return prefixValidationError(err, "/billing")
+		}
+	}
+	if j.Shipping != nil {
+		if err := j.Shipping.Validate(); err != nil {
+			return prefixValidationError(err, "/shipping")
+		}
+	}
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *OrderCustomer) UnmarshalJSON(b []byte) error {
+	type Plain OrderCustomer
+	var plain Plain
+	props := struct {
+		*Plain
+		BillingRaw  json.RawMessage `json:"billing"`
+		Name        *string         `json:"name"`
+		ShippingRaw json.RawMessage `json:"shipping"`
+	}{Plain: &plain}
+	if err := json.Unmarshal(b, &props); err != nil {
+		return err
+	}
+	if props.Name != nil {
+		plain.Name = *props.Name
+	}
+	if props.Name == nil {
+		return &ValidationError{Path: "/name", Keyword: "required", Message: "required"}
+	}
+	if v := props.BillingRaw; v != nil {
+		if err := json.Unmarshal(v, &plain.Billing); err != nil {
+			return prefixValidationError(err, "/billing")
+		}
+	}
+	if v := props.ShippingRaw; v != nil {
+		if err := json.Unmarshal(v, &plain.Shipping); err != nil {
+			return prefixValidationError(err, "/shipping")
+		}
+	}
+	*j = OrderCustomer(plain)
+	return nil
+}
+
+type Order struct {
+	// Customer corresponds to the JSON schema field "customer".
+	Customer *OrderCustomer `json:"customer,omitempty" yaml:"customer,omitempty"`
+
+	// Id corresponds to the JSON schema field "id".
+	Id string `json:"id" yaml:"id"`
+
+	// Lines corresponds to the JSON schema field "lines".
+	Lines []Line `json:"lines" yaml:"lines"`
+
+	// Notes corresponds to the JSON schema field "notes".
+	Notes []string `json:"notes,omitempty" yaml:"notes,omitempty"`
+}
+
+// Validate checks that the value satisfies the constraints declared in the schema.
+func (j *Order) Validate() error {
+	if j.Customer != nil {
+		if err := j.Customer.Validate(); err != nil {
+			return prefixValidationError(err, "/customer")
+		}
+	}
+	for i0, elem0 := range j.Lines {
+		if err := elem0.Validate(); err != nil {
+			return prefixValidationError(err, fmt.Sprintf("/lines/%d", i0))
+		}
+	}
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *Order) UnmarshalJSON(b []byte) error {
+	type Plain Order
+	var plain Plain
+	props := struct {
+		*Plain
+		CustomerRaw json.RawMessage `json:"customer"`
+		Id          *string         `json:"id"`
+		LinesRaw    json.RawMessage `json:"lines"`
+	}{Plain: &plain}
+	if err := json.Unmarshal(b, &props); err != nil {
+		return err
+	}
+	if props.Id != nil {
+		plain.Id = *props.Id
+	}
+	if props.Id == nil {
+		return &ValidationError{Path: "/id", Keyword: "required", Message: "required"}
+	}
+	if v := props.LinesRaw; v == nil || string(v) == "null" {
+		return &ValidationError{Path: "/lines", Keyword: "required", Message: "required"}
+	}
+	if v := props.CustomerRaw; v != nil {
+		if err := json.Unmarshal(v, &plain.Customer); err != nil {
+			return prefixValidationError(err, "/customer")
+		}
+	}
+	if v := props.LinesRaw; v != nil {
+		var elems0 []json.RawMessage
+		if err := json.Unmarshal(v, &elems0); err != nil {
+			return err
+		}
+		if elems0 != nil {
+			plain.Lines = make([]Line, len(elems0))
+		}
+		for i0, elem0 := range elems0 {
+			if err := json.Unmarshal(elem0, &plain.Lines[i0]); err != nil {
+				return prefixValidationError(err, fmt.Sprintf("/lines/%d", i0))
+			}
+		}
+	}
+	*j = Order(plain)
+	return nil
+}
+
+// ValidationError is returned when a value does not conform to the schema it was
+// generated from.
+type ValidationError struct {
+	// Path is the JSON Pointer of the invalid value, relative to the value being
+	// validated.
+	Path string
+
+	// Keyword is the schema keyword that the value violates.
+	Keyword string
+
+	// Message describes the violation.
+	Message string
+}
+
+// Error implements error.
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// prefixValidationError returns err with path, the JSON Pointer of a nested value,
+// prepended to the paths of the validation errors in it, or err itself if it holds
+// none.
+func prefixValidationError(err error, path string) error {
+	switch e := err.(type) {
+	case *ValidationError:
+		prefixed := *e
+		prefixed.Path = path + e.Path
+		return &prefixed
+	}
+	return err
+}
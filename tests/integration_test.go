@@ -1,13 +1,34 @@
 package tests
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/codegen"
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/diff"
 	"github.com/lets-dev-it-out/go-jsonschema/pkg/generator"
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/infer"
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/lint"
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/reverse"
+	"github.com/lets-dev-it-out/go-jsonschema/pkg/schemas"
 	"github.com/stretchr/testify/require"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"io/fs"
 	"log"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 var basicConfig = generator.Config{
@@ -15,8 +36,8 @@ var basicConfig = generator.Config{
 	DefaultPackageName: "github.com/example/test",
 	DefaultOutputName:  "-",
 	ResolveExtensions:  []string{".json", ".yaml"},
-	Warner: func(message string) {
-		log.Printf("[from warner] %s", message)
+	Warner: func(w generator.Warning) {
+		log.Printf("[from warner] %s", w)
 	},
 }
 
@@ -49,31 +70,1703 @@ func TestCrossPackage(t *testing.T) {
 	testExampleFile(t, cfg, "./data/crossPackage/schema.json")
 }
 
+func TestSharedHelpers(t *testing.T) {
+	cfg := basicConfig
+	cfg.SchemaMappings = []generator.SchemaMapping{
+		{
+			SchemaID:    "https://example.com/first",
+			PackageName: "main",
+			OutputName:  "first.go",
+		},
+		{
+			SchemaID:    "https://example.com/second",
+			PackageName: "main",
+			OutputName:  "second.go",
+		},
+	}
+	testExampleFile(t, cfg, "./data/sharedHelpers/first.json")
+
+	paths := runGenerated(t, cfg, "./data/sharedHelpers/first.json",
+		unmarshalCase("First", `{"second":{"count":0}}`),
+		unmarshalCase("First", `{"name":"a","second":{"count":-1}}`),
+	)
+	require.Equal(t, []string{"/name", "/second/count"}, paths)
+}
+
+func TestDoFiles(t *testing.T) {
+	cfg := basicConfig
+	cfg.SchemaMappings = []generator.SchemaMapping{
+		{
+			SchemaID:    "https://example.com/schema",
+			PackageName: "github.com/example/schema",
+			OutputName:  "schema.go",
+		},
+		{
+			SchemaID:    "https://example.com/other",
+			PackageName: "github.com/example/other",
+			OutputName:  "other.go",
+		},
+	}
+	cfg.Parallelism = 2
+	for _, fileNames := range [][]string{
+		{"./data/crossPackage/schema.json", "./data/crossPackage/other.json"},
+		{"./data/crossPackage/other.json", "./data/crossPackage/schema.json"},
+	} {
+		generator, err := generator.New(cfg)
+		require.NoError(t, err)
+		require.NoError(t, generator.DoFiles(context.Background(), fileNames...))
+		testSources(t, generator, fileNames[0])
+	}
+
+	generator, err := generator.New(cfg)
+	require.NoError(t, err)
+	err = generator.DoFiles(context.Background(), "./data/crossPackage/schema.json", "./data/crossPackage/missing.json")
+	require.True(t, errors.Is(err, os.ErrNotExist), "expected a missing file, got %v", err)
+}
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	stale := "// " + codegen.GeneratedComment + "\n\npackage schema\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other.go"), []byte(stale), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "removed.go"), []byte(stale), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "handwritten.go"), []byte("package schema\n"), 0644))
+	// Only the comments before the package clause tell generated files.
+	mentions := "package schema\n\nconst header = `\n" + stale + "`\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "mentions.go"), []byte(mentions), 0644))
+
+	cfg := basicConfig
+	cfg.SchemaMappings = []generator.SchemaMapping{
+		{
+			SchemaID:    "https://example.com/schema",
+			PackageName: "github.com/example/schema",
+			OutputName:  "schema.go",
+		},
+		{
+			SchemaID:    "https://example.com/other",
+			PackageName: "github.com/example/other",
+			OutputName:  "other.go",
+		},
+	}
+	cfg.DeleteStaleFiles = true
+	generator, err := generator.New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, generator.DoFile("./data/crossPackage/schema.json"))
+	require.NoError(t, generator.Write(dir))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	require.Equal(t, []string{"handwritten.go", "mentions.go", "other.go", "schema.go"}, names)
+	for fileName, source := range generator.Sources() {
+		b, err := os.ReadFile(filepath.Join(dir, fileName))
+		require.NoError(t, err)
+		require.Equal(t, string(source), string(b))
+	}
+	info, err := os.Stat(filepath.Join(dir, "other.go"))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestDryRun(t *testing.T) {
+	dir := t.TempDir()
+	stale := "// " + codegen.GeneratedComment + "\n\npackage schema\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "removed.go"), []byte(stale), 0644))
+
+	cfg := basicConfig
+	cfg.SchemaMappings = []generator.SchemaMapping{
+		{
+			SchemaID:    "https://example.com/schema",
+			PackageName: "github.com/example/schema",
+			OutputName:  "schema.go",
+		},
+		{
+			SchemaID:    "https://example.com/other",
+			PackageName: "github.com/example/other",
+			OutputName:  "other.go",
+		},
+	}
+	cfg.DeleteStaleFiles = true
+	generator, err := generator.New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, generator.DoFile("./data/crossPackage/schema.json"))
+
+	schemaFile := filepath.Join(dir, "schema.go")
+	d, err := generator.Diff(dir)
+	require.NoError(t, err)
+	require.Contains(t, string(d), "--- "+os.DevNull+"\n+++ "+schemaFile+"\n@@ -0,0 +1,")
+	require.Contains(t, string(d), "--- "+filepath.Join(dir, "removed.go")+"\n+++ "+os.DevNull+"\n@@ -1,3 +0,0 @@\n")
+	_, err = os.Stat(schemaFile)
+	require.True(t, os.IsNotExist(err))
+
+	require.NoError(t, generator.Write(dir))
+	d, err = generator.Diff(dir)
+	require.NoError(t, err)
+	require.Empty(t, string(d))
+
+	source, err := os.ReadFile(schemaFile)
+	require.NoError(t, err)
+	edited := strings.Replace(string(source), "package schema\n", "package old\n", 1)
+	require.NoError(t, os.WriteFile(schemaFile, []byte(edited), 0644))
+	d, err = generator.Diff(dir)
+	require.NoError(t, err)
+	require.Regexp(t, "^--- "+regexp.QuoteMeta(schemaFile)+"\n\\+\\+\\+ "+regexp.QuoteMeta(schemaFile)+"\n"+
+		"@@ -1,\\d+ \\+1,\\d+ @@\n( .*\n)*-package old\n\\+package schema\n( .*\n)*$", string(d))
+}
+
+func TestWatch(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping go build in short mode")
+	}
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "gojsonschema")
+	out, err := exec.Command("go", "build", "-o", binary, "../cmd/gojsonschema").CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	schemaFile := filepath.Join(dir, "schema.json")
+	require.NoError(t, os.WriteFile(schemaFile,
+		[]byte(`{"type": "object", "properties": {"item": {"$ref": "item.json"}}}`), 0644))
+	writeItem := func(description string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "item.json"),
+			[]byte(`{"type": "object", "description": `+strconv.Quote(description)+`}`), 0644))
+	}
+	writeItem("First version.")
+
+	outputFile := filepath.Join(dir, "schema.go")
+	cmd := exec.Command(binary, "--watch", "--watch-interval", "10ms",
+		"-p", "example.com/watch", "-o", outputFile, schemaFile)
+	require.NoError(t, cmd.Start())
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+	generated := func(s string) func() bool {
+		return func() bool {
+			b, err := os.ReadFile(outputFile)
+			return err == nil && strings.Contains(string(b), s)
+		}
+	}
+	require.Eventually(t, generated("First version."), 10*time.Second, 10*time.Millisecond)
+
+	// The referenced file is watched too.
+	writeItem("Second, longer version.")
+	require.Eventually(t, generated("Second, longer version."), 10*time.Second, 10*time.Millisecond)
+
+	// So is an edit that keeps the size and modification time of the file.
+	info, err := os.Stat(filepath.Join(dir, "item.json"))
+	require.NoError(t, err)
+	writeItem("Second, larger version.")
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "item.json"), info.ModTime(), info.ModTime()))
+	require.Eventually(t, generated("Second, larger version."), 10*time.Second, 10*time.Millisecond)
+}
+
+func TestFiles(t *testing.T) {
+	cfg := basicConfig
+	cfg.SchemaMappings = []generator.SchemaMapping{
+		{
+			SchemaID:    "https://example.com/schema",
+			PackageName: "github.com/example/schema",
+			OutputName:  "schema.go",
+		},
+		{
+			SchemaID:    "https://example.com/other",
+			PackageName: "github.com/example/other",
+			OutputName:  "other.go",
+		},
+	}
+	cfg.RoundTripTests = true
+	generator, err := generator.New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, generator.DoFile("./data/crossPackage/schema.json"))
+
+	sources := generator.Sources()
+	var names []string
+	for _, file := range generator.Files() {
+		names = append(names, file.Name)
+		require.Equal(t, string(sources[file.Name]), string(file.Content))
+		switch file.Name {
+		case "other.go":
+			require.Equal(t, "github.com/example/other", file.Package)
+			require.Equal(t, []string{"https://example.com/other"}, file.SchemaIDs)
+		case "schema.go":
+			require.Equal(t, "github.com/example/schema", file.Package)
+			require.Equal(t, []string{"https://example.com/schema"}, file.SchemaIDs)
+		}
+	}
+	require.Equal(t, []string{"other.go", "schema.go"}, names)
+}
+
+func TestSchemaMappingPatterns(t *testing.T) {
+	cfg := basicConfig
+	cfg.SchemaMappings = []generator.SchemaMapping{
+		{
+			SchemaID:    "https://example.com/*",
+			PackageName: "github.com/example/{name}",
+			OutputName:  "{name}.go",
+		},
+		{
+			SchemaID:    "*",
+			PackageName: "github.com/example/wrong",
+			OutputName:  "wrong.go",
+		},
+	}
+	testExampleFile(t, cfg, "./data/crossPackage/schema.json")
+}
+
+func TestFileMappings(t *testing.T) {
+	cfg := basicConfig
+	cfg.SchemaMappings = []generator.SchemaMapping{
+		{
+			FilePath:    "./data/fileMapping/a.json",
+			PackageName: "github.com/example/a",
+			OutputName:  "a.go",
+		},
+		{
+			FilePath:    "data/fileMapping/b*.json",
+			PackageName: "github.com/example/b",
+			OutputName:  "b.go",
+			RootType:    "Bee",
+		},
+	}
+	testExampleFile(t, cfg, "./data/fileMapping/a.json")
+}
+
+func TestSchemaIdentity(t *testing.T) {
+	// Both copies of the file have the same $id, so the definition is
+	// generated once, in the package of the first, and imported from it
+	// rather than declared again in the package of the second.
+	cfg := basicConfig
+	cfg.SchemaMappings = []generator.SchemaMapping{
+		{
+			FilePath:    "data/schemaID/a/shared.json",
+			PackageName: "github.com/example/a",
+			OutputName:  "a.go",
+		},
+		{
+			FilePath:    "data/schemaID/b/shared.json",
+			PackageName: "github.com/example/b",
+			OutputName:  "b.go",
+		},
+	}
+	testExampleFile(t, cfg, "./data/schemaID/schema.json")
+
+	// Copies whose relative $refs resolve to different files differ.
+	testExampleFile(t, basicConfig, "./data/schemaIDRelativeRefs/schema.json")
+}
+
+func TestGlob(t *testing.T) {
+	matches, err := generator.Glob("./data/**/address.json")
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		filepath.FromSlash("data/packageDoc/address.json"),
+		filepath.FromSlash("data/url/definitions/address.json"),
+	}, matches)
+
+	matches, err = generator.Glob("data/*/[ab].json")
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		filepath.FromSlash("data/fileMapping/a.json"),
+		filepath.FromSlash("data/fileMapping/b.json"),
+	}, matches)
+
+	matches, err = generator.Glob("./nonexistent/**/*.json")
+	require.NoError(t, err)
+	require.Empty(t, matches)
+
+	// Mappings match the same patterns.
+	cfg := basicConfig
+	cfg.SchemaMappings = []generator.SchemaMapping{
+		{
+			FilePath:    "./data/**/a.json",
+			PackageName: "github.com/example/a",
+			OutputName:  "a.go",
+		},
+		{
+			FilePath:    "data/**/b*.json",
+			PackageName: "github.com/example/b",
+			OutputName:  "b.go",
+			RootType:    "Bee",
+		},
+	}
+	testExampleFile(t, cfg, "./data/fileMapping/a.json")
+}
+
+func TestPackageDocs(t *testing.T) {
+	cfg := basicConfig
+	cfg.PackageDocs = true
+	cfg.DefaultOutputName = "shipment/shipment.go"
+	cfg.SchemaMappings = []generator.SchemaMapping{
+		{
+			FilePath:    "./data/packageDoc/address.json",
+			PackageName: "github.com/example/address",
+			OutputName:  "address/address.go",
+		},
+	}
+	testExampleFile(t, cfg, "./data/packageDoc/shipment.json")
+
+	cfg.DefaultOutputName = "combined/combined.go"
+	cfg.SchemaMappings = nil
+	testExampleFile(t, cfg, "./data/packageDoc/shipment.json")
+}
+
+func TestRootTypeOverrides(t *testing.T) {
+	cfg := basicConfig
+	cfg.RootTypeOverrides = map[string]string{
+		"./data/misc/*.json":                "Wrong",
+		"./data/misc/rootTypeOverride.json": "Renamed",
+	}
+	testExampleFile(t, cfg, "./data/misc/rootTypeOverride.json")
+}
+
+func TestInferPackageNames(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"),
+		[]byte("module example.com/m // the module\n\ngo 1.19\n"), 0644))
+
+	cfg := basicConfig
+	cfg.DefaultPackageName = ""
+	cfg.InferPackageNames = true
+	cfg.SchemaMappings = []generator.SchemaMapping{
+		{
+			SchemaID:   "https://example.com/schema",
+			OutputName: filepath.Join(dir, "schema.go"),
+		},
+		{
+			SchemaID:   "https://example.com/other",
+			OutputName: filepath.Join(dir, "internal", "other", "other.go"),
+		},
+	}
+	generator, err := generator.New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, generator.DoFile("./data/crossPackage/schema.json"))
+
+	var packages []string
+	for _, file := range generator.Files() {
+		packages = append(packages, file.Package)
+	}
+	require.Equal(t, []string{"example.com/m/internal/other", "example.com/m"}, packages)
+}
+
+func TestInferredPackageClauses(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/lib/v2\n\ngo 1.19\n"), 0644))
+
+	cfg := basicConfig
+	cfg.DefaultPackageName = ""
+	cfg.InferPackageNames = true
+	cfg.SchemaMappings = []generator.SchemaMapping{
+		{
+			SchemaID:   "https://example.com/schema",
+			OutputName: filepath.Join(dir, "schema.go"),
+		},
+		{
+			SchemaID:   "https://example.com/other",
+			OutputName: filepath.Join(dir, "my-types", "other.go"),
+		},
+	}
+	generator, err := generator.New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, generator.DoFile("./data/crossPackage/schema.json"))
+
+	sources := generator.Sources()
+	require.Contains(t, string(sources[filepath.Join(dir, "schema.go")]), "\npackage lib\n")
+	require.Contains(t, string(sources[filepath.Join(dir, "schema.go")]),
+		"\nimport mytypes \"example.com/lib/v2/my-types\"\n")
+	require.Contains(t, string(sources[filepath.Join(dir, "my-types", "other.go")]), "\npackage mytypes\n")
+}
+
+func TestSplitFiles(t *testing.T) {
+	cfg := basicConfig
+	cfg.DefaultOutputName = "split.go"
+	cfg.SplitFiles = true
+	testExampleFile(t, cfg, "./data/split/schema.json")
+
+	cfg.DefaultOutputName = "chunked.go"
+	cfg.TypesPerFile = 2
+	testExampleFile(t, cfg, "./data/split/schema.json")
+}
+
 func TestCrossPackageNoOutput(t *testing.T) {
 	cfg := basicConfig
-	cfg.SchemaMappings = []generator.SchemaMapping{
+	cfg.SchemaMappings = []generator.SchemaMapping{
+		{
+			SchemaID:    "https://example.com/schema",
+			PackageName: "github.com/example/schema",
+			OutputName:  "schema.go",
+		},
+		{
+			SchemaID:    "https://example.com/other",
+			PackageName: "github.com/example/other",
+		},
+	}
+	testExampleFile(t, cfg, "./data/crossPackageNoOutput/schema.json")
+}
+
+func TestCapitalization(t *testing.T) {
+	cfg := basicConfig
+	cfg.Capitalizations = []string{"ID", "URL", "HtMl"}
+	testExampleFile(t, cfg, "./data/misc/capitalization.json")
+}
+
+func TestBooleanAsSchema(t *testing.T) {
+	cfg := basicConfig
+	testExampleFile(t, cfg, "./data/misc/boolean-as-schema.json")
+}
+
+func TestYAMLSchema(t *testing.T) {
+	cfg := basicConfig
+	cfg.DefaultOutputName = "yamlSchema.go"
+	testExampleFile(t, cfg, "./data/misc/yamlSchema.yaml")
+}
+
+func TestJSONCSchema(t *testing.T) {
+	testExampleFile(t, basicConfig, "./data/misc/jsonc.json")
+}
+
+func TestOpenAPI(t *testing.T) {
+	cfg := basicConfig
+	cfg.OpenAPI = true
+	cfg.DefaultOutputName = "openAPI.go"
+	testExampleFile(t, cfg, "./data/misc/openAPI.yaml")
+}
+
+func TestFormatValidation(t *testing.T) {
+	cfg := basicConfig
+	cfg.ValidateFormats = true
+	testExampleFile(t, cfg, "./data/misc/formatValidation.json")
+
+	cfg.DefaultPackageName = "main"
+	paths := runGenerated(t, cfg, "./data/misc/formatValidation.json",
+		unmarshalCase("FormatValidation", `{"email":"jane@example.com"}`),
+		unmarshalCase("FormatValidation", `{"email":"Jane <jane@example.com>"}`),
+		unmarshalCase("FormatValidation", `{"email":"jane@example.com (Jane)"}`),
+	)
+	require.Equal(t, []string{"<nil>", "/email", "/email"}, paths)
+}
+
+func TestFormatMappings(t *testing.T) {
+	cfg := basicConfig
+	cfg.FormatMappings = []generator.FormatMapping{
+		{Format: "date-time", GoType: "time.Time"},
+		{Format: "ipv4", GoType: "net/netip.Addr"},
+		{Format: "byte", GoType: "string"},
+	}
+	testExampleFile(t, cfg, "./data/misc/formatMappings.json")
+}
+
+func TestExtraTags(t *testing.T) {
+	cfg := basicConfig
+	cfg.ExtraTags = []string{"mapstructure", "bson", "yaml"}
+	testExampleFile(t, cfg, "./data/misc/extraTags.json")
+}
+
+func TestOmitEmpty(t *testing.T) {
+	testExampleFile(t, basicConfig, "./data/misc/omitEmpty.json")
+}
+
+func TestOmitEmptyNever(t *testing.T) {
+	cfg := basicConfig
+	cfg.OmitEmpty = generator.OmitEmptyNever
+	testExampleFile(t, cfg, "./data/misc/omitEmptyNever.json")
+}
+
+func TestOptionalValueTypes(t *testing.T) {
+	cfg := basicConfig
+	cfg.OptionalValueTypes = true
+	testExampleFile(t, cfg, "./data/misc/optionalValueTypes.json")
+}
+
+func TestNullableRequiredPointers(t *testing.T) {
+	cfg := basicConfig
+	cfg.NullableRequiredPointers = true
+	testExampleFile(t, cfg, "./data/misc/nullableRequiredPointers.json")
+}
+
+func TestIntegerTypeFromBounds(t *testing.T) {
+	cfg := basicConfig
+	cfg.DefaultIntegerType = "int64"
+	cfg.IntegerTypeFromBounds = true
+	var warnings []string
+	cfg.Warner = func(w generator.Warning) {
+		warnings = append(warnings, w.Message)
+	}
+	testExampleFile(t, cfg, "./data/misc/integerTypeFromBounds.json")
+	require.Equal(t, []string{
+		"Integer bounds are outside the range of int64 and uint64; declaring it as int64",
+		"Integer bounds are outside the range of int64 and uint64; declaring it as int64",
+	}, warnings)
+}
+
+func TestEnumRange(t *testing.T) {
+	var warnings []string
+	cfg := basicConfig
+	cfg.DefaultIntegerType = "int8"
+	cfg.Warner = func(w generator.Warning) {
+		warnings = append(warnings, w.Message)
+	}
+	testExampleFile(t, cfg, "./data/misc/enumRange.json")
+	require.Equal(t, []string{
+		"Enum value 1e+21 cannot be represented by int8; it is rejected",
+		"Enum value 1000 cannot be represented by int8; it is rejected",
+		"Enum value -129 cannot be represented by int8; it is rejected",
+	}, warnings)
+}
+
+func TestJSONNumber(t *testing.T) {
+	cfg := basicConfig
+	cfg.JSONNumber = true
+	testExampleFile(t, cfg, "./data/misc/jsonNumber.json")
+}
+
+func TestOnlyModels(t *testing.T) {
+	cfg := basicConfig
+	cfg.OnlyModels = true
+	testExampleFile(t, cfg, "./data/misc/onlyModels.json")
+}
+
+func TestNestedValidationPaths(t *testing.T) {
+	cfg := basicConfig
+	cfg.DefaultPackageName = "main"
+	paths := runGenerated(t, cfg, "./data/validation/nestedPaths.json",
+		unmarshalCase("NestedPaths", `{"owner":{}}`),
+		unmarshalCase("NestedPaths", `{"owner":{"name":"a","age":-1}}`),
+		unmarshalCase("NestedPaths", `{"items":[{"n":0},{"n":1}]}`),
+		unmarshalCase("NestedPaths", `{"grid":[[],[{"n":0},{"n":1}]]}`),
+		unmarshalCase("NestedPaths", `{"tags":{"a/b":{"n":1}}}`),
+		unmarshalCase("NestedPaths", `{"chain":`+strings.Repeat(`{"next":`, 40)+`{"n":1}`+strings.Repeat(`}`, 41)),
+		`age := -1
+		value := NestedPaths{Owner: &NestedPathsOwner{Name: "a", Age: &age}}
+		return value.Validate()`,
+		`one := 1
+		value := NestedPaths{Grid: [][]Entry{{{}}, {{}, {N: &one}}}}
+		return value.Validate()`,
+		`one := 1
+		value := NestedPaths{Tags: NestedPathsTags{"a~b": {N: &one}}}
+		return value.Validate()`,
+	)
+	require.Equal(t, []string{
+		"/owner/name", "/owner/age", "/items/1/n", "/grid/1/1/n", "/tags/a~1b/n",
+		"/chain" + strings.Repeat("/next", 40) + "/n",
+		"/owner/age", "/grid/1/1/n", "/tags/a~0b/n",
+	}, paths)
+}
+
+func TestNestedAggregateErrors(t *testing.T) {
+	cfg := basicConfig
+	cfg.DefaultPackageName = "main"
+	cfg.AggregateErrors = true
+	paths := runGenerated(t, cfg, "./data/validation/nestedPaths.json",
+		unmarshalCase("NestedPaths", `{"owner":{"age":-1},"items":[{"n":1},{"n":0},{"n":2}]}`),
+		`one := 1
+		value := NestedPaths{Items: []Entry{{N: &one}}, Tags: NestedPathsTags{"a": {N: &one}}}
+		return value.Validate()`,
+	)
+	require.Equal(t, []string{
+		"/items/0/n /items/2/n /owner/name /owner/age",
+		"/items/0/n /tags/a/n",
+	}, paths)
+}
+
+func TestFullValidation(t *testing.T) {
+	cfg := basicConfig
+	cfg.FullValidation = true
+	testExampleFile(t, cfg, "./data/misc/fullValidation.json")
+}
+
+func TestAggregateErrors(t *testing.T) {
+	cfg := basicConfig
+	cfg.AggregateErrors = true
+	testExampleFile(t, cfg, "./data/misc/aggregateErrors.json")
+}
+
+func TestNestedDefaults(t *testing.T) {
+	cfg := basicConfig
+	cfg.NestedDefaults = true
+	testExampleFile(t, cfg, "./data/misc/nestedDefaults.json")
+}
+
+func TestBuilders(t *testing.T) {
+	cfg := basicConfig
+	cfg.Builders = true
+	testExampleFile(t, cfg, "./data/misc/builders.json")
+
+	cfg.DefaultPackageName = "main"
+	paths := runGenerated(t, cfg, "./data/misc/builders.json",
+		`v, err := NewBuildersBuilder().Name("web").Replicas(2).Build()
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("%s %d %s", v.Name, v.Replicas, v.Image)`,
+		`_, err := NewBuildersBuilder().Name("web").Build()
+		return err`,
+	)
+	require.Equal(t, []string{"web 2 nginx", "/replicas"}, paths)
+
+	paths = runGenerated(t, cfg, "./data/misc/constructors.json",
+		`v, err := NewSettingsBuilder().Build()
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("%d %s", v.Level, *v.Rules[0].Name)`,
+	)
+	require.Equal(t, []string{"1 all"}, paths)
+
+	cfg.Constructors = true
+	paths = runGenerated(t, cfg, "./data/misc/constructors.json",
+		`v, err := NewConstructorsBuilder().Settings(Settings{Level: 2}).Limits(Limits{Max: 3}).Build()
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("%s %d %d %s", v.Name, v.Settings.Level, v.Limits.Max, *v.Tags[0].Key)`,
+	)
+	require.Equal(t, []string{"unnamed 2 3 env"}, paths)
+}
+
+func TestConstructors(t *testing.T) {
+	cfg := basicConfig
+	cfg.Constructors = true
+	testExampleFile(t, cfg, "./data/misc/constructors.json")
+
+	cfg.DefaultPackageName = "main"
+	paths := runGenerated(t, cfg, "./data/misc/constructors.json",
+		`v, err := NewConstructors()
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("%s %d %s %d %s", v.Name, v.Settings.Level, *v.Settings.Rules[0].Name, v.Limits.Max,
+			*v.Tags[0].Key)`,
+	)
+	require.Equal(t, []string{"unnamed 1 all 10 env"}, paths)
+}
+
+func TestGetters(t *testing.T) {
+	cfg := basicConfig
+	cfg.Getters = true
+	testExampleFile(t, cfg, "./data/misc/getters.json")
+}
+
+func TestMaxLengthZero(t *testing.T) {
+	cfg := basicConfig
+	cfg.DefaultPackageName = "main"
+	paths := runGenerated(t, cfg, "./data/validation/6.3_string.json",
+		unmarshalCase("A63String", `{"name":"a","reserved":""}`),
+		unmarshalCase("A63String", `{"name":"a","reserved":"x"}`),
+	)
+	require.Equal(t, []string{"<nil>", "/reserved"}, paths)
+}
+
+func TestMethodNameFields(t *testing.T) {
+	cfg := basicConfig
+	testExampleFile(t, cfg, "./data/misc/methodNames.json")
+
+	cfg.DefaultPackageName = "main"
+	paths := runGenerated(t, cfg, "./data/misc/methodNames.json",
+		unmarshalCase("MethodNames", `{"validate":-1}`),
+		`n := -1
+		value := MethodNames{Validate_2: &n}
+		return value.Validate()`,
+	)
+	require.Equal(t, []string{"/validate", "/validate"}, paths)
+
+	cfg = basicConfig
+	cfg.DeepCopy = true
+	cfg.Getters = true
+	testExampleFile(t, cfg, "./data/misc/accessorNames.json")
+
+	cfg.DefaultPackageName = "main"
+	paths = runGenerated(t, cfg, "./data/misc/accessorNames.json",
+		`name, getName := "a", "b"
+		value := AccessorNames{Name: &name, GetName_2: &getName}
+		if value.DeepCopy().GetName() != "a" || value.GetGetName_2() != "b" {
+			return errors.New("wrong accessor")
+		}
+		return nil`,
+	)
+	require.Equal(t, []string{"<nil>"}, paths)
+}
+
+func TestFieldNameConstants(t *testing.T) {
+	cfg := basicConfig
+	cfg.FieldNameConstants = true
+	testExampleFile(t, cfg, "./data/misc/fieldNameConstants.json")
+}
+
+func TestDeepCopy(t *testing.T) {
+	cfg := basicConfig
+	cfg.DeepCopy = true
+	testExampleFile(t, cfg, "./data/misc/deepCopy.json")
+}
+
+func TestEqual(t *testing.T) {
+	cfg := basicConfig
+	cfg.Equal = true
+	testExampleFile(t, cfg, "./data/misc/equal.json")
+}
+
+func TestFileHeader(t *testing.T) {
+	cfg := basicConfig
+	cfg.FileHeader = "Copyright 2024 Example Inc. Licensed under the Apache License 2.0.\n\n" +
+		"Generated for {{.Package}} from:\n" +
+		"{{range .Sources}}  {{.Name}} ({{.ID}}), sha256 {{.SHA256}}\n{{end}}"
+	testExampleFile(t, cfg, "./data/misc/fileHeader.json")
+
+	cfg.FileHeader = "{{.Nope"
+	_, err := generator.New(cfg)
+	require.Error(t, err)
+}
+
+func TestGoldenFilesAreFormatted(t *testing.T) {
+	err := filepath.WalkDir("./data", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !strings.HasSuffix(path, ".go.output") {
+			return err
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		formatted, err := format.Source(b)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		require.Equal(t, string(formatted), string(b), path)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+// TestBenchmarkCodeIsCurrent checks that the package used by the benchmarks
+// is what the generator produces today; run go generate ./tests/bench if not.
+func TestBenchmarkCodeIsCurrent(t *testing.T) {
+	cfg := basicConfig
+	cfg.DefaultPackageName = "github.com/lets-dev-it-out/go-jsonschema/tests/bench"
+	cfg.DefaultOutputName = "order.go"
+	cfg.ResolveExtensions = []string{".json"}
+	generator, err := generator.New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, generator.DoFile("./data/bench/order.json"))
+
+	b, err := os.ReadFile("./bench/order.go")
+	require.NoError(t, err)
+	require.Equal(t, string(b), string(generator.Sources()["order.go"]))
+}
+
+func TestSinglePassUnmarshal(t *testing.T) {
+	cfg := basicConfig
+	cfg.DefaultPackageName = "main"
+	cfg.ResolveExtensions = []string{".json"}
+	paths := runGenerated(t, cfg, "./data/bench/order.json",
+		unmarshalCase("Line", `{"quantity":1}`),
+		unmarshalCase("Line", `{"sku":null,"quantity":1}`),
+		unmarshalCase("Line", `{"sku":"ABC-1","quantity":0}`),
+		unmarshalCase("Line", `{"sku":"ABC-1","quantity":1,"status":"lost"}`),
+		`var value Address
+		if err := json.Unmarshal([]byte(`+"`"+`{"street":"a","city":"b","country":null}`+"`"+`), &value); err != nil {
+			return err
+		}
+		return fmt.Errorf("%s %s %s", value.Street, value.City, value.Country)`,
+		`var value Address
+		if err := json.Unmarshal([]byte(`+"`"+`{"street":"a","city":"b","country":"BE"}`+"`"+`), &value); err != nil {
+			return err
+		}
+		return fmt.Errorf("%s", value.Country)`,
+	)
+	require.Equal(t, []string{"/sku", "/sku", "/quantity", "/status", "a b NL", "BE"}, paths)
+}
+
+func TestASTHooks(t *testing.T) {
+	cfg := basicConfig
+	cfg.ASTHooks = []generator.ASTHook{
+		func(fset *token.FileSet, file *ast.File) {
+			// Keep only the json tags.
+			ast.Inspect(file, func(n ast.Node) bool {
+				if f, ok := n.(*ast.Field); ok && f.Tag != nil {
+					tag, err := strconv.Unquote(f.Tag.Value)
+					require.NoError(t, err)
+					f.Tag.Value = "`json:" + strconv.Quote(reflect.StructTag(tag).Get("json")) + "`"
+				}
+				return true
+			})
+		},
+	}
+	testExampleFile(t, cfg, "./data/misc/astHooks.json")
+}
+
+func TestEscaping(t *testing.T) {
+	testExampleFile(t, basicConfig, "./data/misc/escaping.json")
+}
+
+func TestCodeStyle(t *testing.T) {
+	cfg := basicConfig
+	cfg.LineWidth = 40
+	testExampleFile(t, cfg, "./data/misc/codeStyle.json")
+
+	cfg.DefaultOutputName = "codeStyleUnwrapped.go"
+	cfg.UnwrappedComments = true
+	testExampleFile(t, cfg, "./data/misc/codeStyle.json")
+
+	// Indentation counts as many characters as it has spaces, and is the
+	// only difference from gofmt.
+	cfg.DefaultOutputName = "-"
+	cfg.IndentSpaces = 4
+	tabs, err := generator.New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, tabs.DoFile("./data/misc/codeStyle.json"))
+	cfg.UnwrappedComments = false
+	spaces, err := generator.New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, spaces.DoFile("./data/misc/codeStyle.json"))
+	require.Contains(t, string(spaces.Sources()["-"]), "\n    // The address that the shipment is\n")
+	require.NotContains(t, string(tabs.Sources()["-"]), "\t")
+	formatted, err := format.Source(tabs.Sources()["-"])
+	require.NoError(t, err)
+	golden, err := os.ReadFile("./data/misc/codeStyleUnwrapped.go.output")
+	require.NoError(t, err)
+	require.Equal(t, string(golden), string(formatted))
+}
+
+func TestComments(t *testing.T) {
+	testExampleFile(t, basicConfig, "./data/misc/comments.json")
+
+	cfg := basicConfig
+	cfg.DefaultOutputName = "commentsUnwrapped.go"
+	cfg.UnwrappedComments = true
+	testExampleFile(t, cfg, "./data/misc/comments.json")
+}
+
+func TestSourceComments(t *testing.T) {
+	cfg := basicConfig
+	cfg.SourceComments = true
+	testExampleFile(t, cfg, "./data/misc/sourceComments.json")
+}
+
+func TestEmbedSchema(t *testing.T) {
+	cfg := basicConfig
+	cfg.EmbedSchema = true
+	testExampleFile(t, cfg, "./data/embedSchema/order.json")
+}
+
+func TestDeclarationOrder(t *testing.T) {
+	testExampleFile(t, basicConfig, "./data/misc/declarationOrder.json")
+}
+
+func TestSQL(t *testing.T) {
+	cfg := basicConfig
+	cfg.SQL = true
+	testExampleFile(t, cfg, "./data/misc/sql.json")
+
+	cfg.DefaultPackageName = "main"
+	paths := runGenerated(t, cfg, "./data/misc/sql.json",
+		`_, err := SqlPriority(2).Value()
+		return err`,
+		`_, err := SqlPriority(4).Value()
+		return err`,
+		`_, err := SqlRatio(1).Value()
+		return err`,
+		`_, err := SqlFlag(false).Value()
+		return err`,
+		`_, err := SqlStatus("deleted").Value()
+		return err`,
+	)
+	require.Equal(t, []string{"<nil>", "", "", "", ""}, paths)
+}
+
+func TestValidateOnMarshal(t *testing.T) {
+	cfg := basicConfig
+	cfg.ValidateOnMarshal = true
+	testExampleFile(t, cfg, "./data/misc/validateOnMarshal.json")
+}
+
+func TestPropertyCounts(t *testing.T) {
+	cfg := basicConfig
+	cfg.CaptureExtras = true
+	cfg.ValidateOnMarshal = true
+	testExampleFile(t, cfg, "./data/misc/propertyCounts.json")
+
+	cfg.DefaultPackageName = "main"
+	paths := runGenerated(t, cfg, "./data/misc/propertyCounts.json",
+		`return (&PropertyCounts{}).Validate()`,
+		`return (&PropertyCounts{Tags: []string{}}).Validate()`,
+		`name := "a"
+		value := PropertyCounts{Name: &name, AdditionalProperties: map[string]interface{}{"x": 1}}
+		_, err := json.Marshal(value)
+		return err`,
+		`name := "a"
+		value := PropertyCounts{Name: &name, AdditionalProperties: map[string]interface{}{"x": 1, "y": 2}}
+		_, err := json.Marshal(value)
+		return err`,
+	)
+	require.Equal(t, []string{"", "", "<nil>", ""}, paths)
+
+	// Optional value fields with omitempty are not marshaled if they are
+	// empty, so they are not counted then.
+	cfg.OptionalValueTypes = true
+	paths = runGenerated(t, cfg, "./data/misc/propertyCounts.json",
+		`return (&PropertyCounts{}).Validate()`,
+		`return (&PropertyCounts{Name: "a"}).Validate()`,
+		`return (&PropertyCounts{Name: "a", AdditionalProperties: map[string]interface{}{"x": 1, "y": 2}}).Validate()`,
+	)
+	require.Equal(t, []string{"", "<nil>", ""}, paths)
+}
+
+func TestPreserveOrder(t *testing.T) {
+	cfg := basicConfig
+	cfg.PreserveOrder = true
+	testExampleFile(t, cfg, "./data/misc/preserveOrder.json")
+}
+
+func TestCaptureExtras(t *testing.T) {
+	cfg := basicConfig
+	cfg.CaptureExtras = true
+	testExampleFile(t, cfg, "./data/misc/captureExtras.json")
+}
+
+func TestDisallowUnknownFields(t *testing.T) {
+	cfg := basicConfig
+	cfg.DisallowUnknownFields = true
+	testExampleFile(t, cfg, "./data/misc/disallowUnknownFields.json")
+}
+
+func TestEasyJSON(t *testing.T) {
+	cfg := basicConfig
+	cfg.EasyJSON = true
+	testExampleFile(t, cfg, "./data/misc/easyJSON.json")
+}
+
+func TestRoundTripTests(t *testing.T) {
+	cfg := basicConfig
+	cfg.RoundTripTests = true
+	cfg.DefaultOutputName = "roundTripTests.go"
+	testExampleFile(t, cfg, "./data/misc/roundTripTests.json")
+}
+
+func TestOnlyReferencedDefinitions(t *testing.T) {
+	cfg := basicConfig
+	cfg.OnlyReferencedDefinitions = true
+	testExampleFile(t, cfg, "./data/misc/onlyReferencedDefinitions.json")
+	// Schemas without a root type are generated for their definitions.
+	testExampleFile(t, cfg, "./data/miscWithDefaults/rootEmptyJustDefinitions.json")
+	// Unless they are only loaded because another schema refers to them.
+	testExampleFile(t, cfg, "./data/sharedDefinitions/order.json")
+}
+
+func TestSharedInlineTypes(t *testing.T) {
+	cfg := basicConfig
+	cfg.SharedInlineTypes = true
+	testExampleFile(t, cfg, "./data/misc/sharedInlineTypes.json")
+}
+
+func TestTupleItems(t *testing.T) {
+	testExampleFile(t, basicConfig, "./data/misc/tupleItems.json")
+}
+
+func TestDocs(t *testing.T) {
+	cfg := basicConfig
+	cfg.Docs = true
+	cfg.DefaultOutputName = "docs.go"
+	testExampleFile(t, cfg, "./data/misc/docs.json")
+}
+
+func TestTypeHooks(t *testing.T) {
+	cfg := basicConfig
+	cfg.TypeHooks = []generator.TypeHook{
+		func(t *schemas.Type) (codegen.Type, []codegen.Import, error) {
+			if _, ok := t.Properties["amount"]; !ok {
+				return nil, nil, nil
+			}
+			if _, ok := t.Properties["currency"]; !ok {
+				return nil, nil, nil
+			}
+			return &codegen.CustomNameType{Type: "money.Money"},
+				[]codegen.Import{{QualifiedName: "example.com/money"}}, nil
+		},
+	}
+	testExampleFile(t, cfg, "./data/misc/typeHooks.json")
+}
+
+func TestFileHooks(t *testing.T) {
+	cfg := basicConfig
+	cfg.FileHooks = []generator.FileHook{
+		func(file *codegen.File) {
+			for _, decl := range file.Package.Decls {
+				typeDecl, ok := decl.(*codegen.TypeDecl)
+				if !ok {
+					continue
+				}
+				if structType, ok := typeDecl.Type.(*codegen.StructType); ok {
+					for i, f := range structType.Fields {
+						structType.Fields[i].Tags = f.Tags + fmt.Sprintf(` db:"%s"`, f.JSONName)
+					}
+				}
+			}
+			file.Package.AddImport("example.com/registry", "")
+			file.Package.AddDecl(&codegen.Method{Impl: func(out *codegen.Emitter) {
+				out.Println("func init() {")
+				out.Indent(1)
+				out.Println(`registry.Register("https://example.com/fileHooks", FileHooks{})`)
+				out.Indent(-1)
+				out.Println("}")
+			}})
+		},
+	}
+	testExampleFile(t, cfg, "./data/misc/fileHooks.json")
+}
+
+func TestRandomValues(t *testing.T) {
+	cfg := basicConfig
+	cfg.RandomValues = true
+	testExampleFile(t, cfg, "./data/misc/randomValues.json")
+}
+
+func TestFuzzTests(t *testing.T) {
+	cfg := basicConfig
+	cfg.FuzzTests = true
+	cfg.DefaultOutputName = "fuzzTests.go"
+	testExampleFile(t, cfg, "./data/misc/fuzzTests.json")
+}
+
+func TestDoURL(t *testing.T) {
+	server := httptest.NewServer(http.FileServer(http.Dir("./data/url")))
+	defer server.Close()
+
+	generator, err := generator.New(basicConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := generator.DoURL(context.Background(), server.URL+"/schema.json"); err != nil {
+		t.Fatal(err)
+	}
+	testSources(t, generator, "./data/url/schema.json")
+}
+
+func TestDoCatalogSchema(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	mux.Handle("/", http.FileServer(http.Dir("./data/url")))
+	mux.HandleFunc("/catalog.json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"schemas": [
+			{"name": "Other", "url": "%[1]s/other.json"},
+			{"name": "URL Example", "url": "%[1]s/schema.json"}
+		]}`, server.URL)
+	})
+
+	for _, name := range []string{"schema", "url example"} {
+		t.Run(name, func(t *testing.T) {
+			cfg := basicConfig
+			cfg.CatalogURL = server.URL + "/catalog.json"
+			generator, err := generator.New(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := generator.DoCatalogSchema(context.Background(), name); err != nil {
+				t.Fatal(err)
+			}
+			testSources(t, generator, "./data/url/schema.json")
+		})
+	}
+
+	t.Run("unknown", func(t *testing.T) {
+		cfg := basicConfig
+		cfg.CatalogURL = server.URL + "/catalog.json"
+		generator, err := generator.New(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := generator.DoCatalogSchema(context.Background(), "unknown"); err == nil {
+			t.Fatal("Expected an error for a schema that is not in the catalog")
+		}
+	})
+}
+
+func TestInvalidGoName(t *testing.T) {
+	for _, schema := range []string{
+		`{"x-go-name": "my-type", "type": "object"}`,
+		`{"type": "object", "definitions": {"a": {"x-go-name": "1A", "type": "object"}}}`,
+		`{"type": "object", "properties": {"a": {"x-go-name": "A B", "type": "string"}}}`,
+	} {
+		g, err := generator.New(basicConfig)
+		require.NoError(t, err)
+		err = g.DoReader("schema.json", strings.NewReader(schema))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "is not a valid Go identifier")
+	}
+}
+
+func TestDoReader(t *testing.T) {
+	fileName := "./data/core/object.json"
+	f, err := os.Open(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	generator, err := generator.New(basicConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := generator.DoReader(fileName, f); err != nil {
+		t.Fatal(err)
+	}
+	testSources(t, generator, fileName)
+}
+
+func TestDoSchema(t *testing.T) {
+	fileName := "./data/core/object.json"
+	schema, err := schemas.FromJSONFile(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	generator, err := generator.New(basicConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := generator.DoSchema(fileName, schema); err != nil {
+		t.Fatal(err)
+	}
+	testSources(t, generator, fileName)
+}
+
+func TestDoFileContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	generator, err := generator.New(basicConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = generator.DoFileContext(ctx, "./data/core/object.json")
+	require.True(t, errors.Is(err, context.Canceled), "expected cancellation, got %v", err)
+	require.NoError(t, generator.DoFileContext(context.Background(), "./data/core/object.json"))
+}
+
+func TestFileSystem(t *testing.T) {
+	cfg := basicConfig
+	cfg.FileSystem = os.DirFS("./data/core")
+
+	generator, err := generator.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := generator.DoFile("refExternalFile.json"); err != nil {
+		t.Fatal(err)
+	}
+	testSources(t, generator, "./data/core/refExternalFile.json")
+}
+
+func TestBundle(t *testing.T) {
+	generator, err := generator.New(basicConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source, err := generator.Bundle("./data/bundle/schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testGoldenFile(t, "./data/bundle/schema.bundled.json.output", source)
+}
+
+func TestInputFiles(t *testing.T) {
+	g, err := generator.New(basicConfig)
+	require.NoError(t, err)
+	require.NoError(t, g.DoFile("./data/embedSchema/order.json"))
+	require.Equal(t, []string{
+		filepath.FromSlash("data/embedSchema/customer.json"),
+		filepath.FromSlash("data/embedSchema/order.json"),
+	}, g.InputFiles())
+}
+
+func TestStandardInput(t *testing.T) {
+	const fileName = "./data/embedSchema/customer.json"
+	withStdin := func(fn func()) {
+		f, err := os.Open(fileName)
+		require.NoError(t, err)
+		defer f.Close()
+		stdin := os.Stdin
+		os.Stdin = f
+		defer func() { os.Stdin = stdin }()
+		fn()
+	}
+
+	g, err := generator.New(basicConfig)
+	require.NoError(t, err)
+	expected, err := g.Bundle(fileName)
+	require.NoError(t, err)
+	withStdin(func() {
+		source, err := g.Bundle("-")
+		require.NoError(t, err)
+		require.Equal(t, string(expected), string(source))
+	})
+
+	withStdin(func() {
+		errs, err := g.Validate("-", map[string]interface{}{"customers": []interface{}{map[string]interface{}{}}})
+		require.NoError(t, err)
+		require.Len(t, errs, 1)
+		require.Equal(t, "/customers/0", errs[0].Path)
+		require.Equal(t, "required", errs[0].Keyword)
+	})
+}
+
+type reverseAddress struct {
+	Street  string    `json:"street"`
+	Country *string   `json:"country,omitempty"`
+	Lines   [2]string `json:"lines"`
+}
+
+type reverseBase struct {
+	ID      int64     `json:"id"`
+	Created time.Time `json:"created"`
+	Name    int       `json:"name"`
+}
+
+type reversePerson struct {
+	reverseBase
+	Name     string                  `json:"name"`
+	Age      uint8                   `json:"age,omitempty"`
+	Score    float64                 `json:"score,string"`
+	Address  *reverseAddress         `json:"address,omitempty"`
+	Previous []reverseAddress        `json:"previous,omitempty"`
+	Labels   map[string]string       `json:"labels,omitempty"`
+	Extra    interface{}             `json:"extra,omitempty"`
+	Data     []byte                  `json:"data,omitempty"`
+	Children []*reversePerson        `json:"children,omitempty"`
+	Meta     struct{ Source string } `json:"meta"`
+	Ignored  string                  `json:"-"`
+	internal string
+}
+
+func TestReverse(t *testing.T) {
+	schema, err := reverse.Schema(&reversePerson{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	source, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testGoldenFile(t, "./data/reverse/person.json.output", append(source, '\n'))
+
+	generator, err := generator.New(basicConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := generator.DoSchema("person.json", schema); err != nil {
+		t.Fatal(err)
+	}
+	testSources(t, generator, "./data/reverse/person.json")
+}
+
+func TestInfer(t *testing.T) {
+	f, err := os.Open("./data/infer/samples.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	schema, err := infer.FromReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testGoldenFile(t, "./data/infer/samples.schema.json.output", append(source, '\n'))
+
+	generator, err := generator.New(basicConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := generator.DoSchema("samples.json", schema); err != nil {
+		t.Fatal(err)
+	}
+	testSources(t, generator, "./data/infer/samples.json")
+}
+
+func TestValidate(t *testing.T) {
+	for _, tt := range []struct {
+		schema   string
+		document string
+		errs     []generator.DocumentError
+	}{
 		{
-			SchemaID:    "https://example.com/schema",
-			PackageName: "github.com/example/schema",
-			OutputName:  "schema.go",
+			schema:   "./data/validation/6.2_numeric.json",
+			document: `{"port": 80, "ratio": 0.5, "step": 1.5, "count": -100}`,
 		},
 		{
-			SchemaID:    "https://example.com/other",
-			PackageName: "github.com/example/other",
+			schema:   "./data/misc/tupleItems.json",
+			document: `{"point": [1, 2], "anything": [1, "a"], "names": ["a"]}`,
+		},
+		{
+			schema:   "./data/misc/tupleItems.json",
+			document: `{"point": [1, "a", 3], "names": [1]}`,
+			errs: []generator.DocumentError{
+				{Path: "/names/0", Keyword: "type", Message: "expected string, got integer"},
+				{Path: "/point/1", Keyword: "type", Message: "expected number, got string"},
+				{Path: "/point/2", Keyword: "not", Message: "value must not match the schema in not"},
+			},
+		},
+		{
+			schema:   "./data/validation/6.2_numeric.json",
+			document: `{"port": 0, "ratio": 1, "step": 0.7, "count": 15.5}`,
+			errs: []generator.DocumentError{
+				{Path: "/count", Keyword: "type", Message: "expected integer, got number"},
+				{Path: "/port", Keyword: "minimum", Message: "must be greater than or equal to 1"},
+				{Path: "/ratio", Keyword: "exclusiveMaximum", Message: "must be less than 1"},
+				{Path: "/step", Keyword: "multipleOf", Message: "must be a multiple of 0.5"},
+			},
+		},
+		{
+			schema:   "./data/validation/6.2_numeric.json",
+			document: `{}`,
+			errs: []generator.DocumentError{
+				{Path: "", Keyword: "required", Message: "field port: required"},
+			},
+		},
+		{
+			schema:   "./data/core/refPointer.json",
+			document: `{"order": {"lines": [{"sku": "a"}]}, "lastLine": {"quantity": 1}}`,
+			errs: []generator.DocumentError{
+				{Path: "/lastLine", Keyword: "required", Message: "field sku: required"},
+			},
 		},
+		{
+			schema:   "./data/core/refExternalFile.json",
+			document: `{"myExternalThing": {"name": 5}, "someOtherExternalThing": {"name": "x"}}`,
+			errs: []generator.DocumentError{
+				{Path: "/myExternalThing/name", Keyword: "type", Message: "expected string, got integer"},
+			},
+		},
+	} {
+		t.Run(titleFromFileName(tt.schema), func(t *testing.T) {
+			generator, err := generator.New(basicConfig)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var document interface{}
+			if err := json.Unmarshal([]byte(tt.document), &document); err != nil {
+				t.Fatal(err)
+			}
+			errs, err := generator.Validate(tt.schema, document)
+			if err != nil {
+				t.Fatal(err)
+			}
+			require.Equal(t, tt.errs, errs)
+		})
 	}
-	testExampleFile(t, cfg, "./data/crossPackageNoOutput/schema.json")
 }
 
-func TestCapitalization(t *testing.T) {
+func TestDiff(t *testing.T) {
+	old, err := schemas.FromFile("./data/diff/old.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	new, err := schemas.FromFile("./data/diff/new.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes := diff.Compare(old, new)
+	require.True(t, diff.HasBreaking(changes))
+	var source strings.Builder
+	for _, c := range changes {
+		source.WriteString(c.String() + "\n")
+	}
+	testGoldenFile(t, "./data/diff/new.diff.output", []byte(source.String()))
+	require.Empty(t, diff.Compare(old, old))
+}
+
+func TestLint(t *testing.T) {
+	schema, err := schemas.FromFile("./data/lint/schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var source strings.Builder
+	for _, issue := range lint.Lint(schema) {
+		source.WriteString(issue.String() + "\n")
+	}
+	testGoldenFile(t, "./data/lint/schema.lint.output", []byte(source.String()))
+}
+
+func TestExtras(t *testing.T) {
+	schema, err := schemas.FromFile("./data/misc/extras.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	require.Equal(t, map[string]json.RawMessage{"x-internal": json.RawMessage("true")}, schema.Extras)
+	require.Equal(t, map[string]json.RawMessage{
+		"x-display-name": json.RawMessage(`"Tag"`),
+	}, schema.Definitions["Tag"].Extras)
+	require.Equal(t, map[string]json.RawMessage{
+		"x-order":  json.RawMessage("1"),
+		"$comment": json.RawMessage(`"Shown first"`),
+	}, schema.Properties["name"].Extras)
+}
+
+func TestMarshalSchema(t *testing.T) {
+	var fileNames []string
+	for _, dir := range []string{"./data/core", "./data/validation"} {
+		matches, err := filepath.Glob(filepath.Join(dir, "*.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		fileNames = append(fileNames, matches...)
+	}
+	for _, fileName := range append(fileNames, "./data/misc/extras.json", "./data/misc/tupleItems.json") {
+		t.Run(titleFromFileName(fileName), func(t *testing.T) {
+			schema, err := schemas.FromFile(fileName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := json.Marshal(schema)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var again schemas.Schema
+			if err := json.Unmarshal(b, &again); err != nil {
+				t.Fatal(err)
+			}
+			require.Equal(t, schema, &again)
+		})
+	}
+
+	schema, err := schemas.FromFile("./data/misc/extras.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	source, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testGoldenFile(t, "./data/misc/extras.marshal.output", append(source, '\n'))
+}
+
+func TestMerge(t *testing.T) {
+	decode := func(s string) *schemas.Type {
+		var t schemas.Type
+		if err := json.Unmarshal([]byte(s), &t); err != nil {
+			panic(err)
+		}
+		return &t
+	}
+
+	merged, err := schemas.Merge(
+		decode(`{"type": "object", "properties": {"a": {"type": "number", "minimum": 1}}, "required": ["a"],
+			"additionalProperties": false}`),
+		decode(`{"properties": {"a": {"type": "integer", "minimum": 0, "pattern": "x"}, "b": {"type": "string"}},
+			"required": ["b", "a"]}`),
+	)
+	require.NoError(t, err)
+	b, err := json.Marshal(merged)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"type": "object",
+		"properties": {
+			"a": {"type": "integer", "minimum": 1, "pattern": "x"},
+			"b": {"type": "string", "not": {}}
+		},
+		"required": ["a", "b"],
+		"additionalProperties": false
+	}`, string(b))
+
+	merged, err = schemas.Merge(decode(`{"pattern": "^a", "enum": ["ab", "ac"]}`), decode(`{"pattern": "b$", "enum": ["ab"]}`))
+	require.NoError(t, err)
+	b, err = json.Marshal(merged)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"pattern": "^a", "enum": ["ab"], "allOf": [{"pattern": "b$"}]}`, string(b))
+
+	_, err = schemas.Merge(decode(`{"type": "string"}`), decode(`{"type": ["number", "null"]}`))
+	require.Error(t, err)
+}
+
+func TestWarnings(t *testing.T) {
+	var warnings []generator.Warning
 	cfg := basicConfig
-	cfg.Capitalizations = []string{"ID", "URL", "HtMl"}
-	testExampleFile(t, cfg, "./data/misc/capitalization.json")
+	cfg.Warner = func(w generator.Warning) {
+		w.Message = ""
+		warnings = append(warnings, w)
+	}
+	g, err := generator.New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, g.DoFile("./data/misc/warnings.json"))
+
+	file := "./data/misc/warnings.json"
+	require.Equal(t, []generator.Warning{
+		{
+			Code:       generator.WarningUnsupported,
+			Severity:   generator.SeverityWarning,
+			SchemaFile: file,
+			Pointer:    "/definitions/point/properties/coordinates",
+		},
+		{
+			Code:       generator.WarningEnumWrapped,
+			Severity:   generator.SeverityInfo,
+			SchemaFile: file,
+			Pointer:    "/definitions/point/properties/label",
+		},
+		{
+			Code:       generator.WarningUnsupported,
+			Severity:   generator.SeverityWarning,
+			SchemaFile: file,
+			Pointer:    "/properties/value",
+		},
+	}, warnings)
+
+	var messages []string
+	cfg.Warner = generator.MessageWarner(func(message string) {
+		messages = append(messages, message)
+	})
+	g, err = generator.New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, g.DoFile("./data/misc/warnings.json"))
+	require.Contains(t, messages, "./data/misc/warnings.json#/properties/value: "+
+		"Property has multiple types; will be represented as interface{} with no validation")
 }
 
-func TestBooleanAsSchema(t *testing.T) {
+func TestFailOnWarning(t *testing.T) {
+	var warnings []generator.Warning
 	cfg := basicConfig
-	testExampleFile(t, cfg, "./data/misc/boolean-as-schema.json")
+	cfg.Warner = func(w generator.Warning) {
+		warnings = append(warnings, w)
+	}
+	cfg.FailOnWarning = true
+	g, err := generator.New(cfg)
+	require.NoError(t, err)
+	err = g.DoFile("./data/misc/warnings.json")
+	var warningsErr *generator.WarningsError
+	require.True(t, errors.As(err, &warningsErr), "expected warnings, got %v", err)
+	require.Equal(t, warnings, warningsErr.Warnings)
+	require.Len(t, warnings, 3)
+	require.True(t, strings.HasPrefix(err.Error(), "3 warnings treated as errors: "))
+
+	require.NoError(t, g.DoFile("./data/core/primitives.json"))
+	dir := t.TempDir()
+	require.NoError(t, g.Write(dir))
+
+	cfg.DefaultOutputName = "-"
+	cfg.Docs = true
+	g, err = generator.New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, g.DoFile("./data/core/primitives.json"))
+	err = g.Write(dir)
+	require.True(t, errors.As(err, &warningsErr), "expected warnings, got %v", err)
+	require.Equal(t, generator.WarningSkipped, warningsErr.Warnings[0].Code)
+}
+
+func TestLenient(t *testing.T) {
+	g, err := generator.New(basicConfig)
+	require.NoError(t, err)
+	var missing *generator.MissingDefinitionError
+	require.True(t, errors.As(g.DoFile("./data/misc/lenient.json"), &missing))
+
+	var pointers []string
+	cfg := basicConfig
+	cfg.Warner = func(w generator.Warning) {
+		require.Equal(t, generator.WarningUnsupported, w.Code)
+		pointers = append(pointers, w.Pointer)
+	}
+	cfg.Lenient = true
+	testExampleFile(t, cfg, "./data/misc/lenient.json")
+	require.Equal(t, []string{
+		"/definitions/tag/properties/color",
+		"/properties/address",
+		"/properties/contact",
+		"/properties/owner",
+	}, pointers)
+
+	cfg.FailOnWarning = true
+	_, err = generator.New(cfg)
+	require.Error(t, err)
+}
+
+func TestErrors(t *testing.T) {
+	g, err := generator.New(basicConfig)
+	require.NoError(t, err)
+	err = g.DoFile("./data/errors/missingDefinition.json")
+	var missing *generator.MissingDefinitionError
+	require.True(t, errors.As(err, &missing), "expected a missing definition, got %v", err)
+	require.Equal(t, "address", missing.Name)
+	require.Equal(t, "#/definitions/address", missing.Ref)
+	require.True(t, errors.Is(err, schemas.ErrNotExist))
+
+	err = g.DoFile("./data/errors/unsupportedRef.json")
+	require.True(t, errors.Is(err, generator.ErrUnsupportedRef), "expected an unsupported $ref, got %v", err)
+
+	cfg := basicConfig
+	cfg.SchemaMappings = []generator.SchemaMapping{
+		{
+			SchemaID:    "https://example.com/missingDefinition",
+			PackageName: "github.com/example/one",
+			OutputName:  "types.go",
+		},
+		{
+			SchemaID:    "https://example.com/unsupportedRef",
+			PackageName: "github.com/example/two",
+			OutputName:  "types.go",
+		},
+	}
+	g, err = generator.New(cfg)
+	require.NoError(t, err)
+	_ = g.DoFile("./data/errors/missingDefinition.json")
+	err = g.DoFile("./data/errors/unsupportedRef.json")
+	require.True(t, errors.Is(err, generator.ErrConflictingOutput), "expected a conflict, got %v", err)
+}
+
+func TestMetaSchema(t *testing.T) {
+	g, err := generator.New(basicConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = g.DoFile("./data/metaSchema/invalid.json")
+	var schemaErr *generator.SchemaError
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("expected a schema error, got %v", err)
+	}
+	require.Equal(t, []generator.DocumentError{
+		{
+			Path: "/properties/name/minLength", Keyword: "minimum", Message: "must be greater than or equal to 0",
+			Line: 8, Column: 20,
+		},
+		{
+			Path: "/properties/name/type", Keyword: "anyOf", Message: "value does not match any of the schemas in anyOf",
+			Line: 7, Column: 15,
+		},
+		{
+			Path: "/properties/tags/items", Keyword: "anyOf", Message: "value does not match any of the schemas in anyOf",
+			Line: 12, Column: 16,
+		},
+		{Path: "/required", Keyword: "type", Message: "expected array, got string", Line: 15, Column: 15},
+	}, schemaErr.Errors)
+
+	var invalid *generator.InvalidSchemaError
+	require.True(t, errors.As(err, &invalid), "expected an invalid schema, got %v", err)
+	require.Equal(t, "./data/metaSchema/invalid.json", invalid.Name)
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, tt := range []struct {
+		fileName string
+		line     int
+		column   int
+		pointer  string
+	}{
+		{fileName: "./data/parseErrors/syntax.json", line: 7, column: 5, pointer: "/properties"},
+		{fileName: "./data/parseErrors/typeError.json", line: 8, column: 41, pointer: "/properties/tags/items/1/minimum"},
+	} {
+		t.Run(titleFromFileName(tt.fileName), func(t *testing.T) {
+			_, err := schemas.FromFile(tt.fileName)
+			parseErr, ok := err.(*schemas.ParseError)
+			if !ok {
+				t.Fatalf("expected a parse error, got %v", err)
+			}
+			require.Equal(t, tt.line, parseErr.Line)
+			require.Equal(t, tt.column, parseErr.Column)
+			require.Equal(t, tt.pointer, parseErr.Pointer)
+		})
+	}
 }
 
 func testExamples(t *testing.T, cfg generator.Config, dataDir string) {
@@ -105,34 +1798,41 @@ func testExampleFile(t *testing.T, cfg generator.Config, fileName string) {
 			t.Fatal(err)
 		}
 
-		if len(generator.Sources()) == 0 {
-			t.Fatal("Expected sources to contain something")
-		}
+		testSources(t, generator, fileName)
+	})
+}
 
-		for outputName, source := range generator.Sources() {
-			if outputName == "-" {
-				outputName = strings.TrimSuffix(filepath.Base(fileName), ".json") + ".go"
-			}
-			outputName += ".output"
+// testSources compares the sources generated for a schema file with the
+// golden files next to it.
+func testSources(t *testing.T, generator *generator.Generator, fileName string) {
+	if len(generator.Sources()) == 0 {
+		t.Fatal("Expected sources to contain something")
+	}
 
-			goldenFileName := filepath.Join(filepath.Dir(fileName), outputName)
-			t.Logf("Using golden data in %s", mustAbs(goldenFileName))
+	for outputName, source := range generator.Sources() {
+		if outputName == "-" {
+			outputName = strings.TrimSuffix(filepath.Base(fileName), ".json") + ".go"
+		}
+		testGoldenFile(t, filepath.Join(filepath.Dir(fileName), outputName+".output"), source)
+	}
+}
 
-			goldenData, err := os.ReadFile(goldenFileName)
-			if err != nil {
-				if !os.IsNotExist(err) {
-					t.Fatal(err)
-				}
-				goldenData = source
-				t.Log("File does not exist; creating it")
-				if err = os.WriteFile(goldenFileName, goldenData, 0655); err != nil {
-					t.Fatal(err)
-				}
-			}
+func testGoldenFile(t *testing.T, goldenFileName string, source []byte) {
+	t.Logf("Using golden data in %s", mustAbs(goldenFileName))
 
-			require.Equal(t, string(goldenData), string(source))
+	goldenData, err := os.ReadFile(goldenFileName)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			t.Fatal(err)
 		}
-	})
+		goldenData = source
+		t.Log("File does not exist; creating it")
+		if err = os.WriteFile(goldenFileName, goldenData, 0655); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	require.Equal(t, string(goldenData), string(source))
 }
 
 func testFailingExampleFile(t *testing.T, cfg generator.Config, fileName string) {
@@ -147,6 +1847,82 @@ func testFailingExampleFile(t *testing.T, cfg generator.Config, fileName string)
 	})
 }
 
+// runGenerated generates the types for a schema file into a program that runs
+// each case, the body of a function returning an error, and returns the paths
+// of the validation errors they return, separated by spaces, or the errors if
+// they are not validation errors.
+func runGenerated(t *testing.T, cfg generator.Config, fileName string, cases ...string) []string {
+	if testing.Short() {
+		t.Skip("skipping go run in short mode")
+	}
+
+	generator, err := generator.New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, generator.DoFile(fileName))
+	dir := t.TempDir()
+	for name, source := range generator.Sources() {
+		if name == "-" {
+			name = "types.go"
+		}
+		require.NoError(t, os.WriteFile(filepath.Join(dir, filepath.Base(name)), source, 0644))
+	}
+	var main strings.Builder
+	main.WriteString(`package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var _ = json.Unmarshal
+
+var cases = []func() error{
+`)
+	for _, c := range cases {
+		fmt.Fprintf(&main, "\tfunc() error {\n\t\t%s\n\t},\n", c)
+	}
+	main.WriteString(`}
+
+func main() {
+	for _, c := range cases {
+		fmt.Println(strings.Join(paths(c()), " "))
+	}
+}
+
+func paths(err error) []string {
+	if errs, ok := err.(interface{ Unwrap() []error }); ok {
+		var result []string
+		for _, err := range errs.Unwrap() {
+			result = append(result, paths(err)...)
+		}
+		return result
+	}
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return []string{validationErr.Path}
+	}
+	return []string{fmt.Sprint(err)}
+}
+`)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte(main.String()), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/m\n\ngo 1.19\n"), 0644))
+
+	cmd := exec.Command("go", "run", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off", "GOWORK=off")
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+	return strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
+}
+
+// unmarshalCase returns a case for runGenerated that unmarshals a document
+// into a value of the named type.
+func unmarshalCase(typeName, document string) string {
+	return fmt.Sprintf("var value %s\n\t\treturn json.Unmarshal([]byte(%s), &value)", typeName, strconv.Quote(document))
+}
+
 func titleFromFileName(fileName string) string {
 	relative := mustRel(mustAbs("./data"), mustAbs(fileName))
 	return strings.TrimSuffix(relative, ".json")
@@ -0,0 +1,59 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/lets-dev-it-out/go-jsonschema/tests/bench"
+	"github.com/lets-dev-it-out/go-jsonschema/tests/bench/baseline"
+)
+
+// orderDocument builds an order with the given number of lines, each of
+// which holds a few nested objects, so that the decoding of nested
+// properties dominates.
+func orderDocument(lines int) []byte {
+	var sb strings.Builder
+	sb.WriteString(`{"id":"order-1","customer":{"name":"Jane",` +
+		`"shipping":{"street":"Main 1","city":"Amsterdam"},` +
+		`"billing":{"street":"Side 2","city":"Utrecht","country":"NL"}},` +
+		`"notes":["fragile","leave at the door"],"lines":[`)
+	for i := 0; i < lines; i++ {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		fmt.Fprintf(&sb, `{"sku":"ABC-%d","quantity":%d,"price":%d.5,"status":"pending",`+
+			`"attributes":{"colour":"red","size":"L"}}`, i, i+1, i)
+	}
+	sb.WriteString(`]}`)
+	return []byte(sb.String())
+}
+
+// BenchmarkUnmarshalNested measures the generated UnmarshalJSON methods on a
+// nested document, both those that the generator generates now and those of
+// package baseline, which decode each object twice. Compare them with
+//
+//	go test -run '^$' -bench UnmarshalNested -benchmem ./tests/
+func BenchmarkUnmarshalNested(b *testing.B) {
+	for _, lines := range []int{1, 100} {
+		doc := orderDocument(lines)
+		for _, pkg := range []struct {
+			name     string
+			newOrder func() interface{}
+		}{
+			{"baseline", func() interface{} { return new(baseline.Order) }},
+			{"current", func() interface{} { return new(bench.Order) }},
+		} {
+			b.Run(fmt.Sprintf("%s/lines=%d", pkg.name, lines), func(b *testing.B) {
+				b.ReportAllocs()
+				b.SetBytes(int64(len(doc)))
+				for i := 0; i < b.N; i++ {
+					if err := json.Unmarshal(doc, pkg.newOrder()); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}